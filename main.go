@@ -1,11 +1,30 @@
 package main
 
 import (
+	"api/src/authentication"
 	"api/src/config"
+	"api/src/controllers"
+	"api/src/db"
+	"api/src/events"
+	"api/src/media"
+	"api/src/messaging"
+	"api/src/migrations"
+	"api/src/notifications"
+	"api/src/ranking"
+	"api/src/repositories"
 	"api/src/router"
+	"api/src/scheduler"
+	"api/src/stream"
+	"api/src/timeline"
+	"api/src/trending"
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 // func init() {
@@ -21,7 +40,140 @@ import (
 
 func main() {
 	config.LoadConfig()
-	fmt.Printf("Server started in port %d!!", config.APIPort)
-	r := router.Generate()
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", config.APIPort), r))
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrations.Command(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := authentication.LoadKeys(); err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := db.CreateConnection()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	repos := repositories.NewMySQLRepositories(db)
+	authentication.SetRevocationChecker(repos.Tokens)
+
+	hub := stream.NewHub(config.StreamBufferSize, config.StreamHistorySize, config.StreamResumeWindow)
+
+	bus := events.NewBus(config.EventsBufferSize)
+	bus.Subscribe("notifications", notifications.NewService(repos, hub).Handle)
+	bus.Subscribe("stream", stream.NewFeed(hub, repos).Handle)
+
+	gateway := messaging.NewGateway()
+
+	trends := trending.NewWorker(repos.Hashtags, config.TrendingWindow, config.TrendingHalfLife, config.TrendingLimit)
+	trends.Start(config.TrendingRefreshInterval)
+
+	timelineStore, closeTimelineStore, err := newTimelineStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	timelines := timeline.NewService(timelineStore, repos.Publications, repos.Users,
+		config.TimelineCapacity, config.TimelineMaxFanOut, config.TimelineWorkers, config.TimelineQueueSize)
+	bus.SubscribeWithoutLoss("timeline", timelines.Handle)
+
+	publisher := scheduler.NewScheduler(repos.Publications, bus, config.SchedulerBatchSize)
+	publisher.Start(config.SchedulerInterval)
+
+	blobs, err := newBlobStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	processor := media.NewProcessor(repos.Media, blobs, config.MediaVariantSizes, config.MediaWorkers, config.MediaQueueSize)
+	// images queued when the previous process stopped were never processed
+	go func(startedAt time.Time) {
+		if _, err := processor.Resume(startedAt); err != nil {
+			log.Printf("Could not resume media processing: %v", err)
+		}
+	}(time.Now())
+
+	collector := media.NewCollector(repos.Media, blobs, config.MediaOrphanGrace)
+	collector.Start(config.MediaGCInterval)
+
+	feedWeights := ranking.DefaultWeights
+	feedWeights.HalfLife = config.FeedHalfLife
+
+	server := &http.Server{
+		Addr: fmt.Sprintf(":%d", config.APIPort),
+		Handler: router.Generate(controllers.NewHandler(repos, db, controllers.Services{
+			Events:   bus,
+			Stream:   hub,
+			Messages: messaging.NewService(repos, gateway, config.SocketRevocationInterval),
+			Trending: trends,
+			Media:    media.NewService(repos.Media, blobs, processor, config.MediaMaxImageSize, config.MediaMaxVideoSize),
+			Rankers:  ranking.NewRankers(repos.Publications, repos.Comments, feedWeights),
+			Timeline: timelines,
+		})),
+	}
+	// streams and sockets never finish by themselves, so they are closed for Shutdown to stop waiting them
+	server.RegisterOnShutdown(hub.Close)
+	server.RegisterOnShutdown(gateway.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		fmt.Printf("Server started in port %d!!", config.APIPort)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down server...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Could not finish pending requests: %v", err)
+	}
+
+	// the scheduler publishes events, so it stops before the bus
+	publisher.Close()
+	bus.Close()
+	// the bus queues updates in the timeline, so it stops after the bus
+	timelines.Close()
+	closeTimelineStore()
+	trends.Close()
+	processor.Close()
+	collector.Close()
+
+	if err := db.Close(); err != nil {
+		log.Printf("Could not close database pool: %v", err)
+	}
+}
+
+// newBlobStore create the blob store chosen by MEDIA_STORAGE
+func newBlobStore() (media.BlobStore, error) {
+	switch config.MediaStorage {
+	case "local":
+		return media.NewLocalStore(config.MediaDir), nil
+	case "s3":
+		return media.NewS3Store(config.S3Endpoint, config.S3Region, config.S3Bucket, config.S3AccessKey, config.S3SecretKey)
+	}
+
+	return nil, fmt.Errorf("Unknown media storage %q, use local or s3", config.MediaStorage)
+}
+
+// newTimelineStore create the timeline store chosen by TIMELINE_STORE and the function that releases it
+func newTimelineStore() (timeline.Store, func(), error) {
+	switch config.TimelineStore {
+	case "memory":
+		return timeline.NewMemoryStore(config.TimelineCapacity), func() {}, nil
+	case "redis":
+		client := timeline.NewRedisClient(config.RedisAddr, config.RedisPassword, config.RedisDB, config.RedisPoolSize, config.RedisTimeout)
+		return timeline.NewRedisStore(client, config.TimelineCapacity, config.TimelineTTL), client.Close, nil
+	}
+
+	return nil, nil, fmt.Errorf("Unknown timeline store %q, use memory or redis", config.TimelineStore)
 }
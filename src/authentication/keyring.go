@@ -0,0 +1,321 @@
+package authentication
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+const minRSAKeyBits = 2048
+
+// Key represent one key used to sign or verify tokens, identified in token header by kid
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private interface{}
+	Public  interface{}
+	ModTime time.Time
+
+	publishedAt time.Time
+	retiredAt   time.Time
+}
+
+// JSONWebKey represent a public key in JWKS format
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JSONWebKeySet represent the document served in /.well-known/jwks.json
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// Keyring hold the keys loaded from a directory with one PEM file per key, named <kid>.pem.
+// Private keys (PKCS8 or PKCS1) can sign and verify, public keys (PKIX) can only verify.
+// When the active key changes, the previous keys keep verifying tokens during the rotation window.
+// Keys newer than the active one are published before being used, so downstream services cache them in advance:
+// without a configured active key, a key only starts signing after being published for the rotation window.
+type Keyring struct {
+	mu       sync.RWMutex
+	dir      string
+	activeID string
+	window   time.Duration
+	keys     map[string]*Key
+	active   *Key
+}
+
+// LoadKeyring read all keys from dir, activeID choose the signing key and the newest private key
+// published for at least window is used when empty
+func LoadKeyring(dir, activeID string, window time.Duration) (*Keyring, error) {
+	keyring := &Keyring{
+		dir:      dir,
+		activeID: activeID,
+		window:   window,
+		keys:     map[string]*Key{},
+	}
+
+	if err := keyring.Reload(); err != nil {
+		return nil, err
+	}
+
+	return keyring, nil
+}
+
+// Reload read keys from disk again, keeping the rotation state of keys already known
+func (keyring *Keyring) Reload() error {
+	keys, err := readKeys(keyring.dir)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	keyring.mu.RLock()
+	for id, key := range keys {
+		if known, ok := keyring.keys[id]; ok {
+			key.publishedAt = known.publishedAt
+		} else if keyring.active == nil {
+			// keys found when the API starts may have been published by other instances since they were written
+			key.publishedAt = key.ModTime
+		} else {
+			key.publishedAt = now
+		}
+	}
+	keyring.mu.RUnlock()
+
+	active, err := chooseActiveKey(keys, keyring.activeID, now.Add(-keyring.window))
+	if err != nil {
+		return err
+	}
+
+	keyring.mu.Lock()
+	defer keyring.mu.Unlock()
+
+	for id, key := range keys {
+		if id == active.ID {
+			continue
+		}
+
+		if known, ok := keyring.keys[id]; ok && !known.retiredAt.IsZero() {
+			key.retiredAt = known.retiredAt
+			continue
+		}
+
+		wasActive := keyring.active != nil && keyring.active.ID == id
+		if wasActive || !key.ModTime.After(active.ModTime) {
+			key.retiredAt = now
+		}
+	}
+
+	keyring.keys = keys
+	keyring.active = active
+
+	return nil
+}
+
+// SigningKey return the active key
+func (keyring *Keyring) SigningKey() *Key {
+	keyring.mu.RLock()
+	defer keyring.mu.RUnlock()
+
+	return keyring.active
+}
+
+// VerificationKey return the key with kid if it still can verify tokens
+func (keyring *Keyring) VerificationKey(kid string) (*Key, error) {
+	keyring.mu.RLock()
+	defer keyring.mu.RUnlock()
+
+	key, ok := keyring.keys[kid]
+	if !ok || !keyring.isValid(key, time.Now()) {
+		return nil, fmt.Errorf("Unknown signing key! %s", kid)
+	}
+
+	return key, nil
+}
+
+// JWKS return the public keys still valid to verify tokens
+func (keyring *Keyring) JWKS() JSONWebKeySet {
+	keyring.mu.RLock()
+	defer keyring.mu.RUnlock()
+
+	now := time.Now()
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+
+	for _, key := range keyring.keys {
+		if keyring.isValid(key, now) {
+			set.Keys = append(set.Keys, key.jwk())
+		}
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].KeyID < set.Keys[j].KeyID
+	})
+
+	return set
+}
+
+func (keyring *Keyring) isValid(key *Key, now time.Time) bool {
+	return key.retiredAt.IsZero() || now.Before(key.retiredAt.Add(keyring.window))
+}
+
+func (key *Key) jwk() JSONWebKey {
+	jwk := JSONWebKey{
+		KeyID:     key.ID,
+		Use:       "sig",
+		Algorithm: key.Method.Alg(),
+	}
+
+	switch public := key.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+
+	return jwk
+}
+
+// chooseActiveKey return the key with activeID or, when it is empty, the newest private key published
+// before publishedBefore. While no key was published for so long, the one published first is used
+func chooseActiveKey(keys map[string]*Key, activeID string, publishedBefore time.Time) (*Key, error) {
+	if activeID != "" {
+		key, ok := keys[activeID]
+		if !ok {
+			return nil, fmt.Errorf("Active key %s not found", activeID)
+		}
+
+		if key.Private == nil {
+			return nil, fmt.Errorf("Active key %s has no private key", activeID)
+		}
+
+		return key, nil
+	}
+
+	var active, first *Key
+	for _, key := range keys {
+		if key.Private == nil {
+			continue
+		}
+
+		if key.publishedAt.After(publishedBefore) {
+			if first == nil || key.publishedAt.Before(first.publishedAt) ||
+				(key.publishedAt.Equal(first.publishedAt) && key.ID < first.ID) {
+				first = key
+			}
+			continue
+		}
+
+		if active == nil || key.ModTime.After(active.ModTime) ||
+			(key.ModTime.Equal(active.ModTime) && key.ID > active.ID) {
+			active = key
+		}
+	}
+
+	if active == nil {
+		active = first
+	}
+
+	if active == nil {
+		return nil, errors.New("No private key found to sign tokens")
+	}
+
+	return active, nil
+}
+
+func readKeys(dir string) (map[string]*Key, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*Key{}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".pem" {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := parseKey(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name(), err)
+		}
+
+		key.ID = strings.TrimSuffix(file.Name(), ".pem")
+		key.ModTime = file.ModTime()
+		keys[key.ID] = key
+	}
+
+	return keys, nil
+}
+
+func parseKey(content []byte) (*Key, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("Invalid PEM file")
+	}
+
+	var parsed interface{}
+	var err error
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("Unsupported PEM block %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return nil, errors.New("RSA key must have at least 2048 bits")
+		}
+		return &Key{Method: jwt.SigningMethodRS256, Private: key, Public: &key.PublicKey}, nil
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return nil, errors.New("RSA key must have at least 2048 bits")
+		}
+		return &Key{Method: jwt.SigningMethodRS256, Public: key}, nil
+	case ed25519.PrivateKey:
+		return &Key{Method: SigningMethodEd25519, Private: key, Public: key.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{Method: SigningMethodEd25519, Public: key}, nil
+	}
+
+	return nil, errors.New("Only RSA and Ed25519 keys are supported")
+}
@@ -0,0 +1,127 @@
+package authentication
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeKey write a new Ed25519 private key named kid.pem to dir, modified at modTime
+func writeKey(t *testing.T, dir, kid string, modTime time.Time) {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, kid+".pem")
+	if err = ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func published(keyring *Keyring, kid string) bool {
+	for _, key := range keyring.JWKS().Keys {
+		if key.KeyID == kid {
+			return true
+		}
+	}
+	return false
+}
+
+func TestKeyringPromotesKeysAfterTheRotationWindow(t *testing.T) {
+	const window = 300 * time.Millisecond
+
+	dir := t.TempDir()
+	now := time.Now()
+	writeKey(t, dir, "old", now.Add(-3*time.Hour))
+	writeKey(t, dir, "current", now.Add(-2*time.Hour))
+
+	keyring, err := LoadKeyring(dir, "", window)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if active := keyring.SigningKey().ID; active != "current" {
+		t.Fatalf("expected the newest key written before the window to sign, got %s", active)
+	}
+
+	// a new key and one copied keeping an old modification time are both unknown to downstream services
+	writeKey(t, dir, "new", time.Now())
+	writeKey(t, dir, "copied", now.Add(-time.Hour))
+	if err = keyring.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if active := keyring.SigningKey().ID; active != "current" {
+		t.Fatalf("expected keys just dropped to wait the window, got %s signing", active)
+	}
+	if !published(keyring, "new") {
+		t.Error("expected the new key published before it signs")
+	}
+
+	time.Sleep(window + 100*time.Millisecond)
+	if err = keyring.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if active := keyring.SigningKey().ID; active != "new" {
+		t.Fatalf("expected the new key to sign after the window, got %s", active)
+	}
+	if _, err = keyring.VerificationKey("current"); err != nil {
+		t.Errorf("expected the previous key to keep verifying during the window: %v", err)
+	}
+}
+
+func TestKeyringSignsWithTheFirstPublishedKeyWhenNoneIsOldEnough(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "first", time.Now().Add(-time.Minute))
+	writeKey(t, dir, "second", time.Now())
+
+	keyring, err := LoadKeyring(dir, "", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if active := keyring.SigningKey().ID; active != "first" {
+		t.Fatalf("expected the key published first to sign, got %s", active)
+	}
+	if !published(keyring, "second") {
+		t.Error("expected the second key published while it waits")
+	}
+}
+
+func TestKeyringUsesTheConfiguredActiveKey(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "configured", time.Now().Add(-2*time.Hour))
+	writeKey(t, dir, "newest", time.Now().Add(-time.Hour))
+
+	keyring, err := LoadKeyring(dir, "configured", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if active := keyring.SigningKey().ID; active != "configured" {
+		t.Fatalf("expected the configured key to sign, got %s", active)
+	}
+
+	if _, err = LoadKeyring(dir, "missing", time.Minute); err == nil {
+		t.Error("expected an error for a missing active key")
+	}
+}
@@ -2,8 +2,13 @@ package authentication
 
 import (
 	"api/src/config"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -12,27 +17,163 @@ import (
 	jwt "github.com/dgrijalva/jwt-go"
 )
 
-func CreateToken(userId uint64) (string, error) {
+// keyring sign tokens with asymmetric keys, when nil tokens are signed with config.SecretKey
+var keyring *Keyring
+
+// RevocationChecker verify if an access token id or its family was revoked, implemented by repositories.TokenRepository
+type RevocationChecker interface {
+	IsAccessTokenRevoked(jti, familyId string) (bool, error)
+}
+
+// revocations is where ValidateToken look for revoked tokens
+var revocations RevocationChecker
+
+// SetRevocationChecker configure where ValidateToken look for revoked tokens
+func SetRevocationChecker(checker RevocationChecker) {
+	revocations = checker
+}
+
+// TokenDetails represent the claims used to identify and revoke an access token
+type TokenDetails struct {
+	ID        string
+	FamilyID  string
+	UserID    uint64
+	ExpiresAt time.Time
+}
+
+// LoadKeys load the keyring from config.JWTKeysDir and reload it periodically to follow key rotation.
+// Without a keys directory tokens keep being signed with HS256 and config.SecretKey.
+func LoadKeys() error {
+	if config.JWTKeysDir == "" {
+		return nil
+	}
+
+	loaded, err := LoadKeyring(config.JWTKeysDir, config.JWTActiveKeyID, config.JWTRotationWindow)
+	if err != nil {
+		return err
+	}
+	keyring = loaded
+
+	go func() {
+		for range time.Tick(config.JWTKeysReloadInterval) {
+			if err := keyring.Reload(); err != nil {
+				log.Printf("Could not reload signing keys: %v", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// PublicKeys return the public keys that downstream services use to verify tokens
+func PublicKeys() JSONWebKeySet {
+	if keyring == nil {
+		return JSONWebKeySet{Keys: []JSONWebKey{}}
+	}
+
+	return keyring.JWKS()
+}
+
+// CreateToken create a short lived access token bound to a refresh token family
+func CreateToken(userId uint64, familyId string) (string, error) {
+	jti, err := randomID()
+	if err != nil {
+		return "", err
+	}
+
 	acl := jwt.MapClaims{}
 	acl["authorized"] = true
-	acl["exp"] = time.Now().Add(time.Hour * 6).Unix()
+	acl["exp"] = time.Now().Add(config.AccessTokenTTL).Unix()
+	acl["jti"] = jti
+	acl["fid"] = familyId
 	acl["userId"] = userId
 
+	if keyring != nil {
+		key := keyring.SigningKey()
+		token := jwt.NewWithClaims(key.Method, acl)
+		token.Header["kid"] = key.ID
+		return token.SignedString(key.Private)
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, acl)
 	return token.SignedString(config.SecretKey) //secret
 }
 
-//ValidateToken verify is received token is valid
+// CreateRefreshToken create a random opaque refresh token
+func CreateRefreshToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// HashRefreshToken return the hash stored in database for refresh token
+func HashRefreshToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// NewFamilyID create the identifier shared by all refresh tokens from one login
+func NewFamilyID() (string, error) {
+	return randomID()
+}
+
+//ValidateToken verify is received token is valid and was not revoked
 func ValidateToken(r *http.Request) error {
+	details, err := GetTokenDetails(r)
+	if err != nil {
+		return err
+	}
+
+	if revocations == nil {
+		return errors.New("Token revocation is not configured")
+	}
+
+	revoked, err := revocations.IsAccessTokenRevoked(details.ID, details.FamilyID)
+	if err != nil {
+		return err
+	}
+
+	if revoked {
+		return errors.New("Token revoked!")
+	}
+
+	return nil
+}
+
+// GetTokenDetails return the claims from a valid token in request
+func GetTokenDetails(r *http.Request) (TokenDetails, error) {
 	tokenString := getToken(r)
 	token, err := jwt.Parse(tokenString, returnVerificationKey)
 	if err != nil {
-		return err
+		return TokenDetails{}, err
 	}
-	if _, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return nil
+
+	permission, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return TokenDetails{}, errors.New("Invalid Token!")
 	}
-	return errors.New("Invalid Token!")
+
+	jti, _ := permission["jti"].(string)
+	familyId, _ := permission["fid"].(string)
+	exp, _ := permission["exp"].(float64)
+	if jti == "" || familyId == "" {
+		return TokenDetails{}, errors.New("Invalid Token!")
+	}
+
+	userID, err := strconv.ParseUint(fmt.Sprintf("%.0f", permission["userId"]), 10, 64)
+	if err != nil {
+		return TokenDetails{}, err
+	}
+
+	return TokenDetails{
+		ID:        jti,
+		FamilyID:  familyId,
+		UserID:    userID,
+		ExpiresAt: time.Unix(int64(exp), 0),
+	}, nil
 }
 
 func getToken(r *http.Request) string {
@@ -65,9 +206,32 @@ func GetUserID(r *http.Request) (uint64, error) {
 }
 
 func returnVerificationKey(token *jwt.Token) (interface{}, error) {
+	if keyring != nil {
+		kid, _ := token.Header["kid"].(string)
+		key, err := keyring.VerificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("Unexpected subscription method! %v", token.Header["alg"])
+		}
+
+		return key.Public, nil
+	}
+
 	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 		return nil, fmt.Errorf("Unexpected subscription method! %v", token.Header["alg"])
 	}
 
 	return config.SecretKey, nil
 }
+
+func randomID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
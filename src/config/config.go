@@ -5,6 +5,8 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,6 +15,73 @@ var (
 	Connection = ""
 	APIPort    = 0
 	SecretKey  []byte
+
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+
+	JWTKeysDir            = ""
+	JWTActiveKeyID        = ""
+	JWTRotationWindow     = time.Hour
+	JWTKeysReloadInterval = time.Minute
+
+	DBMaxOpenConns    = 25
+	DBMaxIdleConns    = 25
+	DBConnMaxLifetime = 5 * time.Minute
+	DBConnMaxIdleTime = time.Minute
+
+	ShutdownTimeout = 15 * time.Second
+
+	MigrationsDir = "src/migrations/sql"
+
+	EventsBufferSize = 1024
+
+	StreamBufferSize        = 64
+	StreamHistorySize       = 100
+	StreamResumeWindow      = 5 * time.Minute
+	StreamHeartbeatInterval = 15 * time.Second
+
+	AllowedOrigins           []string
+	SocketRevocationInterval = 30 * time.Second
+
+	TrendingWindow          = 24 * time.Hour
+	TrendingHalfLife        = 6 * time.Hour
+	TrendingRefreshInterval = time.Minute
+	TrendingLimit           = 10
+
+	SchedulerInterval  = 30 * time.Second
+	SchedulerBatchSize = 100
+
+	FeedHalfLife      = 6 * time.Hour
+	FeedRankingWindow = 200
+
+	TimelineStore     = "memory"
+	TimelineCapacity  = 800
+	TimelineMaxFanOut = 10000
+	TimelineWorkers   = 4
+	TimelineQueueSize = 1000
+	TimelineTTL       = 72 * time.Hour
+
+	RedisAddr     = "localhost:6379"
+	RedisPassword = ""
+	RedisDB       = 0
+	RedisPoolSize = 10
+	RedisTimeout  = 2 * time.Second
+
+	MediaStorage      = "local"
+	MediaDir          = "uploads"
+	MediaMaxImageSize = int64(10 << 20)
+	MediaMaxVideoSize = int64(50 << 20)
+	MediaOrphanGrace  = 24 * time.Hour
+	MediaGCInterval   = time.Hour
+	MediaVariantSizes = []int{150, 600, 1200}
+	MediaWorkers      = 2
+	MediaQueueSize    = 100
+
+	S3Endpoint  = ""
+	S3Region    = "us-east-1"
+	S3Bucket    = ""
+	S3AccessKey = ""
+	S3SecretKey = ""
 )
 
 //LoadConfig initialize environment variables
@@ -34,5 +103,222 @@ func LoadConfig() {
 		os.Getenv("DB_NAME"),
 	)
 
+	if maxOpen, err := strconv.Atoi(os.Getenv("DB_MAX_OPEN_CONNS")); err == nil {
+		DBMaxOpenConns = maxOpen
+	}
+
+	if maxIdle, err := strconv.Atoi(os.Getenv("DB_MAX_IDLE_CONNS")); err == nil {
+		DBMaxIdleConns = maxIdle
+	}
+
+	if lifetime, err := time.ParseDuration(os.Getenv("DB_CONN_MAX_LIFETIME")); err == nil {
+		DBConnMaxLifetime = lifetime
+	}
+
+	if idleTime, err := time.ParseDuration(os.Getenv("DB_CONN_MAX_IDLE_TIME")); err == nil {
+		DBConnMaxIdleTime = idleTime
+	}
+
+	if timeout, err := time.ParseDuration(os.Getenv("SHUTDOWN_TIMEOUT")); err == nil {
+		ShutdownTimeout = timeout
+	}
+
+	if dir := os.Getenv("MIGRATIONS_DIR"); dir != "" {
+		MigrationsDir = dir
+	}
+
 	SecretKey = []byte(os.Getenv("SECRET_KEY"))
+
+	if ttl, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_TTL")); err == nil {
+		AccessTokenTTL = ttl
+	}
+
+	if ttl, err := time.ParseDuration(os.Getenv("REFRESH_TOKEN_TTL")); err == nil {
+		RefreshTokenTTL = ttl
+	}
+
+	JWTKeysDir = os.Getenv("JWT_KEYS_DIR")
+	JWTActiveKeyID = os.Getenv("JWT_ACTIVE_KID")
+
+	if window, err := time.ParseDuration(os.Getenv("JWT_ROTATION_WINDOW")); err == nil {
+		JWTRotationWindow = window
+	}
+
+	if interval, err := time.ParseDuration(os.Getenv("JWT_KEYS_RELOAD_INTERVAL")); err == nil {
+		JWTKeysReloadInterval = interval
+	}
+
+	if size, err := strconv.Atoi(os.Getenv("EVENTS_BUFFER_SIZE")); err == nil && size > 0 {
+		EventsBufferSize = size
+	}
+
+	if size, err := strconv.Atoi(os.Getenv("STREAM_BUFFER_SIZE")); err == nil && size > 0 {
+		StreamBufferSize = size
+	}
+
+	if size, err := strconv.Atoi(os.Getenv("STREAM_HISTORY_SIZE")); err == nil && size > 0 {
+		StreamHistorySize = size
+	}
+
+	if window, err := time.ParseDuration(os.Getenv("STREAM_RESUME_WINDOW")); err == nil {
+		StreamResumeWindow = window
+	}
+
+	if interval, err := time.ParseDuration(os.Getenv("STREAM_HEARTBEAT_INTERVAL")); err == nil && interval > 0 {
+		StreamHeartbeatInterval = interval
+	}
+
+	AllowedOrigins = parseList(os.Getenv("ALLOWED_ORIGINS"))
+
+	if interval, err := time.ParseDuration(os.Getenv("SOCKET_REVOCATION_INTERVAL")); err == nil && interval > 0 {
+		SocketRevocationInterval = interval
+	}
+
+	if window, err := time.ParseDuration(os.Getenv("TRENDING_WINDOW")); err == nil && window > 0 {
+		TrendingWindow = window
+	}
+
+	if halfLife, err := time.ParseDuration(os.Getenv("TRENDING_HALF_LIFE")); err == nil && halfLife > 0 {
+		TrendingHalfLife = halfLife
+	}
+
+	if interval, err := time.ParseDuration(os.Getenv("TRENDING_REFRESH_INTERVAL")); err == nil && interval > 0 {
+		TrendingRefreshInterval = interval
+	}
+
+	if limit, err := strconv.Atoi(os.Getenv("TRENDING_LIMIT")); err == nil && limit > 0 {
+		TrendingLimit = limit
+	}
+
+	if interval, err := time.ParseDuration(os.Getenv("SCHEDULER_INTERVAL")); err == nil && interval > 0 {
+		SchedulerInterval = interval
+	}
+
+	if size, err := strconv.Atoi(os.Getenv("SCHEDULER_BATCH_SIZE")); err == nil && size > 0 {
+		SchedulerBatchSize = size
+	}
+
+	if halfLife, err := time.ParseDuration(os.Getenv("FEED_HALF_LIFE")); err == nil && halfLife > 0 {
+		FeedHalfLife = halfLife
+	}
+
+	if window, err := strconv.Atoi(os.Getenv("FEED_RANKING_WINDOW")); err == nil && window > 0 {
+		FeedRankingWindow = window
+	}
+
+	if storage := os.Getenv("MEDIA_STORAGE"); storage != "" {
+		MediaStorage = storage
+	}
+
+	if dir := os.Getenv("MEDIA_DIR"); dir != "" {
+		MediaDir = dir
+	}
+
+	if size, err := strconv.ParseInt(os.Getenv("MEDIA_MAX_IMAGE_SIZE"), 10, 64); err == nil && size > 0 {
+		MediaMaxImageSize = size
+	}
+
+	if size, err := strconv.ParseInt(os.Getenv("MEDIA_MAX_VIDEO_SIZE"), 10, 64); err == nil && size > 0 {
+		MediaMaxVideoSize = size
+	}
+
+	if grace, err := time.ParseDuration(os.Getenv("MEDIA_ORPHAN_GRACE")); err == nil && grace > 0 {
+		MediaOrphanGrace = grace
+	}
+
+	if interval, err := time.ParseDuration(os.Getenv("MEDIA_GC_INTERVAL")); err == nil && interval > 0 {
+		MediaGCInterval = interval
+	}
+
+	if sizes, err := parseSizes(os.Getenv("MEDIA_VARIANT_SIZES")); err == nil && len(sizes) > 0 {
+		MediaVariantSizes = sizes
+	}
+
+	if workers, err := strconv.Atoi(os.Getenv("MEDIA_WORKERS")); err == nil && workers > 0 {
+		MediaWorkers = workers
+	}
+
+	if size, err := strconv.Atoi(os.Getenv("MEDIA_QUEUE_SIZE")); err == nil && size > 0 {
+		MediaQueueSize = size
+	}
+
+	S3Endpoint = os.Getenv("S3_ENDPOINT")
+	S3Bucket = os.Getenv("S3_BUCKET")
+	S3AccessKey = os.Getenv("S3_ACCESS_KEY")
+	S3SecretKey = os.Getenv("S3_SECRET_KEY")
+
+	if region := os.Getenv("S3_REGION"); region != "" {
+		S3Region = region
+	}
+
+	if store := os.Getenv("TIMELINE_STORE"); store != "" {
+		TimelineStore = store
+	}
+
+	if capacity, err := strconv.Atoi(os.Getenv("TIMELINE_CAPACITY")); err == nil && capacity > 0 {
+		TimelineCapacity = capacity
+	}
+
+	if maxFanOut, err := strconv.Atoi(os.Getenv("TIMELINE_MAX_FAN_OUT")); err == nil && maxFanOut >= 0 {
+		TimelineMaxFanOut = maxFanOut
+	}
+
+	if workers, err := strconv.Atoi(os.Getenv("TIMELINE_WORKERS")); err == nil && workers > 0 {
+		TimelineWorkers = workers
+	}
+
+	if size, err := strconv.Atoi(os.Getenv("TIMELINE_QUEUE_SIZE")); err == nil && size > 0 {
+		TimelineQueueSize = size
+	}
+
+	if ttl, err := time.ParseDuration(os.Getenv("TIMELINE_TTL")); err == nil && ttl >= 0 {
+		TimelineTTL = ttl
+	}
+
+	if addr := os.Getenv("REDIS_ADDR"); addr != "" {
+		RedisAddr = addr
+	}
+
+	RedisPassword = os.Getenv("REDIS_PASSWORD")
+
+	if db, err := strconv.Atoi(os.Getenv("REDIS_DB")); err == nil && db >= 0 {
+		RedisDB = db
+	}
+
+	if size, err := strconv.Atoi(os.Getenv("REDIS_POOL_SIZE")); err == nil && size > 0 {
+		RedisPoolSize = size
+	}
+
+	if timeout, err := time.ParseDuration(os.Getenv("REDIS_TIMEOUT")); err == nil && timeout > 0 {
+		RedisTimeout = timeout
+	}
+}
+
+// parseSizes read a comma separated list of positive sizes, like 150,600,1200
+func parseSizes(value string) ([]int, error) {
+	var sizes []int
+	for _, field := range strings.Split(value, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		if size <= 0 {
+			return nil, fmt.Errorf("Invalid size %d", size)
+		}
+		sizes = append(sizes, size)
+	}
+
+	return sizes, nil
+}
+
+// parseList read a comma separated list, ignoring empty fields
+func parseList(value string) []string {
+	var list []string
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			list = append(list, field)
+		}
+	}
+
+	return list
 }
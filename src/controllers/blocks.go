@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/events"
+	"api/src/responses"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// BlockUser block user, removing the follow relationship in both directions
+func (handler *Handler) BlockUser(w http.ResponseWriter, r *http.Request) {
+	handler.changeRelation(w, r, handler.users.Block, "Não é possível bloquear você mesmo", events.UserBlocked)
+}
+
+// UnblockUser remove the block from user
+func (handler *Handler) UnblockUser(w http.ResponseWriter, r *http.Request) {
+	handler.changeRelation(w, r, handler.users.Unblock, "Não é possível desbloquear você mesmo", "")
+}
+
+// MuteUser hide publications from user in the logged user feed
+func (handler *Handler) MuteUser(w http.ResponseWriter, r *http.Request) {
+	handler.changeRelation(w, r, handler.users.Mute, "Não é possível silenciar você mesmo", "")
+}
+
+// UnmuteUser show publications from user in the logged user feed again
+func (handler *Handler) UnmuteUser(w http.ResponseWriter, r *http.Request) {
+	handler.changeRelation(w, r, handler.users.Unmute, "Não é possível deixar de silenciar você mesmo", events.UserUnmuted)
+}
+
+// changeRelation apply change from the logged user to the user in path, publishing event when it is not empty
+func (handler *Handler) changeRelation(w http.ResponseWriter, r *http.Request, change func(userId, otherId uint64) error, selfError string, event events.Type) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	otherId, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if userId == otherId {
+		responses.AppError(w, http.StatusForbidden, errors.New(selfError))
+		return
+	}
+
+	other, err := handler.users.FindByID(otherId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if other.ID == 0 {
+		responses.AppError(w, http.StatusNotFound, errors.New("Usuário não encontrado"))
+		return
+	}
+
+	if err = change(userId, otherId); err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if event != "" {
+		handler.events.Publish(events.Event{Type: event, ActorId: userId, UserId: otherId})
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,151 @@
+package controllers_test
+
+import (
+	"api/src/controllers"
+	"api/src/events"
+	"api/src/models"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// searchUsers return the sorted nicks found by the user search of userId
+func searchUsers(t *testing.T, server *server, userId uint64, filter string) []string {
+	t.Helper()
+
+	var page struct {
+		Data []models.User `json:"data"`
+	}
+	decode(t, server.request(http.MethodGet, "/users?user="+filter, server.token(userId), nil), http.StatusOK, &page)
+
+	nicks := []string{}
+	for _, user := range page.Data {
+		nicks = append(nicks, user.Nick)
+	}
+	sort.Strings(nicks)
+	return nicks
+}
+
+func TestBlockAndMuteRequests(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{"block yourself", http.MethodPost, fmt.Sprintf("/users/%d/block", aliceId), http.StatusForbidden},
+		{"mute yourself", http.MethodPost, fmt.Sprintf("/users/%d/mute", aliceId), http.StatusForbidden},
+		{"block missing user", http.MethodPost, "/users/999/block", http.StatusNotFound},
+		{"mute missing user", http.MethodPost, "/users/999/mute", http.StatusNotFound},
+		{"invalid id", http.MethodDelete, "/users/abc/block", http.StatusBadRequest},
+		{"without token", http.MethodPost, fmt.Sprintf("/users/%d/block", aliceId+1), http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := server.token(aliceId)
+			if test.status == http.StatusUnauthorized {
+				token = ""
+			}
+
+			decode(t, server.request(test.method, test.path, token, nil), test.status, nil)
+		})
+	}
+}
+
+func TestBlockHidesUsersFromEachOther(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+	bobId := server.createUser("bob")
+	carolId := server.createUser("carol")
+
+	server.follow(aliceId, bobId, http.StatusNoContent)
+	server.follow(bobId, aliceId, http.StatusNoContent)
+	server.follow(aliceId, carolId, http.StatusNoContent)
+	server.publish(aliceId, models.Publication{Title: "alice", Content: "from alice"})
+	server.publish(bobId, models.Publication{Title: "bob", Content: "from bob"})
+	server.publish(carolId, models.Publication{Title: "carol", Content: "from carol"})
+
+	decode(t, server.request(http.MethodPost, fmt.Sprintf("/users/%d/block", bobId), server.token(aliceId), nil), http.StatusNoContent, nil)
+	if blocked := server.events.published(events.UserBlocked); len(blocked) != 1 || blocked[0].ActorId != aliceId || blocked[0].UserId != bobId {
+		t.Errorf("expected one block event from alice, got %+v", blocked)
+	}
+
+	for _, pair := range [][2]uint64{{aliceId, bobId}, {bobId, aliceId}} {
+		if following, _ := server.repos.Users.IsFollower(pair[0], pair[1]); following {
+			t.Errorf("expected block to remove %d following %d", pair[1], pair[0])
+		}
+	}
+
+	// neither side can follow the other back while the block lasts
+	server.follow(bobId, aliceId, http.StatusForbidden)
+	server.follow(aliceId, bobId, http.StatusForbidden)
+
+	viewers := []struct {
+		name  string
+		id    uint64
+		users []string
+	}{
+		{"blocker", aliceId, []string{"alice", "carol"}},
+		{"blocked", bobId, []string{"bob", "carol"}},
+		{"bystander", carolId, []string{"alice", "bob", "carol"}},
+	}
+
+	for _, viewer := range viewers {
+		t.Run(viewer.name, func(t *testing.T) {
+			if nicks := searchUsers(t, server, viewer.id, ""); !reflect.DeepEqual(nicks, viewer.users) {
+				t.Errorf("expected users %v, got %v", viewer.users, nicks)
+			}
+		})
+	}
+
+	decode(t, server.request(http.MethodDelete, fmt.Sprintf("/users/%d/block", bobId), server.token(aliceId), nil), http.StatusNoContent, nil)
+	server.follow(aliceId, bobId, http.StatusNoContent)
+
+	if feed := server.feed(t, aliceId, ""); !reflect.DeepEqual(feed, []string{"carol", "bob", "alice"}) {
+		t.Errorf("expected the feed back after unblock, got %v", feed)
+	}
+}
+
+func TestMuteHidesPublicationsOnlyFromTheMuter(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+	bobId := server.createUser("bob")
+	carolId := server.createUser("carol")
+
+	server.follow(aliceId, bobId, http.StatusNoContent)
+	server.follow(carolId, bobId, http.StatusNoContent)
+	server.publish(bobId, models.Publication{Title: "bob", Content: "from bob"})
+
+	mute := fmt.Sprintf("/users/%d/mute", bobId)
+
+	steps := []struct {
+		name   string
+		method string
+		alice  []string
+		carol  []string
+	}{
+		{"muted", http.MethodPost, []string{}, []string{"bob"}},
+		{"unmuted", http.MethodDelete, []string{"bob"}, []string{"bob"}},
+	}
+
+	for _, step := range steps {
+		decode(t, server.request(step.method, mute, server.token(aliceId), nil), http.StatusNoContent, nil)
+
+		if feed := server.feed(t, aliceId, ""); !reflect.DeepEqual(feed, step.alice) {
+			t.Errorf("%s: expected muter feed %v, got %v", step.name, step.alice, feed)
+		}
+		if feed := server.feed(t, carolId, ""); !reflect.DeepEqual(feed, step.carol) {
+			t.Errorf("%s: expected feed of others %v, got %v", step.name, step.carol, feed)
+		}
+	}
+
+	if following, _ := server.repos.Users.IsFollower(bobId, aliceId); !following {
+		t.Error("expected mute to keep the follow")
+	}
+}
@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/events"
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/responses"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateComment add new comment or reply in publication
+func (handler *Handler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	publicationId, err := strconv.ParseUint(params["publicationId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.AppError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var comment models.Comment
+	if err = json.Unmarshal(reqBody, &comment); err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	comment.AuthorId = userId
+	comment.PublicationId = publicationId
+
+	if err = comment.Prepare(); err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	publication, err := handler.publications.FindById(publicationId, userId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if publication.ID == 0 {
+		responses.AppError(w, http.StatusNotFound, errors.New("Publicação não encontrada"))
+		return
+	}
+
+	if comment.ParentCommentId != 0 {
+		parent, err := handler.comments.FindById(comment.ParentCommentId)
+		if err != nil {
+			responses.AppError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if parent.ID == 0 || parent.PublicationId != publicationId {
+			responses.AppError(w, http.StatusBadRequest, errors.New("O comentário respondido não pertence a esta publicação"))
+			return
+		}
+
+		if parent.ParentCommentId != 0 {
+			responses.AppError(w, http.StatusBadRequest, errors.New("Não é possível responder uma resposta"))
+			return
+		}
+	}
+
+	comment.ID, err = handler.comments.Create(comment)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	handler.events.Publish(events.Event{
+		Type:          events.CommentCreated,
+		ActorId:       userId,
+		UserId:        publication.AuthorId,
+		PublicationId: publicationId,
+		CommentId:     comment.ID,
+	})
+
+	responses.JSON(w, http.StatusCreated, comment)
+}
+
+// FindCommentsByPublication find one page of first level comments from publication, newest first, with their replies
+func (handler *Handler) FindCommentsByPublication(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	publicationId, err := strconv.ParseUint(params["publicationId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	publication, err := handler.publications.FindById(publicationId, userId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if publication.ID == 0 {
+		responses.AppError(w, http.StatusNotFound, errors.New("Publicação não encontrada"))
+		return
+	}
+
+	comments, err := handler.comments.FindByPublication(publicationId, page)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, pagination.NewPage(comments, page, models.Comment.Cursor))
+}
+
+// UpdateComment edit comment content, only comment author can edit it
+func (handler *Handler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	publicationId, err := strconv.ParseUint(params["publicationId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	commentId, err := strconv.ParseUint(params["commentId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	existComment, err := handler.comments.FindById(commentId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if existComment.ID == 0 || existComment.PublicationId != publicationId {
+		responses.AppError(w, http.StatusNotFound, errors.New("Comentário não encontrado"))
+		return
+	}
+
+	if existComment.AuthorId != userId {
+		responses.AppError(w, http.StatusForbidden, errors.New("Não é possível atualizar um comentário que não seja seu"))
+		return
+	}
+
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.AppError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var comment models.Comment
+	if err = json.Unmarshal(reqBody, &comment); err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = comment.Prepare(); err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = handler.comments.Update(commentId, comment); err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// DeleteComment remove comment, allowed to comment author and publication author
+func (handler *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	publicationId, err := strconv.ParseUint(params["publicationId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	commentId, err := strconv.ParseUint(params["commentId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	existComment, err := handler.comments.FindById(commentId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if existComment.ID == 0 || existComment.PublicationId != publicationId {
+		responses.AppError(w, http.StatusNotFound, errors.New("Comentário não encontrado"))
+		return
+	}
+
+	if existComment.AuthorId != userId {
+		publication, err := handler.publications.FindById(publicationId, userId)
+		if err != nil {
+			responses.AppError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if publication.AuthorId != userId {
+			responses.AppError(w, http.StatusForbidden, errors.New("Não é possível remover um comentário que não seja seu"))
+			return
+		}
+	}
+
+	if err = handler.comments.Delete(commentId); err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
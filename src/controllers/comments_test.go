@@ -0,0 +1,142 @@
+package controllers_test
+
+import (
+	"api/src/controllers"
+	"api/src/models"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// comment create a comment from author in publication, replying to parent when it is not zero
+func (server *server) comment(authorId, publicationId, parentId uint64, content string) models.Comment {
+	server.t.Helper()
+
+	var comment models.Comment
+	body := models.Comment{Content: content, ParentCommentId: parentId}
+	decode(server.t, server.request(http.MethodPost, fmt.Sprintf("/publications/%d/comments", publicationId), server.token(authorId), body), http.StatusCreated, &comment)
+	return comment
+}
+
+// thread return every page of comments of publication as "content: replies", newest comment first
+func thread(t *testing.T, server *server, userId, publicationId uint64) []string {
+	t.Helper()
+
+	threads := []string{}
+	cursor := ""
+	for {
+		var page struct {
+			Data       []models.Comment `json:"data"`
+			NextCursor string           `json:"next_cursor"`
+			HasMore    bool             `json:"has_more"`
+		}
+		path := fmt.Sprintf("/publications/%d/comments?limit=1&cursor=%s", publicationId, cursor)
+		decode(t, server.request(http.MethodGet, path, server.token(userId), nil), http.StatusOK, &page)
+
+		for _, comment := range page.Data {
+			line := comment.Content + ":"
+			for _, reply := range comment.Replies {
+				line += " " + reply.Content
+			}
+			threads = append(threads, line)
+		}
+
+		if !page.HasMore {
+			return threads
+		}
+		cursor = page.NextCursor
+	}
+}
+
+func TestCommentsThread(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+	bobId := server.createUser("bob")
+	post := server.publish(aliceId, models.Publication{Title: "post", Content: "hello"})
+	other := server.publish(aliceId, models.Publication{Title: "other", Content: "elsewhere"})
+	diary := server.publish(aliceId, models.Publication{Title: "diary", Content: "secret", Visibility: models.VisibilityPrivate})
+
+	first := server.comment(bobId, post.ID, 0, "first")
+	server.comment(aliceId, post.ID, first.ID, "thanks")
+	reply := server.comment(bobId, post.ID, first.ID, "welcome")
+	server.comment(aliceId, post.ID, 0, "second")
+	elsewhere := server.comment(bobId, other.ID, 0, "elsewhere")
+
+	if found, expected := thread(t, server, bobId, post.ID), []string{"second:", "first: thanks welcome"}; !reflect.DeepEqual(found, expected) {
+		t.Errorf("expected thread %v, got %v", expected, found)
+	}
+
+	rejected := []struct {
+		name          string
+		publicationId uint64
+		body          models.Comment
+		status        int
+	}{
+		{"reply to a reply", post.ID, models.Comment{Content: "deeper", ParentCommentId: reply.ID}, http.StatusBadRequest},
+		{"reply to a comment of other publication", post.ID, models.Comment{Content: "lost", ParentCommentId: elsewhere.ID}, http.StatusBadRequest},
+		{"reply to a missing comment", post.ID, models.Comment{Content: "lost", ParentCommentId: 999}, http.StatusBadRequest},
+		{"blank content", post.ID, models.Comment{Content: "  "}, http.StatusBadRequest},
+		{"hidden publication", diary.ID, models.Comment{Content: "peek"}, http.StatusNotFound},
+	}
+
+	for _, test := range rejected {
+		path := fmt.Sprintf("/publications/%d/comments", test.publicationId)
+		decode(t, server.request(http.MethodPost, path, server.token(bobId), test.body), test.status, nil)
+	}
+
+	if found, expected := thread(t, server, bobId, post.ID), []string{"second:", "first: thanks welcome"}; !reflect.DeepEqual(found, expected) {
+		t.Errorf("expected rejected comments left out of thread %v, got %v", expected, found)
+	}
+
+	decode(t, server.request(http.MethodGet, fmt.Sprintf("/publications/%d/comments", diary.ID), server.token(bobId), nil), http.StatusNotFound, nil)
+	decode(t, server.request(http.MethodGet, fmt.Sprintf("/publications/%d/comments?cursor=invalid", post.ID), server.token(bobId), nil), http.StatusBadRequest, nil)
+	decode(t, server.request(http.MethodGet, fmt.Sprintf("/publications/%d/comments", post.ID), "", nil), http.StatusUnauthorized, nil)
+}
+
+func TestCommentOwnership(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+	bobId := server.createUser("bob")
+	carolId := server.createUser("carol")
+	post := server.publish(aliceId, models.Publication{Title: "post", Content: "hello"})
+	other := server.publish(aliceId, models.Publication{Title: "other", Content: "elsewhere"})
+
+	byBob := server.comment(bobId, post.ID, 0, "bob")
+	byCarol := server.comment(carolId, post.ID, 0, "carol")
+	byAlice := server.comment(aliceId, post.ID, 0, "alice")
+
+	path := func(comment models.Comment) string {
+		return fmt.Sprintf("/publications/%d/comments/%d", post.ID, comment.ID)
+	}
+
+	steps := []struct {
+		name   string
+		method string
+		path   string
+		userId uint64
+		status int
+		thread []string
+	}{
+		{"publication author edits comment of other", http.MethodPut, path(byBob), aliceId, http.StatusForbidden, []string{"alice:", "carol:", "bob:"}},
+		{"other user edits comment", http.MethodPut, path(byBob), carolId, http.StatusForbidden, []string{"alice:", "carol:", "bob:"}},
+		{"author edits own comment", http.MethodPut, path(byBob), bobId, http.StatusNoContent, []string{"alice:", "carol:", "edited:"}},
+		{"edit through other publication", http.MethodPut, fmt.Sprintf("/publications/%d/comments/%d", other.ID, byBob.ID), bobId, http.StatusNotFound, []string{"alice:", "carol:", "edited:"}},
+		{"edit missing comment", http.MethodPut, fmt.Sprintf("/publications/%d/comments/999", post.ID), bobId, http.StatusNotFound, []string{"alice:", "carol:", "edited:"}},
+		{"other user deletes comment", http.MethodDelete, path(byCarol), bobId, http.StatusForbidden, []string{"alice:", "carol:", "edited:"}},
+		{"commenter deletes comment of publication author", http.MethodDelete, path(byAlice), bobId, http.StatusForbidden, []string{"alice:", "carol:", "edited:"}},
+		{"author deletes own comment", http.MethodDelete, path(byBob), bobId, http.StatusNoContent, []string{"alice:", "carol:"}},
+		{"publication author deletes any comment", http.MethodDelete, path(byCarol), aliceId, http.StatusNoContent, []string{"alice:"}},
+		{"delete missing comment", http.MethodDelete, path(byCarol), aliceId, http.StatusNotFound, []string{"alice:"}},
+	}
+
+	for _, step := range steps {
+		decode(t, server.request(step.method, step.path, server.token(step.userId), models.Comment{Content: "edited"}), step.status, nil)
+
+		if found := thread(t, server, aliceId, post.ID); !reflect.DeepEqual(found, step.thread) {
+			t.Errorf("%s: expected thread %v, got %v", step.name, step.thread, found)
+		}
+	}
+
+	decode(t, server.request(http.MethodDelete, path(byAlice), "", nil), http.StatusUnauthorized, nil)
+}
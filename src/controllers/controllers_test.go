@@ -0,0 +1,189 @@
+package controllers_test
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/controllers"
+	"api/src/events"
+	"api/src/messaging"
+	"api/src/models"
+	"api/src/ranking"
+	"api/src/repositories"
+	"api/src/repositories/memory"
+	"api/src/router"
+	"api/src/trending"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder keep the published events, so tests can check them without a bus
+type recorder struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func (recorder *recorder) Publish(event events.Event) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	recorder.events = append(recorder.events, event)
+}
+
+// published return the events recorded with type kind
+func (recorder *recorder) published(kind events.Type) []events.Event {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	var found []events.Event
+	for _, event := range recorder.events {
+		if event.Type == kind {
+			found = append(found, event)
+		}
+	}
+	return found
+}
+
+// server is the API routes wired to in memory repositories
+type server struct {
+	t        *testing.T
+	repos    repositories.Repositories
+	events   *recorder
+	services controllers.Services
+	router   http.Handler
+}
+
+// newServer create a server over empty in memory repositories
+func newServer(t *testing.T, services controllers.Services) *server {
+	return serve(t, memory.NewRepositories(), services)
+}
+
+// serve create a server over repos, services without Events publish to the recorder of the server and
+// services without Rankers, Messages or Trending use the default ones
+func serve(t *testing.T, repos repositories.Repositories, services controllers.Services) *server {
+	config.SecretKey = []byte("test secret")
+
+	authentication.SetRevocationChecker(repos.Tokens)
+
+	recorded := &recorder{}
+	if services.Events == nil {
+		services.Events = recorded
+	}
+	if services.Rankers == nil {
+		services.Rankers = ranking.NewRankers(repos.Publications, repos.Comments, ranking.DefaultWeights)
+	}
+	if services.Messages == nil {
+		// sockets look for revoked tokens often, so tests do not wait to see them closed
+		services.Messages = messaging.NewService(repos, messaging.NewGateway(), 10*time.Millisecond)
+	}
+	if services.Trending == nil {
+		// the worker is not started, tests refresh it when they need a ranking
+		services.Trending = trending.NewWorker(repos.Hashtags, time.Hour, time.Hour, 10)
+	}
+
+	return &server{
+		t:        t,
+		repos:    repos,
+		events:   recorded,
+		services: services,
+		router:   router.Generate(controllers.NewHandler(repos, nil, services)),
+	}
+}
+
+// request send body as JSON with token as bearer when it is not empty
+func (server *server) request(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			server.t.Fatal(err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	r := httptest.NewRequest(method, path, reader)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, r)
+	return w
+}
+
+// createUser sign up a user with nick as name, e-mail prefix and password, returning its id
+func (server *server) createUser(nick string) uint64 {
+	w := server.request(http.MethodPost, "/users", "", map[string]string{
+		"name": nick, "nick": nick, "email": nick + "@example.com", "password": nick,
+	})
+	if w.Code != http.StatusCreated {
+		server.t.Fatalf("creating %s: %d %s", nick, w.Code, w.Body)
+	}
+
+	user, err := server.repos.Users.FindByEmail(nick + "@example.com")
+	if err != nil {
+		server.t.Fatal(err)
+	}
+	return user.ID
+}
+
+// token create an access token for user, bound to a family of its own
+func (server *server) token(userId uint64) string {
+	token, err := authentication.CreateToken(userId, fmt.Sprintf("family-%d", userId))
+	if err != nil {
+		server.t.Fatal(err)
+	}
+	return token
+}
+
+// publish create publication as author, returning it as the API does
+func (server *server) publish(authorId uint64, publication models.Publication) models.Publication {
+	server.t.Helper()
+
+	decode(server.t, server.request(http.MethodPost, "/publications", server.token(authorId), publication), http.StatusCreated, &publication)
+	return publication
+}
+
+// follow make follower follow user, failing unless the API answers with status
+func (server *server) follow(followerId, userId uint64, status int) {
+	server.t.Helper()
+
+	decode(server.t, server.request(http.MethodPost, fmt.Sprintf("/users/%d/follower", userId), server.token(followerId), nil), status, nil)
+}
+
+// feed return the titles of the first page of the home feed of user
+func (server *server) feed(t *testing.T, userId uint64, query string) []string {
+	t.Helper()
+
+	var page struct {
+		Data []models.Publication `json:"data"`
+	}
+	decode(t, server.request(http.MethodGet, "/publications"+query, server.token(userId), nil), http.StatusOK, &page)
+
+	titles := []string{}
+	for _, publication := range page.Data {
+		titles = append(titles, publication.Title)
+	}
+	return titles
+}
+
+// decode read the JSON body of w into value, failing when status is not the expected one
+func decode(t *testing.T, w *httptest.ResponseRecorder, status int, value interface{}) {
+	t.Helper()
+
+	if w.Code != status {
+		t.Fatalf("expected status %d, got %d %s", status, w.Code, w.Body)
+	}
+
+	if value != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), value); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
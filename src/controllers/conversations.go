@@ -0,0 +1,316 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/messaging"
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/responses"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+// webSocketProtocol is the subprotocol browsers use to send the access token, since they cannot
+// set the Authorization header: new WebSocket(url, ["bearer", token])
+const webSocketProtocol = "bearer"
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	Subprotocols:    []string{webSocketProtocol},
+	CheckOrigin:     checkOrigin,
+}
+
+// checkOrigin accept sockets from the API host itself and from the origins in config.AllowedOrigins.
+// Clients that are not browsers send no Origin and are accepted, since they cannot be hijacked by other sites.
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	parsed, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	if strings.EqualFold(parsed.Host, r.Host) {
+		return true
+	}
+
+	for _, allowed := range config.AllowedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CreateConversation start a direct conversation or a small group with the logged user
+func (handler *Handler) CreateConversation(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.AppError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var conversation models.Conversation
+	if err = json.Unmarshal(reqBody, &conversation); err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = conversation.Prepare(userId); err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	conversation, created, err := handler.messages.StartConversation(conversation)
+	if err != nil {
+		messagingError(w, err)
+		return
+	}
+
+	if !created {
+		responses.JSON(w, http.StatusOK, conversation)
+		return
+	}
+
+	responses.JSON(w, http.StatusCreated, conversation)
+}
+
+// FindConversations find one page of conversations from the logged user, most recently active first
+func (handler *Handler) FindConversations(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	conversations, err := handler.messages.Conversations(userId, page)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, pagination.NewPage(conversations, page, models.Conversation.Cursor))
+}
+
+// FindConversation find one conversation from the logged user with its members
+func (handler *Handler) FindConversation(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	conversationId, err := strconv.ParseUint(params["conversationId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	conversation, err := handler.messages.Conversation(userId, conversationId)
+	if err != nil {
+		messagingError(w, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, conversation)
+}
+
+// FindMessages find one page of the conversation history, newest first
+func (handler *Handler) FindMessages(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	conversationId, err := strconv.ParseUint(params["conversationId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	messages, err := handler.messages.Messages(userId, conversationId, page)
+	if err != nil {
+		messagingError(w, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, pagination.NewPage(messages, page, models.Message.Cursor))
+}
+
+// SendMessage send a message in conversation, delivered in real time to members connected in WebSocket
+func (handler *Handler) SendMessage(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	conversationId, err := strconv.ParseUint(params["conversationId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.AppError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var message models.Message
+	if err = json.Unmarshal(reqBody, &message); err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	message.ConversationId = conversationId
+	message.SenderId = userId
+
+	if err = message.Prepare(); err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	message, err = handler.messages.Send(message)
+	if err != nil {
+		messagingError(w, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusCreated, message)
+}
+
+// MarkConversationRead save the last message the logged user read in conversation
+func (handler *Handler) MarkConversationRead(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	conversationId, err := strconv.ParseUint(params["conversationId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.AppError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var read messaging.Frame
+	if err = json.Unmarshal(reqBody, &read); err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if read.MessageId == 0 {
+		responses.AppError(w, http.StatusBadRequest, errors.New("O campo messageId é obrigatório"))
+		return
+	}
+
+	if err = handler.messages.MarkRead(userId, conversationId, read.MessageId); err != nil {
+		messagingError(w, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// DirectMessagesSocket upgrade the request to the WebSocket that delivers messages, typing
+// indicators and read receipts. The token comes in the Authorization header or in the bearer subprotocol.
+func (handler *Handler) DirectMessagesSocket(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") == "" {
+		if token := tokenFromProtocols(websocket.Subprotocols(r)); token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	if err := authentication.ValidateToken(r); err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	details, err := authentication.GetTokenDetails(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	// the upgrader already answered the request when it fails
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	// the socket lives only while the token that opened it is valid
+	handler.messages.Serve(messaging.Session{
+		UserId:    details.UserID,
+		TokenId:   details.ID,
+		FamilyId:  details.FamilyID,
+		ExpiresAt: details.ExpiresAt,
+	}, conn)
+}
+
+// tokenFromProtocols return the token sent after the bearer subprotocol
+func tokenFromProtocols(protocols []string) string {
+	for i, protocol := range protocols {
+		if strings.EqualFold(protocol, webSocketProtocol) && i+1 < len(protocols) {
+			return protocols[i+1]
+		}
+	}
+
+	return ""
+}
+
+func messagingError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, messaging.ErrConversationNotFound):
+		responses.AppError(w, http.StatusNotFound, errors.New("Conversa não encontrada"))
+	case errors.Is(err, messaging.ErrUserNotFound):
+		responses.AppError(w, http.StatusNotFound, errors.New("Usuário não encontrado"))
+	case errors.Is(err, messaging.ErrBlocked):
+		responses.AppError(w, http.StatusForbidden, errors.New("Não é possível enviar mensagens para este usuário"))
+	default:
+		responses.AppError(w, http.StatusInternalServerError, err)
+	}
+}
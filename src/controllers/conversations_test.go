@@ -0,0 +1,150 @@
+package controllers_test
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/controllers"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// dial open the messages socket of api with token, sending origin when it is not empty
+func dial(api *httptest.Server, token, origin string) (*websocket.Conn, *http.Response, error) {
+	header := http.Header{"Authorization": {"Bearer " + token}}
+	if origin != "" {
+		header.Set("Origin", origin)
+	}
+
+	return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(api.URL, "http")+"/ws", header)
+}
+
+func TestSocketAcceptsOnlyAllowedOrigins(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+
+	api := httptest.NewServer(server.router)
+	defer api.Close()
+
+	config.AllowedOrigins = []string{"https://devbook.app"}
+	defer func() { config.AllowedOrigins = nil }()
+
+	tests := []struct {
+		name   string
+		origin string
+		status int
+	}{
+		{"client without origin", "", http.StatusSwitchingProtocols},
+		{"api host", api.URL, http.StatusSwitchingProtocols},
+		{"allowed origin", "https://devbook.app", http.StatusSwitchingProtocols},
+		{"other site", "https://evil.example", http.StatusForbidden},
+		{"allowed host with another scheme", "http://devbook.app", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn, response, err := dial(api, server.token(aliceId), test.origin)
+			if conn != nil {
+				conn.Close()
+			}
+
+			if response == nil {
+				t.Fatalf("no response: %v", err)
+			}
+			if response.StatusCode != test.status {
+				t.Errorf("expected status %d, got %d", test.status, response.StatusCode)
+			}
+		})
+	}
+}
+
+// sessionEnd is one way a session of alice ends, closing her live connections with reason
+type sessionEnd struct {
+	name   string
+	open   func(t *testing.T, server *server) string
+	end    func(t *testing.T, server *server, token string)
+	reason string
+}
+
+var sessionEnds = []sessionEnd{
+	{
+		name: "logout",
+		open: func(t *testing.T, server *server) string { return login(t, server, "alice").AccessToken },
+		end: func(t *testing.T, server *server, token string) {
+			decode(t, server.request(http.MethodPost, "/logout", token, nil), http.StatusNoContent, nil)
+		},
+		reason: "Token revoked",
+	},
+	{
+		name: "family revoked elsewhere",
+		open: func(t *testing.T, server *server) string { return login(t, server, "alice").AccessToken },
+		end: func(t *testing.T, server *server, token string) {
+			details := tokenDetails(t, token)
+			if err := server.repos.Tokens.RevokeFamily(details.FamilyID, time.Now().Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+		},
+		reason: "Token revoked",
+	},
+	{
+		name: "token expired",
+		open: func(t *testing.T, server *server) string {
+			ttl := config.AccessTokenTTL
+			defer func() { config.AccessTokenTTL = ttl }()
+
+			config.AccessTokenTTL = time.Second
+			return server.token(1)
+		},
+		end:    func(t *testing.T, server *server, token string) {},
+		reason: "Token expired",
+	},
+}
+
+func TestSocketClosesWhenSessionEnds(t *testing.T) {
+	for _, test := range sessionEnds {
+		t.Run(test.name, func(t *testing.T) {
+			server := newServer(t, controllers.Services{})
+			server.createUser("alice")
+
+			api := httptest.NewServer(server.router)
+			defer api.Close()
+
+			token := test.open(t, server)
+			conn, _, err := dial(api, token, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			test.end(t, server, token)
+
+			conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+			_, _, err = conn.ReadMessage()
+
+			var closed *websocket.CloseError
+			if !errors.As(err, &closed) || closed.Code != websocket.ClosePolicyViolation || closed.Text != test.reason {
+				t.Errorf("expected the socket closed with %q, got %v", test.reason, err)
+			}
+		})
+	}
+}
+
+// tokenDetails read the claims of token as the API does
+func tokenDetails(t *testing.T, token string) authentication.TokenDetails {
+	t.Helper()
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	details, err := authentication.GetTokenDetails(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return details
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/events"
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/repositories"
+	"api/src/responses"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// FindDrafts find the drafts and scheduled publications from logged user
+func (handler *Handler) FindDrafts(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	drafts, err := handler.publications.FindDrafts(userId, page)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, pagination.NewPage(drafts, page, models.Publication.Cursor))
+}
+
+// UpdateDraft edit a draft or scheduled publication from logged user, which can also be scheduled
+// or published now by changing its status
+func (handler *Handler) UpdateDraft(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	publicationId, err := strconv.ParseUint(params["publicationId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	draft, err := handler.publications.FindById(publicationId, userId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if draft.ID == 0 || draft.AuthorId != userId || draft.Status == models.StatusPublished {
+		responses.AppError(w, http.StatusNotFound, errors.New("Rascunho não encontrado"))
+		return
+	}
+
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.AppError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var publication models.Publication
+	if err = json.Unmarshal(reqBody, &publication); err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if publication.Visibility == "" {
+		publication.Visibility = draft.Visibility
+	}
+
+	if publication.MediaIds == nil {
+		publication.MediaIds = draft.MediaIds
+	}
+
+	if publication.Status == "" {
+		publication.Status = draft.Status
+	}
+
+	if publication.Status == models.StatusScheduled && publication.PublishAt == nil {
+		publication.PublishAt = draft.PublishAt
+	}
+
+	if err = publication.Prepare(); err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// the scheduler may publish the draft after it was read, the repository refuses the update then
+	if err = handler.publications.UpdateDraft(publicationId, publication); err != nil {
+		if errors.Is(err, repositories.ErrNotDraft) {
+			responses.AppError(w, http.StatusNotFound, errors.New("Rascunho não encontrado"))
+			return
+		}
+		if errors.Is(err, repositories.ErrMediaUnavailable) {
+			responses.AppError(w, http.StatusBadRequest, errors.New("Mídia não encontrada ou já usada em outra publicação"))
+			return
+		}
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	updated, err := handler.publications.FindById(publicationId, userId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if updated.Status == models.StatusPublished {
+		handler.events.Publish(events.Event{Type: events.PublicationCreated, ActorId: userId, PublicationId: publicationId})
+		handler.publishMentions(updated, nil)
+	}
+
+	responses.JSON(w, http.StatusOK, updated)
+}
@@ -0,0 +1,114 @@
+package controllers_test
+
+import (
+	"api/src/controllers"
+	"api/src/events"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/repositories/memory"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// publishedMeanwhile run the scheduler right before each draft update, like when it publishes the draft
+// after the handler checked it
+type publishedMeanwhile struct {
+	repositories.PublicationRepository
+}
+
+func (publications publishedMeanwhile) UpdateDraft(publicationId uint64, publication models.Publication) error {
+	if _, err := publications.PublishDue(time.Now().Add(24*time.Hour), 10); err != nil {
+		return err
+	}
+	return publications.PublicationRepository.UpdateDraft(publicationId, publication)
+}
+
+// drafts return the titles of the drafts from user
+func drafts(t *testing.T, server *server, userId uint64) []string {
+	t.Helper()
+
+	var page struct {
+		Data []models.Publication `json:"data"`
+	}
+	decode(t, server.request(http.MethodGet, "/drafts", server.token(userId), nil), http.StatusOK, &page)
+
+	titles := []string{}
+	for _, publication := range page.Data {
+		titles = append(titles, publication.Title)
+	}
+	return titles
+}
+
+func TestUpdateDraft(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+	bobId := server.createUser("bob")
+
+	publishAt := time.Now().Add(time.Hour)
+	draft := server.publish(aliceId, models.Publication{Title: "draft", Content: "not yet", Status: models.StatusDraft})
+	scheduled := server.publish(aliceId, models.Publication{Title: "scheduled", Content: "later", Status: models.StatusScheduled, PublishAt: &publishAt})
+	published := server.publish(aliceId, models.Publication{Title: "published", Content: "now"})
+
+	if titles := drafts(t, server, aliceId); !reflect.DeepEqual(titles, []string{"scheduled", "draft"}) {
+		t.Errorf("expected drafts of alice, got %v", titles)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name   string
+		userId uint64
+		id     uint64
+		body   models.Publication
+		status int
+	}{
+		{"edit keeping the status", aliceId, draft.ID, models.Publication{Title: "draft", Content: "edited"}, http.StatusOK},
+		{"schedule in the past", aliceId, draft.ID, models.Publication{Title: "draft", Content: "edited", Status: models.StatusScheduled, PublishAt: &past}, http.StatusBadRequest},
+		{"draft of other user", bobId, draft.ID, models.Publication{Title: "draft", Content: "mine"}, http.StatusNotFound},
+		{"published publication", aliceId, published.ID, models.Publication{Title: "published", Content: "again"}, http.StatusNotFound},
+		{"publish scheduled now", aliceId, scheduled.ID, models.Publication{Title: "scheduled", Content: "now", Status: models.StatusPublished}, http.StatusOK},
+		{"edit after publishing", aliceId, scheduled.ID, models.Publication{Title: "scheduled", Content: "again"}, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decode(t, server.request(http.MethodPut, fmt.Sprintf("/drafts/%d", test.id), server.token(test.userId), test.body), test.status, nil)
+		})
+	}
+
+	if titles := drafts(t, server, aliceId); !reflect.DeepEqual(titles, []string{"draft"}) {
+		t.Errorf("expected only the draft left, got %v", titles)
+	}
+
+	created := server.events.published(events.PublicationCreated)
+	if len(created) != 2 || created[1].PublicationId != scheduled.ID {
+		t.Errorf("expected the scheduled publication announced once when published, got %+v", created)
+	}
+}
+
+func TestUpdateDraftPublishedByScheduler(t *testing.T) {
+	repos := memory.NewRepositories()
+	repos.Publications = publishedMeanwhile{repos.Publications}
+	server := serve(t, repos, controllers.Services{})
+	aliceId := server.createUser("alice")
+
+	// the scheduler publishes only due publications, so the edit is the one rescheduling it
+	publishAt := time.Now().Add(time.Hour)
+	scheduled := server.publish(aliceId, models.Publication{Title: "scheduled", Content: "later", Status: models.StatusScheduled, PublishAt: &publishAt})
+
+	later := time.Now().Add(48 * time.Hour)
+	decode(t, server.request(http.MethodPut, fmt.Sprintf("/drafts/%d", scheduled.ID), server.token(aliceId), models.Publication{
+		Title: "scheduled", Content: "edited", Status: models.StatusScheduled, PublishAt: &later,
+	}), http.StatusNotFound, nil)
+
+	found, err := repos.Publications.FindById(scheduled.ID, aliceId)
+	if err != nil || found.Status != models.StatusPublished || found.Content != "later" {
+		t.Errorf("expected the publication kept as the scheduler published it, got %+v, %v", found, err)
+	}
+
+	if created := server.events.published(events.PublicationCreated); len(created) != 0 {
+		t.Errorf("expected the edit not to announce the publication, got %+v", created)
+	}
+}
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/events"
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/repositories"
+	"api/src/responses"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// FindFollowRequests find pending follow requests sent to the logged user
+func (handler *Handler) FindFollowRequests(w http.ResponseWriter, r *http.Request) {
+	userIdInToken, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	userId, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if userId != userIdInToken {
+		responses.AppError(w, http.StatusForbidden, errors.New("Não é possível ver solicitações de outro usuário"))
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	requests, err := handler.users.FindFollowRequests(userId, page)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, pagination.NewPage(requests, page, models.FollowRequest.Cursor))
+}
+
+// ApproveFollowRequest make requester a follower of the logged user
+func (handler *Handler) ApproveFollowRequest(w http.ResponseWriter, r *http.Request) {
+	userId, requesterId, ok := followRequestParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := handler.users.ApproveFollowRequest(userId, requesterId); err != nil {
+		if errors.Is(err, repositories.ErrFollowRequestNotFound) {
+			responses.AppError(w, http.StatusNotFound, err)
+			return
+		}
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	handler.events.Publish(events.Event{Type: events.FollowRequestApproved, ActorId: userId, UserId: requesterId})
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// RejectFollowRequest remove a pending follow request sent to the logged user
+func (handler *Handler) RejectFollowRequest(w http.ResponseWriter, r *http.Request) {
+	userId, requesterId, ok := followRequestParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := handler.users.DeleteFollowRequest(userId, requesterId); err != nil {
+		if errors.Is(err, repositories.ErrFollowRequestNotFound) {
+			responses.AppError(w, http.StatusNotFound, err)
+			return
+		}
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// requestToFollow create a pending request to follow a private account, answered with 202 until it is approved
+func (handler *Handler) requestToFollow(w http.ResponseWriter, userId, requesterId uint64) {
+	following, err := handler.users.IsFollower(userId, requesterId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if following {
+		responses.AppError(w, http.StatusConflict, errors.New("Você já segue este usuário"))
+		return
+	}
+
+	if err = handler.users.CreateFollowRequest(userId, requesterId); err != nil {
+		if errors.Is(err, repositories.ErrFollowRequestExists) {
+			responses.AppError(w, http.StatusConflict, err)
+			return
+		}
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	handler.events.Publish(events.Event{Type: events.FollowRequested, ActorId: requesterId, UserId: userId})
+
+	responses.JSON(w, http.StatusAccepted, nil)
+}
+
+// followRequestParams read the logged user and requester id, writing the error response when they are invalid
+func followRequestParams(w http.ResponseWriter, r *http.Request) (uint64, uint64, bool) {
+	userIdInToken, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return 0, 0, false
+	}
+
+	params := mux.Vars(r)
+	userId, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return 0, 0, false
+	}
+
+	requesterId, err := strconv.ParseUint(params["requesterId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return 0, 0, false
+	}
+
+	if userId != userIdInToken {
+		responses.AppError(w, http.StatusForbidden, errors.New("Não é possível responder solicitações de outro usuário"))
+		return 0, 0, false
+	}
+
+	return userId, requesterId, true
+}
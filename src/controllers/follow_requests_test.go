@@ -0,0 +1,83 @@
+package controllers_test
+
+import (
+	"api/src/controllers"
+	"api/src/models"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// editProfile send the profile of nick with extra fields, returning the user stored afterwards
+func editProfile(t *testing.T, server *server, userId uint64, nick string, extra map[string]interface{}) models.User {
+	t.Helper()
+
+	body := map[string]interface{}{"name": nick, "nick": nick, "email": nick + "@example.com"}
+	for field, value := range extra {
+		body[field] = value
+	}
+
+	decode(t, server.request(http.MethodPut, fmt.Sprintf("/users/%d", userId), server.token(userId), body), http.StatusNoContent, nil)
+
+	user, err := server.repos.Users.FindByID(userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestProfileEditKeepsPrivacyWhenOmitted(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	ownerId := server.createUser("owner")
+
+	edits := []struct {
+		name    string
+		extra   map[string]interface{}
+		private bool
+	}{
+		{"turn private", map[string]interface{}{"isPrivate": true}, true},
+		{"edit private profile without isPrivate", nil, true},
+		{"turn public", map[string]interface{}{"isPrivate": false}, false},
+		{"edit public profile without isPrivate", nil, false},
+	}
+
+	for _, edit := range edits {
+		if user := editProfile(t, server, ownerId, "owner", edit.extra); user.IsPrivate != edit.private {
+			t.Errorf("%s: expected private %v", edit.name, edit.private)
+		}
+	}
+}
+
+func TestPendingRequestsApprovedWhenAccountTurnsPublic(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	ownerId := server.createUser("owner")
+	requesterId := server.createUser("requester")
+
+	editProfile(t, server, ownerId, "owner", map[string]interface{}{"isPrivate": true})
+	hidden := server.publish(ownerId, models.Publication{Title: "hidden", Content: "only for followers"})
+
+	server.follow(requesterId, ownerId, http.StatusAccepted)
+
+	path := fmt.Sprintf("/publications/%d", hidden.ID)
+	decode(t, server.request(http.MethodGet, path, server.token(requesterId), nil), http.StatusNotFound, nil)
+
+	editProfile(t, server, ownerId, "owner", map[string]interface{}{"isPrivate": false})
+
+	if following, _ := server.repos.Users.IsFollower(ownerId, requesterId); !following {
+		t.Error("expected pending requester to follow the public account")
+	}
+
+	var requests struct {
+		Data []models.FollowRequest `json:"data"`
+	}
+	decode(t, server.request(http.MethodGet, fmt.Sprintf("/users/%d/follow-requests", ownerId), server.token(ownerId), nil), http.StatusOK, &requests)
+	if len(requests.Data) != 0 {
+		t.Errorf("expected no orphaned requests, got %+v", requests.Data)
+	}
+
+	decode(t, server.request(http.MethodGet, path, server.token(requesterId), nil), http.StatusOK, nil)
+
+	// turning private again must not bring the old request back
+	editProfile(t, server, ownerId, "owner", map[string]interface{}{"isPrivate": true})
+	decode(t, server.request(http.MethodGet, path, server.token(requesterId), nil), http.StatusOK, nil)
+}
@@ -0,0 +1,54 @@
+package controllers_test
+
+import (
+	"api/src/controllers"
+	"api/src/events"
+	"api/src/pagination"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestUnfollowPublishesOnlyRemovedFollows(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+	bobId := server.createUser("bob")
+	carolId := server.createUser("carol")
+	editProfile(t, server, carolId, "carol", map[string]interface{}{"isPrivate": true})
+
+	server.follow(bobId, aliceId, http.StatusNoContent)
+	server.follow(bobId, carolId, http.StatusAccepted)
+
+	steps := []struct {
+		name   string
+		userId uint64
+		status int
+	}{
+		{"unfollow", aliceId, http.StatusNoContent},
+		{"unfollow again", aliceId, http.StatusNoContent},
+		{"cancel pending request", carolId, http.StatusNoContent},
+		{"unfollow yourself", bobId, http.StatusForbidden},
+	}
+
+	for _, step := range steps {
+		path := fmt.Sprintf("/users/%d/unfollow", step.userId)
+		decode(t, server.request(http.MethodPost, path, server.token(bobId), nil), step.status, nil)
+
+		if following, _ := server.repos.Users.IsFollower(step.userId, bobId); following {
+			t.Errorf("%s: expected bob not following", step.name)
+		}
+
+		if published := server.events.published(events.UserUnfollowed); len(published) != 1 {
+			t.Errorf("%s: expected only the first unfollow published, got %+v", step.name, published)
+		}
+	}
+
+	if requests, _ := server.repos.Users.FindFollowRequests(carolId, pagination.Params{Limit: 10}); len(requests) != 0 {
+		t.Errorf("expected the pending request canceled, got %+v", requests)
+	}
+
+	expected := events.Event{Type: events.UserUnfollowed, ActorId: bobId, UserId: aliceId}
+	if published := server.events.published(events.UserUnfollowed); len(published) != 1 || published[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, published)
+	}
+}
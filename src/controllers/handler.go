@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"api/src/events"
+	"api/src/media"
+	"api/src/messaging"
+	"api/src/ranking"
+	"api/src/repositories"
+	"api/src/stream"
+	"api/src/timeline"
+	"api/src/trending"
+	"context"
+	"database/sql"
+)
+
+// Pool is the database connection pool shared by the API, implemented by *sql.DB
+type Pool interface {
+	PingContext(ctx context.Context) error
+	Stats() sql.DBStats
+}
+
+// Services group the in-process services used by controllers besides the repositories
+type Services struct {
+	// Events receive the domain events produced by controllers
+	Events events.Publisher
+	// Stream deliver live updates to connected users
+	Stream *stream.Hub
+	// Messages send direct messages, serve their WebSocket and watch the sessions of open streams
+	Messages *messaging.Service
+	// Trending hold the hashtags ranking refreshed in background
+	Trending *trending.Worker
+	// Media store uploaded images and videos
+	Media *media.Service
+	// Rankers order the home feed, chosen by the client with the ranking parameter
+	Rankers ranking.Rankers
+	// Timeline serve the home feed from the materialized timelines, the feed is read from the repository when nil
+	Timeline *timeline.Service
+}
+
+// Handler hold the repositories used by controllers, injected so controllers can be tested without database
+type Handler struct {
+	pool          Pool
+	events        events.Publisher
+	stream        *stream.Hub
+	messages      *messaging.Service
+	trending      *trending.Worker
+	media         *media.Service
+	rankers       ranking.Rankers
+	timeline      *timeline.Service
+	users         repositories.UserRepository
+	publications  repositories.PublicationRepository
+	comments      repositories.CommentRepository
+	tokens        repositories.TokenRepository
+	notifications repositories.NotificationRepository
+	hashtags      repositories.HashtagRepository
+	search        repositories.Searcher
+}
+
+// NewHandler create a handler with the received repositories and services, pool may be nil when there is no database
+func NewHandler(repos repositories.Repositories, pool Pool, services Services) *Handler {
+	return &Handler{
+		pool:          pool,
+		events:        services.Events,
+		stream:        services.Stream,
+		messages:      services.Messages,
+		trending:      services.Trending,
+		media:         services.Media,
+		rankers:       services.Rankers,
+		timeline:      services.Timeline,
+		users:         repos.Users,
+		publications:  repos.Publications,
+		comments:      repos.Comments,
+		tokens:        repos.Tokens,
+		notifications: repos.Notifications,
+		hashtags:      repos.Hashtags,
+		search:        repos.Search,
+	}
+}
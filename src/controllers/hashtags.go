@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/responses"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// FindPublicationsByHashtag find one page of publications with the hashtag, most recent first
+func (handler *Handler) FindPublicationsByHashtag(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	tag := models.NormalizeHashtag(mux.Vars(r)["tag"])
+	if tag == "" {
+		responses.AppError(w, http.StatusBadRequest, errors.New("Hashtag inválida"))
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	publications, err := handler.hashtags.FindPublications(tag, userId, page)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, pagination.NewPage(publications, page, models.Publication.Cursor))
+}
+
+// FindTrendingHashtags return the hashtags ranking computed by the trending worker
+func (handler *Handler) FindTrendingHashtags(w http.ResponseWriter, r *http.Request) {
+	responses.JSON(w, http.StatusOK, handler.trending.Trending())
+}
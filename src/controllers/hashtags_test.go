@@ -0,0 +1,102 @@
+package controllers_test
+
+import (
+	"api/src/controllers"
+	"api/src/models"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFindPublicationsByHashtag(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+	bobId := server.createUser("bob")
+	carolId := server.createUser("carol")
+
+	server.follow(carolId, bobId, http.StatusNoContent)
+	server.publish(aliceId, models.Publication{Title: "first", Content: "learning #Go and #mysql"})
+	server.publish(bobId, models.Publication{Title: "second", Content: "more #go"})
+	server.publish(bobId, models.Publication{Title: "hidden", Content: "secret #go", Visibility: models.VisibilityFollowers})
+	server.publish(aliceId, models.Publication{Title: "private", Content: "diary #go", Visibility: models.VisibilityPrivate})
+
+	tests := []struct {
+		name   string
+		viewer uint64
+		path   string
+		status int
+		titles []string
+	}{
+		{"visible to author without following", aliceId, "/hashtags/go/publications", http.StatusOK, []string{"private", "second", "first"}},
+		{"visible to follower", carolId, "/hashtags/go/publications", http.StatusOK, []string{"hidden", "second", "first"}},
+		{"tag in any case", carolId, "/hashtags/GO/publications", http.StatusOK, []string{"hidden", "second", "first"}},
+		{"first page", carolId, "/hashtags/go/publications?limit=2", http.StatusOK, []string{"hidden", "second"}},
+		{"other hashtag", carolId, "/hashtags/mysql/publications", http.StatusOK, []string{"first"}},
+		{"unused hashtag", carolId, "/hashtags/rust/publications", http.StatusOK, []string{}},
+		{"invalid hashtag", carolId, "/hashtags/%23/publications", http.StatusBadRequest, nil},
+		{"invalid limit", carolId, "/hashtags/go/publications?limit=abc", http.StatusBadRequest, nil},
+		{"without token", 0, "/hashtags/go/publications", http.StatusUnauthorized, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := ""
+			if test.viewer != 0 {
+				token = server.token(test.viewer)
+			}
+
+			w := server.request(http.MethodGet, test.path, token, nil)
+			if test.status != http.StatusOK {
+				decode(t, w, test.status, nil)
+				return
+			}
+
+			var page struct {
+				Data []models.Publication `json:"data"`
+			}
+			decode(t, w, http.StatusOK, &page)
+
+			titles := []string{}
+			for _, publication := range page.Data {
+				titles = append(titles, publication.Title)
+			}
+
+			if !reflect.DeepEqual(titles, test.titles) {
+				t.Errorf("expected %v, got %v", test.titles, titles)
+			}
+		})
+	}
+}
+
+func TestFindTrendingHashtags(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+
+	for _, content := range []string{"#go and #mysql", "more #go", "#go #rust", "secret #rust"} {
+		server.publish(aliceId, models.Publication{Title: "post", Content: content})
+	}
+	server.publish(aliceId, models.Publication{Title: "private", Content: "#diary", Visibility: models.VisibilityPrivate})
+
+	var trending models.Trending
+	decode(t, server.request(http.MethodGet, "/hashtags/trending", server.token(aliceId), nil), http.StatusOK, &trending)
+	if len(trending.Hashtags) != 0 {
+		t.Errorf("expected no ranking before the worker refresh, got %+v", trending.Hashtags)
+	}
+
+	if err := server.services.Trending.Refresh(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	decode(t, server.request(http.MethodGet, "/hashtags/trending", server.token(aliceId), nil), http.StatusOK, &trending)
+
+	expected := []models.TrendingHashtag{{Tag: "go", Uses: 3}, {Tag: "rust", Uses: 2}, {Tag: "mysql", Uses: 1}}
+	var ranking []models.TrendingHashtag
+	for _, hashtag := range trending.Hashtags {
+		ranking = append(ranking, models.TrendingHashtag{Tag: hashtag.Tag, Uses: hashtag.Uses})
+	}
+
+	if !reflect.DeepEqual(ranking, expected) {
+		t.Errorf("expected %+v, got %+v", expected, ranking)
+	}
+}
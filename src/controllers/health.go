@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"api/src/db"
+	"api/src/responses"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const healthCheckTimeout = 2 * time.Second
+
+// DatabaseHealth ping the database and return the connection pool statistics
+func (handler *Handler) DatabaseHealth(w http.ResponseWriter, r *http.Request) {
+	if handler.pool == nil {
+		responses.AppError(w, http.StatusServiceUnavailable, errors.New("Banco de dados não configurado"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	status, statusCode := "up", http.StatusOK
+	if err := handler.pool.PingContext(ctx); err != nil {
+		status, statusCode = "down", http.StatusServiceUnavailable
+	}
+
+	responses.JSON(w, statusCode, struct {
+		Status string       `json:"status"`
+		Pool   db.PoolStats `json:"pool"`
+	}{
+		Status: status,
+		Pool:   db.NewPoolStats(handler.pool.Stats()),
+	})
+}
@@ -0,0 +1,13 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/responses"
+	"net/http"
+)
+
+// JWKS return the public keys used to verify tokens issued by this API
+func JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	responses.JSON(w, http.StatusOK, authentication.PublicKeys())
+}
@@ -0,0 +1,103 @@
+package controllers_test
+
+import (
+	"api/src/controllers"
+	"api/src/events"
+	"api/src/models"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestLikeAndUnlike(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+	bobId := server.createUser("bob")
+
+	post := server.publish(aliceId, models.Publication{Title: "post", Content: "hello"})
+	diary := server.publish(aliceId, models.Publication{Title: "diary", Content: "secret", Visibility: models.VisibilityPrivate})
+
+	path := func(publication models.Publication, action string) string {
+		return fmt.Sprintf("/publications/%d/%s", publication.ID, action)
+	}
+
+	steps := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+		likes  uint64
+	}{
+		{"like", path(post, "like"), server.token(bobId), http.StatusNoContent, 1},
+		{"like twice", path(post, "like"), server.token(bobId), http.StatusConflict, 1},
+		{"like own publication", path(post, "like"), server.token(aliceId), http.StatusNoContent, 2},
+		{"unlike", path(post, "unlike"), server.token(bobId), http.StatusNoContent, 1},
+		{"unlike twice", path(post, "unlike"), server.token(bobId), http.StatusConflict, 1},
+		{"like hidden publication", path(diary, "like"), server.token(bobId), http.StatusNotFound, 1},
+		{"unlike hidden publication", path(diary, "unlike"), server.token(bobId), http.StatusNotFound, 1},
+		{"like missing publication", "/publications/999/like", server.token(bobId), http.StatusNotFound, 1},
+		{"unlike missing publication", "/publications/999/unlike", server.token(bobId), http.StatusNotFound, 1},
+		{"invalid id", "/publications/abc/like", server.token(bobId), http.StatusBadRequest, 1},
+		{"without token", path(post, "like"), "", http.StatusUnauthorized, 1},
+	}
+
+	for _, step := range steps {
+		decode(t, server.request(http.MethodPost, step.path, step.token, nil), step.status, nil)
+
+		var publication models.Publication
+		decode(t, server.request(http.MethodGet, fmt.Sprintf("/publications/%d", post.ID), server.token(aliceId), nil), http.StatusOK, &publication)
+		if publication.Likes != step.likes {
+			t.Errorf("%s: expected %d likes, got %d", step.name, step.likes, publication.Likes)
+		}
+	}
+
+	liked := []events.Event{
+		{Type: events.PublicationLiked, ActorId: bobId, UserId: aliceId, PublicationId: post.ID},
+		{Type: events.PublicationLiked, ActorId: aliceId, UserId: aliceId, PublicationId: post.ID},
+	}
+	if published := server.events.published(events.PublicationLiked); !reflect.DeepEqual(published, liked) {
+		t.Errorf("expected only accepted likes published, got %+v", published)
+	}
+}
+
+func TestFindPublicationLikes(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+	post := server.publish(aliceId, models.Publication{Title: "post", Content: "hello"})
+	diary := server.publish(aliceId, models.Publication{Title: "diary", Content: "secret", Visibility: models.VisibilityPrivate})
+
+	for _, nick := range []string{"bob", "carol", "dave"} {
+		userId := server.createUser(nick)
+		decode(t, server.request(http.MethodPost, fmt.Sprintf("/publications/%d/like", post.ID), server.token(userId), nil), http.StatusNoContent, nil)
+	}
+
+	var nicks []string
+	cursor := ""
+	for pages := 0; pages < 3; pages++ {
+		var page struct {
+			Data       []models.User `json:"data"`
+			NextCursor string        `json:"next_cursor"`
+			HasMore    bool          `json:"has_more"`
+		}
+		query := fmt.Sprintf("/publications/%d/likes?limit=2&cursor=%s", post.ID, cursor)
+		decode(t, server.request(http.MethodGet, query, server.token(aliceId), nil), http.StatusOK, &page)
+
+		for _, user := range page.Data {
+			nicks = append(nicks, user.Nick)
+		}
+		if !page.HasMore {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	if expected := []string{"dave", "carol", "bob"}; !reflect.DeepEqual(nicks, expected) {
+		t.Errorf("expected likes most recent first %v, got %v", expected, nicks)
+	}
+
+	strangerId := server.createUser("erin")
+	decode(t, server.request(http.MethodGet, fmt.Sprintf("/publications/%d/likes", diary.ID), server.token(strangerId), nil), http.StatusNotFound, nil)
+	decode(t, server.request(http.MethodGet, fmt.Sprintf("/publications/%d/likes?cursor=invalid", post.ID), server.token(aliceId), nil), http.StatusBadRequest, nil)
+	decode(t, server.request(http.MethodGet, fmt.Sprintf("/publications/%d/likes", post.ID), "", nil), http.StatusUnauthorized, nil)
+}
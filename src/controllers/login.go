@@ -2,18 +2,20 @@ package controllers
 
 import (
 	"api/src/authentication"
-	"api/src/db"
+	"api/src/config"
 	"api/src/models"
 	"api/src/repositories"
 	"api/src/responses"
 	"api/src/secure"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
+	"time"
 )
 
 //Login ensure athenticate user
-func Login(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	reqBody, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		responses.AppError(w, http.StatusUnprocessableEntity, err)
@@ -26,29 +28,163 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, err := db.CreateConnection()
+	userExist, err := handler.users.FindByEmail(user.Email)
 	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
-	defer db.Close()
 
-	repository := repositories.NewUserRepository(db)
-	userExist, err := repository.FindByEmail(user.Email)
+	if err = secure.VerifyPassword(userExist.Password, user.Password); err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	familyId, err := authentication.NewFamilyID()
 	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
+		return
 	}
 
-	if err = secure.VerifyPassword(userExist.Password, user.Password); err != nil {
-		responses.AppError(w, http.StatusUnauthorized, err)
+	refreshToken, err := authentication.CreateRefreshToken()
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if _, err = handler.tokens.CreateRefreshToken(models.RefreshToken{
+		UserId:    userExist.ID,
+		FamilyId:  familyId,
+		TokenHash: authentication.HashRefreshToken(refreshToken),
+		ExpiresAt: time.Now().Add(config.RefreshTokenTTL),
+	}); err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	accessToken, err := authentication.CreateToken(userExist.ID, familyId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, newAuthenticationTokens(accessToken, refreshToken))
+}
+
+// RefreshToken rotate refresh token and return a new pair of tokens.
+// Using a refresh token already rotated revoke the whole token family.
+func (handler *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.AppError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var tokens models.AuthenticationTokens
+	if err = json.Unmarshal(reqBody, &tokens); err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if tokens.RefreshToken == "" {
+		responses.AppError(w, http.StatusBadRequest, errors.New("Refresh token cannot be blank"))
+		return
+	}
+
+	usedToken, err := handler.tokens.FindRefreshTokenByHash(authentication.HashRefreshToken(tokens.RefreshToken))
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if usedToken.ID == 0 {
+		responses.AppError(w, http.StatusUnauthorized, errors.New("Refresh token inválido"))
 		return
 	}
 
-	token, err := authentication.CreateToken(userExist.ID)
+	if usedToken.RevokedAt != nil {
+		handler.revokeReusedFamily(w, usedToken.FamilyId)
+		return
+	}
+
+	if time.Now().After(usedToken.ExpiresAt) {
+		responses.AppError(w, http.StatusUnauthorized, errors.New("Refresh token expirado"))
+		return
+	}
+
+	refreshToken, err := authentication.CreateRefreshToken()
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if _, err = handler.tokens.RotateRefreshToken(usedToken.ID, models.RefreshToken{
+		UserId:    usedToken.UserId,
+		FamilyId:  usedToken.FamilyId,
+		TokenHash: authentication.HashRefreshToken(refreshToken),
+		ExpiresAt: time.Now().Add(config.RefreshTokenTTL),
+	}); err != nil {
+		if errors.Is(err, repositories.ErrRefreshTokenReused) {
+			handler.revokeReusedFamily(w, usedToken.FamilyId)
+			return
+		}
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	accessToken, err := authentication.CreateToken(usedToken.UserId, usedToken.FamilyId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, newAuthenticationTokens(accessToken, refreshToken))
+}
+
+// Logout revoke the access token in request and the whole family it was issued to,
+// so other access tokens from the same login stop working too
+func (handler *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	details, err := authentication.GetTokenDetails(r)
 	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	if err = handler.tokens.RevokeFamily(details.FamilyID, time.Now().Add(config.AccessTokenTTL)); err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
+		return
 	}
 
-	w.Write([]byte(token))
+	if err = handler.tokens.RevokeAccessToken(details.ID, details.ExpiresAt); err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+	handler.disconnect(details.FamilyID)
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
 
+func (handler *Handler) revokeReusedFamily(w http.ResponseWriter, familyId string) {
+	if err := handler.tokens.RevokeFamily(familyId, time.Now().Add(config.AccessTokenTTL)); err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+	handler.disconnect(familyId)
+
+	responses.AppError(w, http.StatusUnauthorized, errors.New("Refresh token reutilizado, a sessão foi encerrada"))
+}
+
+// disconnect close the message sockets and live streams opened with tokens from the revoked family
+func (handler *Handler) disconnect(familyId string) {
+	if handler.messages != nil {
+		handler.messages.Disconnect(familyId)
+	}
+}
+
+func newAuthenticationTokens(accessToken, refreshToken string) models.AuthenticationTokens {
+	return models.AuthenticationTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(config.AccessTokenTTL.Seconds()),
+	}
 }
@@ -0,0 +1,51 @@
+package controllers_test
+
+import (
+	"api/src/controllers"
+	"api/src/models"
+	"net/http"
+	"testing"
+)
+
+func login(t *testing.T, server *server, nick string) models.AuthenticationTokens {
+	var tokens models.AuthenticationTokens
+	decode(t, server.request(http.MethodPost, "/login", "", map[string]string{
+		"email": nick + "@example.com", "password": nick,
+	}), http.StatusOK, &tokens)
+	return tokens
+}
+
+func TestLogoutRevokesTheWholeFamily(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	server.createUser("alice")
+
+	session := login(t, server, "alice")
+	other := login(t, server, "alice")
+
+	var refreshed models.AuthenticationTokens
+	decode(t, server.request(http.MethodPost, "/token/refresh", "", map[string]string{
+		"refreshToken": session.RefreshToken,
+	}), http.StatusOK, &refreshed)
+
+	decode(t, server.request(http.MethodPost, "/logout", refreshed.AccessToken, nil), http.StatusNoContent, nil)
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"logged out token", refreshed.AccessToken, http.StatusUnauthorized},
+		{"token issued before refresh", session.AccessToken, http.StatusUnauthorized},
+		{"token from another login", other.AccessToken, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decode(t, server.request(http.MethodGet, "/users?user=alice", test.token, nil), test.status, nil)
+		})
+	}
+
+	decode(t, server.request(http.MethodPost, "/token/refresh", "", map[string]string{
+		"refreshToken": refreshed.RefreshToken,
+	}), http.StatusUnauthorized, nil)
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/media"
+	"api/src/models"
+	"api/src/responses"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// multipartOverhead is the room left for boundaries and headers when limiting the upload request body
+const multipartOverhead = 1 << 20
+
+// UploadMedia receive an image or video in the file field of a multipart form
+func (handler *Handler) UploadMedia(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, handler.media.MaxUploadSize()+multipartOverhead)
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			responses.AppError(w, http.StatusRequestEntityTooLarge, errors.New("Arquivo muito grande"))
+			return
+		}
+		responses.AppError(w, http.StatusBadRequest, errors.New("Envie o arquivo no campo file de um formulário multipart"))
+		return
+	}
+	defer file.Close()
+	defer r.MultipartForm.RemoveAll()
+
+	uploaded, err := handler.media.Upload(r.Context(), userId, file)
+	if err != nil {
+		switch {
+		case errors.Is(err, media.ErrUnsupportedType):
+			responses.AppError(w, http.StatusUnsupportedMediaType, errors.New("Tipo de arquivo não suportado"))
+		case errors.Is(err, media.ErrTooLarge):
+			responses.AppError(w, http.StatusRequestEntityTooLarge, errors.New("Arquivo muito grande"))
+		default:
+			responses.AppError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	responses.JSON(w, http.StatusCreated, uploaded.WithURL())
+}
+
+// FindMedia find one media that logged user is allowed to see
+func (handler *Handler) FindMedia(w http.ResponseWriter, r *http.Request) {
+	found, ok := handler.visibleMedia(w, r)
+	if !ok {
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, found.WithURL())
+}
+
+// FindMediaContent send the content of one media that logged user is allowed to see,
+// or of its variant when the size query parameter is received
+func (handler *Handler) FindMediaContent(w http.ResponseWriter, r *http.Request) {
+	found, ok := handler.visibleMedia(w, r)
+	if !ok {
+		return
+	}
+
+	var content io.ReadCloser
+	var err error
+
+	contentType, contentLength := found.ContentType, strconv.FormatInt(found.Size, 10)
+
+	if size := r.URL.Query().Get("size"); size != "" {
+		parsedSize, parseErr := strconv.Atoi(size)
+		if parseErr != nil {
+			responses.AppError(w, http.StatusBadRequest, errors.New("O parâmetro size deve ser um número"))
+			return
+		}
+
+		variant, ok := found.Variant(parsedSize)
+		if !ok {
+			responses.AppError(w, http.StatusNotFound, errors.New("Variante não encontrada"))
+			return
+		}
+
+		contentType, contentLength = variant.ContentType, ""
+		content, err = handler.media.OpenVariant(r.Context(), variant)
+	} else {
+		content, err = handler.media.Open(r.Context(), found)
+	}
+	if err != nil {
+		if errors.Is(err, media.ErrBlobNotFound) {
+			responses.AppError(w, http.StatusNotFound, errors.New("Mídia não encontrada"))
+			return
+		}
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	if contentLength != "" {
+		w.Header().Set("Content-Length", contentLength)
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+
+	io.Copy(w, content)
+}
+
+// visibleMedia find the media from route, answering 404 when it does not exist or logged user cannot see it.
+// Media not attached is visible only to its owner, attached media follows the publication visibility
+func (handler *Handler) visibleMedia(w http.ResponseWriter, r *http.Request) (models.Media, bool) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return models.Media{}, false
+	}
+
+	params := mux.Vars(r)
+	mediaId, err := strconv.ParseUint(params["mediaId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return models.Media{}, false
+	}
+
+	found, err := handler.media.Find(mediaId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return models.Media{}, false
+	}
+
+	visible := found.ID != 0 && found.PublicationId == 0 && found.OwnerId == userId
+	if found.ID != 0 && found.PublicationId != 0 {
+		publication, err := handler.publications.FindById(found.PublicationId, userId)
+		if err != nil {
+			responses.AppError(w, http.StatusInternalServerError, err)
+			return models.Media{}, false
+		}
+		visible = publication.ID != 0
+	}
+
+	if !visible {
+		responses.AppError(w, http.StatusNotFound, errors.New("Mídia não encontrada"))
+		return models.Media{}, false
+	}
+
+	return found, true
+}
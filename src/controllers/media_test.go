@@ -0,0 +1,106 @@
+package controllers_test
+
+import (
+	"api/src/controllers"
+	"api/src/media"
+	"api/src/models"
+	"api/src/repositories/memory"
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+)
+
+// upload send content in field of a multipart form, declaring contentType for the file
+func (server *server) upload(token, field, contentType string, content []byte) *httptest.ResponseRecorder {
+	server.t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename="upload"`, field))
+	header.Set("Content-Type", contentType)
+	part, err := form.CreatePart(header)
+	if err != nil {
+		server.t.Fatal(err)
+	}
+	part.Write(content)
+	form.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/media", &body)
+	r.Header.Set("Content-Type", form.FormDataContentType())
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, r)
+	return w
+}
+
+func TestUploadMedia(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 10, 10))); err != nil {
+		t.Fatal(err)
+	}
+	picture := encoded.Bytes()
+	clip := append([]byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"), make([]byte, 2048)...)
+
+	repos := memory.NewRepositories()
+	server := serve(t, repos, controllers.Services{Media: media.NewService(repos.Media, media.NewLocalStore(t.TempDir()), nil, 1024, 4096)})
+	aliceId := server.createUser("alice")
+	bobId := server.createUser("bob")
+
+	tests := []struct {
+		name        string
+		token       string
+		field       string
+		contentType string
+		content     []byte
+		status      int
+	}{
+		{"image", server.token(aliceId), "file", "image/png", picture, http.StatusCreated},
+		{"image declared as another type", server.token(aliceId), "file", "application/octet-stream", picture, http.StatusCreated},
+		{"video over the image limit", server.token(aliceId), "file", "video/mp4", clip, http.StatusCreated},
+		{"text declared as image", server.token(aliceId), "file", "image/png", []byte("just some words"), http.StatusUnsupportedMediaType},
+		{"html declared as image", server.token(aliceId), "file", "image/png", []byte("<html><script>alert(1)</script></html>"), http.StatusUnsupportedMediaType},
+		{"image over its limit", server.token(aliceId), "file", "image/png", append(append([]byte{}, picture...), make([]byte, 1024)...), http.StatusRequestEntityTooLarge},
+		{"body over every limit", server.token(aliceId), "file", "video/mp4", make([]byte, 2<<20), http.StatusRequestEntityTooLarge},
+		{"other field", server.token(aliceId), "picture", "image/png", picture, http.StatusBadRequest},
+		{"without token", "", "file", "image/png", picture, http.StatusUnauthorized},
+	}
+
+	uploaded := map[string]models.Media{}
+	for _, test := range tests {
+		w := server.upload(test.token, test.field, test.contentType, test.content)
+
+		if test.status != http.StatusCreated {
+			decode(t, w, test.status, nil)
+			continue
+		}
+
+		var found models.Media
+		decode(t, w, test.status, &found)
+		uploaded[test.name] = found
+	}
+
+	if sniffed := uploaded["image declared as another type"]; sniffed.ContentType != "image/png" || sniffed.OwnerId != aliceId {
+		t.Errorf("expected the sniffed type saved, got %+v", sniffed)
+	}
+	if video := uploaded["video over the image limit"]; video.ContentType != "video/mp4" || video.Size != 2072 {
+		t.Errorf("expected the video saved, got %+v", video)
+	}
+
+	stored := uploaded["image"]
+	w := server.request(http.MethodGet, stored.URL, server.token(aliceId), nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" || !bytes.Equal(w.Body.Bytes(), picture) {
+		t.Errorf("expected the owner to read the image, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	decode(t, server.request(http.MethodGet, stored.URL, server.token(bobId), nil), http.StatusNotFound, nil)
+}
@@ -0,0 +1,91 @@
+package controllers_test
+
+import (
+	"api/src/controllers"
+	"api/src/events"
+	"api/src/models"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestMentions(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+	bobId := server.createUser("bob")
+	carolId := server.createUser("carol")
+	daveId := server.createUser("dave")
+
+	decode(t, server.request(http.MethodPost, fmt.Sprintf("/users/%d/block", aliceId), server.token(carolId), nil), http.StatusNoContent, nil)
+
+	greeting := server.publish(aliceId, models.Publication{Title: "greeting", Content: "hi @bob, @carol and @nobody"})
+	server.publish(aliceId, models.Publication{Title: "secret", Content: "only me and @bob", Visibility: models.VisibilityPrivate})
+
+	expected := []models.Mention{{Offset: 3, Length: 4, UserId: bobId}}
+	if !reflect.DeepEqual(greeting.Mentions, expected) {
+		t.Errorf("expected mentions %+v, got %+v", expected, greeting.Mentions)
+	}
+
+	// editing keeps bob, so only dave is mentioned again
+	decode(t, server.request(http.MethodPut, fmt.Sprintf("/publications/%d", greeting.ID), server.token(aliceId), models.Publication{
+		Title: "greeting", Content: "hi @bob and @dave",
+	}), http.StatusNoContent, nil)
+
+	mentioned := map[uint64]int{}
+	for _, event := range server.events.published(events.UserMentioned) {
+		if event.ActorId != aliceId {
+			t.Errorf("expected alice mentioning, got %+v", event)
+		}
+		mentioned[event.UserId]++
+	}
+	if !reflect.DeepEqual(mentioned, map[uint64]int{bobId: 2, daveId: 1}) {
+		t.Errorf("expected bob mentioned in two publications and dave once, got %v", mentioned)
+	}
+
+	tests := []struct {
+		name   string
+		viewer uint64
+		path   string
+		status int
+		titles []string
+	}{
+		{"mentions seen by mentioned user", bobId, fmt.Sprintf("/users/%d/mentions", bobId), http.StatusOK, []string{"greeting"}},
+		{"mentions seen by author", aliceId, fmt.Sprintf("/users/%d/mentions", bobId), http.StatusOK, []string{"secret", "greeting"}},
+		{"mention added by edit", daveId, fmt.Sprintf("/users/%d/mentions", daveId), http.StatusOK, []string{"greeting"}},
+		{"mention blocked by user", carolId, fmt.Sprintf("/users/%d/mentions", carolId), http.StatusOK, []string{}},
+		{"first page", aliceId, fmt.Sprintf("/users/%d/mentions?limit=1", bobId), http.StatusOK, []string{"secret"}},
+		{"missing user", bobId, "/users/999/mentions", http.StatusNotFound, nil},
+		{"invalid id", bobId, "/users/abc/mentions", http.StatusBadRequest, nil},
+		{"without token", 0, fmt.Sprintf("/users/%d/mentions", bobId), http.StatusUnauthorized, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := ""
+			if test.viewer != 0 {
+				token = server.token(test.viewer)
+			}
+
+			w := server.request(http.MethodGet, test.path, token, nil)
+			if test.status != http.StatusOK {
+				decode(t, w, test.status, nil)
+				return
+			}
+
+			var page struct {
+				Data []models.Publication `json:"data"`
+			}
+			decode(t, w, http.StatusOK, &page)
+
+			titles := []string{}
+			for _, publication := range page.Data {
+				titles = append(titles, publication.Title)
+			}
+
+			if !reflect.DeepEqual(titles, test.titles) {
+				t.Errorf("expected %v, got %v", test.titles, titles)
+			}
+		})
+	}
+}
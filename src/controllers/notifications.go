@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/responses"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+)
+
+// FindNotifications find one page from the logged user inbox with the number of unread notifications
+func (handler *Handler) FindNotifications(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	notifications, err := handler.notifications.Find(userId, page)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	unread, err := handler.notifications.CountUnread(userId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, models.NotificationPage{
+		Page:   pagination.NewPage(notifications, page, models.Notification.Cursor),
+		Unread: unread,
+	})
+}
+
+// MarkNotificationsAsRead mark the received notifications as read, all of them when no id is sent
+func (handler *Handler) MarkNotificationsAsRead(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		responses.AppError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var read models.NotificationsRead
+	if len(reqBody) > 0 {
+		if err = json.Unmarshal(reqBody, &read); err != nil {
+			responses.AppError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if err = handler.notifications.MarkAsRead(userId, read.IDs); err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
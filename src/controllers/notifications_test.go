@@ -0,0 +1,81 @@
+package controllers_test
+
+import (
+	"api/src/controllers"
+	"api/src/events"
+	"api/src/models"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestNotificationsInboxAndRead(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+	bobId := server.createUser("bob")
+
+	var ids []uint64
+	for _, notificationType := range []string{models.NotificationFollow, models.NotificationLike, models.NotificationComment} {
+		id, err := server.repos.Notifications.Create(models.Notification{UserId: aliceId, ActorId: bobId, Type: notificationType})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	steps := []struct {
+		name   string
+		userId uint64
+		body   interface{}
+		unread uint64
+	}{
+		{"nothing read", 0, nil, 3},
+		{"one read", aliceId, models.NotificationsRead{IDs: []uint64{ids[0]}}, 2},
+		{"notifications of others", bobId, models.NotificationsRead{IDs: []uint64{ids[1]}}, 2},
+		{"everything read", aliceId, nil, 0},
+	}
+
+	for _, step := range steps {
+		if step.userId != 0 {
+			decode(t, server.request(http.MethodPost, "/notifications/read", server.token(step.userId), step.body), http.StatusNoContent, nil)
+		}
+
+		var page struct {
+			Data   []models.Notification `json:"data"`
+			Unread uint64                `json:"unread"`
+		}
+		decode(t, server.request(http.MethodGet, "/notifications?limit=2", server.token(aliceId), nil), http.StatusOK, &page)
+
+		if page.Unread != step.unread {
+			t.Errorf("%s: expected %d unread, got %d", step.name, step.unread, page.Unread)
+		}
+		if len(page.Data) != 2 || page.Data[0].ID != ids[2] || page.Data[0].ActorNick != "bob" {
+			t.Errorf("%s: expected the newest page of the inbox, got %+v", step.name, page.Data)
+		}
+	}
+
+	decode(t, server.request(http.MethodGet, "/notifications", "", nil), http.StatusUnauthorized, nil)
+}
+
+func TestFollowAndPublishEmitEvents(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+	bobId := server.createUser("bob")
+
+	server.follow(bobId, aliceId, http.StatusNoContent)
+	publication := server.publish(aliceId, models.Publication{Title: "news", Content: "hello"})
+	decode(t, server.request(http.MethodPost, fmt.Sprintf("/publications/%d/like", publication.ID), server.token(bobId), nil), http.StatusNoContent, nil)
+
+	expected := []events.Event{
+		{Type: events.UserFollowed, ActorId: bobId, UserId: aliceId},
+		{Type: events.PublicationCreated, ActorId: aliceId, PublicationId: publication.ID},
+		{Type: events.PublicationLiked, ActorId: bobId, UserId: aliceId, PublicationId: publication.ID},
+	}
+
+	for _, event := range expected {
+		published := server.events.published(event.Type)
+		if len(published) != 1 || published[0] != event {
+			t.Errorf("expected %+v, got %+v", event, published)
+		}
+	}
+}
@@ -2,8 +2,11 @@ package controllers
 
 import (
 	"api/src/authentication"
-	"api/src/db"
+	"api/src/config"
+	"api/src/events"
 	"api/src/models"
+	"api/src/pagination"
+	"api/src/ranking"
 	"api/src/repositories"
 	"api/src/responses"
 	"encoding/json"
@@ -11,12 +14,13 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 // CreatePublication add new publication in database
-func CreatePublication(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) CreatePublication(w http.ResponseWriter, r *http.Request) {
 	userId, err := authentication.GetUserID(r)
 	if err != nil {
 		responses.AppError(w, http.StatusUnauthorized, err)
@@ -35,83 +39,171 @@ func CreatePublication(w http.ResponseWriter, r *http.Request) {
 	}
 
 	publication.AuthorId = userId
+	publication.RepostOfId = 0
 
 	if err = publication.Prepare(); err != nil {
 		responses.AppError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	db, err := db.CreateConnection()
+	if publication.QuoteOfId != 0 {
+		quoted, err := handler.publications.FindById(publication.QuoteOfId, userId)
+		if err != nil {
+			responses.AppError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if quoted.ID == 0 || quoted.Status != models.StatusPublished {
+			responses.AppError(w, http.StatusBadRequest, errors.New("Publicação citada não encontrada"))
+			return
+		}
+
+		// quoting a repost quotes the publication it shares
+		if quoted.RepostOfId != 0 {
+			publication.QuoteOfId = quoted.RepostOfId
+		}
+	}
+
+	publication.ID, err = handler.publications.Create(publication)
+
 	if err != nil {
+		if errors.Is(err, repositories.ErrMediaUnavailable) {
+			responses.AppError(w, http.StatusBadRequest, errors.New("Mídia não encontrada ou já usada em outra publicação"))
+			return
+		}
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
-	defer db.Close()
-
-	repository := repositories.NewPublicationRepository(db)
-	publication.ID, err = repository.Create(publication)
 
+	// read it back to return the mentions resolved by the repository
+	publication, err = handler.publications.FindById(publication.ID, userId)
 	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	// drafts and scheduled publications are announced only when published
+	if publication.Status == models.StatusPublished {
+		handler.events.Publish(events.Event{Type: events.PublicationCreated, ActorId: userId, PublicationId: publication.ID})
+		handler.publishMentions(publication, nil)
+	}
+
 	responses.JSON(w, http.StatusCreated, publication)
 }
 
-// FindAllPublicationsByUser find publications from user
-func FindAllPublicationsByUser(w http.ResponseWriter, r *http.Request) {
+// FindAllPublicationsByUser find the feed from user, the ranking parameter choose how it is ordered.
+// Ranked feeds rank a window of the most recent publications and page through that ranking, the cursor
+// keeps the window and the position, so it is valid only with the ranking that created it
+func (handler *Handler) FindAllPublicationsByUser(w http.ResponseWriter, r *http.Request) {
 	userId, err := authentication.GetUserID(r)
 	if err != nil {
 		responses.AppError(w, http.StatusUnauthorized, err)
 		return
 	}
 
-	db, err := db.CreateConnection()
+	name := r.URL.Query().Get("ranking")
+	if name == "" {
+		name = ranking.Chronological
+	}
+
+	ranker, ok := handler.rankers[name]
+	if !ok {
+		responses.AppError(w, http.StatusBadRequest, errors.New("O parâmetro ranking deve ser chronological ou engagement"))
+		return
+	}
+
+	feed := func(page pagination.Params) ([]models.Publication, error) {
+		if handler.timeline != nil {
+			return handler.timeline.Feed(r.Context(), userId, page)
+		}
+		return handler.publications.Find(userId, page)
+	}
+
+	if name == ranking.Chronological {
+		page, err := pagination.FromRequest(r)
+		if err != nil {
+			responses.AppError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		publications, err := feed(page)
+		if err != nil {
+			responses.AppError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		responses.JSON(w, http.StatusOK, pagination.NewPage(publications, page, models.Publication.Cursor))
+		return
+	}
+
+	limit, err := pagination.LimitFromRequest(r)
 	if err != nil {
-		responses.AppError(w, http.StatusInternalServerError, err)
+		responses.AppError(w, http.StatusBadRequest, err)
 		return
 	}
-	defer db.Close()
 
-	repository := repositories.NewPublicationRepository(db)
-	publications, err := repository.Find(userId)
+	var cursor *ranking.Cursor
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		decoded, err := ranking.DecodeCursor(value)
+		if err != nil {
+			responses.AppError(w, http.StatusBadRequest, err)
+			return
+		}
+		cursor = &decoded
+	}
+
+	page, err := ranking.Page(ranker, userId, feed, config.FeedRankingWindow, limit, cursor, time.Now())
 	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	responses.JSON(w, http.StatusOK, publications)
+	responses.JSON(w, http.StatusOK, page)
 }
 
-// FindPublicationByID find publications by publication id
-func FindPublicationByID(w http.ResponseWriter, r *http.Request) {
+// FindMentions find publications that mention user and logged user is allowed to see
+func (handler *Handler) FindMentions(w http.ResponseWriter, r *http.Request) {
+	viewerId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
 	params := mux.Vars(r)
-	publicationId, err := strconv.ParseUint(params["publicationId"], 10, 64)
+	userId, err := strconv.ParseUint(params["userId"], 10, 64)
 	if err != nil {
 		responses.AppError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	db, err := db.CreateConnection()
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	user, err := handler.users.FindByID(userId)
 	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
-	defer db.Close()
 
-	repository := repositories.NewPublicationRepository(db)
-	publication, err := repository.FindById(publicationId)
+	if user.ID == 0 {
+		responses.AppError(w, http.StatusNotFound, errors.New("Usuário não encontrado"))
+		return
+	}
+
+	publications, err := handler.publications.FindByMention(userId, viewerId, page)
 	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	responses.JSON(w, http.StatusOK, publication)
+	responses.JSON(w, http.StatusOK, pagination.NewPage(publications, page, models.Publication.Cursor))
 }
 
-// UpdatePublicationByID update publication by publication id
-func UpdatePublicationByID(w http.ResponseWriter, r *http.Request) {
+// FindPublicationByID find publications by publication id
+func (handler *Handler) FindPublicationByID(w http.ResponseWriter, r *http.Request) {
 	userId, err := authentication.GetUserID(r)
 	if err != nil {
 		responses.AppError(w, http.StatusUnauthorized, err)
@@ -125,15 +217,36 @@ func UpdatePublicationByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, err := db.CreateConnection()
+	publication, err := handler.publications.FindById(publicationId, userId)
 	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
-	defer db.Close()
 
-	repository := repositories.NewPublicationRepository(db)
-	existPublication, err := repository.FindById(publicationId)
+	if publication.ID == 0 {
+		responses.AppError(w, http.StatusNotFound, errors.New("Publicação não encontrada"))
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, publication)
+}
+
+// UpdatePublicationByID update publication by publication id
+func (handler *Handler) UpdatePublicationByID(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	publicationId, err := strconv.ParseUint(params["publicationId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	existPublication, err := handler.publications.FindById(publicationId, userId)
 	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
@@ -144,6 +257,11 @@ func UpdatePublicationByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if existPublication.RepostOfId != 0 {
+		responses.AppError(w, http.StatusBadRequest, errors.New("Não é possível editar um repost"))
+		return
+	}
+
 	reqBody, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		responses.AppError(w, http.StatusUnprocessableEntity, err)
@@ -156,21 +274,46 @@ func UpdatePublicationByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if publication.Visibility == "" {
+		publication.Visibility = existPublication.Visibility
+	}
+
+	if publication.MediaIds == nil {
+		publication.MediaIds = existPublication.MediaIds
+	}
+
+	// status changes only through the drafts endpoints
+	publication.Status = existPublication.Status
+	publication.PublishAt = existPublication.PublishAt
+
 	if err = publication.Prepare(); err != nil {
 		responses.AppError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	if err = repository.Update(publicationId, publication); err != nil {
+	if err = handler.publications.Update(publicationId, publication); err != nil {
+		if errors.Is(err, repositories.ErrMediaUnavailable) {
+			responses.AppError(w, http.StatusBadRequest, errors.New("Mídia não encontrada ou já usada em outra publicação"))
+			return
+		}
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if existPublication.Status == models.StatusPublished {
+		updated, err := handler.publications.FindById(publicationId, userId)
+		if err != nil {
+			responses.AppError(w, http.StatusInternalServerError, err)
+			return
+		}
+		handler.publishMentions(updated, existPublication.Mentions)
+	}
+
 	responses.JSON(w, http.StatusNoContent, nil)
 }
 
 // DeletePublicationByID remove publication by publication id
-func DeletePublicationByID(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) DeletePublicationByID(w http.ResponseWriter, r *http.Request) {
 	userId, err := authentication.GetUserID(r)
 	if err != nil {
 		responses.AppError(w, http.StatusUnauthorized, err)
@@ -184,29 +327,259 @@ func DeletePublicationByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, err := db.CreateConnection()
+	existPublication, err := handler.publications.FindById(publicationId, userId)
 	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
-	defer db.Close()
 
-	repository := repositories.NewPublicationRepository(db)
-	existPublication, err := repository.FindById(publicationId)
+	if existPublication.AuthorId != userId {
+		responses.AppError(w, http.StatusForbidden, errors.New("Não é possível remover uma publicação que não seja sua"))
+		return
+	}
+
+	if err = handler.publications.Delete(publicationId); err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// LikePublication add like from logged user in publication
+func (handler *Handler) LikePublication(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	publicationId, err := strconv.ParseUint(params["publicationId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	existPublication, err := handler.publications.FindById(publicationId, userId)
 	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	if existPublication.AuthorId != userId {
-		responses.AppError(w, http.StatusForbidden, errors.New("Não é possível remover uma publicação que não seja sua"))
+	if existPublication.ID == 0 {
+		responses.AppError(w, http.StatusNotFound, errors.New("Publicação não encontrada"))
 		return
 	}
 
-	if err = repository.Delete(publicationId); err != nil {
+	if err = handler.publications.Like(publicationId, userId); err != nil {
+		if errors.Is(err, repositories.ErrAlreadyLiked) {
+			responses.AppError(w, http.StatusConflict, err)
+			return
+		}
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	handler.events.Publish(events.Event{
+		Type:          events.PublicationLiked,
+		ActorId:       userId,
+		UserId:        existPublication.AuthorId,
+		PublicationId: publicationId,
+	})
+
 	responses.JSON(w, http.StatusNoContent, nil)
 }
+
+// UnlikePublication remove like from logged user in publication
+func (handler *Handler) UnlikePublication(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	publicationId, err := strconv.ParseUint(params["publicationId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	existPublication, err := handler.publications.FindById(publicationId, userId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if existPublication.ID == 0 {
+		responses.AppError(w, http.StatusNotFound, errors.New("Publicação não encontrada"))
+		return
+	}
+
+	if err = handler.publications.Unlike(publicationId, userId); err != nil {
+		if errors.Is(err, repositories.ErrNotLiked) {
+			responses.AppError(w, http.StatusConflict, err)
+			return
+		}
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// RepostPublication share with the followers from logged user a publication the user is allowed to see
+func (handler *Handler) RepostPublication(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	publicationId, err := strconv.ParseUint(params["publicationId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	original, err := handler.publications.FindById(publicationId, userId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// reposting a repost shares the publication it shares
+	if original.RepostOfId != 0 {
+		if original, err = handler.publications.FindById(original.RepostOfId, userId); err != nil {
+			responses.AppError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	if original.ID == 0 || original.Status != models.StatusPublished {
+		responses.AppError(w, http.StatusNotFound, errors.New("Publicação não encontrada"))
+		return
+	}
+
+	if original.Visibility == models.VisibilityPrivate {
+		responses.AppError(w, http.StatusBadRequest, errors.New("Não é possível repostar uma publicação privada"))
+		return
+	}
+
+	repostId, err := handler.publications.Repost(original.ID, userId)
+	if err != nil {
+		if errors.Is(err, repositories.ErrAlreadyReposted) {
+			responses.AppError(w, http.StatusConflict, err)
+			return
+		}
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	repost, err := handler.publications.FindById(repostId, userId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	handler.events.Publish(events.Event{
+		Type:          events.PublicationReposted,
+		ActorId:       userId,
+		UserId:        original.AuthorId,
+		PublicationId: repostId,
+	})
+
+	responses.JSON(w, http.StatusCreated, repost)
+}
+
+// UndoRepost remove the repost of publication made by logged user
+func (handler *Handler) UndoRepost(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	publicationId, err := strconv.ParseUint(params["publicationId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = handler.publications.Unrepost(publicationId, userId); err != nil {
+		if errors.Is(err, repositories.ErrNotReposted) {
+			responses.AppError(w, http.StatusConflict, err)
+			return
+		}
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// FindPublicationLikes find one page of users that liked the publication, most recent like first
+func (handler *Handler) FindPublicationLikes(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	publicationId, err := strconv.ParseUint(params["publicationId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	publication, err := handler.publications.FindById(publicationId, userId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if publication.ID == 0 {
+		responses.AppError(w, http.StatusNotFound, errors.New("Publicação não encontrada"))
+		return
+	}
+
+	users, err := handler.publications.FindLikesByPublicationId(publicationId, page)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, pagination.NewPage(users, page, models.User.RelationCursor))
+}
+
+// publishMentions publish one event for each user mentioned in publication that was not in previous mentions
+func (handler *Handler) publishMentions(publication models.Publication, previous []models.Mention) {
+	notified := map[uint64]bool{}
+	for _, mention := range previous {
+		notified[mention.UserId] = true
+	}
+
+	for _, mention := range publication.Mentions {
+		if notified[mention.UserId] {
+			continue
+		}
+		notified[mention.UserId] = true
+
+		handler.events.Publish(events.Event{
+			Type:          events.UserMentioned,
+			ActorId:       publication.AuthorId,
+			UserId:        mention.UserId,
+			PublicationId: publication.ID,
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package controllers_test
+
+import (
+	"api/src/config"
+	"api/src/controllers"
+	"api/src/models"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestPublicationVisibilityByViewer(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	authorId := server.createUser("author")
+	followerId := server.createUser("follower")
+	formerId := server.createUser("former")
+	strangerId := server.createUser("stranger")
+
+	server.follow(followerId, authorId, http.StatusNoContent)
+	server.follow(formerId, authorId, http.StatusNoContent)
+
+	publications := map[string]uint64{}
+	for _, visibility := range []string{models.VisibilityPublic, models.VisibilityFollowers, models.VisibilityPrivate} {
+		publications[visibility] = server.publish(authorId, models.Publication{
+			Title: visibility, Content: "only for " + visibility, Visibility: visibility,
+		}).ID
+	}
+
+	decode(t, server.request(http.MethodPost, fmt.Sprintf("/users/%d/unfollow", authorId), server.token(formerId), nil), http.StatusNoContent, nil)
+
+	tests := []struct {
+		name     string
+		viewerId uint64
+		visible  []string
+		feed     []string
+	}{
+		{
+			"author", authorId,
+			[]string{models.VisibilityPrivate, models.VisibilityFollowers, models.VisibilityPublic},
+			[]string{models.VisibilityPrivate, models.VisibilityFollowers, models.VisibilityPublic},
+		},
+		{
+			"follower", followerId,
+			[]string{models.VisibilityFollowers, models.VisibilityPublic},
+			[]string{models.VisibilityFollowers, models.VisibilityPublic},
+		},
+		// the feed has only publications of followed users, so the others reach public ones just by id
+		{"former follower", formerId, []string{models.VisibilityPublic}, []string{}},
+		{"stranger", strangerId, []string{models.VisibilityPublic}, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			visible := map[string]bool{}
+			for _, title := range test.visible {
+				visible[title] = true
+			}
+
+			for visibility, publicationId := range publications {
+				status := http.StatusNotFound
+				if visible[visibility] {
+					status = http.StatusOK
+				}
+
+				w := server.request(http.MethodGet, fmt.Sprintf("/publications/%d", publicationId), server.token(test.viewerId), nil)
+				if w.Code != status {
+					t.Errorf("%s publication: expected status %d, got %d", visibility, status, w.Code)
+				}
+			}
+
+			if titles := server.feed(t, test.viewerId, ""); !reflect.DeepEqual(titles, test.feed) {
+				t.Errorf("expected feed %v, got %v", test.feed, titles)
+			}
+		})
+	}
+}
+
+func TestFeedRankedByEngagement(t *testing.T) {
+	window := config.FeedRankingWindow
+	defer func() { config.FeedRankingWindow = window }()
+	config.FeedRankingWindow = 3
+
+	server := newServer(t, controllers.Services{})
+	readerId := server.createUser("reader")
+	authorId := server.createUser("author")
+	server.follow(readerId, authorId, http.StatusNoContent)
+
+	publications := map[string]models.Publication{}
+	for _, title := range []string{"first", "second", "third", "fourth"} {
+		publications[title] = server.publish(authorId, models.Publication{Title: title, Content: title})
+	}
+
+	// only the older publications are liked, the second is inside the window of the three most recent
+	for _, nick := range []string{"carol", "dave"} {
+		likerId := server.createUser(nick)
+		for _, title := range []string{"first", "second"} {
+			decode(t, server.request(http.MethodPost, fmt.Sprintf("/publications/%d/like", publications[title].ID), server.token(likerId), nil), http.StatusNoContent, nil)
+		}
+	}
+
+	pages := func(ranking string) ([][]string, string) {
+		var titles [][]string
+		var firstCursor string
+		cursor := ""
+		for requests := 0; requests < 10; requests++ {
+			var page struct {
+				Data       []models.Publication `json:"data"`
+				NextCursor string               `json:"next_cursor"`
+				HasMore    bool                 `json:"has_more"`
+			}
+			path := fmt.Sprintf("/publications?limit=2&ranking=%s&cursor=%s", ranking, cursor)
+			decode(t, server.request(http.MethodGet, path, server.token(readerId), nil), http.StatusOK, &page)
+
+			var found []string
+			for _, publication := range page.Data {
+				found = append(found, publication.Title)
+			}
+			titles = append(titles, found)
+
+			if firstCursor == "" {
+				firstCursor = page.NextCursor
+			}
+			if !page.HasMore {
+				break
+			}
+			cursor = page.NextCursor
+		}
+		return titles, firstCursor
+	}
+
+	ranked, rankedCursor := pages("engagement")
+	if expected := [][]string{{"second", "fourth"}, {"third"}, {"first"}}; !reflect.DeepEqual(ranked, expected) {
+		t.Errorf("expected the liked publication to rise inside its window %v, got %v", expected, ranked)
+	}
+
+	chronological, chronologicalCursor := pages("chronological")
+	if expected := [][]string{{"fourth", "third"}, {"second", "first"}}; !reflect.DeepEqual(chronological, expected) {
+		t.Errorf("expected the most recent first %v, got %v", expected, chronological)
+	}
+
+	mixed := []string{
+		"/publications?ranking=chronological&cursor=" + rankedCursor,
+		"/publications?ranking=engagement&cursor=" + chronologicalCursor,
+		"/publications?ranking=engagement&limit=0",
+		"/publications?ranking=popular",
+	}
+	for _, path := range mixed {
+		decode(t, server.request(http.MethodGet, path, server.token(readerId), nil), http.StatusBadRequest, nil)
+	}
+}
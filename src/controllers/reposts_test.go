@@ -0,0 +1,134 @@
+package controllers_test
+
+import (
+	"api/src/controllers"
+	"api/src/events"
+	"api/src/models"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestRepostsAndQuotes(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+	bobId := server.createUser("bob")
+	carolId := server.createUser("carol")
+	daveId := server.createUser("dave")
+
+	server.follow(carolId, bobId, http.StatusNoContent)
+	server.follow(carolId, daveId, http.StatusNoContent)
+
+	original := server.publish(aliceId, models.Publication{Title: "original", Content: "worth sharing"})
+	diary := server.publish(aliceId, models.Publication{Title: "diary", Content: "mine", Visibility: models.VisibilityPrivate})
+	friends := server.publish(aliceId, models.Publication{Title: "friends", Content: "followers", Visibility: models.VisibilityFollowers})
+
+	repost := func(t *testing.T, userId uint64, path string, status int) models.Publication {
+		t.Helper()
+
+		var publication models.Publication
+		var target interface{}
+		if status == http.StatusCreated {
+			target = &publication
+		}
+		decode(t, server.request(http.MethodPost, path, server.token(userId), nil), status, target)
+		return publication
+	}
+
+	shared := repost(t, bobId, fmt.Sprintf("/publications/%d/repost", original.ID), http.StatusCreated)
+	if shared.RepostOfId != original.ID || shared.Original == nil || shared.Original.Title != "original" {
+		t.Fatalf("expected repost with original embedded, got %+v", shared)
+	}
+
+	tests := []struct {
+		name   string
+		userId uint64
+		path   string
+		status int
+	}{
+		{"repost of a repost shares the original", daveId, fmt.Sprintf("/publications/%d/repost", shared.ID), http.StatusCreated},
+		{"same publication twice", bobId, fmt.Sprintf("/publications/%d/repost", original.ID), http.StatusConflict},
+		{"own private publication", aliceId, fmt.Sprintf("/publications/%d/repost", diary.ID), http.StatusBadRequest},
+		{"publication hidden from user", daveId, fmt.Sprintf("/publications/%d/repost", friends.ID), http.StatusNotFound},
+		{"missing publication", bobId, "/publications/999/repost", http.StatusNotFound},
+		{"invalid id", bobId, "/publications/abc/repost", http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if created := repost(t, test.userId, test.path, test.status); test.status == http.StatusCreated && created.RepostOfId != original.ID {
+				t.Errorf("expected repost of original, got %+v", created)
+			}
+		})
+	}
+	decode(t, server.request(http.MethodPost, fmt.Sprintf("/publications/%d/repost", original.ID), "", nil), http.StatusUnauthorized, nil)
+
+	reposted := server.events.published(events.PublicationReposted)
+	if len(reposted) != 2 || reposted[0].ActorId != bobId || reposted[1].ActorId != daveId || reposted[1].UserId != aliceId {
+		t.Errorf("expected an event for each repost, got %+v", reposted)
+	}
+
+	decode(t, server.request(http.MethodPut, fmt.Sprintf("/publications/%d", shared.ID), server.token(bobId), models.Publication{
+		Title: "edited", Content: "edited",
+	}), http.StatusBadRequest, nil)
+
+	quote := server.publish(daveId, models.Publication{Title: "quote", Content: "look", QuoteOfId: shared.ID})
+	if quote.QuoteOfId != original.ID || quote.Original == nil || quote.Original.ID != original.ID {
+		t.Errorf("expected quote of the original, got %+v", quote)
+	}
+	decode(t, server.request(http.MethodPost, "/publications", server.token(daveId), models.Publication{
+		Title: "quote", Content: "hidden", QuoteOfId: friends.ID,
+	}), http.StatusBadRequest, nil)
+
+	// carol follows both reposters, the original shows once as the latest repost
+	if entries := reposts(t, server, carolId); !reflect.DeepEqual(entries, []string{"quote", "dave reposted original"}) {
+		t.Errorf("expected quote and the original once, got %v", entries)
+	}
+
+	undo := []struct {
+		name    string
+		userId  uint64
+		status  int
+		reposts uint64
+	}{
+		{"undo repost", daveId, http.StatusNoContent, 1},
+		{"undo again", daveId, http.StatusConflict, 1},
+		{"undo without repost", carolId, http.StatusConflict, 1},
+		{"undo last repost", bobId, http.StatusNoContent, 0},
+	}
+
+	for _, step := range undo {
+		decode(t, server.request(http.MethodDelete, fmt.Sprintf("/publications/%d/repost", original.ID), server.token(step.userId), nil), step.status, nil)
+
+		var found models.Publication
+		decode(t, server.request(http.MethodGet, fmt.Sprintf("/publications/%d", original.ID), server.token(carolId), nil), http.StatusOK, &found)
+		if found.Reposts != step.reposts {
+			t.Errorf("%s: expected %d reposts, got %d", step.name, step.reposts, found.Reposts)
+		}
+	}
+
+	if entries := reposts(t, server, carolId); !reflect.DeepEqual(entries, []string{"quote"}) {
+		t.Errorf("expected only the quote after reposts were undone, got %v", entries)
+	}
+}
+
+// reposts return the home feed of user with reposts as "nick reposted title"
+func reposts(t *testing.T, server *server, userId uint64) []string {
+	t.Helper()
+
+	var page struct {
+		Data []models.Publication `json:"data"`
+	}
+	decode(t, server.request(http.MethodGet, "/publications", server.token(userId), nil), http.StatusOK, &page)
+
+	entries := []string{}
+	for _, publication := range page.Data {
+		if publication.RepostOfId != 0 && publication.Original != nil {
+			entries = append(entries, publication.AuthorNick+" reposted "+publication.Original.Title)
+			continue
+		}
+		entries = append(entries, publication.Title)
+	}
+	return entries
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/models"
+	"api/src/responses"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// FindPublicationRevisions list the versions of one publication oldest first, ending with the current one,
+// each compared word by word to the version before it
+func (handler *Handler) FindPublicationRevisions(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	publicationId, err := strconv.ParseUint(params["publicationId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	publication, err := handler.publications.FindById(publicationId, userId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if publication.ID == 0 {
+		responses.AppError(w, http.StatusNotFound, errors.New("Publicação não encontrada"))
+		return
+	}
+
+	revisions, err := handler.publications.FindRevisions(publicationId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	current := models.PublicationRevision{
+		PublicationId: publication.ID,
+		Title:         publication.Title,
+		Content:       publication.Content,
+		Current:       true,
+		CreatedAt:     publication.CreatedAt,
+	}
+	if publication.EditedAt != nil {
+		current.CreatedAt = *publication.EditedAt
+	}
+
+	revisions = append(revisions, current)
+	models.CompareRevisions(revisions)
+
+	responses.JSON(w, http.StatusOK, revisions)
+}
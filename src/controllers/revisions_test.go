@@ -0,0 +1,87 @@
+package controllers_test
+
+import (
+	"api/src/controllers"
+	"api/src/models"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// revisions return the versions of publication seen by user as "title: content", oldest first
+func revisions(t *testing.T, server *server, userId, publicationId uint64) ([]string, []models.PublicationRevision) {
+	t.Helper()
+
+	var found []models.PublicationRevision
+	decode(t, server.request(http.MethodGet, fmt.Sprintf("/publications/%d/revisions", publicationId), server.token(userId), nil), http.StatusOK, &found)
+
+	versions := []string{}
+	for _, revision := range found {
+		versions = append(versions, revision.Title+": "+revision.Content)
+	}
+	return versions, found
+}
+
+func TestPublicationRevisions(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+	bobId := server.createUser("bob")
+
+	publication := server.publish(aliceId, models.Publication{Title: "Hello", Content: "first version"})
+	diary := server.publish(aliceId, models.Publication{Title: "diary", Content: "mine", Visibility: models.VisibilityPrivate})
+
+	edits := []struct {
+		name     string
+		edit     models.Publication
+		versions []string
+		edited   bool
+	}{
+		{"unchanged text", models.Publication{Title: "Hello", Content: "first version", Visibility: models.VisibilityFollowers}, []string{"Hello: first version"}, false},
+		{"case only edit", models.Publication{Title: "hello", Content: "first version"}, []string{"Hello: first version", "hello: first version"}, true},
+		{"content edit", models.Publication{Title: "hello", Content: "second version"}, []string{"Hello: first version", "hello: first version", "hello: second version"}, true},
+	}
+
+	for _, edit := range edits {
+		decode(t, server.request(http.MethodPut, fmt.Sprintf("/publications/%d", publication.ID), server.token(aliceId), edit.edit), http.StatusNoContent, nil)
+
+		versions, found := revisions(t, server, aliceId, publication.ID)
+		if !reflect.DeepEqual(versions, edit.versions) {
+			t.Errorf("%s: expected versions %v, got %v", edit.name, edit.versions, versions)
+		}
+
+		var current models.Publication
+		decode(t, server.request(http.MethodGet, fmt.Sprintf("/publications/%d", publication.ID), server.token(aliceId), nil), http.StatusOK, &current)
+		if (current.EditedAt != nil) != edit.edited {
+			t.Errorf("%s: expected edited %v, got %v", edit.name, edit.edited, current.EditedAt)
+		}
+
+		if last := found[len(found)-1]; !last.Current {
+			t.Errorf("%s: expected the current version last, got %+v", edit.name, last)
+		}
+	}
+
+	_, found := revisions(t, server, aliceId, publication.ID)
+	expected := []models.DiffSegment{{Type: models.DiffDelete, Text: "Hello"}, {Type: models.DiffInsert, Text: "hello"}}
+	if len(found) != 3 || !reflect.DeepEqual(found[1].TitleDiff, expected) {
+		t.Errorf("expected the case only edit in the title diff, got %+v", found)
+	}
+
+	requests := []struct {
+		name   string
+		userId uint64
+		path   string
+		status int
+	}{
+		{"private publication", bobId, fmt.Sprintf("/publications/%d/revisions", diary.ID), http.StatusNotFound},
+		{"edited to followers only", bobId, fmt.Sprintf("/publications/%d/revisions", publication.ID), http.StatusNotFound},
+		{"missing publication", bobId, "/publications/999/revisions", http.StatusNotFound},
+		{"invalid id", bobId, "/publications/abc/revisions", http.StatusBadRequest},
+	}
+
+	for _, request := range requests {
+		t.Run(request.name, func(t *testing.T) {
+			decode(t, server.request(http.MethodGet, request.path, server.token(request.userId), nil), request.status, nil)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/responses"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	searchTypeUsers        = "users"
+	searchTypePublications = "publications"
+
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// Search find users or publications matching the q parameter ordered by relevance,
+// type chooses what is searched and defaults to publications
+func (handler *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		responses.AppError(w, http.StatusBadRequest, errors.New("O parâmetro q é obrigatório"))
+		return
+	}
+
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	switch r.URL.Query().Get("type") {
+	case searchTypeUsers:
+		users, err := handler.search.SearchUsers(query, userId, limit+1, offset)
+		if err != nil {
+			responses.AppError(w, http.StatusInternalServerError, err)
+			return
+		}
+		responses.JSON(w, http.StatusOK, searchPage(users, limit))
+
+	case searchTypePublications, "":
+		publications, err := handler.search.SearchPublications(query, userId, limit+1, offset)
+		if err != nil {
+			responses.AppError(w, http.StatusInternalServerError, err)
+			return
+		}
+		responses.JSON(w, http.StatusOK, searchPage(publications, limit))
+
+	default:
+		responses.AppError(w, http.StatusBadRequest, errors.New("O parâmetro type deve ser users ou publications"))
+	}
+}
+
+// searchPage trim the extra result fetched to know if there is a next page, clients ask it with page
+func searchPage[T models.User | models.Publication](results []T, limit int) pagination.Page {
+	page := pagination.Page{Data: results}
+
+	if len(results) > limit {
+		page.Data = results[:limit]
+		page.HasMore = true
+	}
+
+	if results == nil {
+		page.Data = []T{}
+	}
+
+	return page
+}
+
+// pageParams read limit and page from query string and return limit and offset
+func pageParams(r *http.Request) (int, int, error) {
+	limit, page := defaultSearchLimit, 1
+	query := r.URL.Query()
+
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return 0, 0, errors.New("O parâmetro limit deve ser um número positivo")
+		}
+		limit = parsed
+	}
+
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	if value := query.Get("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return 0, 0, errors.New("O parâmetro page deve ser um número positivo")
+		}
+		page = parsed
+	}
+
+	return limit, (page - 1) * limit, nil
+}
@@ -0,0 +1,80 @@
+package controllers_test
+
+import (
+	"api/src/controllers"
+	"api/src/models"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+	bobId := server.createUser("bob")
+	carolId := server.createUser("carol")
+	malloryId := server.createUser("mallory")
+
+	server.follow(aliceId, bobId, http.StatusNoContent)
+	server.publish(bobId, models.Publication{Title: "golang golang tips", Content: "golang"})
+	server.publish(carolId, models.Publication{Title: "golang and mysql", Content: "databases"})
+	server.publish(bobId, models.Publication{Title: "golang secrets", Content: "friends only", Visibility: models.VisibilityFollowers})
+	server.publish(malloryId, models.Publication{Title: "golang spam", Content: "spam"})
+	decode(t, server.request(http.MethodPost, fmt.Sprintf("/users/%d/block", malloryId), server.token(aliceId), nil), http.StatusNoContent, nil)
+
+	tests := []struct {
+		name    string
+		viewer  uint64
+		query   url.Values
+		status  int
+		results []string
+		hasMore bool
+	}{
+		{"publications by relevance", aliceId, url.Values{"q": {"golang"}}, http.StatusOK, []string{"golang golang tips", "golang secrets", "golang and mysql"}, false},
+		{"publications visible to viewer", carolId, url.Values{"q": {"golang"}, "type": {"publications"}}, http.StatusOK, []string{"golang golang tips", "golang spam", "golang and mysql"}, false},
+		{"first page", aliceId, url.Values{"q": {"golang"}, "limit": {"2"}}, http.StatusOK, []string{"golang golang tips", "golang secrets"}, true},
+		{"last page", aliceId, url.Values{"q": {"golang"}, "limit": {"2"}, "page": {"2"}}, http.StatusOK, []string{"golang and mysql"}, false},
+		{"nothing matching", aliceId, url.Values{"q": {"rust"}}, http.StatusOK, []string{}, false},
+		{"users", carolId, url.Values{"q": {"bob"}, "type": {"users"}}, http.StatusOK, []string{"bob"}, false},
+		{"blocked users", aliceId, url.Values{"q": {"mallory"}, "type": {"users"}}, http.StatusOK, []string{}, false},
+		{"without query", aliceId, url.Values{"q": {"  "}}, http.StatusBadRequest, nil, false},
+		{"unknown type", aliceId, url.Values{"q": {"golang"}, "type": {"hashtags"}}, http.StatusBadRequest, nil, false},
+		{"invalid limit", aliceId, url.Values{"q": {"golang"}, "limit": {"0"}}, http.StatusBadRequest, nil, false},
+		{"without token", 0, url.Values{"q": {"golang"}}, http.StatusUnauthorized, nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := ""
+			if test.viewer != 0 {
+				token = server.token(test.viewer)
+			}
+
+			w := server.request(http.MethodGet, "/search?"+test.query.Encode(), token, nil)
+			if test.status != http.StatusOK {
+				decode(t, w, test.status, nil)
+				return
+			}
+
+			var page struct {
+				Data []struct {
+					Title string `json:"title"`
+					Nick  string `json:"nick"`
+				} `json:"data"`
+				HasMore bool `json:"has_more"`
+			}
+			decode(t, w, http.StatusOK, &page)
+
+			results := []string{}
+			for _, result := range page.Data {
+				results = append(results, result.Title+result.Nick)
+			}
+
+			if !reflect.DeepEqual(results, test.results) || page.HasMore != test.hasMore {
+				t.Errorf("expected %v with more %v, got %v with more %v", test.results, test.hasMore, results, page.HasMore)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/messaging"
+	"api/src/responses"
+	"api/src/stream"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Stream keep an open Server-Sent Events connection pushing new publications and notifications
+// to the logged user. Clients resume with the Last-Event-ID header after reconnecting. The stream
+// ends with a session event when the token that opened it expires or is revoked.
+func (handler *Handler) Stream(w http.ResponseWriter, r *http.Request) {
+	details, err := authentication.GetTokenDetails(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	var lastEventId uint64
+	if value := r.Header.Get("Last-Event-ID"); value != "" {
+		if lastEventId, err = strconv.ParseUint(value, 10, 64); err != nil {
+			responses.AppError(w, http.StatusBadRequest, errors.New("O cabeçalho Last-Event-ID deve ser um número"))
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		responses.AppError(w, http.StatusInternalServerError, errors.New("Streaming não suportado"))
+		return
+	}
+
+	subscription, replay := handler.stream.Subscribe(details.UserID, lastEventId)
+	defer handler.stream.Unsubscribe(subscription)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, message := range replay {
+		if err = writeEvent(w, message); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(config.StreamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	ended := make(chan string, 1)
+	go func() {
+		ended <- handler.messages.Watch(messaging.Session{
+			UserId:    details.UserID,
+			TokenId:   details.ID,
+			FamilyId:  details.FamilyID,
+			ExpiresAt: details.ExpiresAt,
+		}, done)
+	}()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case reason := <-ended:
+			// the client must authenticate again instead of resuming with the same token
+			data, _ := json.Marshal(map[string]string{"reason": reason})
+			fmt.Fprintf(w, "event: session\ndata: %s\n\n", data)
+			flusher.Flush()
+			return
+
+		case message, ok := <-subscription.Messages:
+			if !ok {
+				// the hub disconnected a slow client or is closing, client reconnects with Last-Event-ID
+				fmt.Fprint(w, "event: close\ndata: {}\n\n")
+				flusher.Flush()
+				return
+			}
+
+			if err = writeEvent(w, message); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case <-heartbeat.C:
+			if _, err = fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent write message in the Server-Sent Events format, data is one line of JSON
+func writeEvent(w http.ResponseWriter, message stream.Message) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", message.ID, message.Event, message.Data)
+	return err
+}
@@ -0,0 +1,61 @@
+package controllers_test
+
+import (
+	"api/src/controllers"
+	"api/src/stream"
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamClosesWhenSessionEnds(t *testing.T) {
+	for _, test := range sessionEnds {
+		t.Run(test.name, func(t *testing.T) {
+			server := newServer(t, controllers.Services{Stream: stream.NewHub(10, 10, time.Minute)})
+			server.createUser("alice")
+
+			api := httptest.NewServer(server.router)
+			defer api.Close()
+
+			token := test.open(t, server)
+			request, err := http.NewRequest(http.MethodGet, api.URL+"/stream", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			request.Header.Set("Authorization", "Bearer "+token)
+
+			client := http.Client{Timeout: 3 * time.Second}
+			response, err := client.Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer response.Body.Close()
+
+			if response.StatusCode != http.StatusOK {
+				t.Fatalf("expected the stream opened, got status %d", response.StatusCode)
+			}
+
+			test.end(t, server, token)
+
+			// the server ends the response after the session event, so reading stops at its end
+			var lines []string
+			scanner := bufio.NewScanner(response.Body)
+			for scanner.Scan() {
+				if line := scanner.Text(); line != "" && !strings.HasPrefix(line, ":") {
+					lines = append(lines, line)
+				}
+			}
+			if err = scanner.Err(); err != nil {
+				t.Fatalf("expected the stream closed, got %v", err)
+			}
+
+			expected := []string{"event: session", `data: {"reason":"` + test.reason + `"}`}
+			if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+				t.Errorf("expected the stream to end with %q, got %q", expected, lines)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/responses"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// FindSuggestions return the users that the logged user may want to follow, best ranked first.
+// Suggestions are a ranking instead of a list, so only the limit of the query string is read
+func (handler *Handler) FindSuggestions(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	suggestions, err := handler.users.FindSuggestions(userId, page.Limit)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if suggestions == nil {
+		suggestions = []models.Suggestion{}
+	}
+
+	responses.JSON(w, http.StatusOK, suggestions)
+}
+
+// DismissSuggestion stop suggesting the user in path to the logged user
+func (handler *Handler) DismissSuggestion(w http.ResponseWriter, r *http.Request) {
+	handler.changeRelation(w, r, handler.users.DismissSuggestion, "Não é possível dispensar a sugestão de você mesmo", "")
+}
+
+// FindMutualFollowers find one page of users that follow both the logged user and the user in path
+func (handler *Handler) FindMutualFollowers(w http.ResponseWriter, r *http.Request) {
+	viewerId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	params := mux.Vars(r)
+	userId, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	users, err := handler.users.FindMutualFollowers(viewerId, userId, page)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, pagination.NewPage(users, page, models.User.Cursor))
+}
@@ -0,0 +1,103 @@
+package controllers_test
+
+import (
+	"api/src/controllers"
+	"api/src/models"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// suggestions return the suggestions of user as "nick followedByFollowing/sharedFollowers", best ranked first
+func suggestions(t *testing.T, server *server, userId uint64, query string) []string {
+	t.Helper()
+
+	var found []models.Suggestion
+	decode(t, server.request(http.MethodGet, "/users/suggestions"+query, server.token(userId), nil), http.StatusOK, &found)
+
+	ranked := []string{}
+	for _, suggestion := range found {
+		ranked = append(ranked, fmt.Sprintf("%s %d/%d", suggestion.User.Nick, suggestion.FollowedByFollowing, suggestion.SharedFollowers))
+	}
+	return ranked
+}
+
+func TestFindSuggestions(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	ids := map[string]uint64{}
+	for _, nick := range []string{"alice", "bob", "carol", "dave", "erin", "frank", "grace"} {
+		ids[nick] = server.createUser(nick)
+	}
+
+	// alice follows bob and carol and is followed by dave, erin is followed by bob and carol, frank by bob and dave
+	for _, follow := range [][2]string{
+		{"alice", "bob"}, {"alice", "carol"}, {"dave", "alice"},
+		{"bob", "erin"}, {"carol", "erin"}, {"bob", "frank"}, {"dave", "frank"},
+	} {
+		server.follow(ids[follow[0]], ids[follow[1]], http.StatusNoContent)
+	}
+
+	tests := []struct {
+		name        string
+		viewer      string
+		query       string
+		suggestions []string
+	}{
+		{"ranked by users followed", "alice", "", []string{"erin 2/0", "frank 1/1"}},
+		{"limited", "alice", "?limit=1", []string{"erin 2/0"}},
+		{"followed by followers, most recent user first", "frank", "", []string{"erin 0/1", "alice 0/1"}},
+		{"without connections", "grace", "", []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if found := suggestions(t, server, ids[test.viewer], test.query); !reflect.DeepEqual(found, test.suggestions) {
+				t.Errorf("expected %v, got %v", test.suggestions, found)
+			}
+		})
+	}
+
+	decode(t, server.request(http.MethodGet, "/users/suggestions?limit=0", server.token(ids["alice"]), nil), http.StatusBadRequest, nil)
+	decode(t, server.request(http.MethodGet, "/users/suggestions", "", nil), http.StatusUnauthorized, nil)
+}
+
+func TestDismissSuggestion(t *testing.T) {
+	server := newServer(t, controllers.Services{})
+	aliceId := server.createUser("alice")
+	bobId := server.createUser("bob")
+	carolId := server.createUser("carol")
+	daveId := server.createUser("dave")
+
+	// alice follows bob, who follows carol and dave
+	server.follow(aliceId, bobId, http.StatusNoContent)
+	server.follow(bobId, carolId, http.StatusNoContent)
+	server.follow(bobId, daveId, http.StatusNoContent)
+
+	tests := []struct {
+		name        string
+		path        string
+		token       string
+		status      int
+		suggestions []string
+	}{
+		{"dismiss yourself", fmt.Sprintf("/users/suggestions/%d", aliceId), server.token(aliceId), http.StatusForbidden, []string{"dave 1/0", "carol 1/0"}},
+		{"dismiss missing user", "/users/suggestions/999", server.token(aliceId), http.StatusNotFound, []string{"dave 1/0", "carol 1/0"}},
+		{"invalid id", "/users/suggestions/abc", server.token(aliceId), http.StatusBadRequest, []string{"dave 1/0", "carol 1/0"}},
+		{"without token", fmt.Sprintf("/users/suggestions/%d", daveId), "", http.StatusUnauthorized, []string{"dave 1/0", "carol 1/0"}},
+		{"dismissed", fmt.Sprintf("/users/suggestions/%d", daveId), server.token(aliceId), http.StatusNoContent, []string{"carol 1/0"}},
+		{"dismissed again", fmt.Sprintf("/users/suggestions/%d", daveId), server.token(aliceId), http.StatusNoContent, []string{"carol 1/0"}},
+	}
+
+	for _, test := range tests {
+		decode(t, server.request(http.MethodDelete, test.path, test.token, nil), test.status, nil)
+
+		if found := suggestions(t, server, aliceId, ""); !reflect.DeepEqual(found, test.suggestions) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.suggestions, found)
+		}
+	}
+
+	if found := suggestions(t, server, bobId, ""); !reflect.DeepEqual(found, []string{}) {
+		t.Errorf("expected dismissals of alice to leave the suggestions of bob alone, got %v", found)
+	}
+}
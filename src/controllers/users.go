@@ -2,8 +2,9 @@ package controllers
 
 import (
 	"api/src/authentication"
-	"api/src/db"
+	"api/src/events"
 	"api/src/models"
+	"api/src/pagination"
 	"api/src/repositories"
 	"api/src/responses"
 	"api/src/secure"
@@ -18,7 +19,7 @@ import (
 )
 
 //CreateUser insert user in database
-func CreateUser(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	reqBody, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		responses.AppError(w, http.StatusUnprocessableEntity, err)
@@ -36,15 +37,7 @@ func CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, err := db.CreateConnection()
-	if err != nil {
-		responses.AppError(w, http.StatusInternalServerError, err)
-		return
-	}
-	defer db.Close()
-
-	repository := repositories.NewUserRepository(db)
-	user.ID, err = repository.Create(user)
+	user.ID, err = handler.users.Create(user)
 	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
@@ -54,28 +47,32 @@ func CreateUser(w http.ResponseWriter, r *http.Request) {
 }
 
 //FindAllUsers find all user in database
-func FindAllUsersFilteredByNameOrNick(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) FindAllUsersFilteredByNameOrNick(w http.ResponseWriter, r *http.Request) {
+	userId, err := authentication.GetUserID(r)
+	if err != nil {
+		responses.AppError(w, http.StatusUnauthorized, err)
+		return
+	}
+
 	nameOrNick := strings.ToLower(r.URL.Query().Get("user"))
 
-	db, err := db.CreateConnection()
+	page, err := pagination.FromRequest(r)
 	if err != nil {
-		responses.AppError(w, http.StatusInternalServerError, err)
+		responses.AppError(w, http.StatusBadRequest, err)
 		return
 	}
-	defer db.Close()
 
-	repository := repositories.NewUserRepository(db)
-	users, err := repository.Find(nameOrNick)
+	users, err := handler.users.Find(nameOrNick, userId, page)
 	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	responses.JSON(w, http.StatusOK, users)
+	responses.JSON(w, http.StatusOK, pagination.NewPage(users, page, models.User.Cursor))
 }
 
 //FindUserById find one user in database
-func FindUserById(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) FindUserById(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
 	userId, err := strconv.ParseUint(params["userId"], 10, 64)
@@ -84,15 +81,7 @@ func FindUserById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, err := db.CreateConnection()
-	if err != nil {
-		responses.AppError(w, http.StatusInternalServerError, err)
-		return
-	}
-	defer db.Close()
-
-	repository := repositories.NewUserRepository(db)
-	user, err := repository.FindByID(userId)
+	user, err := handler.users.FindByID(userId)
 	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
@@ -102,7 +91,7 @@ func FindUserById(w http.ResponseWriter, r *http.Request) {
 }
 
 //UpdateUserById update one user in database
-func UpdateUserById(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) UpdateUserById(w http.ResponseWriter, r *http.Request) {
 	param := mux.Vars(r)
 	userId, err := strconv.ParseUint(param["userId"], 10, 64)
 	if err != nil {
@@ -127,26 +116,25 @@ func UpdateUserById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var user models.User
-	if err = json.Unmarshal(reqBody, &user); err != nil {
-		responses.AppError(w, http.StatusBadRequest, err)
+	current, err := handler.users.FindByID(userId)
+	if err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	if err = user.Prepare("edit"); err != nil {
+	// isPrivate is optional, so a profile edit without it keeps the account as it is
+	user := models.User{IsPrivate: current.IsPrivate}
+	if err = json.Unmarshal(reqBody, &user); err != nil {
 		responses.AppError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	db, err := db.CreateConnection()
-	if err != nil {
-		responses.AppError(w, http.StatusInternalServerError, err)
+	if err = user.Prepare("edit"); err != nil {
+		responses.AppError(w, http.StatusBadRequest, err)
 		return
 	}
-	defer db.Close()
 
-	repository := repositories.NewUserRepository(db)
-	if err = repository.Update(userId, user); err != nil {
+	if err = handler.users.Update(userId, user); err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -155,7 +143,7 @@ func UpdateUserById(w http.ResponseWriter, r *http.Request) {
 }
 
 //DeleteUser remove user
-func DeleteUser(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	userId, err := strconv.ParseUint(params["userId"], 10, 64)
 	if err != nil {
@@ -166,21 +154,15 @@ func DeleteUser(w http.ResponseWriter, r *http.Request) {
 	userIdInToken, err := authentication.GetUserID(r)
 	if err != nil {
 		responses.AppError(w, http.StatusUnauthorized, err)
+		return
 	}
 
 	if userId != userIdInToken {
 		responses.AppError(w, http.StatusForbidden, errors.New("Não é possível remover um usuario que não seja o seu"))
-	}
-
-	db, err := db.CreateConnection()
-	if err != nil {
-		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
-	defer db.Close()
 
-	repository := repositories.NewUserRepository(db)
-	if err = repository.Delete(userId); err != nil {
+	if err = handler.users.Delete(userId); err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -189,7 +171,7 @@ func DeleteUser(w http.ResponseWriter, r *http.Request) {
 }
 
 // FollowerUser allows one user to follow another
-func FollowerUser(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) FollowerUser(w http.ResponseWriter, r *http.Request) {
 	followerId, err := authentication.GetUserID(r)
 	if err != nil {
 		responses.AppError(w, http.StatusUnauthorized, err)
@@ -208,23 +190,45 @@ func FollowerUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, err := db.CreateConnection()
+	user, err := handler.users.FindByID(userId)
 	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
-	defer db.Close()
 
-	repository := repositories.NewUserRepository(db)
-	if err = repository.Follower(userId, followerId); err != nil {
+	if user.ID == 0 {
+		responses.AppError(w, http.StatusNotFound, errors.New("Usuário não encontrado"))
+		return
+	}
+
+	blocked, err := handler.users.IsBlocked(userId, followerId)
+	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if blocked {
+		responses.AppError(w, http.StatusForbidden, errors.New("Não é possível seguir este usuário"))
+		return
+	}
+
+	if user.IsPrivate {
+		handler.requestToFollow(w, userId, followerId)
+		return
+	}
+
+	if err = handler.users.Follower(userId, followerId); err != nil {
+		responses.AppError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	handler.events.Publish(events.Event{Type: events.UserFollowed, ActorId: followerId, UserId: userId})
+
 	responses.JSON(w, http.StatusNoContent, nil)
 }
 
-func UnfollowUser(w http.ResponseWriter, r *http.Request) {
+// UnfollowUser stop following user, canceling the follow request when it is still pending
+func (handler *Handler) UnfollowUser(w http.ResponseWriter, r *http.Request) {
 	followerId, err := authentication.GetUserID(r)
 	if err != nil {
 		responses.AppError(w, http.StatusUnauthorized, err)
@@ -243,24 +247,26 @@ func UnfollowUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, err := db.CreateConnection()
+	unfollowed, err := handler.users.Unfollow(userId, followerId)
 	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
-	defer db.Close()
 
-	repository := repositories.NewUserRepository(db)
-	if err = repository.Unfollow(userId, followerId); err != nil {
+	if err = handler.users.DeleteFollowRequest(userId, followerId); err != nil && !errors.Is(err, repositories.ErrFollowRequestNotFound) {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if unfollowed {
+		handler.events.Publish(events.Event{Type: events.UserUnfollowed, ActorId: followerId, UserId: userId})
+	}
+
 	responses.JSON(w, http.StatusNoContent, nil)
 }
 
-// FindFollowers find all followers from user
-func FindFollowers(w http.ResponseWriter, r *http.Request) {
+// FindFollowers find one page of followers from user, most recent follow first
+func (handler *Handler) FindFollowers(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	userId, err := strconv.ParseUint(params["userId"], 10, 64)
 	if err != nil {
@@ -268,25 +274,23 @@ func FindFollowers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, err := db.CreateConnection()
+	page, err := pagination.FromRequest(r)
 	if err != nil {
-		responses.AppError(w, http.StatusInternalServerError, err)
+		responses.AppError(w, http.StatusBadRequest, err)
 		return
 	}
-	defer db.Close()
 
-	repository := repositories.NewUserRepository(db)
-	followers, err := repository.FindFollowersByUserId(userId)
+	followers, err := handler.users.FindFollowersByUserId(userId, page)
 	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	responses.JSON(w, http.StatusOK, followers)
+	responses.JSON(w, http.StatusOK, pagination.NewPage(followers, page, models.User.RelationCursor))
 }
 
-// FindFollowing find all users that user is following
-func FindFollowing(w http.ResponseWriter, r *http.Request) {
+// FindFollowing find one page of users that user is following, most recent follow first
+func (handler *Handler) FindFollowing(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	userId, err := strconv.ParseUint(params["userId"], 10, 64)
 	if err != nil {
@@ -294,25 +298,23 @@ func FindFollowing(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, err := db.CreateConnection()
+	page, err := pagination.FromRequest(r)
 	if err != nil {
-		responses.AppError(w, http.StatusInternalServerError, err)
+		responses.AppError(w, http.StatusBadRequest, err)
 		return
 	}
-	defer db.Close()
 
-	repository := repositories.NewUserRepository(db)
-	users, err := repository.FindFollowingByUserId(userId)
+	users, err := handler.users.FindFollowingByUserId(userId, page)
 	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	responses.JSON(w, http.StatusOK, users)
+	responses.JSON(w, http.StatusOK, pagination.NewPage(users, page, models.User.RelationCursor))
 }
 
 //UpdatePassword update password from user
-func UpdatePassword(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) UpdatePassword(w http.ResponseWriter, r *http.Request) {
 	userIdInToken, err := authentication.GetUserID(r)
 	if err != nil {
 		responses.AppError(w, http.StatusUnauthorized, err)
@@ -339,15 +341,7 @@ func UpdatePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, err := db.CreateConnection()
-	if err != nil {
-		responses.AppError(w, http.StatusInternalServerError, err)
-		return
-	}
-	defer db.Close()
-
-	repository := repositories.NewUserRepository(db)
-	existPassword, err := repository.FindPasswordById(userId)
+	existPassword, err := handler.users.FindPasswordById(userId)
 	if err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
@@ -364,7 +358,7 @@ func UpdatePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err = repository.UpdateUserPassword(userId, string(passwordWithHash)); err != nil {
+	if err = handler.users.UpdateUserPassword(userId, string(passwordWithHash)); err != nil {
 		responses.AppError(w, http.StatusInternalServerError, err)
 		return
 	}
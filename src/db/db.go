@@ -3,11 +3,25 @@ package db
 import (
 	"api/src/config"
 	"database/sql"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql" //Driver
 )
 
-//CreateConnection open connection with database mysql
+// PoolStats represent the connection pool statistics exposed by the API
+type PoolStats struct {
+	MaxOpenConnections int           `json:"maxOpenConnections"`
+	OpenConnections    int           `json:"openConnections"`
+	InUse              int           `json:"inUse"`
+	Idle               int           `json:"idle"`
+	WaitCount          int64         `json:"waitCount"`
+	WaitDuration       time.Duration `json:"waitDurationNs"`
+	MaxIdleClosed      int64         `json:"maxIdleClosed"`
+	MaxIdleTimeClosed  int64         `json:"maxIdleTimeClosed"`
+	MaxLifetimeClosed  int64         `json:"maxLifetimeClosed"`
+}
+
+//CreateConnection open the connection pool with database mysql, shared by the whole API until shutdown
 func CreateConnection() (*sql.DB, error) {
 	db, err := sql.Open("mysql", config.Connection)
 
@@ -15,6 +29,26 @@ func CreateConnection() (*sql.DB, error) {
 		return nil, err
 	}
 
+	db.SetMaxOpenConns(config.DBMaxOpenConns)
+	db.SetMaxIdleConns(config.DBMaxIdleConns)
+	db.SetConnMaxLifetime(config.DBConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.DBConnMaxIdleTime)
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// CreateMigrationConnection open a connection allowing many statements per query, used only to run migrations
+func CreateMigrationConnection() (*sql.DB, error) {
+	db, err := sql.Open("mysql", config.Connection+"&multiStatements=true")
+	if err != nil {
+		return nil, err
+	}
+
 	if err = db.Ping(); err != nil {
 		db.Close()
 		return nil, err
@@ -22,3 +56,18 @@ func CreateConnection() (*sql.DB, error) {
 
 	return db, nil
 }
+
+// NewPoolStats convert sql.DBStats in PoolStats
+func NewPoolStats(stats sql.DBStats) PoolStats {
+	return PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration,
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}
+}
@@ -0,0 +1,112 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPublishDropsEventsInsteadOfWaitingForSlowSubscribers(t *testing.T) {
+	bus := NewBus(1)
+
+	release := make(chan struct{})
+	handled := make(chan Type, 10)
+	bus.Subscribe("slow", func(event Event) {
+		<-release
+		handled <- event.Type
+	})
+
+	published := make(chan struct{})
+	go func() {
+		for _, kind := range []Type{UserFollowed, PublicationLiked, CommentCreated, UserBlocked} {
+			bus.Publish(Event{Type: kind})
+		}
+		close(published)
+	}()
+
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("publish blocked on a slow subscriber")
+	}
+
+	close(release)
+	bus.Close()
+	close(handled)
+
+	// the subscriber holds at most one event while another waits in its buffer, the rest is dropped
+	var received []Type
+	for kind := range handled {
+		received = append(received, kind)
+	}
+	if len(received) == 0 || len(received) > 2 || received[0] != UserFollowed {
+		t.Errorf("expected the first events kept in order and the others dropped, got %v", received)
+	}
+}
+
+func TestPublishWaitsForSubscribersWithoutLoss(t *testing.T) {
+	bus := NewBus(1)
+
+	release := make(chan struct{})
+	handled := make(chan Type, 10)
+	bus.SubscribeWithoutLoss("timeline", func(event Event) {
+		<-release
+		handled <- event.Type
+	})
+
+	kinds := []Type{UserFollowed, PublicationCreated, UserUnfollowed, UserBlocked}
+	published := make(chan struct{})
+	go func() {
+		for _, kind := range kinds {
+			bus.Publish(Event{Type: kind})
+		}
+		close(published)
+	}()
+
+	select {
+	case <-published:
+		t.Fatal("publish did not wait for the full buffer of a subscriber without loss")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	<-published
+	bus.Close()
+	close(handled)
+
+	var received []Type
+	for kind := range handled {
+		received = append(received, kind)
+	}
+	if !reflect.DeepEqual(received, kinds) {
+		t.Errorf("expected every event in order, got %v", received)
+	}
+}
+
+func TestHandlerPanicDoesNotStopSubscriber(t *testing.T) {
+	bus := NewBus(10)
+
+	handled := make(chan Type, 10)
+	bus.Subscribe("flaky", func(event Event) {
+		if event.Type == UserBlocked {
+			panic("bad event")
+		}
+		handled <- event.Type
+	})
+
+	bus.Publish(Event{Type: UserBlocked})
+	bus.Publish(Event{Type: UserFollowed})
+	bus.Close()
+
+	if len(handled) != 1 || <-handled != UserFollowed {
+		t.Error("expected the subscriber to keep handling events after a panic")
+	}
+}
+
+func TestPublishAfterCloseIsIgnored(t *testing.T) {
+	bus := NewBus(1)
+	bus.Subscribe("closed", func(event Event) { t.Errorf("unexpected %s event after close", event.Type) })
+	bus.Close()
+
+	bus.Publish(Event{Type: UserFollowed})
+}
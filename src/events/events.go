@@ -0,0 +1,163 @@
+// Package events deliver domain events from controllers to the subsystems that react to them
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Type identify what happened in one event
+type Type string
+
+const (
+	// UserFollowed is published when actor starts following user
+	UserFollowed Type = "user.followed"
+	// UserUnfollowed is published when actor stops following user
+	UserUnfollowed Type = "user.unfollowed"
+	// UserBlocked is published when actor blocks user, ending the follow relationship in both directions
+	UserBlocked Type = "user.blocked"
+	// UserUnmuted is published when actor stops muting user
+	UserUnmuted Type = "user.unmuted"
+	// FollowRequested is published when actor asks to follow the private account of user
+	FollowRequested Type = "follow.requested"
+	// FollowRequestApproved is published when actor approves the follow request sent by user
+	FollowRequestApproved Type = "follow.approved"
+	// PublicationCreated is published when actor creates a publication
+	PublicationCreated Type = "publication.created"
+	// PublicationLiked is published when actor likes a publication from user
+	PublicationLiked Type = "publication.liked"
+	// PublicationReposted is published when actor reposts a publication from user, PublicationId is the repost
+	PublicationReposted Type = "publication.reposted"
+	// UserMentioned is published when actor mentions user in a publication, once per publication and user
+	UserMentioned Type = "user.mentioned"
+	// CommentCreated is published when actor comments in a publication from user
+	CommentCreated Type = "comment.created"
+)
+
+// Event describe one action done by actor that affects user
+type Event struct {
+	Type          Type
+	ActorId       uint64
+	UserId        uint64
+	PublicationId uint64
+	CommentId     uint64
+	OccurredAt    time.Time
+}
+
+// Publisher receive events produced by controllers
+type Publisher interface {
+	Publish(event Event)
+}
+
+// Handler consume one event
+type Handler func(event Event)
+
+type subscriber struct {
+	name    string
+	handler Handler
+	events  chan Event
+	// lossless subscribers make Publish wait for room in their buffer instead of dropping events
+	lossless bool
+}
+
+// Bus deliver every published event to all subscribers. Each subscriber has its own
+// buffer and goroutine, so publishing does not block: when the buffer is full the event is dropped,
+// unless the subscriber was added with SubscribeWithoutLoss.
+type Bus struct {
+	mu          sync.RWMutex
+	closed      bool
+	bufferSize  int
+	subscribers []*subscriber
+	wg          sync.WaitGroup
+}
+
+// NewBus create a bus where each subscriber buffer bufferSize events
+func NewBus(bufferSize int) *Bus {
+	return &Bus{bufferSize: bufferSize}
+}
+
+// Subscribe start delivering events to handler, name is used in logs
+func (bus *Bus) Subscribe(name string, handler Handler) {
+	bus.subscribe(name, handler, false)
+}
+
+// SubscribeWithoutLoss start delivering events to handler like Subscribe, but Publish waits while its buffer
+// is full instead of dropping events. The handler must be quick and must not publish, so it does not
+// hold back publishers
+func (bus *Bus) SubscribeWithoutLoss(name string, handler Handler) {
+	bus.subscribe(name, handler, true)
+}
+
+func (bus *Bus) subscribe(name string, handler Handler, lossless bool) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	if bus.closed {
+		return
+	}
+
+	sub := &subscriber{name: name, handler: handler, events: make(chan Event, bus.bufferSize), lossless: lossless}
+	bus.subscribers = append(bus.subscribers, sub)
+
+	bus.wg.Add(1)
+	go func() {
+		defer bus.wg.Done()
+		for event := range sub.events {
+			sub.handle(event)
+		}
+	}()
+}
+
+// Publish send event to all subscribers without waiting for them, except for a full buffer of a subscriber
+// that does not lose events
+func (bus *Bus) Publish(event Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	bus.mu.RLock()
+	defer bus.mu.RUnlock()
+
+	if bus.closed {
+		return
+	}
+
+	for _, sub := range bus.subscribers {
+		if sub.lossless {
+			sub.events <- event
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			log.Printf("events: %s buffer is full, dropping %s event", sub.name, event.Type)
+		}
+	}
+}
+
+// Close stop accepting events and wait until subscribers handle the buffered ones
+func (bus *Bus) Close() {
+	bus.mu.Lock()
+	if !bus.closed {
+		bus.closed = true
+		for _, sub := range bus.subscribers {
+			close(sub.events)
+		}
+	}
+	bus.mu.Unlock()
+
+	bus.wg.Wait()
+}
+
+// handle run handler recovering from panics, so one bad event does not stop the subscriber
+func (sub *subscriber) handle(event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("events: %s panicked handling %s event: %v", sub.name, event.Type, r)
+		}
+	}()
+
+	sub.handler(event)
+}
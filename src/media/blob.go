@@ -0,0 +1,99 @@
+// Package media receive uploaded images and videos and keep their content in a pluggable blob store
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrBlobNotFound is returned when there is no content stored with the key
+var ErrBlobNotFound = errors.New("Blob not found")
+
+// BlobStore keep the content of media by key, keys are relative paths separated by slashes
+type BlobStore interface {
+	Put(ctx context.Context, key, contentType string, body io.ReadSeeker) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// LocalStore keep blobs as files inside one directory
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore create a store that writes blobs inside dir, creating it when needed
+func NewLocalStore(dir string) *LocalStore {
+	return &LocalStore{dir: dir}
+}
+
+// Put write the blob to a temporary file and rename it, so readers never see partial content
+func (store *LocalStore) Put(ctx context.Context, key, contentType string, body io.ReadSeeker) error {
+	name, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = io.Copy(file, body); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err = file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), name)
+}
+
+// Get open the blob for reading
+func (store *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	name, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+
+	return file, err
+}
+
+// Delete remove the blob, removing a missing blob is not an error
+func (store *LocalStore) Delete(ctx context.Context, key string) error {
+	name, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// path return the file of key, refusing keys that would leave the store directory
+func (store *LocalStore) path(key string) (string, error) {
+	if key == "" || path.IsAbs(key) || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return "", errors.New("Invalid blob key")
+	}
+
+	return filepath.Join(store.dir, filepath.FromSlash(key)), nil
+}
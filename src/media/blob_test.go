@@ -0,0 +1,180 @@
+package media_test
+
+import (
+	"api/src/media"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// blobStoreCases run against every BlobStore, keys are under prefix so runs do not see each other
+var blobStoreCases = []struct {
+	name string
+	test func(t *testing.T, store media.BlobStore, prefix string)
+}{
+	{"PutAndGet", testPutAndGet},
+	{"PutReplaces", testPutReplaces},
+	{"Delete", testDelete},
+}
+
+func testPutAndGet(t *testing.T, store media.BlobStore, prefix string) {
+	ctx := context.Background()
+	key := prefix + "/nested/picture.png"
+
+	if err := store.Put(ctx, key, "image/png", bytes.NewReader([]byte("content"))); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Delete(ctx, key)
+
+	if content := readBlob(t, store, key); content != "content" {
+		t.Errorf("expected %q, got %q", "content", content)
+	}
+
+	if _, err := store.Get(ctx, prefix+"/missing.png"); err != media.ErrBlobNotFound {
+		t.Errorf("expected missing blob not found, got %v", err)
+	}
+}
+
+func testPutReplaces(t *testing.T, store media.BlobStore, prefix string) {
+	ctx := context.Background()
+	key := prefix + "/picture.png"
+
+	for _, content := range []string{"first", "second"} {
+		if err := store.Put(ctx, key, "image/png", bytes.NewReader([]byte(content))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer store.Delete(ctx, key)
+
+	if content := readBlob(t, store, key); content != "second" {
+		t.Errorf("expected the last content, got %q", content)
+	}
+}
+
+func testDelete(t *testing.T, store media.BlobStore, prefix string) {
+	ctx := context.Background()
+	key := prefix + "/picture.png"
+
+	if err := store.Put(ctx, key, "image/png", bytes.NewReader([]byte("content"))); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, step := range []string{"delete", "delete again"} {
+		if err := store.Delete(ctx, key); err != nil {
+			t.Fatalf("%s: %v", step, err)
+		}
+		if _, err := store.Get(ctx, key); err != media.ErrBlobNotFound {
+			t.Errorf("%s: expected blob not found, got %v", step, err)
+		}
+	}
+}
+
+// readBlob return the content stored in key
+func readBlob(t *testing.T, store media.BlobStore, key string) string {
+	t.Helper()
+
+	body, err := store.Get(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestLocalStore(t *testing.T) {
+	for _, c := range blobStoreCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) { c.test(t, media.NewLocalStore(t.TempDir()), "1") })
+	}
+}
+
+func TestLocalStoreRefusesKeysOutsideItsDirectory(t *testing.T) {
+	root := t.TempDir()
+	store := media.NewLocalStore(filepath.Join(root, "blobs"))
+	ctx := context.Background()
+
+	if err := os.WriteFile(filepath.Join(root, "secret"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []string{"", "..", "../secret", "1/../../secret", "/secret", "1/./picture.png", "1//picture.png", "1/picture.png/"}
+	for _, key := range keys {
+		if err := store.Put(ctx, key, "image/png", bytes.NewReader([]byte("replaced"))); err == nil {
+			t.Errorf("%q: expected put refused", key)
+		}
+		if body, err := store.Get(ctx, key); err == nil {
+			body.Close()
+			t.Errorf("%q: expected get refused", key)
+		}
+		if err := store.Delete(ctx, key); err == nil {
+			t.Errorf("%q: expected delete refused", key)
+		}
+	}
+
+	if content, err := os.ReadFile(filepath.Join(root, "secret")); err != nil || string(content) != "secret" {
+		t.Errorf("expected the file outside the store untouched, got %q, %v", content, err)
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "secret" {
+			t.Errorf("expected nothing written, found %s", entry.Name())
+		}
+	}
+}
+
+// TestS3Store run the cases against the bucket S3_TEST_BUCKET, which must exist, in the S3 compatible
+// service at S3_TEST_ENDPOINT, like a local MinIO. Blobs are written under a random prefix and removed
+func TestS3Store(t *testing.T) {
+	endpoint, bucket := os.Getenv("S3_TEST_ENDPOINT"), os.Getenv("S3_TEST_BUCKET")
+	if endpoint == "" || bucket == "" {
+		t.Skip("S3_TEST_ENDPOINT or S3_TEST_BUCKET not set")
+	}
+
+	region := os.Getenv("S3_TEST_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	store, err := media.NewS3Store(endpoint, region, bucket, os.Getenv("S3_TEST_ACCESS_KEY"), os.Getenv("S3_TEST_SECRET_KEY"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range blobStoreCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			random := make([]byte, 8)
+			if _, err := rand.Read(random); err != nil {
+				t.Fatal(err)
+			}
+			c.test(t, store, "test-"+hex.EncodeToString(random))
+		})
+	}
+
+	t.Run("WrongCredentials", func(t *testing.T) {
+		refused, err := media.NewS3Store(endpoint, region, bucket, "wrong", "wrong")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = refused.Put(context.Background(), "test-refused/picture.png", "image/png", bytes.NewReader([]byte("content")))
+		if err == nil || errors.Is(err, media.ErrBlobNotFound) {
+			t.Errorf("expected the service to refuse the signature, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package media
+
+import (
+	"api/src/repositories"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// collectBatchSize is how many orphans are removed by each query of one collection
+const collectBatchSize = 100
+
+// Collector remove in background the media that stayed without publication for longer than the grace period,
+// like uploads never published and media from deleted publications
+type Collector struct {
+	media repositories.MediaRepository
+	blobs BlobStore
+	grace time.Duration
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewCollector create a collector for orphans older than grace
+func NewCollector(media repositories.MediaRepository, blobs BlobStore, grace time.Duration) *Collector {
+	return &Collector{media: media, blobs: blobs, grace: grace, done: make(chan struct{})}
+}
+
+// Start collect orphans now and then every interval until Close
+func (collector *Collector) Start(interval time.Duration) {
+	collector.wg.Add(1)
+	go func() {
+		defer collector.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if removed, err := collector.Collect(context.Background(), time.Now()); err != nil {
+				log.Printf("media: collect failed after removing %d orphans: %v", removed, err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-collector.done:
+				return
+			}
+		}
+	}()
+}
+
+// Collect remove row, content and variants of every media orphan at now, returning how many were removed.
+// The row is claimed first, so media attached to a publication after it was found is kept with its content.
+// Content that could not be removed after that is reported in the error, since no row points to it anymore.
+func (collector *Collector) Collect(ctx context.Context, now time.Time) (int, error) {
+	removed := 0
+
+	for {
+		orphans, err := collector.media.FindOrphans(now.Add(-collector.grace), collectBatchSize)
+		if err != nil || len(orphans) == 0 {
+			return removed, err
+		}
+
+		for _, media := range orphans {
+			deleted, err := collector.media.DeleteOrphan(media.ID)
+			if err != nil {
+				return removed, err
+			}
+
+			// attached to a publication after it was found
+			if !deleted {
+				continue
+			}
+
+			keys := []string{media.StorageKey}
+			for _, variant := range media.Variants {
+				keys = append(keys, variant.StorageKey)
+			}
+
+			for _, key := range keys {
+				if err = collector.blobs.Delete(ctx, key); err != nil {
+					return removed, fmt.Errorf("media %d was removed but its content %s was left in storage: %w", media.ID, key, err)
+				}
+			}
+
+			removed++
+		}
+
+		select {
+		case <-collector.done:
+			return removed, nil
+		default:
+		}
+	}
+}
+
+// Close stop the collector and wait the collection running to finish
+func (collector *Collector) Close() {
+	collector.closeOnce.Do(func() { close(collector.done) })
+	collector.wg.Wait()
+}
@@ -0,0 +1,204 @@
+package media_test
+
+import (
+	"api/src/media"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/repositories/memory"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blobs keep blobs in memory, failing to delete the keys in broken
+type blobs struct {
+	mu     sync.Mutex
+	keys   map[string][]byte
+	broken map[string]bool
+}
+
+func newBlobs() *blobs {
+	return &blobs{keys: map[string][]byte{}, broken: map[string]bool{}}
+}
+
+func (blobs *blobs) Put(ctx context.Context, key, contentType string, body io.ReadSeeker) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	blobs.mu.Lock()
+	defer blobs.mu.Unlock()
+
+	blobs.keys[key] = data
+	return nil
+}
+
+func (blobs *blobs) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	blobs.mu.Lock()
+	defer blobs.mu.Unlock()
+
+	data, ok := blobs.keys[key]
+	if !ok {
+		return nil, media.ErrBlobNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (blobs *blobs) Delete(ctx context.Context, key string) error {
+	blobs.mu.Lock()
+	defer blobs.mu.Unlock()
+
+	if blobs.broken[key] {
+		return errors.New("storage unavailable")
+	}
+
+	delete(blobs.keys, key)
+	return nil
+}
+
+// stored return the keys left in the store, sorted
+func (blobs *blobs) stored() []string {
+	blobs.mu.Lock()
+	defer blobs.mu.Unlock()
+
+	keys := []string{}
+	for key := range blobs.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// attaching run attach after the orphans are found, like a publication created while the collector runs
+type attaching struct {
+	repositories.MediaRepository
+	attach func()
+}
+
+func (repository *attaching) FindOrphans(createdBefore time.Time, limit int) ([]models.Media, error) {
+	orphans, err := repository.MediaRepository.FindOrphans(createdBefore, limit)
+	if repository.attach != nil {
+		repository.attach()
+		repository.attach = nil
+	}
+	return orphans, err
+}
+
+func TestCollectorRemovesOnlyOrphans(t *testing.T) {
+	tests := []struct {
+		name    string
+		attach  []string
+		race    []string
+		broken  []string
+		removed int
+		failed  bool
+		stored  []string
+		rows    []string
+	}{
+		{
+			name:    "orphans with their variants",
+			removed: 2,
+			stored:  []string{},
+			rows:    []string{},
+		},
+		{
+			name:    "attached media",
+			attach:  []string{"first.png"},
+			removed: 1,
+			stored:  []string{"first.png", "first_150.jpg"},
+			rows:    []string{"first.png"},
+		},
+		{
+			name:    "media attached after the orphans were found",
+			race:    []string{"first.png"},
+			removed: 1,
+			stored:  []string{"first.png", "first_150.jpg"},
+			rows:    []string{"first.png"},
+		},
+		{
+			name:    "content left in storage",
+			broken:  []string{"first_150.jpg"},
+			removed: 0,
+			failed:  true,
+			stored:  []string{"first_150.jpg", "second.png"},
+			rows:    []string{"second.png"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repos := memory.NewRepositories()
+			authorId, err := repos.Users.Create(models.User{Name: "alice", Nick: "alice", Email: "alice@devbook.com", Password: "alice"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			store := newBlobs()
+			for _, key := range test.broken {
+				store.broken[key] = true
+			}
+
+			ids := map[string]uint64{}
+			for _, key := range []string{"first.png", "second.png"} {
+				if ids[key], err = repos.Media.Create(models.Media{OwnerId: authorId, ContentType: "image/png", Size: 1, StorageKey: key}); err != nil {
+					t.Fatal(err)
+				}
+				store.keys[key] = nil
+			}
+
+			processed := models.Media{ID: ids["first.png"], Variants: []models.MediaVariant{{Size: 150, StorageKey: "first_150.jpg"}}}
+			if err = repos.Media.SaveVariants(processed); err != nil {
+				t.Fatal(err)
+			}
+			store.keys["first_150.jpg"] = nil
+
+			attach := func(keys []string) {
+				publication := models.Publication{Title: "post", Content: "media", Visibility: models.VisibilityPublic, Status: models.StatusPublished, AuthorId: authorId}
+				for _, key := range keys {
+					publication.MediaIds = append(publication.MediaIds, ids[key])
+				}
+				if _, err := repos.Publications.Create(publication); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if len(test.attach) > 0 {
+				attach(test.attach)
+			}
+
+			mediaRepository := &attaching{MediaRepository: repos.Media}
+			if len(test.race) > 0 {
+				mediaRepository.attach = func() { attach(test.race) }
+			}
+
+			collector := media.NewCollector(mediaRepository, store, 0)
+			removed, err := collector.Collect(context.Background(), time.Now().Add(time.Minute))
+
+			if removed != test.removed || (err != nil) != test.failed {
+				t.Errorf("expected %d removed and failure %v, got %d and %v", test.removed, test.failed, removed, err)
+			}
+
+			if stored := store.stored(); !reflect.DeepEqual(stored, test.stored) {
+				t.Errorf("expected %v left in storage, got %v", test.stored, stored)
+			}
+
+			rows := []string{}
+			for _, key := range []string{"first.png", "second.png"} {
+				if found, _ := repos.Media.FindById(ids[key]); found.ID != 0 {
+					rows = append(rows, key)
+				}
+			}
+
+			if !reflect.DeepEqual(rows, test.rows) {
+				t.Errorf("expected rows of %v kept, got %v", test.rows, rows)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	jpegMarkerPrefix = 0xFF
+	jpegStartOfImage = 0xD8
+	jpegStartOfScan  = 0xDA
+	jpegAPP1         = 0xE1
+)
+
+// ErrInvalidJPEG is returned when the segments of a JPEG file cannot be read
+var ErrInvalidJPEG = errors.New("Invalid JPEG file")
+
+// StripJPEGMetadata return the JPEG without its APP1 segments, where EXIF and XMP metadata live,
+// so location and device details are not published. Image data after the start of scan is kept as is
+func StripJPEGMetadata(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != jpegMarkerPrefix || data[1] != jpegStartOfImage {
+		return nil, ErrInvalidJPEG
+	}
+
+	stripped := make([]byte, 0, len(data))
+	stripped = append(stripped, data[:2]...)
+
+	position := 2
+	for position < len(data) {
+		if data[position] != jpegMarkerPrefix {
+			return nil, ErrInvalidJPEG
+		}
+
+		// markers can be preceded by any number of fill bytes
+		for position < len(data) && data[position] == jpegMarkerPrefix {
+			position++
+		}
+		if position == len(data) {
+			return nil, ErrInvalidJPEG
+		}
+
+		marker := data[position]
+		position++
+
+		if marker == jpegStartOfScan {
+			stripped = append(stripped, jpegMarkerPrefix, marker)
+			return append(stripped, data[position:]...), nil
+		}
+
+		// standalone markers have no length
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			stripped = append(stripped, jpegMarkerPrefix, marker)
+			continue
+		}
+
+		if position+2 > len(data) {
+			return nil, ErrInvalidJPEG
+		}
+
+		length := int(binary.BigEndian.Uint16(data[position:]))
+		if length < 2 || position+length > len(data) {
+			return nil, ErrInvalidJPEG
+		}
+
+		if marker != jpegAPP1 {
+			stripped = append(stripped, jpegMarkerPrefix, marker)
+			stripped = append(stripped, data[position:position+length]...)
+		}
+
+		position += length
+	}
+
+	return stripped, nil
+}
@@ -0,0 +1,270 @@
+package media
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	// decoders for the accepted image types, jpeg and png are registered by the imports above
+	_ "image/gif"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// maxProcessPixels refuse images that would need too much memory to decode, like decompression bombs
+	maxProcessPixels = 50_000_000
+	// blurhashSize is the side of the square the image is reduced to before computing its blurhash
+	blurhashSize = 32
+	jpegQuality  = 85
+	// resumeBatchSize is how many unprocessed images are read by each query of Resume
+	resumeBatchSize = 100
+)
+
+// ErrImageTooLarge is returned when the dimensions of an image exceed what the processor decodes
+var ErrImageTooLarge = errors.New("Image dimensions are too large")
+
+// Processor generate in background the variants and blurhash of uploaded images,
+// using a fixed pool of workers reading from a bounded queue
+type Processor struct {
+	media repositories.MediaRepository
+	blobs BlobStore
+	sizes []int
+
+	jobs      chan uint64
+	mu        sync.RWMutex
+	closed    bool
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewProcessor create a processor with workers generating one variant for each size, the longest side
+// of a variant is its size. Up to queueSize images wait to be processed, Enqueue refuses more than that
+func NewProcessor(media repositories.MediaRepository, blobs BlobStore, sizes []int, workers, queueSize int) *Processor {
+	processor := &Processor{
+		media: media,
+		blobs: blobs,
+		sizes: sizes,
+		jobs:  make(chan uint64, queueSize),
+		done:  make(chan struct{}),
+	}
+
+	for i := 0; i < workers; i++ {
+		processor.wg.Add(1)
+		go func() {
+			defer processor.wg.Done()
+
+			for mediaId := range processor.jobs {
+				if err := processor.Process(context.Background(), mediaId); err != nil {
+					log.Printf("media: processing %d failed: %v", mediaId, err)
+				}
+			}
+		}()
+	}
+
+	return processor
+}
+
+// Enqueue schedule media to be processed without waiting, so uploads are never held by a full queue.
+// Return false when the queue is full or the processor is closed, Resume processes that media on the next start
+func (processor *Processor) Enqueue(mediaId uint64) bool {
+	processor.mu.RLock()
+	defer processor.mu.RUnlock()
+
+	if processor.closed {
+		return false
+	}
+
+	select {
+	case processor.jobs <- mediaId:
+		return true
+	default:
+		return false
+	}
+}
+
+// Resume enqueue the images uploaded before startedAt that were never processed, like the ones queued or being
+// processed when the previous process stopped. It waits for room in the queue, so it is run in background
+// at startup, and returns how many images were enqueued before finishing or the processor being closed
+func (processor *Processor) Resume(startedAt time.Time) (int, error) {
+	enqueued := 0
+	afterId := uint64(0)
+
+	for {
+		pending, err := processor.media.FindUnprocessed(startedAt, afterId, resumeBatchSize)
+		if err != nil || len(pending) == 0 {
+			return enqueued, err
+		}
+
+		for _, media := range pending {
+			if !processor.wait(media.ID) {
+				return enqueued, nil
+			}
+
+			enqueued++
+			afterId = media.ID
+		}
+	}
+}
+
+// Process generate and save the variants and blurhash of one image, media that no longer exists is ignored.
+// Images that cannot be decoded are marked as failed, so Resume does not enqueue them again, while errors
+// reading or writing blobs leave the image to be tried on the next start
+func (processor *Processor) Process(ctx context.Context, mediaId uint64) error {
+	media, err := processor.media.FindById(mediaId)
+	if err != nil || media.ID == 0 {
+		return err
+	}
+
+	content, err := processor.blobs.Get(ctx, media.StorageKey)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadAll(content)
+	content.Close()
+	if err != nil {
+		return err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return processor.fail(media.ID, err)
+	}
+	if config.Width*config.Height > maxProcessPixels {
+		return processor.fail(media.ID, ErrImageTooLarge)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return processor.fail(media.ID, err)
+	}
+
+	media.Width, media.Height = img.Bounds().Dx(), img.Bounds().Dy()
+	media.Variants = nil
+
+	for _, size := range processor.sizes {
+		// images are never enlarged, clients use the original when it is smaller than the size
+		if size >= media.Width && size >= media.Height {
+			continue
+		}
+
+		variant, err := processor.saveVariant(ctx, media, img, size)
+		if err != nil {
+			return err
+		}
+		media.Variants = append(media.Variants, variant)
+	}
+
+	media.Blurhash = Blurhash(scale(img, blurhashSize))
+
+	return processor.media.SaveVariants(media)
+}
+
+// fail mark media as failed because of err, which is returned
+func (processor *Processor) fail(mediaId uint64, err error) error {
+	if markErr := processor.media.MarkFailed(mediaId); markErr != nil {
+		return fmt.Errorf("%w, also could not mark media as failed: %v", err, markErr)
+	}
+
+	return err
+}
+
+// Close stop accepting images and wait the workers to process the ones already queued
+func (processor *Processor) Close() {
+	processor.closeOnce.Do(func() { close(processor.done) })
+
+	processor.mu.Lock()
+	if !processor.closed {
+		processor.closed = true
+		close(processor.jobs)
+	}
+	processor.mu.Unlock()
+
+	processor.wg.Wait()
+}
+
+// wait schedule media to be processed, waiting while the queue is full. Return false when the processor is closed
+func (processor *Processor) wait(mediaId uint64) bool {
+	processor.mu.RLock()
+	defer processor.mu.RUnlock()
+
+	if processor.closed {
+		return false
+	}
+
+	// done is closed before Close takes the lock, so a full queue never holds Close
+	select {
+	case processor.jobs <- mediaId:
+		return true
+	case <-processor.done:
+		return false
+	}
+}
+
+// saveVariant store img reduced to size, as JPEG when it is opaque and PNG to keep transparency
+func (processor *Processor) saveVariant(ctx context.Context, media models.Media, img image.Image, size int) (models.MediaVariant, error) {
+	scaled := scale(img, size)
+
+	var encoded bytes.Buffer
+	variant := models.MediaVariant{Size: size, Width: scaled.Bounds().Dx(), Height: scaled.Bounds().Dy()}
+
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		variant.ContentType = "image/jpeg"
+		if err := jpeg.Encode(&encoded, scaled, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return models.MediaVariant{}, err
+		}
+	} else {
+		variant.ContentType = "image/png"
+		if err := png.Encode(&encoded, scaled); err != nil {
+			return models.MediaVariant{}, err
+		}
+	}
+
+	variant.StorageKey = variantKey(media.StorageKey, size, allowedTypes[variant.ContentType])
+	if err := processor.blobs.Put(ctx, variant.StorageKey, variant.ContentType, bytes.NewReader(encoded.Bytes())); err != nil {
+		return models.MediaVariant{}, err
+	}
+
+	return variant, nil
+}
+
+// scale reduce img to fit in a square of size, keeping its proportions
+func scale(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width >= height && width > size {
+		width, height = size, height*size/width
+	} else if height > width && height > size {
+		width, height = width*size/height, size
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+	return scaled
+}
+
+// variantKey derive the storage key of one variant from the key of the original
+func variantKey(key string, size int, extension string) string {
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(key, path.Ext(key)), size, extension)
+}
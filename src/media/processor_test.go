@@ -0,0 +1,219 @@
+package media_test
+
+import (
+	"api/src/media"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/repositories/memory"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+)
+
+// uploads is media uploaded by one user to in memory repositories and blobs
+type uploads struct {
+	t       *testing.T
+	repos   repositories.Repositories
+	blobs   *blobs
+	ownerId uint64
+}
+
+func newUploads(t *testing.T) *uploads {
+	repos := memory.NewRepositories()
+	ownerId, err := repos.Users.Create(models.User{Name: "alice", Nick: "alice", Email: "alice@devbook.com", Password: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &uploads{t: t, repos: repos, blobs: newBlobs(), ownerId: ownerId}
+}
+
+// add create media with content stored in blobs, returning its id
+func (uploads *uploads) add(key, contentType string, content []byte) uint64 {
+	uploads.t.Helper()
+
+	id, err := uploads.repos.Media.Create(models.Media{OwnerId: uploads.ownerId, ContentType: contentType, Size: int64(len(content)), StorageKey: key})
+	if err != nil {
+		uploads.t.Fatal(err)
+	}
+	if err = uploads.blobs.Put(context.Background(), key, contentType, bytes.NewReader(content)); err != nil {
+		uploads.t.Fatal(err)
+	}
+	return id
+}
+
+// picture return a PNG with the dimensions
+func picture(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return encoded.Bytes()
+}
+
+// giant return a PNG that declares the dimensions but holds no pixels, like a decompression bomb
+func giant(width, height uint32) []byte {
+	header := make([]byte, 13)
+	binary.BigEndian.PutUint32(header[0:], width)
+	binary.BigEndian.PutUint32(header[4:], height)
+	header[8], header[9] = 8, 6
+
+	chunk := append([]byte("IHDR"), header...)
+	encoded := append([]byte("\x89PNG\r\n\x1a\n"), 0, 0, 0, 13)
+	encoded = append(encoded, chunk...)
+	checksum := make([]byte, 4)
+	binary.BigEndian.PutUint32(checksum, crc32.ChecksumIEEE(chunk))
+	return append(encoded, checksum...)
+}
+
+func TestEnqueueNeverWaitsForRoom(t *testing.T) {
+	// without workers nothing leaves the queue
+	processor := media.NewProcessor(memory.NewRepositories().Media, newBlobs(), []int{150}, 0, 1)
+
+	steps := []struct {
+		name     string
+		enqueued bool
+	}{
+		{"queue with room", true},
+		{"full queue", false},
+	}
+
+	for _, step := range steps {
+		result := make(chan bool)
+		go func() { result <- processor.Enqueue(1) }()
+
+		select {
+		case enqueued := <-result:
+			if enqueued != step.enqueued {
+				t.Errorf("%s: expected enqueued %v, got %v", step.name, step.enqueued, enqueued)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s: Enqueue is waiting", step.name)
+		}
+	}
+
+	processor.Close()
+	if processor.Enqueue(2) {
+		t.Error("expected closed processor to refuse media")
+	}
+}
+
+func TestResumeProcessesImagesLeftByPreviousStart(t *testing.T) {
+	uploads := newUploads(t)
+	repos, store := uploads.repos, uploads.blobs
+
+	pending := uploads.add("pending.png", "image/png", picture(t, 300, 200))
+	processed := uploads.add("processed.png", "image/png", picture(t, 300, 200))
+	video := uploads.add("video.mp4", "video/mp4", []byte("not an image"))
+
+	previous := media.NewProcessor(repos.Media, store, []int{150}, 1, 10)
+	if err := previous.Process(context.Background(), processed); err != nil {
+		t.Fatal(err)
+	}
+	previous.Close()
+
+	startedAt := time.Now().Add(time.Minute)
+	processor := media.NewProcessor(repos.Media, store, []int{150}, 1, 10)
+	enqueued, err := processor.Resume(startedAt)
+	if err != nil || enqueued != 1 {
+		t.Fatalf("expected only the pending image enqueued, got %d, %v", enqueued, err)
+	}
+	processor.Close()
+
+	for _, mediaId := range []uint64{pending, processed} {
+		found, _ := repos.Media.FindById(mediaId)
+		if found.Blurhash == "" || found.Width != 300 || len(found.Variants) != 1 {
+			t.Errorf("expected %d processed, got %+v", mediaId, found)
+		}
+	}
+
+	if found, _ := repos.Media.FindById(video); found.Blurhash != "" || len(found.Variants) != 0 {
+		t.Errorf("expected video not processed, got %+v", found)
+	}
+}
+
+func TestCloseStopsResumeWaitingForRoom(t *testing.T) {
+	uploads := newUploads(t)
+	for _, key := range []string{"1.png", "2.png", "3.png"} {
+		uploads.add(key, "image/png", picture(t, 10, 10))
+	}
+
+	// without workers the queue fills with the first image and Resume waits
+	processor := media.NewProcessor(uploads.repos.Media, uploads.blobs, []int{150}, 0, 1)
+
+	type result struct {
+		enqueued int
+		err      error
+	}
+	resumed := make(chan result)
+	go func() {
+		enqueued, err := processor.Resume(time.Now().Add(time.Minute))
+		resumed <- result{enqueued, err}
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	closed := make(chan struct{})
+	go func() {
+		processor.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close is waiting for Resume")
+	}
+
+	if r := <-resumed; r.err != nil || r.enqueued != 1 {
+		t.Errorf("expected Resume to stop after the first image, got %d, %v", r.enqueued, r.err)
+	}
+}
+
+func TestProcessMarksUndecodableImagesFailed(t *testing.T) {
+	uploads := newUploads(t)
+	repos := uploads.repos
+
+	corrupt := uploads.add("corrupt.png", "image/png", []byte("\x89PNG\r\n\x1a\nnot really"))
+	bomb := uploads.add("bomb.png", "image/png", giant(100000, 100000))
+	lost := uploads.add("lost.png", "image/png", picture(t, 10, 10))
+	delete(uploads.blobs.keys, "lost.png")
+
+	processor := media.NewProcessor(repos.Media, uploads.blobs, []int{150}, 0, 10)
+	defer processor.Close()
+
+	tests := []struct {
+		name    string
+		mediaId uint64
+		err     error
+		failed  bool
+	}{
+		{"content that is not an image", corrupt, nil, true},
+		{"dimensions over the limit", bomb, media.ErrImageTooLarge, true},
+		{"content missing from the blob store", lost, media.ErrBlobNotFound, false},
+	}
+
+	for _, test := range tests {
+		err := processor.Process(context.Background(), test.mediaId)
+		if err == nil || (test.err != nil && !errors.Is(err, test.err)) {
+			t.Errorf("%s: expected error %v, got %v", test.name, test.err, err)
+		}
+
+		if found, _ := repos.Media.FindById(test.mediaId); found.Failed != test.failed || found.Blurhash != "" {
+			t.Errorf("%s: expected failed %v, got %+v", test.name, test.failed, found)
+		}
+	}
+
+	// only the image that may succeed later is enqueued on the next start
+	enqueued, err := processor.Resume(time.Now().Add(time.Minute))
+	if err != nil || enqueued != 1 {
+		t.Errorf("expected only the image with missing content enqueued, got %d, %v", enqueued, err)
+	}
+}
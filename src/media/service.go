@@ -0,0 +1,144 @@
+package media
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// sniffLength is how many bytes http.DetectContentType looks at
+const sniffLength = 512
+
+var (
+	// ErrUnsupportedType is returned when the uploaded content is not an accepted image or video
+	ErrUnsupportedType = errors.New("Unsupported media type")
+	// ErrTooLarge is returned when the uploaded content is bigger than the limit of its type
+	ErrTooLarge = errors.New("Media is too large")
+)
+
+// allowedTypes map the accepted content types to their file extension
+var allowedTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"video/mp4":  ".mp4",
+	"video/webm": ".webm",
+}
+
+// Service validate uploads and keep media rows and their content consistent
+type Service struct {
+	media        repositories.MediaRepository
+	blobs        BlobStore
+	processor    *Processor
+	maxImageSize int64
+	maxVideoSize int64
+}
+
+// NewService create a media service saving content in blobs, images and videos bigger than their limits are refused.
+// Uploaded images are sent to processor, when it is not nil
+func NewService(media repositories.MediaRepository, blobs BlobStore, processor *Processor, maxImageSize, maxVideoSize int64) *Service {
+	return &Service{media: media, blobs: blobs, processor: processor, maxImageSize: maxImageSize, maxVideoSize: maxVideoSize}
+}
+
+// MaxUploadSize return the biggest content accepted for any type
+func (service *Service) MaxUploadSize() int64 {
+	if service.maxImageSize > service.maxVideoSize {
+		return service.maxImageSize
+	}
+	return service.maxVideoSize
+}
+
+// Upload read content, detecting its type from the bytes instead of trusting the client,
+// and store it as media from owner not attached to any publication
+func (service *Service) Upload(ctx context.Context, ownerId uint64, content io.Reader) (models.Media, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(content, service.MaxUploadSize()+1))
+	if err != nil {
+		return models.Media{}, err
+	}
+
+	sniffed := data
+	if len(sniffed) > sniffLength {
+		sniffed = sniffed[:sniffLength]
+	}
+
+	contentType := http.DetectContentType(sniffed)
+	extension, ok := allowedTypes[contentType]
+	if !ok {
+		return models.Media{}, ErrUnsupportedType
+	}
+
+	limit := service.maxImageSize
+	if strings.HasPrefix(contentType, "video/") {
+		limit = service.maxVideoSize
+	}
+	if int64(len(data)) > limit {
+		return models.Media{}, ErrTooLarge
+	}
+
+	if contentType == "image/jpeg" {
+		if data, err = StripJPEGMetadata(data); err != nil {
+			return models.Media{}, ErrUnsupportedType
+		}
+	}
+
+	key, err := newKey(ownerId, extension)
+	if err != nil {
+		return models.Media{}, err
+	}
+
+	media := models.Media{OwnerId: ownerId, ContentType: contentType, Size: int64(len(data)), StorageKey: key}
+
+	// the row is created first, so content is never stored without a row the collector can find
+	if media.ID, err = service.media.Create(media); err != nil {
+		return models.Media{}, err
+	}
+
+	if err = service.blobs.Put(ctx, key, contentType, bytes.NewReader(data)); err != nil {
+		if deleteErr := service.media.Delete(media.ID); deleteErr != nil {
+			return models.Media{}, fmt.Errorf("%w, also could not remove media row: %v", err, deleteErr)
+		}
+		return models.Media{}, err
+	}
+
+	if service.processor != nil && strings.HasPrefix(contentType, "image/") && !service.processor.Enqueue(media.ID) {
+		log.Printf("media: queue full, %d is processed on the next start", media.ID)
+	}
+
+	return service.media.FindById(media.ID)
+}
+
+// Find return one media, with zero id when it does not exist
+func (service *Service) Find(mediaId uint64) (models.Media, error) {
+	return service.media.FindById(mediaId)
+}
+
+// Open return the content of media, the caller must close it
+func (service *Service) Open(ctx context.Context, media models.Media) (io.ReadCloser, error) {
+	return service.blobs.Get(ctx, media.StorageKey)
+}
+
+// OpenVariant return the content of one variant, the caller must close it
+func (service *Service) OpenVariant(ctx context.Context, variant models.MediaVariant) (io.ReadCloser, error) {
+	return service.blobs.Get(ctx, variant.StorageKey)
+}
+
+// newKey create a random storage key grouped by owner
+func newKey(ownerId uint64, extension string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%d/%s%s", ownerId, hex.EncodeToString(random), extension), nil
+}
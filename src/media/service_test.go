@@ -0,0 +1,158 @@
+package media_test
+
+import (
+	"api/src/media"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"testing"
+)
+
+// mp4 is the start of an MP4 file, padded to size
+func mp4(size int) []byte {
+	header := []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom")
+	return append(header, make([]byte, size-len(header))...)
+}
+
+// photo return a JPEG and the same JPEG carrying an EXIF segment with a location
+func photo(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 40, 30)), nil); err != nil {
+		t.Fatal(err)
+	}
+	clean := encoded.Bytes()
+
+	exif := []byte("Exif\x00\x00GPS -23.5505,-46.6333")
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(exif)+2))
+	segment = append(segment, exif...)
+
+	tagged := append(append(append([]byte{}, clean[:2]...), segment...), clean[2:]...)
+	return clean, tagged
+}
+
+func TestUploadDetectsTypeFromContent(t *testing.T) {
+	uploads := newUploads(t)
+	service := media.NewService(uploads.repos.Media, uploads.blobs, nil, 1<<20, 1<<20)
+
+	tests := []struct {
+		name        string
+		content     []byte
+		contentType string
+	}{
+		{"png", picture(t, 10, 10), "image/png"},
+		{"gif", []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;"), "image/gif"},
+		{"mp4", mp4(64), "video/mp4"},
+		{"text", []byte("just some words"), ""},
+		{"html", []byte("<html><body>hi</body></html>"), ""},
+		{"script named as image", []byte("#!/bin/sh\nrm -rf /\n"), ""},
+		{"empty", nil, ""},
+	}
+
+	for _, test := range tests {
+		uploaded, err := service.Upload(context.Background(), uploads.ownerId, bytes.NewReader(test.content))
+
+		if test.contentType == "" {
+			if err != media.ErrUnsupportedType {
+				t.Errorf("%s: expected unsupported type, got %+v, %v", test.name, uploaded, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if uploaded.ContentType != test.contentType || uploaded.Size != int64(len(test.content)) {
+			t.Errorf("%s: expected %s with %d bytes, got %+v", test.name, test.contentType, len(test.content), uploaded)
+		}
+	}
+
+	if len(uploads.blobs.keys) != 3 {
+		t.Errorf("expected only the accepted uploads stored, got %d blobs", len(uploads.blobs.keys))
+	}
+}
+
+func TestUploadLimitsSizeByType(t *testing.T) {
+	small := picture(t, 10, 10)
+	imageLimit := int64(len(small))
+
+	tests := []struct {
+		name    string
+		content []byte
+		err     error
+	}{
+		{"image at the limit", small, nil},
+		{"image over the limit", picture(t, 300, 300), media.ErrTooLarge},
+		{"video over the image limit", mp4(int(imageLimit) * 2), nil},
+		{"video over its limit", mp4(int(imageLimit)*4 + 1), media.ErrTooLarge},
+		{"content over every limit", mp4(int(imageLimit) * 8), media.ErrTooLarge},
+	}
+
+	for _, test := range tests {
+		uploads := newUploads(t)
+		service := media.NewService(uploads.repos.Media, uploads.blobs, nil, imageLimit, imageLimit*4)
+
+		if _, err := service.Upload(context.Background(), uploads.ownerId, bytes.NewReader(test.content)); err != test.err {
+			t.Errorf("%s: expected %v, got %v", test.name, test.err, err)
+		}
+
+		if stored := len(uploads.blobs.keys) == 1; stored != (test.err == nil) {
+			t.Errorf("%s: expected stored %v, got %d blobs", test.name, test.err == nil, len(uploads.blobs.keys))
+		}
+	}
+}
+
+func TestUploadStripsJPEGMetadata(t *testing.T) {
+	uploads := newUploads(t)
+	service := media.NewService(uploads.repos.Media, uploads.blobs, nil, 1<<20, 1<<20)
+	clean, tagged := photo(t)
+
+	uploaded, err := service.Upload(context.Background(), uploads.ownerId, bytes.NewReader(tagged))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored := uploads.blobs.keys[uploaded.StorageKey]
+	if !bytes.Equal(stored, clean) || uploaded.Size != int64(len(clean)) {
+		t.Errorf("expected the JPEG stored without the EXIF segment, got %d bytes, size %d", len(stored), uploaded.Size)
+	}
+
+	decoded, err := jpeg.Decode(bytes.NewReader(stored))
+	if err != nil || decoded.Bounds().Dx() != 40 || decoded.Bounds().Dy() != 30 {
+		t.Errorf("expected the stripped JPEG to decode, got %v", err)
+	}
+}
+
+func TestStripJPEGMetadata(t *testing.T) {
+	clean, tagged := photo(t)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected []byte
+		err      error
+	}{
+		{"with EXIF", tagged, clean, nil},
+		{"without EXIF", clean, clean, nil},
+		{"with fill bytes before the EXIF marker", append([]byte{0xFF, 0xD8, 0xFF}, tagged[2:]...), clean, nil},
+		{"not a JPEG", picture(t, 10, 10), nil, media.ErrInvalidJPEG},
+		{"too short", []byte{0xFF, 0xD8}, nil, media.ErrInvalidJPEG},
+		{"segment longer than the file", append([]byte{}, tagged[:10]...), nil, media.ErrInvalidJPEG},
+		{"garbage between segments", append([]byte{0xFF, 0xD8, 0x00}, clean[2:]...), nil, media.ErrInvalidJPEG},
+	}
+
+	for _, test := range tests {
+		stripped, err := media.StripJPEGMetadata(test.data)
+		if err != test.err {
+			t.Errorf("%s: expected %v, got %v", test.name, test.err, err)
+			continue
+		}
+		if !bytes.Equal(stripped, test.expected) {
+			t.Errorf("%s: expected %d bytes, got %d", test.name, len(test.expected), len(stripped))
+		}
+	}
+}
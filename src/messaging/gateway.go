@@ -0,0 +1,240 @@
+package messaging
+
+import (
+	"api/src/models"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// FrameMessage carry a message sent in one conversation
+	FrameMessage = "message"
+	// FrameTyping tell that a member is typing in one conversation
+	FrameTyping = "typing"
+	// FrameRead tell the last message a member read in one conversation
+	FrameRead = "read"
+	// FrameError answer a frame from client that could not be handled
+	FrameError = "error"
+
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = pongWait * 9 / 10
+	maxFrameSize   = 8 * 1024
+	sendBufferSize = 64
+)
+
+// Frame is the JSON document exchanged in the WebSocket in both directions
+type Frame struct {
+	Type           string          `json:"type"`
+	ConversationId uint64          `json:"conversationId,omitempty"`
+	UserId         uint64          `json:"userId,omitempty"`
+	MessageId      uint64          `json:"messageId,omitempty"`
+	Content        string          `json:"content,omitempty"`
+	Message        *models.Message `json:"message,omitempty"`
+	Error          string          `json:"error,omitempty"`
+}
+
+type socket struct {
+	userId uint64
+	conn   *websocket.Conn
+	send   chan Frame
+	closed bool
+	// reason is the close frame sent when the socket is closed because its session ended
+	reason []byte
+}
+
+// Gateway keep the WebSocket connections from each user and deliver frames to them.
+// Delivering never blocks: a client whose buffer is full is closed.
+type Gateway struct {
+	mu      sync.Mutex
+	closed  bool
+	clients map[uint64]map[*socket]bool
+	// sessions hold the channels closed when the login family of a watched session is revoked
+	sessions map[string]map[chan struct{}]bool
+}
+
+// NewGateway create a gateway without connections
+func NewGateway() *Gateway {
+	return &Gateway{clients: map[uint64]map[*socket]bool{}, sessions: map[string]map[chan struct{}]bool{}}
+}
+
+// Deliver send frame to all connections from users
+func (gateway *Gateway) Deliver(userIds []uint64, frame Frame) {
+	gateway.mu.Lock()
+	defer gateway.mu.Unlock()
+
+	for _, userId := range userIds {
+		for client := range gateway.clients[userId] {
+			gateway.sendTo(client, frame)
+		}
+	}
+}
+
+// Disconnect end the sessions opened with tokens from the login family, after it was revoked
+func (gateway *Gateway) Disconnect(familyId string) {
+	gateway.mu.Lock()
+	defer gateway.mu.Unlock()
+
+	for revoked := range gateway.sessions[familyId] {
+		close(revoked)
+	}
+	delete(gateway.sessions, familyId)
+}
+
+// Close disconnect every client and stop accepting new ones
+func (gateway *Gateway) Close() {
+	gateway.mu.Lock()
+	defer gateway.mu.Unlock()
+
+	gateway.closed = true
+	for _, clients := range gateway.clients {
+		for client := range clients {
+			gateway.remove(client)
+		}
+	}
+}
+
+// register add the client from user, returning nil when the gateway is closed
+func (gateway *Gateway) register(session Session, conn *websocket.Conn) *socket {
+	gateway.mu.Lock()
+	defer gateway.mu.Unlock()
+
+	if gateway.closed {
+		return nil
+	}
+
+	client := &socket{userId: session.UserId, conn: conn, send: make(chan Frame, sendBufferSize)}
+	if gateway.clients[session.UserId] == nil {
+		gateway.clients[session.UserId] = map[*socket]bool{}
+	}
+	gateway.clients[session.UserId][client] = true
+
+	return client
+}
+
+// watch return a channel closed when familyId is disconnected
+func (gateway *Gateway) watch(familyId string) chan struct{} {
+	gateway.mu.Lock()
+	defer gateway.mu.Unlock()
+
+	revoked := make(chan struct{})
+	if gateway.sessions[familyId] == nil {
+		gateway.sessions[familyId] = map[chan struct{}]bool{}
+	}
+	gateway.sessions[familyId][revoked] = true
+
+	return revoked
+}
+
+// unwatch stop tracking a channel returned by watch
+func (gateway *Gateway) unwatch(familyId string, revoked chan struct{}) {
+	gateway.mu.Lock()
+	defer gateway.mu.Unlock()
+
+	delete(gateway.sessions[familyId], revoked)
+	if len(gateway.sessions[familyId]) == 0 {
+		delete(gateway.sessions, familyId)
+	}
+}
+
+// reply send frame only to the client that sent the frame being answered
+func (gateway *Gateway) reply(client *socket, frame Frame) {
+	gateway.mu.Lock()
+	defer gateway.mu.Unlock()
+
+	gateway.sendTo(client, frame)
+}
+
+// unregister remove the client, its writer closes the socket after that
+func (gateway *Gateway) unregister(client *socket) {
+	gateway.mu.Lock()
+	defer gateway.mu.Unlock()
+
+	gateway.remove(client)
+}
+
+// expire close the client because the token that opened it expired or was revoked
+func (gateway *Gateway) expire(client *socket, reason string) {
+	gateway.mu.Lock()
+	defer gateway.mu.Unlock()
+
+	gateway.end(client, reason)
+}
+
+// end close the client telling it the session is no longer valid, so it must authenticate again
+func (gateway *Gateway) end(client *socket, reason string) {
+	if client.closed {
+		return
+	}
+
+	client.reason = websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason)
+	gateway.remove(client)
+}
+
+func (gateway *Gateway) sendTo(client *socket, frame Frame) {
+	if client.closed {
+		return
+	}
+
+	select {
+	case client.send <- frame:
+	default:
+		gateway.remove(client)
+	}
+}
+
+func (gateway *Gateway) remove(client *socket) {
+	if client.closed {
+		return
+	}
+
+	client.closed = true
+	close(client.send)
+
+	delete(gateway.clients[client.userId], client)
+	if len(gateway.clients[client.userId]) == 0 {
+		delete(gateway.clients, client.userId)
+	}
+}
+
+// writePump write frames from client buffer and pings, it is the only goroutine writing in the socket
+func (client *socket) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		client.conn.Close()
+	}()
+
+	for {
+		select {
+		case frame, ok := <-client.send:
+			client.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				reason := client.reason
+				if reason == nil {
+					reason = websocket.FormatCloseMessage(websocket.CloseGoingAway, "")
+				}
+				client.conn.WriteMessage(websocket.CloseMessage, reason)
+				return
+			}
+
+			encoded, err := json.Marshal(frame)
+			if err != nil {
+				continue
+			}
+
+			if err = client.conn.WriteMessage(websocket.TextMessage, encoded); err != nil {
+				return
+			}
+
+		case <-ticker.C:
+			client.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := client.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
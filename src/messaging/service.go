@@ -0,0 +1,324 @@
+// Package messaging implement direct messages between users, delivered in real time through WebSocket
+package messaging
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/repositories"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// ErrConversationNotFound is returned when conversation does not exist or user is not a member
+	ErrConversationNotFound = errors.New("Conversation not found")
+	// ErrUserNotFound is returned when one of the members of a new conversation does not exist
+	ErrUserNotFound = errors.New("User not found")
+	// ErrBlocked is returned when the sender and one of the members blocked each other
+	ErrBlocked = errors.New("Users blocked each other")
+)
+
+// Session identify the access token that opened a WebSocket, the socket is closed when it expires or is revoked
+type Session struct {
+	UserId    uint64
+	TokenId   string
+	FamilyId  string
+	ExpiresAt time.Time
+}
+
+// Service hold the rules of direct messages shared by REST endpoints and the WebSocket
+type Service struct {
+	gateway            *Gateway
+	users              repositories.UserRepository
+	conversations      repositories.ConversationRepository
+	tokens             repositories.TokenRepository
+	revocationInterval time.Duration
+}
+
+// NewService create a messaging service delivering the real time frames through gateway. Open sockets
+// look for the revocation of their token every revocationInterval.
+func NewService(repos repositories.Repositories, gateway *Gateway, revocationInterval time.Duration) *Service {
+	return &Service{
+		gateway:            gateway,
+		users:              repos.Users,
+		conversations:      repos.Conversations,
+		tokens:             repos.Tokens,
+		revocationInterval: revocationInterval,
+	}
+}
+
+// Disconnect end the sessions opened with tokens from the login family, used when it is revoked
+func (service *Service) Disconnect(familyId string) {
+	service.gateway.Disconnect(familyId)
+}
+
+// StartConversation create a conversation with the prepared members, returning the existing one
+// when it is a direct conversation between two users that already talked. The boolean is true
+// when a new conversation was created.
+func (service *Service) StartConversation(conversation models.Conversation) (models.Conversation, bool, error) {
+	creatorId := conversation.MemberIds[0]
+
+	for _, memberId := range conversation.MemberIds[1:] {
+		member, err := service.users.FindByID(memberId)
+		if err != nil {
+			return models.Conversation{}, false, err
+		}
+
+		if member.ID == 0 {
+			return models.Conversation{}, false, ErrUserNotFound
+		}
+	}
+
+	if err := service.checkBlocks(creatorId, conversation.MemberIds); err != nil {
+		return models.Conversation{}, false, err
+	}
+
+	if len(conversation.MemberIds) == 2 {
+		conversationId, err := service.conversations.FindDirect(creatorId, conversation.MemberIds[1])
+		if err != nil {
+			return models.Conversation{}, false, err
+		}
+
+		if conversationId != 0 {
+			existing, err := service.conversations.FindById(conversationId)
+			return existing, false, err
+		}
+	}
+
+	conversationId, err := service.conversations.Create(conversation)
+	if err != nil {
+		return models.Conversation{}, false, err
+	}
+
+	created, err := service.conversations.FindById(conversationId)
+	return created, true, err
+}
+
+// Conversation find one conversation from user
+func (service *Service) Conversation(userId, conversationId uint64) (models.Conversation, error) {
+	conversation, err := service.conversations.FindById(conversationId)
+	if err != nil {
+		return models.Conversation{}, err
+	}
+
+	if !conversation.HasMember(userId) {
+		return models.Conversation{}, ErrConversationNotFound
+	}
+
+	return conversation, nil
+}
+
+// Conversations find one page of conversations from user
+func (service *Service) Conversations(userId uint64, page pagination.Params) ([]models.Conversation, error) {
+	return service.conversations.FindByUser(userId, page)
+}
+
+// Messages find one page of the history from one conversation of user
+func (service *Service) Messages(userId, conversationId uint64, page pagination.Params) ([]models.Message, error) {
+	if _, err := service.Conversation(userId, conversationId); err != nil {
+		return nil, err
+	}
+
+	return service.conversations.FindMessages(conversationId, page)
+}
+
+// Send persist a prepared message and deliver it to all members connected
+func (service *Service) Send(message models.Message) (models.Message, error) {
+	conversation, err := service.Conversation(message.SenderId, message.ConversationId)
+	if err != nil {
+		return models.Message{}, err
+	}
+
+	if err = service.checkBlocks(message.SenderId, conversation.MemberIds); err != nil {
+		return models.Message{}, err
+	}
+
+	if message.ID, err = service.conversations.CreateMessage(message); err != nil {
+		return models.Message{}, err
+	}
+
+	for _, member := range conversation.Members {
+		if member.UserId == message.SenderId {
+			message.SenderNick = member.Nick
+		}
+	}
+	message.CreatedAt = time.Now()
+
+	service.gateway.Deliver(conversation.MemberIds, Frame{
+		Type:           FrameMessage,
+		ConversationId: message.ConversationId,
+		Message:        &message,
+	})
+
+	return message, nil
+}
+
+// Typing tell the other members that user is typing
+func (service *Service) Typing(userId, conversationId uint64) error {
+	conversation, err := service.Conversation(userId, conversationId)
+	if err != nil {
+		return err
+	}
+
+	service.gateway.Deliver(otherMembers(conversation, userId), Frame{
+		Type:           FrameTyping,
+		ConversationId: conversationId,
+		UserId:         userId,
+	})
+
+	return nil
+}
+
+// MarkRead save that user read the conversation up to message and tell the other members
+func (service *Service) MarkRead(userId, conversationId, messageId uint64) error {
+	conversation, err := service.Conversation(userId, conversationId)
+	if err != nil {
+		return err
+	}
+
+	if err = service.conversations.MarkRead(conversationId, userId, messageId); err != nil {
+		return err
+	}
+
+	service.gateway.Deliver(otherMembers(conversation, userId), Frame{
+		Type:           FrameRead,
+		ConversationId: conversationId,
+		UserId:         userId,
+		MessageId:      messageId,
+	})
+
+	return nil
+}
+
+// Serve handle the WebSocket opened in session until it is closed or the session ends, answering frames sent by client
+func (service *Service) Serve(session Session, conn *websocket.Conn) {
+	client := service.gateway.register(session, conn)
+	if client == nil {
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+		conn.Close()
+		return
+	}
+	defer service.gateway.unregister(client)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go client.writePump()
+	go func() {
+		if reason := service.Watch(session, done); reason != "" {
+			service.gateway.expire(client, reason)
+		}
+	}()
+
+	conn.SetReadLimit(maxFrameSize)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var frame Frame
+		if err = json.Unmarshal(data, &frame); err != nil {
+			service.gateway.reply(client, Frame{Type: FrameError, Error: "Invalid frame"})
+			continue
+		}
+
+		if err = service.handleFrame(session.UserId, frame); err != nil {
+			service.gateway.reply(client, Frame{Type: FrameError, ConversationId: frame.ConversationId, Error: err.Error()})
+		}
+	}
+}
+
+// Watch block until the token of session expires or is revoked, returning why the session ended,
+// or until done is closed, returning an empty reason. Connections that live longer than one request
+// use it to close themselves when the user logs out.
+func (service *Service) Watch(session Session, done <-chan struct{}) string {
+	disconnected := service.gateway.watch(session.FamilyId)
+	defer service.gateway.unwatch(session.FamilyId, disconnected)
+
+	expiry := time.NewTimer(time.Until(session.ExpiresAt))
+	defer expiry.Stop()
+
+	ticker := time.NewTicker(service.revocationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return ""
+
+		case <-disconnected:
+			return "Token revoked"
+
+		case <-expiry.C:
+			return "Token expired"
+
+		case <-ticker.C:
+			// a failed check keeps the session, the next tick tries again
+			revoked, err := service.tokens.IsAccessTokenRevoked(session.TokenId, session.FamilyId)
+			if err == nil && revoked {
+				return "Token revoked"
+			}
+		}
+	}
+}
+
+func (service *Service) handleFrame(userId uint64, frame Frame) error {
+	switch frame.Type {
+	case FrameMessage:
+		message := models.Message{ConversationId: frame.ConversationId, SenderId: userId, Content: frame.Content}
+		if err := message.Prepare(); err != nil {
+			return err
+		}
+
+		_, err := service.Send(message)
+		return err
+
+	case FrameTyping:
+		return service.Typing(userId, frame.ConversationId)
+
+	case FrameRead:
+		return service.MarkRead(userId, frame.ConversationId, frame.MessageId)
+	}
+
+	return errors.New("Unknown frame type")
+}
+
+// checkBlocks return ErrBlocked when user and one of the members blocked each other
+func (service *Service) checkBlocks(userId uint64, memberIds []uint64) error {
+	for _, memberId := range memberIds {
+		if memberId == userId {
+			continue
+		}
+
+		blocked, err := service.users.IsBlocked(userId, memberId)
+		if err != nil {
+			return err
+		}
+
+		if blocked {
+			return ErrBlocked
+		}
+	}
+
+	return nil
+}
+
+func otherMembers(conversation models.Conversation, userId uint64) []uint64 {
+	var members []uint64
+	for _, memberId := range conversation.MemberIds {
+		if memberId != userId {
+			members = append(members, memberId)
+		}
+	}
+
+	return members
+}
@@ -0,0 +1,120 @@
+package migrations
+
+import (
+	"api/src/config"
+	"api/src/db"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const usage = "usage: api migrate up [steps] | down [steps] | status | create <name>"
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Command run the migrate subcommand with received arguments
+func Command(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			return errors.New(usage)
+		}
+		return create(config.MigrationsDir, args[1], out)
+	}
+
+	steps := 0
+	if len(args) > 1 {
+		parsed, err := strconv.Atoi(args[1])
+		if err != nil || parsed < 1 {
+			return errors.New(usage)
+		}
+		steps = parsed
+	}
+
+	db, err := db.CreateMigrationConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx, steps)
+		for _, migration := range applied {
+			fmt.Fprintf(out, "applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "no pending migrations")
+		}
+		return err
+	case "down":
+		if steps == 0 {
+			steps = 1
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			fmt.Fprintf(out, "reverted %04d_%s\n", migration.Version, migration.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied at " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(out, "%04d_%s\t%s\n", status.Version, status.Name, state)
+		}
+		return nil
+	}
+
+	return errors.New(usage)
+}
+
+// create write empty up and down files for the next version in dir
+func create(dir, name string, out io.Writer) error {
+	name = strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return errors.New("Migration name cannot be blank")
+	}
+
+	migrations, err := parse(os.DirFS(dir), ".")
+	if err != nil {
+		return err
+	}
+
+	var version uint64 = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	for _, direction := range []string{"up", "down"} {
+		fileName := filepath.Join(dir, fmt.Sprintf("%04d_%s.%s.sql", version, name, direction))
+		if err = ioutil.WriteFile(fileName, []byte{}, 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "created %s\n", fileName)
+	}
+
+	return nil
+}
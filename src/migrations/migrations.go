@@ -0,0 +1,283 @@
+// Package migrations apply the versioned schema changes embedded in the binary
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+const lockTimeoutSeconds = 30
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration represent one schema version with the SQL to apply and revert it
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status represent if one migration was applied in database
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator apply migrations holding an advisory lock, so concurrent deploys do not run them twice
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// Load read all migrations embedded in the binary ordered by version
+func Load() ([]Migration, error) {
+	return parse(files, "sql")
+}
+
+// NewMigrator create a migrator for the embedded migrations, db must allow multi statements
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up apply up to steps pending migrations, all of them when steps is zero
+func (migrator *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var applied []Migration
+
+	err := migrator.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrator.migrations {
+			if steps > 0 && len(applied) == steps {
+				break
+			}
+
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+
+			if err = execute(ctx, conn, migration.Up); err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			if _, err = conn.ExecContext(ctx,
+				"INSERT INTO schema_migrations (version, name) values (?, ?)",
+				migration.Version, migration.Name,
+			); err != nil {
+				return err
+			}
+
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down revert the last steps applied migrations
+func (migrator *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+
+	err := migrator.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrator.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := migrator.migrations[i]
+			if _, ok := versions[migration.Version]; !ok {
+				continue
+			}
+
+			if err = execute(ctx, conn, migration.Down); err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			if _, err = conn.ExecContext(ctx,
+				"DELETE FROM schema_migrations WHERE version = ?", migration.Version,
+			); err != nil {
+				return err
+			}
+
+			reverted = append(reverted, migration)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status return all migrations with the moment they were applied
+func (migrator *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+
+	err := migrator.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrator.migrations {
+			status := Status{Migration: migration}
+			if appliedAt, ok := versions[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+func (migrator *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := migrator.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err = conn.QueryRowContext(ctx,
+		"SELECT GET_LOCK(CONCAT(DATABASE(), '.schema_migrations'), ?)", lockTimeoutSeconds,
+	).Scan(&locked); err != nil {
+		return err
+	}
+
+	if locked.Int64 != 1 {
+		return errors.New("Could not acquire migrations lock, another migration is running")
+	}
+	defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(CONCAT(DATABASE(), '.schema_migrations'))")
+
+	if _, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+		  version bigint primary key,
+		  name varchar(255) not null,
+		  applied_at timestamp default current_timestamp
+		) ENGINE=INNODB`,
+	); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// execute run the statements from one migration file, empty files are allowed
+func execute(ctx context.Context, conn *sql.Conn, statements string) error {
+	if strings.TrimSpace(statements) == "" {
+		return nil
+	}
+
+	_, err := conn.ExecContext(ctx, statements)
+	return err
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[uint64]time.Time, error) {
+	lines, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	versions := map[uint64]time.Time{}
+	for lines.Next() {
+		var version uint64
+		var appliedAt time.Time
+
+		if err = lines.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		versions[version] = appliedAt
+	}
+
+	return versions, lines.Err()
+}
+
+func parse(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[uint64]*Migration{}
+	found := map[string]bool{}
+	for _, entry := range entries {
+		matches := fileNamePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(matches[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != matches[2] {
+			return nil, fmt.Errorf("Migration %04d has two different names", version)
+		}
+
+		key := fmt.Sprintf("%d.%s", version, matches[3])
+		if found[key] {
+			return nil, fmt.Errorf("Migration %04d has two %s files", version, matches[3])
+		}
+
+		found[key] = true
+		if matches[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if !found[fmt.Sprintf("%d.up", migration.Version)] || !found[fmt.Sprintf("%d.down", migration.Version)] {
+			return nil, fmt.Errorf("Migration %04d_%s must have up and down files", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
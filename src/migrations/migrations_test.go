@@ -0,0 +1,205 @@
+package migrations
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParse(t *testing.T) {
+	file := func(content string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(content)} }
+
+	tests := []struct {
+		name       string
+		files      fstest.MapFS
+		migrations []Migration
+		err        string
+	}{
+		{
+			name: "ordered by version",
+			files: fstest.MapFS{
+				"sql/0010_late.up.sql":     file("late up"),
+				"sql/0010_late.down.sql":   file("late down"),
+				"sql/0002_second.up.sql":   file("second up"),
+				"sql/0002_second.down.sql": file(""),
+				"sql/0001_first.up.sql":    file("first up"),
+				"sql/0001_first.down.sql":  file("first down"),
+			},
+			migrations: []Migration{
+				{Version: 1, Name: "first", Up: "first up", Down: "first down"},
+				{Version: 2, Name: "second", Up: "second up"},
+				{Version: 10, Name: "late", Up: "late up", Down: "late down"},
+			},
+		},
+		{
+			name: "other files ignored",
+			files: fstest.MapFS{
+				"sql/0001_first.up.sql":   file("up"),
+				"sql/0001_first.down.sql": file("down"),
+				"sql/README.md":           file("notes"),
+				"sql/first.up.sql":        file("no version"),
+				"sql/0002_second.sql":     file("no direction"),
+			},
+			migrations: []Migration{{Version: 1, Name: "first", Up: "up", Down: "down"}},
+		},
+		{
+			name:  "empty directory",
+			files: fstest.MapFS{"sql": &fstest.MapFile{Mode: 0755 | os.ModeDir}},
+		},
+		{
+			name: "missing down file",
+			files: fstest.MapFS{
+				"sql/0001_first.up.sql": file("up"),
+			},
+			err: "Migration 0001_first must have up and down files",
+		},
+		{
+			name: "missing up file",
+			files: fstest.MapFS{
+				"sql/0001_first.down.sql": file("down"),
+			},
+			err: "Migration 0001_first must have up and down files",
+		},
+		{
+			name: "up and down names differ",
+			files: fstest.MapFS{
+				"sql/0001_first.up.sql":     file("up"),
+				"sql/0001_renamed.down.sql": file("down"),
+			},
+			err: "Migration 0001 has two different names",
+		},
+		{
+			name: "duplicate version",
+			files: fstest.MapFS{
+				"sql/0001_first.up.sql":   file("up"),
+				"sql/0001_first.down.sql": file("down"),
+				"sql/01_first.up.sql":     file("other up"),
+				"sql/01_first.down.sql":   file("other down"),
+			},
+			err: "Migration 0001 has two",
+		},
+		{
+			name:  "missing directory",
+			files: fstest.MapFS{},
+			err:   "open sql",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			migrations, err := parse(test.files, "sql")
+
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected error containing %q, got %v", test.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(migrations) != 0 || len(test.migrations) != 0 {
+				if !reflect.DeepEqual(migrations, test.migrations) {
+					t.Errorf("expected %+v, got %+v", test.migrations, migrations)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadEmbeddedMigrations(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != uint64(i+1) {
+			t.Errorf("expected versions without gaps, found %04d_%s at position %d", migration.Version, migration.Name, i+1)
+		}
+		if strings.TrimSpace(migration.Up) == "" || strings.TrimSpace(migration.Down) == "" {
+			t.Errorf("expected %04d_%s to change the schema both ways", migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		create   string
+		files    []string
+		err      string
+	}{
+		{
+			name:   "first migration",
+			create: "initial",
+			files:  []string{"0001_initial.down.sql", "0001_initial.up.sql"},
+		},
+		{
+			name:     "next version with a normalized name",
+			existing: []string{"0001_initial.up.sql", "0001_initial.down.sql", "0007_later.up.sql", "0007_later.down.sql"},
+			create:   "Add User Bio!",
+			files: []string{
+				"0001_initial.down.sql", "0001_initial.up.sql", "0007_later.down.sql", "0007_later.up.sql",
+				"0008_add_user_bio.down.sql", "0008_add_user_bio.up.sql",
+			},
+		},
+		{
+			name:   "blank name",
+			create: "--",
+			err:    "Migration name cannot be blank",
+		},
+		{
+			name:     "broken directory",
+			existing: []string{"0001_initial.up.sql"},
+			create:   "next",
+			files:    []string{"0001_initial.up.sql"},
+			err:      "must have up and down files",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range test.existing {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var out bytes.Buffer
+			err := create(dir, test.create, &out)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected error containing %q, got %v", test.err, err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			files := []string{}
+			for _, entry := range entries {
+				files = append(files, entry.Name())
+			}
+			sort.Strings(files)
+
+			expected := append([]string{}, test.files...)
+			sort.Strings(expected)
+			if !reflect.DeepEqual(files, expected) {
+				t.Errorf("expected files %v, got %v", expected, files)
+			}
+		})
+	}
+}
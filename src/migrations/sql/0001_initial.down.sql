@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS publication_likes;
+DROP TABLE IF EXISTS publications;
+DROP TABLE IF EXISTS followers;
+DROP TABLE IF EXISTS users;
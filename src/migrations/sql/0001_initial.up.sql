@@ -0,0 +1,97 @@
+CREATE TABLE users (
+  id int auto_increment primary key,
+  name varchar(50) not null,
+  nick varchar(50) not null unique,
+  email varchar(50) not null unique,
+  password varchar(100) not null,
+  createdAt timestamp default current_timestamp()
+) ENGINE = INNODB;
+
+
+CREATE TABLE followers(
+  user_id int not null,
+  FOREIGN KEY (user_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  follower_id int not null,
+  FOREIGN KEY (follower_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  primary key(user_id, follower_id)
+) ENGINE=INNODB;
+
+CREATE TABLE publications (
+  id int auto_increment primary key,
+  title varchar(50) not null,
+  content varchar(300) not null,
+
+  author_id int not null,
+  FOREIGN KEY(author_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  likes int default 0,
+  createdAt timestamp default current_timestamp
+) ENGINE=INNODB;
+
+CREATE TABLE publication_likes (
+  publication_id int not null,
+  FOREIGN KEY (publication_id)
+  REFERENCES publications(id)
+  ON DELETE CASCADE,
+
+  user_id int not null,
+  FOREIGN KEY (user_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  createdAt timestamp default current_timestamp,
+
+  primary key(publication_id, user_id)
+) ENGINE=INNODB;
+
+CREATE TABLE comments (
+  id int auto_increment primary key,
+  content varchar(300) not null,
+
+  publication_id int not null,
+  FOREIGN KEY (publication_id)
+  REFERENCES publications(id)
+  ON DELETE CASCADE,
+
+  author_id int not null,
+  FOREIGN KEY (author_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  parent_comment_id int null,
+  FOREIGN KEY (parent_comment_id)
+  REFERENCES comments(id)
+  ON DELETE CASCADE,
+
+  createdAt timestamp default current_timestamp
+) ENGINE=INNODB;
+
+CREATE TABLE refresh_tokens (
+  id int auto_increment primary key,
+
+  user_id int not null,
+  FOREIGN KEY (user_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  family_id char(32) not null,
+  token_hash char(64) not null unique,
+  expires_at timestamp not null,
+  revoked_at timestamp null default null,
+  createdAt timestamp default current_timestamp,
+
+  INDEX (family_id)
+) ENGINE=INNODB;
+
+CREATE TABLE revoked_tokens (
+  jti char(32) primary key,
+  expires_at timestamp not null
+) ENGINE=INNODB;
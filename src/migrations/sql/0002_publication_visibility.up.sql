@@ -0,0 +1,2 @@
+ALTER TABLE publications
+  ADD COLUMN visibility varchar(20) not null default 'public' AFTER content;
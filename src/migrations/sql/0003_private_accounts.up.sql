@@ -0,0 +1,17 @@
+ALTER TABLE users ADD COLUMN is_private boolean not null default false AFTER password;
+
+CREATE TABLE follow_requests(
+  user_id int not null,
+  FOREIGN KEY (user_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  requester_id int not null,
+  FOREIGN KEY (requester_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  createdAt timestamp default current_timestamp,
+
+  primary key(user_id, requester_id)
+) ENGINE=INNODB;
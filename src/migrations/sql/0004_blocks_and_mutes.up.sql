@@ -0,0 +1,31 @@
+CREATE TABLE user_blocks(
+  user_id int not null,
+  FOREIGN KEY (user_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  blocked_id int not null,
+  FOREIGN KEY (blocked_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  createdAt timestamp default current_timestamp,
+
+  primary key(user_id, blocked_id)
+) ENGINE=INNODB;
+
+CREATE TABLE user_mutes(
+  user_id int not null,
+  FOREIGN KEY (user_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  muted_id int not null,
+  FOREIGN KEY (muted_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  createdAt timestamp default current_timestamp,
+
+  primary key(user_id, muted_id)
+) ENGINE=INNODB;
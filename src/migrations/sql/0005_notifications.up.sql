@@ -0,0 +1,30 @@
+CREATE TABLE notifications(
+  id int auto_increment primary key,
+
+  user_id int not null,
+  FOREIGN KEY (user_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  actor_id int not null,
+  FOREIGN KEY (actor_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  type varchar(30) not null,
+
+  publication_id int null,
+  FOREIGN KEY (publication_id)
+  REFERENCES publications(id)
+  ON DELETE CASCADE,
+
+  comment_id int null,
+  FOREIGN KEY (comment_id)
+  REFERENCES comments(id)
+  ON DELETE CASCADE,
+
+  read_at timestamp null default null,
+  createdAt timestamp default current_timestamp,
+
+  INDEX notifications_inbox (user_id, createdAt, id)
+) ENGINE=INNODB;
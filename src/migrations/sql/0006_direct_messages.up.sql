@@ -0,0 +1,42 @@
+CREATE TABLE conversations(
+  id int auto_increment primary key,
+  createdAt timestamp default current_timestamp,
+  updatedAt timestamp(6) default current_timestamp(6)
+) ENGINE=INNODB;
+
+CREATE TABLE conversation_members(
+  conversation_id int not null,
+  FOREIGN KEY (conversation_id)
+  REFERENCES conversations(id)
+  ON DELETE CASCADE,
+
+  user_id int not null,
+  FOREIGN KEY (user_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  last_read_message_id int not null default 0,
+  joinedAt timestamp default current_timestamp,
+
+  primary key(conversation_id, user_id),
+  INDEX conversation_members_user (user_id)
+) ENGINE=INNODB;
+
+CREATE TABLE messages(
+  id int auto_increment primary key,
+
+  conversation_id int not null,
+  FOREIGN KEY (conversation_id)
+  REFERENCES conversations(id)
+  ON DELETE CASCADE,
+
+  sender_id int not null,
+  FOREIGN KEY (sender_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  content varchar(1000) not null,
+  createdAt timestamp default current_timestamp,
+
+  INDEX messages_history (conversation_id, createdAt, id)
+) ENGINE=INNODB;
@@ -0,0 +1,3 @@
+ALTER TABLE publications DROP INDEX publications_search;
+
+ALTER TABLE users DROP INDEX users_search;
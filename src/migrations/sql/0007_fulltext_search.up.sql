@@ -0,0 +1,3 @@
+ALTER TABLE users ADD FULLTEXT INDEX users_search (name, nick);
+
+ALTER TABLE publications ADD FULLTEXT INDEX publications_search (title, content);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS publication_hashtags;
+DROP TABLE IF EXISTS hashtags;
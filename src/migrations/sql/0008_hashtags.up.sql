@@ -0,0 +1,20 @@
+CREATE TABLE hashtags(
+  id int auto_increment primary key,
+  tag varchar(100) not null unique,
+  createdAt timestamp default current_timestamp
+) ENGINE=INNODB;
+
+CREATE TABLE publication_hashtags(
+  publication_id int not null,
+  FOREIGN KEY (publication_id)
+  REFERENCES publications(id)
+  ON DELETE CASCADE,
+
+  hashtag_id int not null,
+  FOREIGN KEY (hashtag_id)
+  REFERENCES hashtags(id)
+  ON DELETE CASCADE,
+
+  primary key(publication_id, hashtag_id),
+  INDEX publication_hashtags_tag (hashtag_id, publication_id)
+) ENGINE=INNODB;
@@ -0,0 +1,17 @@
+CREATE TABLE publication_mentions(
+  publication_id int not null,
+  FOREIGN KEY (publication_id)
+  REFERENCES publications(id)
+  ON DELETE CASCADE,
+
+  user_id int not null,
+  FOREIGN KEY (user_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  mention_offset int not null,
+  mention_length int not null,
+
+  primary key(publication_id, mention_offset),
+  INDEX publication_mentions_user (user_id, publication_id)
+) ENGINE=INNODB;
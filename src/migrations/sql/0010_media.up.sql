@@ -0,0 +1,22 @@
+CREATE TABLE media(
+  id int auto_increment primary key,
+
+  owner_id int null,
+  FOREIGN KEY (owner_id)
+  REFERENCES users(id)
+  ON DELETE SET NULL,
+
+  publication_id int null,
+  FOREIGN KEY (publication_id)
+  REFERENCES publications(id)
+  ON DELETE SET NULL,
+
+  position tinyint not null default 0,
+  storage_key varchar(255) not null unique,
+  content_type varchar(100) not null,
+  size bigint not null,
+  createdAt timestamp default current_timestamp,
+
+  INDEX media_publication (publication_id, position),
+  INDEX media_orphans (publication_id, createdAt)
+) ENGINE=INNODB;
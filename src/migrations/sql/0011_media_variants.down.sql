@@ -0,0 +1,5 @@
+ALTER TABLE media
+  DROP COLUMN variants,
+  DROP COLUMN blurhash,
+  DROP COLUMN height,
+  DROP COLUMN width;
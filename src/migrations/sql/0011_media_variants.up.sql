@@ -0,0 +1,5 @@
+ALTER TABLE media
+  ADD COLUMN width int not null default 0,
+  ADD COLUMN height int not null default 0,
+  ADD COLUMN blurhash varchar(64) null,
+  ADD COLUMN variants json null;
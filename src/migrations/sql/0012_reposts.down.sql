@@ -0,0 +1,6 @@
+ALTER TABLE publications
+  DROP FOREIGN KEY publications_repost_of,
+  DROP FOREIGN KEY publications_quote_of,
+  DROP INDEX publications_repost,
+  DROP COLUMN repost_of_id,
+  DROP COLUMN quote_of_id;
@@ -0,0 +1,6 @@
+ALTER TABLE publications
+  ADD COLUMN repost_of_id int null,
+  ADD COLUMN quote_of_id int null,
+  ADD CONSTRAINT publications_repost_of FOREIGN KEY (repost_of_id) REFERENCES publications(id) ON DELETE CASCADE,
+  ADD CONSTRAINT publications_quote_of FOREIGN KEY (quote_of_id) REFERENCES publications(id) ON DELETE SET NULL,
+  ADD UNIQUE INDEX publications_repost (repost_of_id, author_id);
@@ -0,0 +1,5 @@
+ALTER TABLE publications
+  DROP INDEX publications_drafts,
+  DROP INDEX publications_due,
+  DROP COLUMN publish_at,
+  DROP COLUMN status;
@@ -0,0 +1,5 @@
+ALTER TABLE publications
+  ADD COLUMN status varchar(20) not null default 'published' AFTER visibility,
+  ADD COLUMN publish_at timestamp null AFTER status,
+  ADD INDEX publications_due (status, publish_at),
+  ADD INDEX publications_drafts (author_id, status, createdAt);
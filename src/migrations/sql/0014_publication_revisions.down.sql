@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS publication_revisions;
+
+ALTER TABLE publications
+  DROP COLUMN edited_at;
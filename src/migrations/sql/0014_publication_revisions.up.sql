@@ -0,0 +1,17 @@
+ALTER TABLE publications
+  ADD COLUMN edited_at timestamp null AFTER createdAt;
+
+CREATE TABLE publication_revisions(
+  id int auto_increment primary key,
+
+  publication_id int not null,
+  FOREIGN KEY (publication_id)
+  REFERENCES publications(id)
+  ON DELETE CASCADE,
+
+  title varchar(50) not null,
+  content varchar(300) not null,
+  createdAt timestamp not null,
+
+  INDEX publication_revisions_publication (publication_id, createdAt)
+) ENGINE=INNODB;
@@ -0,0 +1,15 @@
+CREATE TABLE suggestion_dismissals(
+  user_id int not null,
+  FOREIGN KEY (user_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  dismissed_id int not null,
+  FOREIGN KEY (dismissed_id)
+  REFERENCES users(id)
+  ON DELETE CASCADE,
+
+  createdAt timestamp default current_timestamp,
+
+  primary key(user_id, dismissed_id)
+) ENGINE=INNODB;
@@ -0,0 +1,4 @@
+CREATE TABLE revoked_families (
+  family_id char(32) primary key,
+  expires_at timestamp not null
+) ENGINE=INNODB;
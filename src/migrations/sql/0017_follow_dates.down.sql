@@ -0,0 +1,4 @@
+ALTER TABLE followers
+  DROP INDEX followers_follower_created,
+  DROP INDEX followers_user_created,
+  DROP COLUMN createdAt;
@@ -0,0 +1,4 @@
+ALTER TABLE followers
+  ADD createdAt timestamp default current_timestamp,
+  ADD INDEX followers_user_created (user_id, createdAt),
+  ADD INDEX followers_follower_created (follower_id, createdAt);
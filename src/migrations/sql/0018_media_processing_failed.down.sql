@@ -0,0 +1,2 @@
+ALTER TABLE media
+  DROP COLUMN processing_failed;
@@ -0,0 +1,2 @@
+ALTER TABLE media
+  ADD COLUMN processing_failed boolean not null default false;
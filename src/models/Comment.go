@@ -0,0 +1,55 @@
+package models
+
+import (
+	"api/src/pagination"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const commentMaxLength = 300
+
+// Comment represent a comment made in one publication
+type Comment struct {
+	ID              uint64    `json:"id,omitempty"`
+	Content         string    `json:"content,omitempty"`
+	PublicationId   uint64    `json:"publicationId,omitempty"`
+	AuthorId        uint64    `json:"authorId,omitempty"`
+	AuthorNick      string    `json:"authorNick,omitempty"`
+	ParentCommentId uint64    `json:"parentCommentId,omitempty"`
+	Replies         []Comment `json:"replies,omitempty"`
+	CreatedAt       time.Time `json:"createdAt,omitempty"`
+}
+
+// Cursor return the pagination cursor pointing to comment
+func (comment Comment) Cursor() pagination.Cursor {
+	return pagination.Cursor{CreatedAt: comment.CreatedAt, ID: comment.ID}
+}
+
+// Prepare validate and format comment
+func (comment *Comment) Prepare() error {
+	comment.format()
+
+	if err := comment.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (comment *Comment) validate() error {
+	if comment.Content == "" {
+		return errors.New("Content cannot be blank")
+	}
+
+	if utf8.RuneCountInString(comment.Content) > commentMaxLength {
+		return errors.New("Content cannot be longer than 300 characters")
+	}
+
+	return nil
+}
+
+func (comment *Comment) format() {
+	comment.Content = strings.TrimSpace(comment.Content)
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"api/src/pagination"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxConversationMembers is the size limit of group conversations, including the creator
+	MaxConversationMembers = 10
+
+	messageMaxLength = 1000
+)
+
+// Conversation represent a direct conversation between two users or a small group
+type Conversation struct {
+	ID        uint64               `json:"id,omitempty"`
+	MemberIds []uint64             `json:"memberIds,omitempty"`
+	Members   []ConversationMember `json:"members,omitempty"`
+	CreatedAt time.Time            `json:"createdAt,omitempty"`
+	UpdatedAt time.Time            `json:"updatedAt,omitempty"`
+}
+
+// ConversationMember represent one user in conversation and the last message the user read
+type ConversationMember struct {
+	UserId            uint64 `json:"userId"`
+	Nick              string `json:"nick,omitempty"`
+	LastReadMessageId uint64 `json:"lastReadMessageId"`
+}
+
+// Message represent one message sent in a conversation
+type Message struct {
+	ID             uint64    `json:"id,omitempty"`
+	ConversationId uint64    `json:"conversationId,omitempty"`
+	SenderId       uint64    `json:"senderId,omitempty"`
+	SenderNick     string    `json:"senderNick,omitempty"`
+	Content        string    `json:"content,omitempty"`
+	CreatedAt      time.Time `json:"createdAt,omitempty"`
+}
+
+// Cursor return the pagination cursor pointing to conversation, ordered by last activity
+func (conversation Conversation) Cursor() pagination.Cursor {
+	return pagination.Cursor{CreatedAt: conversation.UpdatedAt, ID: conversation.ID}
+}
+
+// HasMember return if user belongs to conversation
+func (conversation Conversation) HasMember(userId uint64) bool {
+	for _, member := range conversation.Members {
+		if member.UserId == userId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Prepare validate the members received to create conversation, adding the creator to them
+func (conversation *Conversation) Prepare(creatorId uint64) error {
+	members := []uint64{creatorId}
+	seen := map[uint64]bool{creatorId: true}
+
+	for _, memberId := range conversation.MemberIds {
+		if memberId == 0 {
+			return errors.New("Member id cannot be zero")
+		}
+
+		if !seen[memberId] {
+			seen[memberId] = true
+			members = append(members, memberId)
+		}
+	}
+
+	if len(members) < 2 {
+		return errors.New("Conversation must have at least one member besides the creator")
+	}
+
+	if len(members) > MaxConversationMembers {
+		return fmt.Errorf("Conversation cannot have more than %d members", MaxConversationMembers)
+	}
+
+	conversation.MemberIds = members
+	return nil
+}
+
+// Cursor return the pagination cursor pointing to message
+func (message Message) Cursor() pagination.Cursor {
+	return pagination.Cursor{CreatedAt: message.CreatedAt, ID: message.ID}
+}
+
+// Prepare validate and format message
+func (message *Message) Prepare() error {
+	message.Content = strings.TrimSpace(message.Content)
+
+	if message.Content == "" {
+		return errors.New("Content cannot be blank")
+	}
+
+	if utf8.RuneCountInString(message.Content) > messageMaxLength {
+		return fmt.Errorf("Content cannot be longer than %d characters", messageMaxLength)
+	}
+
+	return nil
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"api/src/pagination"
+	"time"
+)
+
+// FollowRequest represent a pending request to follow a private account
+type FollowRequest struct {
+	UserId        uint64    `json:"userId"`
+	RequesterId   uint64    `json:"requesterId"`
+	RequesterName string    `json:"requesterName,omitempty"`
+	RequesterNick string    `json:"requesterNick,omitempty"`
+	CreatedAt     time.Time `json:"createdAt,omitempty"`
+}
+
+// Cursor return the pagination cursor pointing to follow request
+func (request FollowRequest) Cursor() pagination.Cursor {
+	return pagination.Cursor{CreatedAt: request.CreatedAt, ID: request.RequesterId}
+}
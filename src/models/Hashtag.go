@@ -0,0 +1,81 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// HashtagMaxLength is the size of the tag column, longer hashtags are ignored
+const HashtagMaxLength = 100
+
+// HashtagUse count how many publications used one hashtag during one hour
+type HashtagUse struct {
+	Tag   string
+	Hour  time.Time
+	Count uint64
+}
+
+// TrendingHashtag is one hashtag ranked by the trending worker
+type TrendingHashtag struct {
+	Tag   string  `json:"tag"`
+	Uses  uint64  `json:"uses"`
+	Score float64 `json:"score"`
+}
+
+// Trending is the ranking of hashtags with the moment it was computed
+type Trending struct {
+	Hashtags  []TrendingHashtag `json:"hashtags"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
+
+// ExtractHashtags return the distinct hashtags from text in lower case and in the order they appear,
+// a hashtag starts after a character that is not part of a word and must have at least one letter
+func ExtractHashtags(text string) []string {
+	var tags []string
+	seen := map[string]bool{}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] != '#' {
+			continue
+		}
+
+		if i > 0 {
+			previous, _ := utf8.DecodeLastRuneInString(text[:i])
+			if isHashtagRune(previous) {
+				continue
+			}
+		}
+
+		end := i + 1
+		hasLetter := false
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !isHashtagRune(r) {
+				break
+			}
+			hasLetter = hasLetter || unicode.IsLetter(r)
+			end += size
+		}
+
+		tag := NormalizeHashtag(text[i+1 : end])
+		if hasLetter && utf8.RuneCountInString(tag) <= HashtagMaxLength && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+
+		i = end - 1
+	}
+
+	return tags
+}
+
+// NormalizeHashtag return tag in the form it is stored, without the leading # and in lower case
+func NormalizeHashtag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+func isHashtagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
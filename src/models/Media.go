@@ -0,0 +1,66 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxPublicationMedia is how many media one publication can reference
+const MaxPublicationMedia = 4
+
+// Media represent one uploaded image or video, attached to at most one publication.
+// Images receive their dimensions, blurhash and variants after upload, when processed,
+// or are marked as failed when they cannot be decoded and are served only as uploaded
+type Media struct {
+	ID            uint64         `json:"id,omitempty"`
+	OwnerId       uint64         `json:"ownerId,omitempty"`
+	PublicationId uint64         `json:"publicationId,omitempty"`
+	ContentType   string         `json:"contentType,omitempty"`
+	Size          int64          `json:"size"`
+	Width         int            `json:"width,omitempty"`
+	Height        int            `json:"height,omitempty"`
+	Blurhash      string         `json:"blurhash,omitempty"`
+	Variants      []MediaVariant `json:"variants,omitempty"`
+	Failed        bool           `json:"processingFailed,omitempty"`
+	StorageKey    string         `json:"-"`
+	Position      int            `json:"-"`
+	URL           string         `json:"url,omitempty"`
+	CreatedAt     time.Time      `json:"createdAt,omitempty"`
+}
+
+// MediaVariant is one downscaled copy of an image that fits in a square of Size pixels
+type MediaVariant struct {
+	Size        int    `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ContentType string `json:"contentType"`
+	StorageKey  string `json:"-"`
+	URL         string `json:"url,omitempty"`
+}
+
+// WithURL return media with the URLs the API serves its content and variants from
+func (media Media) WithURL() Media {
+	media.URL = fmt.Sprintf("/media/%d/content", media.ID)
+
+	if len(media.Variants) > 0 {
+		variants := make([]MediaVariant, len(media.Variants))
+		for i, variant := range media.Variants {
+			variant.URL = fmt.Sprintf("%s?size=%d", media.URL, variant.Size)
+			variants[i] = variant
+		}
+		media.Variants = variants
+	}
+
+	return media
+}
+
+// Variant return the variant with size
+func (media Media) Variant(size int) (MediaVariant, bool) {
+	for _, variant := range media.Variants {
+		if variant.Size == size {
+			return variant, true
+		}
+	}
+
+	return MediaVariant{}, false
+}
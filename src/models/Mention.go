@@ -0,0 +1,59 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Mention is one @nick reference inside publication content resolved to a user,
+// offset and length count characters from content and include the @
+type Mention struct {
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	UserId uint64 `json:"userId"`
+	Nick   string `json:"-"`
+}
+
+// ExtractMentions return the @nick references from text in the order they appear, not resolved yet,
+// a mention starts after a character that is not part of a nick and a trailing dot ends the sentence
+func ExtractMentions(text string) []Mention {
+	var mentions []Mention
+	var previous rune
+	offset := 0
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r != '@' || (offset > 0 && isNickRune(previous)) {
+			previous = r
+			offset++
+			i += size
+			continue
+		}
+
+		end := i + size
+		for end < len(text) {
+			next, nextSize := utf8.DecodeRuneInString(text[end:])
+			if !isNickRune(next) {
+				break
+			}
+			end += nextSize
+		}
+
+		nick := strings.TrimRight(text[i+size:end], ".")
+		length := utf8.RuneCountInString(nick) + 1
+		if nick != "" {
+			mentions = append(mentions, Mention{Offset: offset, Length: length, Nick: nick})
+		}
+
+		previous, _ = utf8.DecodeLastRuneInString("@" + nick)
+		offset += length
+		i += size + len(nick)
+	}
+
+	return mentions
+}
+
+func isNickRune(r rune) bool {
+	return r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		text     string
+		mentions []Mention
+	}{
+		{"no mentions", nil},
+		{"hi @bob, @carol.", []Mention{{Offset: 3, Length: 4, Nick: "bob"}, {Offset: 9, Length: 6, Nick: "carol"}}},
+		{"@bob.smith at start", []Mention{{Offset: 0, Length: 10, Nick: "bob.smith"}}},
+		{"mail alice@devbook.com", nil},
+		{"olá @joão", []Mention{{Offset: 4, Length: 5, Nick: "joão"}}},
+		{"alone @ and @@bob", []Mention{{Offset: 13, Length: 4, Nick: "bob"}}},
+		{"@bob @bob", []Mention{{Offset: 0, Length: 4, Nick: "bob"}, {Offset: 5, Length: 4, Nick: "bob"}}},
+	}
+
+	for _, test := range tests {
+		if mentions := ExtractMentions(test.text); !reflect.DeepEqual(mentions, test.mentions) {
+			t.Errorf("%q: expected %+v, got %+v", test.text, test.mentions, mentions)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"api/src/pagination"
+	"time"
+)
+
+const (
+	// NotificationFollow is sent when someone follows the user
+	NotificationFollow = "follow"
+	// NotificationFollowRequest is sent when someone asks to follow a private account
+	NotificationFollowRequest = "follow_request"
+	// NotificationFollowApproved is sent when a private account approves the follow request
+	NotificationFollowApproved = "follow_approved"
+	// NotificationPublication is sent to followers when a followed user publishes
+	NotificationPublication = "publication"
+	// NotificationLike is sent when someone likes a publication from the user
+	NotificationLike = "like"
+	// NotificationRepost is sent when someone reposts a publication from the user
+	NotificationRepost = "repost"
+	// NotificationComment is sent when someone comments in a publication from the user
+	NotificationComment = "comment"
+	// NotificationMention is sent when someone mentions the user in a publication
+	NotificationMention = "mention"
+	// NotificationReply is sent when someone replies a comment from the user
+	NotificationReply = "reply"
+)
+
+// Notification represent one item from the user inbox
+type Notification struct {
+	ID            uint64    `json:"id"`
+	UserId        uint64    `json:"userId"`
+	ActorId       uint64    `json:"actorId"`
+	ActorNick     string    `json:"actorNick,omitempty"`
+	Type          string    `json:"type"`
+	PublicationId uint64    `json:"publicationId,omitempty"`
+	CommentId     uint64    `json:"commentId,omitempty"`
+	Read          bool      `json:"read"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// Cursor return the pagination cursor pointing to notification
+func (notification Notification) Cursor() pagination.Cursor {
+	return pagination.Cursor{CreatedAt: notification.CreatedAt, ID: notification.ID}
+}
+
+// NotificationPage is one page from the inbox with the number of unread notifications
+type NotificationPage struct {
+	pagination.Page
+	Unread uint64 `json:"unread"`
+}
+
+// NotificationsRead list the notifications to mark as read, all of them when IDs is empty
+type NotificationsRead struct {
+	IDs []uint64 `json:"ids"`
+}
@@ -1,19 +1,65 @@
 package models
 
 import (
+	"api/src/pagination"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 )
 
+const (
+	// VisibilityPublic publications are visible to every user
+	VisibilityPublic = "public"
+	// VisibilityFollowers publications are visible to author and followers
+	VisibilityFollowers = "followers"
+	// VisibilityPrivate publications are visible only to author
+	VisibilityPrivate = "private"
+
+	// StatusDraft publications are visible only to author until published
+	StatusDraft = "draft"
+	// StatusScheduled publications are published by the scheduler at PublishAt
+	StatusScheduled = "scheduled"
+	// StatusPublished publications are visible according to their visibility
+	StatusPublished = "published"
+)
+
+// Publication is a post from author. Reposts share the publication RepostOfId without content of their own
+// and quotes comment the publication QuoteOfId, both receive it in Original when the viewer can see it.
+// EditedAt is set once the title or content of a published publication changes
 type Publication struct {
-	ID         uint64    `json:"id,omitempty"`
-	Title      string    `json:"title,omitempty"`
-	Content    string    `json:"content,omitempty"`
-	AuthorId   uint64    `json:"authorId,omitempty"`
-	AuthorNick string    `json:"authorNick,omitempty"`
-	Likes      uint64    `json:"likes"`
-	CreatedAt  time.Time `json:"createdAt,omitempty"`
+	ID         uint64       `json:"id,omitempty"`
+	Title      string       `json:"title,omitempty"`
+	Content    string       `json:"content,omitempty"`
+	Visibility string       `json:"visibility,omitempty"`
+	Status     string       `json:"status,omitempty"`
+	PublishAt  *time.Time   `json:"publishAt,omitempty"`
+	AuthorId   uint64       `json:"authorId,omitempty"`
+	AuthorNick string       `json:"authorNick,omitempty"`
+	Likes      uint64       `json:"likes"`
+	Reposts    uint64       `json:"reposts"`
+	RepostOfId uint64       `json:"repostOfId,omitempty"`
+	QuoteOfId  uint64       `json:"quoteOfId,omitempty"`
+	Original   *Publication `json:"original,omitempty"`
+	Hashtags   []string     `json:"hashtags,omitempty"`
+	Mentions   []Mention    `json:"mentions,omitempty"`
+	MediaIds   []uint64     `json:"mediaIds,omitempty"`
+	Media      []Media      `json:"media,omitempty"`
+	CreatedAt  time.Time    `json:"createdAt,omitempty"`
+	EditedAt   *time.Time   `json:"editedAt,omitempty"`
+}
+
+// OriginalId return the publication reposted or quoted by publication, zero when it is neither
+func (publication Publication) OriginalId() uint64 {
+	if publication.RepostOfId != 0 {
+		return publication.RepostOfId
+	}
+	return publication.QuoteOfId
+}
+
+// Cursor return the pagination cursor pointing to publication
+func (publication Publication) Cursor() pagination.Cursor {
+	return pagination.Cursor{CreatedAt: publication.CreatedAt, ID: publication.ID}
 }
 
 // Prepare validate and format publication
@@ -35,10 +81,64 @@ func (publication *Publication) validate() error {
 		return errors.New("Content cannot be blank")
 	}
 
+	if len(publication.MediaIds) > MaxPublicationMedia {
+		return fmt.Errorf("Publication can have at most %d media", MaxPublicationMedia)
+	}
+
+	switch publication.Visibility {
+	case "", VisibilityPublic, VisibilityFollowers, VisibilityPrivate:
+	default:
+		return errors.New("Visibility must be public, followers or private")
+	}
+
+	switch publication.Status {
+	case "", StatusDraft, StatusPublished:
+	case StatusScheduled:
+		if publication.PublishAt == nil || !publication.PublishAt.After(time.Now()) {
+			return errors.New("Scheduled publications need a publishAt in the future")
+		}
+	default:
+		return errors.New("Status must be draft, scheduled or published")
+	}
+
 	return nil
 }
 
 func (publication *Publication) format() {
 	publication.Title = strings.TrimSpace(publication.Title)
 	publication.Content = strings.TrimSpace(publication.Content)
+
+	if publication.Visibility == "" {
+		publication.Visibility = VisibilityPublic
+	}
+
+	if publication.Status == "" {
+		publication.Status = StatusPublished
+	}
+
+	if publication.Status != StatusScheduled {
+		publication.PublishAt = nil
+	}
+
+	publication.MediaIds = distinctIds(publication.MediaIds)
+	publication.Hashtags = ExtractHashtags(publication.Content)
+	publication.Mentions = ExtractMentions(publication.Content)
+}
+
+// distinctIds remove repeated ids keeping the first position of each one, nil stays nil
+func distinctIds(ids []uint64) []uint64 {
+	if ids == nil {
+		return nil
+	}
+
+	distinct := []uint64{}
+	seen := map[uint64]bool{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			distinct = append(distinct, id)
+		}
+	}
+
+	return distinct
 }
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"time"
+	"unicode"
+)
+
+const (
+	// DiffEqual segments are in both versions
+	DiffEqual = "equal"
+	// DiffInsert segments were added by the newer version
+	DiffInsert = "insert"
+	// DiffDelete segments were removed by the newer version
+	DiffDelete = "delete"
+)
+
+// PublicationRevision is one version of the title and content of a publication. Stored revisions are the
+// versions replaced by edits, CreatedAt is when the version was written and the diffs compare it to the previous one
+type PublicationRevision struct {
+	ID            uint64        `json:"id,omitempty"`
+	PublicationId uint64        `json:"publicationId"`
+	Title         string        `json:"title"`
+	Content       string        `json:"content"`
+	Current       bool          `json:"current"`
+	TitleDiff     []DiffSegment `json:"titleDiff,omitempty"`
+	ContentDiff   []DiffSegment `json:"contentDiff,omitempty"`
+	CreatedAt     time.Time     `json:"createdAt"`
+}
+
+// DiffSegment is a run of text that was kept, inserted or deleted between two versions
+type DiffSegment struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// CompareRevisions fill the diffs of each revision against the one before it, revisions must be oldest first
+func CompareRevisions(revisions []PublicationRevision) {
+	for i := 1; i < len(revisions); i++ {
+		revisions[i].TitleDiff = DiffWords(revisions[i-1].Title, revisions[i].Title)
+		revisions[i].ContentDiff = DiffWords(revisions[i-1].Content, revisions[i].Content)
+	}
+}
+
+// DiffWords compare two texts word by word, joining the equal and deleted segments gives previous back
+// and joining the equal and inserted ones gives current. Whitespace is kept as segments of its own
+func DiffWords(previous, current string) []DiffSegment {
+	before, after := splitWords(previous), splitWords(current)
+
+	// common[i][j] is the length of the longest common subsequence of before[i:] and after[j:]
+	common := make([][]int, len(before)+1)
+	for i := range common {
+		common[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	var segments []DiffSegment
+	add := func(kind, text string) {
+		if last := len(segments) - 1; last >= 0 && segments[last].Type == kind {
+			segments[last].Text += text
+			return
+		}
+		segments = append(segments, DiffSegment{Type: kind, Text: text})
+	}
+
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			add(DiffEqual, before[i])
+			i++
+			j++
+		case j == len(after) || (i < len(before) && common[i+1][j] >= common[i][j+1]):
+			add(DiffDelete, before[i])
+			i++
+		default:
+			add(DiffInsert, after[j])
+			j++
+		}
+	}
+
+	return segments
+}
+
+// splitWords break text in alternating runs of whitespace and of everything else
+func splitWords(text string) []string {
+	var words []string
+	start := 0
+	var wasSpace bool
+
+	for i, r := range text {
+		isSpace := unicode.IsSpace(r)
+		if i > start && isSpace != wasSpace {
+			words = append(words, text[start:i])
+			start = i
+		}
+		wasSpace = isSpace
+	}
+
+	if start < len(text) {
+		words = append(words, text[start:])
+	}
+
+	return words
+}
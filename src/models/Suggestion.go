@@ -0,0 +1,19 @@
+package models
+
+// FollowingWeight is how much a followed user that also follows the suggestion counts over a shared follower,
+// following someone is a stronger sign of the same interests than being followed by the same people
+const FollowingWeight = 2
+
+// Suggestion represent a user that the logged user may want to follow, with the connections between them
+type Suggestion struct {
+	User User `json:"user"`
+	// FollowedByFollowing count the users followed by the logged user that follow the suggested one
+	FollowedByFollowing uint64 `json:"followedByFollowing"`
+	// SharedFollowers count the followers of the logged user that also follow the suggested one
+	SharedFollowers uint64 `json:"sharedFollowers"`
+}
+
+// Score rank the suggestion by its connections to the logged user
+func (suggestion Suggestion) Score() uint64 {
+	return FollowingWeight*suggestion.FollowedByFollowing + suggestion.SharedFollowers
+}
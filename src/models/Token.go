@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// RefreshToken represent a refresh token stored in database, only the token hash is persisted
+type RefreshToken struct {
+	ID        uint64
+	UserId    uint64
+	FamilyId  string
+	TokenHash string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+}
+
+// AuthenticationTokens represent tokens returned to user after login or refresh
+type AuthenticationTokens struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	TokenType    string `json:"tokenType"`
+	ExpiresIn    int64  `json:"expiresIn"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"api/src/pagination"
 	"api/src/secure"
 	"errors"
 	"strings"
@@ -16,7 +17,20 @@ type User struct {
 	Nick      string    `json:"nick,omitempty"`
 	Email     string    `json:"email,omitempty"`
 	Password  string    `json:"password,omitempty"`
+	IsPrivate bool      `json:"isPrivate"`
 	CreatedAt time.Time `json:"createdAt,omitempty"`
+	// RelatedAt is when the relation that listed the user began, like the like or the follow
+	RelatedAt time.Time `json:"-"`
+}
+
+// Cursor return the pagination cursor pointing to user
+func (user User) Cursor() pagination.Cursor {
+	return pagination.Cursor{CreatedAt: user.CreatedAt, ID: user.ID}
+}
+
+// RelationCursor return the pagination cursor pointing to user in lists ordered by when the relation began
+func (user User) RelationCursor() pagination.Cursor {
+	return pagination.Cursor{CreatedAt: user.RelatedAt, ID: user.ID}
 }
 
 //Prepare execute methods validate and format in received user
@@ -0,0 +1,151 @@
+// Package notifications turn domain events into notifications stored in the inbox of affected users
+package notifications
+
+import (
+	"api/src/events"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/stream"
+	"log"
+)
+
+// Pusher deliver notifications to users connected right now, like the stream hub
+type Pusher interface {
+	Push(userId uint64, event string, data interface{}) error
+}
+
+// Service consume domain events and persist the notifications they produce
+type Service struct {
+	pusher        Pusher
+	notifications repositories.NotificationRepository
+	users         repositories.UserRepository
+	publications  repositories.PublicationRepository
+	comments      repositories.CommentRepository
+}
+
+// NewService create a notification service using the received repositories,
+// created notifications are also sent to pusher when it is not nil
+func NewService(repos repositories.Repositories, pusher Pusher) *Service {
+	return &Service{
+		pusher:        pusher,
+		notifications: repos.Notifications,
+		users:         repos.Users,
+		publications:  repos.Publications,
+		comments:      repos.Comments,
+	}
+}
+
+// Handle create the notifications for one event, errors are logged because producers do not wait for them
+func (service *Service) Handle(event events.Event) {
+	if err := service.handle(event); err != nil {
+		log.Printf("notifications: could not handle %s event: %v", event.Type, err)
+	}
+}
+
+func (service *Service) handle(event events.Event) error {
+	switch event.Type {
+	case events.UserFollowed:
+		return service.notify(event.UserId, event, models.NotificationFollow)
+	case events.FollowRequested:
+		return service.notify(event.UserId, event, models.NotificationFollowRequest)
+	case events.FollowRequestApproved:
+		return service.notify(event.UserId, event, models.NotificationFollowApproved)
+	case events.PublicationLiked:
+		return service.notify(event.UserId, event, models.NotificationLike)
+	case events.PublicationReposted:
+		return service.notify(event.UserId, event, models.NotificationRepost)
+	case events.CommentCreated:
+		return service.notifyComment(event)
+	case events.PublicationCreated:
+		return service.notifyFollowers(event)
+	case events.UserMentioned:
+		return service.notifyMention(event)
+	}
+
+	return nil
+}
+
+// notify create one notification to user, unless user is the actor or one of them blocked the other
+func (service *Service) notify(userId uint64, event events.Event, notificationType string) error {
+	if userId == 0 || userId == event.ActorId {
+		return nil
+	}
+
+	blocked, err := service.users.IsBlocked(userId, event.ActorId)
+	if err != nil || blocked {
+		return err
+	}
+
+	notification := models.Notification{
+		UserId:        userId,
+		ActorId:       event.ActorId,
+		Type:          notificationType,
+		PublicationId: event.PublicationId,
+		CommentId:     event.CommentId,
+		CreatedAt:     event.OccurredAt,
+	}
+
+	if notification.ID, err = service.notifications.Create(notification); err != nil {
+		return err
+	}
+
+	return service.push(notification)
+}
+
+// push send the created notification to the user connections with the actor nick filled
+func (service *Service) push(notification models.Notification) error {
+	if service.pusher == nil {
+		return nil
+	}
+
+	actor, err := service.users.FindByID(notification.ActorId)
+	if err != nil {
+		return err
+	}
+	notification.ActorNick = actor.Nick
+
+	return service.pusher.Push(notification.UserId, stream.EventNotification, notification)
+}
+
+// notifyComment notify publication author and, for replies, the author of the answered comment
+func (service *Service) notifyComment(event events.Event) error {
+	if err := service.notify(event.UserId, event, models.NotificationComment); err != nil {
+		return err
+	}
+
+	comment, err := service.comments.FindById(event.CommentId)
+	if err != nil || comment.ParentCommentId == 0 {
+		return err
+	}
+
+	parent, err := service.comments.FindById(comment.ParentCommentId)
+	if err != nil || parent.AuthorId == event.UserId {
+		return err
+	}
+
+	return service.notify(parent.AuthorId, event, models.NotificationReply)
+}
+
+// notifyFollowers notify followers from author about a new publication they are allowed to see
+func (service *Service) notifyFollowers(event events.Event) error {
+	publication, err := service.publications.FindById(event.PublicationId, event.ActorId)
+	if err != nil || publication.ID == 0 || publication.Visibility == models.VisibilityPrivate {
+		return err
+	}
+
+	return service.notifications.CreateForFollowers(models.Notification{
+		ActorId:       event.ActorId,
+		Type:          models.NotificationPublication,
+		PublicationId: event.PublicationId,
+	})
+}
+
+// notifyMention notify the mentioned user when the publication is visible to the user
+func (service *Service) notifyMention(event events.Event) error {
+	publication, err := service.publications.FindById(event.PublicationId, event.UserId)
+	if err != nil || publication.ID == 0 {
+		return err
+	}
+
+	return service.notify(event.UserId, event, models.NotificationMention)
+}
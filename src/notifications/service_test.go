@@ -0,0 +1,181 @@
+package notifications_test
+
+import (
+	"api/src/events"
+	"api/src/models"
+	"api/src/notifications"
+	"api/src/pagination"
+	"api/src/repositories"
+	"api/src/repositories/memory"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// pusher record the notifications pushed to connected users
+type pusher struct {
+	mu     sync.Mutex
+	pushed map[uint64][]string
+}
+
+func (pusher *pusher) Push(userId uint64, event string, data interface{}) error {
+	pusher.mu.Lock()
+	defer pusher.mu.Unlock()
+
+	notification := data.(models.Notification)
+	pusher.pushed[userId] = append(pusher.pushed[userId], notification.Type+" from "+notification.ActorNick)
+	return nil
+}
+
+// inbox return the notifications of user as "type from actor", newest first
+func inbox(t *testing.T, repos repositories.Repositories, userId uint64) []string {
+	t.Helper()
+
+	found, err := repos.Notifications.Find(userId, pagination.Params{Limit: pagination.MaxLimit})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := []string{}
+	for _, notification := range found {
+		received = append(received, notification.Type+" from "+notification.ActorNick)
+	}
+	return received
+}
+
+func TestServiceNotifiesAffectedUsers(t *testing.T) {
+	tests := []struct {
+		name   string
+		event  func(ids map[string]uint64) events.Event
+		inbox  map[string][]string
+		pushed bool
+	}{
+		{
+			name: "follow",
+			event: func(ids map[string]uint64) events.Event {
+				return events.Event{Type: events.UserFollowed, ActorId: ids["bob"], UserId: ids["alice"]}
+			},
+			inbox:  map[string][]string{"alice": {"follow from bob"}},
+			pushed: true,
+		},
+		{
+			name: "own like",
+			event: func(ids map[string]uint64) events.Event {
+				return events.Event{Type: events.PublicationLiked, ActorId: ids["alice"], UserId: ids["alice"], PublicationId: ids["post"]}
+			},
+			inbox: map[string][]string{"alice": {}},
+		},
+		{
+			name: "like from blocked user",
+			event: func(ids map[string]uint64) events.Event {
+				return events.Event{Type: events.PublicationLiked, ActorId: ids["mallory"], UserId: ids["alice"], PublicationId: ids["post"]}
+			},
+			inbox: map[string][]string{"alice": {}},
+		},
+		{
+			name: "reply",
+			event: func(ids map[string]uint64) events.Event {
+				return events.Event{Type: events.CommentCreated, ActorId: ids["carol"], UserId: ids["alice"], PublicationId: ids["post"], CommentId: ids["reply"]}
+			},
+			inbox:  map[string][]string{"alice": {"comment from carol"}, "bob": {"reply from carol"}},
+			pushed: true,
+		},
+		{
+			name: "mention",
+			event: func(ids map[string]uint64) events.Event {
+				return events.Event{Type: events.UserMentioned, ActorId: ids["alice"], UserId: ids["carol"], PublicationId: ids["post"]}
+			},
+			inbox:  map[string][]string{"carol": {"mention from alice"}},
+			pushed: true,
+		},
+		{
+			name: "mention in private publication",
+			event: func(ids map[string]uint64) events.Event {
+				return events.Event{Type: events.UserMentioned, ActorId: ids["alice"], UserId: ids["carol"], PublicationId: ids["diary"]}
+			},
+			inbox: map[string][]string{"carol": {}},
+		},
+		{
+			name: "publication",
+			event: func(ids map[string]uint64) events.Event {
+				return events.Event{Type: events.PublicationCreated, ActorId: ids["alice"], PublicationId: ids["post"]}
+			},
+			inbox: map[string][]string{"alice": {}, "bob": {"publication from alice"}, "carol": {"publication from alice"}},
+		},
+		{
+			name: "private publication",
+			event: func(ids map[string]uint64) events.Event {
+				return events.Event{Type: events.PublicationCreated, ActorId: ids["alice"], PublicationId: ids["diary"]}
+			},
+			inbox: map[string][]string{"bob": {}, "carol": {}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repos, ids := world(t)
+			pushed := &pusher{pushed: map[uint64][]string{}}
+
+			notifications.NewService(repos, pushed).Handle(test.event(ids))
+
+			for nick, expected := range test.inbox {
+				if received := inbox(t, repos, ids[nick]); !reflect.DeepEqual(received, expected) {
+					t.Errorf("expected inbox of %s %v, got %v", nick, expected, received)
+				}
+
+				if test.pushed && len(expected) > 0 && !reflect.DeepEqual(pushed.pushed[ids[nick]], expected) {
+					t.Errorf("expected %v pushed to %s, got %v", expected, nick, pushed.pushed[ids[nick]])
+				}
+			}
+		})
+	}
+}
+
+// world create alice, followed by bob and carol and blocked by mallory, with a public post where carol
+// replied to bob and a private diary
+func world(t *testing.T) (repositories.Repositories, map[string]uint64) {
+	t.Helper()
+
+	repos := memory.NewRepositories()
+	ids := map[string]uint64{}
+
+	for _, nick := range []string{"alice", "bob", "carol", "mallory"} {
+		id, err := repos.Users.Create(models.User{Name: nick, Nick: nick, Email: nick + "@devbook.com", Password: nick})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[nick] = id
+	}
+
+	steps := []error{
+		repos.Users.Follower(ids["alice"], ids["bob"]),
+		repos.Users.Follower(ids["alice"], ids["carol"]),
+		repos.Users.Block(ids["mallory"], ids["alice"]),
+	}
+	for _, err := range steps {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var err error
+	for _, publication := range []models.Publication{
+		{Title: "post", Content: "hello", Visibility: models.VisibilityPublic, Status: models.StatusPublished, AuthorId: ids["alice"]},
+		{Title: "diary", Content: "secret", Visibility: models.VisibilityPrivate, Status: models.StatusPublished, AuthorId: ids["alice"]},
+	} {
+		if ids[publication.Title], err = repos.Publications.Create(publication); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if ids["comment"], err = repos.Comments.Create(models.Comment{Content: "first", PublicationId: ids["post"], AuthorId: ids["bob"]}); err != nil {
+		t.Fatal(err)
+	}
+	if ids["reply"], err = repos.Comments.Create(models.Comment{
+		Content: "answer", PublicationId: ids["post"], AuthorId: ids["carol"], ParentCommentId: ids["comment"],
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return repos, ids
+}
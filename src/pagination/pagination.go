@@ -0,0 +1,166 @@
+// Package pagination implement opaque cursors over the (createdAt, id) ordering used by list endpoints
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultLimit is used when request does not send limit
+	DefaultLimit = 20
+	// MaxLimit is the biggest page a client can ask
+	MaxLimit = 100
+)
+
+// ErrInvalidCursor is returned when the cursor received was not created by Encode
+var ErrInvalidCursor = errors.New("Invalid cursor")
+
+// Cursor point to the last item from a page, items are ordered by createdAt and id descending
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uint64
+}
+
+// Params represent the page asked by client
+type Params struct {
+	Limit int
+	After *Cursor
+}
+
+// Page represent the envelope returned by list endpoints
+type Page struct {
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	HasMore    bool        `json:"has_more"`
+}
+
+// FromRequest read limit and cursor from query string
+func FromRequest(r *http.Request) (Params, error) {
+	limit, err := LimitFromRequest(r)
+	if err != nil {
+		return Params{}, err
+	}
+
+	params := Params{Limit: limit}
+
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		cursor, err := Decode(value)
+		if err != nil {
+			return Params{}, err
+		}
+		params.After = &cursor
+	}
+
+	return params, nil
+}
+
+// LimitFromRequest read only the limit from query string, for lists whose cursor is not a Cursor
+func LimitFromRequest(r *http.Request) (int, error) {
+	limit := DefaultLimit
+
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return 0, errors.New("Limit must be a positive number")
+		}
+		limit = parsed
+	}
+
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	return limit, nil
+}
+
+// FetchLimit return how many rows repositories must read, one more than limit to know if there is a next page
+func (params Params) FetchLimit() int {
+	return params.Limit + 1
+}
+
+// Where return the SQL condition that skip items until the cursor, using the received column names
+func (params Params) Where(createdAtColumn, idColumn string) (string, []interface{}) {
+	if params.After == nil {
+		return "1 = 1", nil
+	}
+
+	condition := fmt.Sprintf("(%s < ? OR (%s = ? AND %s < ?))", createdAtColumn, createdAtColumn, idColumn)
+	return condition, []interface{}{params.After.CreatedAt, params.After.CreatedAt, params.After.ID}
+}
+
+// Includes verify if an item comes after the cursor, used by repositories that filter in memory
+func (params Params) Includes(createdAt time.Time, id uint64) bool {
+	if params.After == nil {
+		return true
+	}
+
+	if createdAt.Equal(params.After.CreatedAt) {
+		return id < params.After.ID
+	}
+
+	return createdAt.Before(params.After.CreatedAt)
+}
+
+// Before verify if item a comes before item b in (createdAt, id) descending order
+func Before(aCreatedAt time.Time, aID uint64, bCreatedAt time.Time, bID uint64) bool {
+	if aCreatedAt.Equal(bCreatedAt) {
+		return aID > bID
+	}
+
+	return aCreatedAt.After(bCreatedAt)
+}
+
+// NewPage trim the extra item fetched by repository and build the envelope with the next cursor
+func NewPage[T any](items []T, params Params, cursorOf func(T) Cursor) Page {
+	page := Page{Data: items}
+
+	if len(items) > params.Limit {
+		items = items[:params.Limit]
+		page.Data = items
+		page.HasMore = true
+		page.NextCursor = Encode(cursorOf(items[len(items)-1]))
+	}
+
+	if items == nil {
+		page.Data = []T{}
+	}
+
+	return page
+}
+
+// Encode create the opaque representation of cursor
+func Encode(cursor Cursor) string {
+	raw := fmt.Sprintf("%d:%d", cursor.CreatedAt.UnixNano(), cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode read a cursor created by Encode
+func Decode(value string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 2 {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	nanoseconds, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{CreatedAt: time.Unix(0, nanoseconds), ID: id}, nil
+}
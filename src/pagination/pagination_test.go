@@ -0,0 +1,206 @@
+package pagination_test
+
+import (
+	"api/src/pagination"
+	"encoding/base64"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// item is a row of a list endpoint
+type item struct {
+	ID        uint64
+	CreatedAt time.Time
+}
+
+func (item item) cursor() pagination.Cursor {
+	return pagination.Cursor{CreatedAt: item.CreatedAt, ID: item.ID}
+}
+
+var noon = time.Date(2026, 1, 1, 12, 0, 0, 123456789, time.UTC)
+
+func TestEncodeAndDecode(t *testing.T) {
+	cursors := []pagination.Cursor{
+		{CreatedAt: noon, ID: 42},
+		{CreatedAt: time.Unix(0, 0), ID: 0},
+		{CreatedAt: noon.Add(-time.Nanosecond), ID: ^uint64(0)},
+	}
+
+	for _, cursor := range cursors {
+		decoded, err := pagination.Decode(pagination.Encode(cursor))
+		if err != nil {
+			t.Fatalf("decoding %+v: %v", cursor, err)
+		}
+		if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID {
+			t.Errorf("expected %+v after round trip, got %+v", cursor, decoded)
+		}
+	}
+}
+
+func TestDecodeRejectsInvalidCursors(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+	valid := pagination.Encode(pagination.Cursor{CreatedAt: noon, ID: 42})
+
+	cursors := map[string]string{
+		"not base64":           "%%%",
+		"padded base64":        base64.URLEncoding.EncodeToString([]byte("1:23")),
+		"empty":                encode(""),
+		"missing id":           encode("1700000000"),
+		"extra part":           encode("1:2:3"),
+		"time is not a number": encode("noon:42"),
+		"negative id":          encode("1700000000:-1"),
+		"id overflows":         encode("1700000000:18446744073709551616"),
+		"truncated":            valid[:len(valid)-3],
+		"tampered":             valid[:len(valid)-1] + "!",
+	}
+
+	for name, value := range cursors {
+		if cursor, err := pagination.Decode(value); err != pagination.ErrInvalidCursor {
+			t.Errorf("%s: expected invalid cursor, got %+v, %v", name, cursor, err)
+		}
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	cursor := pagination.Cursor{CreatedAt: noon, ID: 7}
+
+	tests := []struct {
+		query  string
+		limit  int
+		after  *pagination.Cursor
+		failed bool
+	}{
+		{"", pagination.DefaultLimit, nil, false},
+		{"?limit=5", 5, nil, false},
+		{"?limit=1000", pagination.MaxLimit, nil, false},
+		{"?limit=3&cursor=" + pagination.Encode(cursor), 3, &cursor, false},
+		{"?limit=0", 0, nil, true},
+		{"?limit=-1", 0, nil, true},
+		{"?limit=ten", 0, nil, true},
+		{"?cursor=invalid", 0, nil, true},
+	}
+
+	for _, test := range tests {
+		params, err := pagination.FromRequest(httptest.NewRequest("GET", "/items"+test.query, nil))
+		if (err != nil) != test.failed {
+			t.Errorf("%q: expected failure %v, got %v", test.query, test.failed, err)
+			continue
+		}
+		if test.failed {
+			continue
+		}
+
+		if params.Limit != test.limit {
+			t.Errorf("%q: expected limit %d, got %d", test.query, test.limit, params.Limit)
+		}
+		if (params.After == nil) != (test.after == nil) ||
+			params.After != nil && (!params.After.CreatedAt.Equal(test.after.CreatedAt) || params.After.ID != test.after.ID) {
+			t.Errorf("%q: expected cursor %+v, got %+v", test.query, test.after, params.After)
+		}
+	}
+}
+
+func TestWhere(t *testing.T) {
+	condition, args := pagination.Params{Limit: 10}.Where("p.createdAt", "p.id")
+	if condition != "1 = 1" || len(args) != 0 {
+		t.Errorf("expected no condition without cursor, got %q %v", condition, args)
+	}
+
+	after := pagination.Cursor{CreatedAt: noon, ID: 7}
+	condition, args = pagination.Params{Limit: 10, After: &after}.Where("p.createdAt", "p.id")
+
+	if expected := "(p.createdAt < ? OR (p.createdAt = ? AND p.id < ?))"; condition != expected {
+		t.Errorf("expected %q, got %q", expected, condition)
+	}
+	if expected := []interface{}{noon, noon, uint64(7)}; !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected args %v, got %v", expected, args)
+	}
+}
+
+func TestIncludesBreaksTiesById(t *testing.T) {
+	after := pagination.Cursor{CreatedAt: noon, ID: 7}
+	params := pagination.Params{Limit: 10, After: &after}
+
+	items := []struct {
+		name     string
+		item     item
+		included bool
+	}{
+		{"older", item{ID: 99, CreatedAt: noon.Add(-time.Nanosecond)}, true},
+		{"same time and lower id", item{ID: 6, CreatedAt: noon}, true},
+		{"the cursor itself", item{ID: 7, CreatedAt: noon}, false},
+		{"same time and higher id", item{ID: 8, CreatedAt: noon}, false},
+		{"newer", item{ID: 1, CreatedAt: noon.Add(time.Nanosecond)}, false},
+	}
+
+	for _, test := range items {
+		if included := params.Includes(test.item.CreatedAt, test.item.ID); included != test.included {
+			t.Errorf("%s: expected included %v, got %v", test.name, test.included, included)
+		}
+
+		before := pagination.Before(after.CreatedAt, after.ID, test.item.CreatedAt, test.item.ID)
+		if before != test.included {
+			t.Errorf("%s: expected cursor before item %v, got %v", test.name, test.included, before)
+		}
+	}
+
+	if (pagination.Params{Limit: 10}).Includes(noon, 7) != true {
+		t.Error("expected every item included without cursor")
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	rows := func(count int) []item {
+		var items []item
+		for id := count; id > 0; id-- {
+			items = append(items, item{ID: uint64(id), CreatedAt: noon})
+		}
+		return items
+	}
+
+	tests := []struct {
+		name    string
+		fetched []item
+		ids     []uint64
+		hasMore bool
+	}{
+		{"nothing", nil, []uint64{}, false},
+		{"fewer than limit", rows(2), []uint64{2, 1}, false},
+		{"exactly limit", rows(3), []uint64{3, 2, 1}, false},
+		{"one more than limit", rows(4), []uint64{4, 3, 2}, true},
+	}
+
+	for _, test := range tests {
+		params := pagination.Params{Limit: 3}
+		page := pagination.NewPage(test.fetched, params, item.cursor)
+
+		ids := []uint64{}
+		for _, item := range page.Data.([]item) {
+			ids = append(ids, item.ID)
+		}
+
+		if !reflect.DeepEqual(ids, test.ids) || page.HasMore != test.hasMore {
+			t.Errorf("%s: expected %v with more %v, got %v with more %v", test.name, test.ids, test.hasMore, ids, page.HasMore)
+		}
+
+		if !test.hasMore {
+			if page.NextCursor != "" {
+				t.Errorf("%s: expected no next cursor, got %q", test.name, page.NextCursor)
+			}
+			continue
+		}
+
+		// ties on createdAt continue from the id of the last item returned
+		next, err := pagination.Decode(page.NextCursor)
+		if err != nil || next.ID != 2 || !next.CreatedAt.Equal(noon) {
+			t.Errorf("%s: expected next cursor after item 2, got %+v, %v", test.name, next, err)
+		}
+
+		params.After = &next
+		if !params.Includes(noon, 1) || params.Includes(noon, 2) {
+			t.Errorf("%s: expected the next page to start at item 1", test.name)
+		}
+	}
+}
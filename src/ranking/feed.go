@@ -0,0 +1,128 @@
+package ranking
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cursor point to the next page of a ranked feed. The candidates are the window most recent publications
+// after Window in chronological order, or the most recent ones when it is nil. They are ranked as of RankedAt,
+// so every page of the window sees the same ranking, and the next page starts at Position of that ranking
+type Cursor struct {
+	Window   *pagination.Cursor
+	RankedAt time.Time
+	Position int
+}
+
+// Reader read one chronological page of the feed of viewer, with one more item than limit when there is more
+type Reader func(page pagination.Params) ([]models.Publication, error)
+
+// Page rank the window of candidates the cursor points to and return limit publications from its position.
+// A ranker can move a publication anywhere inside the window, and after the last page of a window the
+// next cursor moves to the following window of older publications
+func Page(ranker FeedRanker, viewerId uint64, read Reader, window, limit int, cursor *Cursor, now time.Time) (pagination.Page, error) {
+	if cursor == nil {
+		cursor = &Cursor{RankedAt: now}
+	}
+
+	candidates, err := read(pagination.Params{Limit: window, After: cursor.Window})
+	if err != nil {
+		return pagination.Page{}, err
+	}
+
+	olderWindow := len(candidates) > window
+	if olderWindow {
+		candidates = candidates[:window]
+	}
+
+	ranked, err := ranker.Rank(viewerId, candidates, cursor.RankedAt)
+	if err != nil {
+		return pagination.Page{}, err
+	}
+
+	start := cursor.Position
+	if start > len(ranked) {
+		start = len(ranked)
+	}
+	end := start + limit
+	if end > len(ranked) {
+		end = len(ranked)
+	}
+
+	page := pagination.Page{Data: append([]models.Publication{}, ranked[start:end]...)}
+
+	switch {
+	case end < len(ranked):
+		page.HasMore = true
+		page.NextCursor = EncodeCursor(Cursor{Window: cursor.Window, RankedAt: cursor.RankedAt, Position: end})
+
+	case olderWindow:
+		last := candidates[len(candidates)-1].Cursor()
+		page.HasMore = true
+		page.NextCursor = EncodeCursor(Cursor{Window: &last, RankedAt: cursor.RankedAt})
+	}
+
+	return page, nil
+}
+
+// EncodeCursor create the opaque representation of cursor
+func EncodeCursor(cursor Cursor) string {
+	window := "-"
+	if cursor.Window != nil {
+		window = fmt.Sprintf("%d.%d", cursor.Window.CreatedAt.UnixNano(), cursor.Window.ID)
+	}
+
+	raw := fmt.Sprintf("ranked:%d:%d:%s", cursor.RankedAt.UnixNano(), cursor.Position, window)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor read a cursor created by EncodeCursor, chronological cursors are refused
+func DecodeCursor(value string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return Cursor{}, pagination.ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 4 || parts[0] != "ranked" {
+		return Cursor{}, pagination.ErrInvalidCursor
+	}
+
+	rankedAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return Cursor{}, pagination.ErrInvalidCursor
+	}
+
+	position, err := strconv.Atoi(parts[2])
+	if err != nil || position < 0 {
+		return Cursor{}, pagination.ErrInvalidCursor
+	}
+
+	cursor := Cursor{RankedAt: time.Unix(0, rankedAt), Position: position}
+	if parts[3] == "-" {
+		return cursor, nil
+	}
+
+	window := strings.Split(parts[3], ".")
+	if len(window) != 2 {
+		return Cursor{}, pagination.ErrInvalidCursor
+	}
+
+	createdAt, err := strconv.ParseInt(window[0], 10, 64)
+	if err != nil {
+		return Cursor{}, pagination.ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseUint(window[1], 10, 64)
+	if err != nil {
+		return Cursor{}, pagination.ErrInvalidCursor
+	}
+
+	cursor.Window = &pagination.Cursor{CreatedAt: time.Unix(0, createdAt), ID: id}
+	return cursor, nil
+}
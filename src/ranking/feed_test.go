@@ -0,0 +1,153 @@
+package ranking_test
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/ranking"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// byLikes rank publications by likes, recording the moment of each ranking
+type byLikes struct {
+	rankedAt []time.Time
+}
+
+func (ranker *byLikes) Rank(viewerId uint64, publications []models.Publication, now time.Time) ([]models.Publication, error) {
+	ranker.rankedAt = append(ranker.rankedAt, now)
+
+	ranked := append([]models.Publication(nil), publications...)
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Likes > ranked[j].Likes })
+	return ranked, nil
+}
+
+// chronological read feed like a repository, the most recent first and one more than the limit
+func chronological(feed []models.Publication) ranking.Reader {
+	return func(page pagination.Params) ([]models.Publication, error) {
+		var found []models.Publication
+		for _, publication := range feed {
+			if page.Includes(publication.CreatedAt, publication.ID) && len(found) < page.FetchLimit() {
+				found = append(found, publication)
+			}
+		}
+		return found, nil
+	}
+}
+
+func TestPageRanksTheWholeWindow(t *testing.T) {
+	// ids 7 to 1 from the most recent, 3 and 1 are the most liked of their windows
+	likes := map[uint64]uint64{7: 1, 6: 0, 5: 2, 4: 0, 3: 9, 2: 0, 1: 5}
+	var feed []models.Publication
+	for id := uint64(7); id > 0; id-- {
+		feed = append(feed, post(id, 1, likes[id], time.Duration(8-id)*time.Minute))
+	}
+
+	ranker := &byLikes{}
+	var pages [][]uint64
+	var cursor *ranking.Cursor
+
+	for request := 0; request < 10; request++ {
+		page, err := ranking.Page(ranker, 1, chronological(feed), 5, 2, cursor, now.Add(time.Duration(request)*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var ids []uint64
+		for _, publication := range page.Data.([]models.Publication) {
+			ids = append(ids, publication.ID)
+		}
+		pages = append(pages, ids)
+
+		if !page.HasMore {
+			break
+		}
+
+		next, err := ranking.DecodeCursor(page.NextCursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cursor = &next
+	}
+
+	// the first window holds 7 to 3, the last page of a window can be short
+	expected := [][]uint64{{3, 5}, {7, 6}, {4}, {1, 2}}
+	if !reflect.DeepEqual(pages, expected) {
+		t.Errorf("expected pages %v, got %v", expected, pages)
+	}
+
+	for _, rankedAt := range ranker.rankedAt {
+		if !rankedAt.Equal(now) {
+			t.Errorf("expected every page ranked as of the first request, got %v", ranker.rankedAt)
+			break
+		}
+	}
+}
+
+func TestPageAtTheEndOfAWindow(t *testing.T) {
+	page, err := ranking.Page(&byLikes{}, 1, chronological(nil), 5, 2, nil, now)
+	if err != nil || page.HasMore || page.NextCursor != "" || len(page.Data.([]models.Publication)) != 0 {
+		t.Errorf("expected an empty last page, got %+v, %v", page, err)
+	}
+
+	// a position past a window that shrank continues in the next window
+	feed := []models.Publication{post(3, 1, 0, 0), post(2, 1, 0, time.Minute), post(1, 1, 0, 2*time.Minute)}
+	cursor := ranking.Cursor{RankedAt: now, Position: 10}
+	page, err = ranking.Page(&byLikes{}, 1, chronological(feed), 2, 2, &cursor, now)
+	if err != nil || len(page.Data.([]models.Publication)) != 0 || !page.HasMore {
+		t.Fatalf("expected an empty page with more, got %+v, %v", page, err)
+	}
+
+	next, err := ranking.DecodeCursor(page.NextCursor)
+	if err != nil || next.Position != 0 || next.Window == nil || next.Window.ID != 2 {
+		t.Errorf("expected the next window after 2, got %+v, %v", next, err)
+	}
+}
+
+func TestPageFailsWithReader(t *testing.T) {
+	failure := errors.New("database unavailable")
+	read := func(page pagination.Params) ([]models.Publication, error) { return nil, failure }
+
+	if _, err := ranking.Page(&byLikes{}, 1, read, 5, 2, nil, now); !errors.Is(err, failure) {
+		t.Errorf("expected %v, got %v", failure, err)
+	}
+}
+
+func TestEncodeAndDecodeCursor(t *testing.T) {
+	window := pagination.Cursor{CreatedAt: now.Add(-time.Hour), ID: 42}
+	cursors := []ranking.Cursor{
+		{RankedAt: now, Position: 20},
+		{Window: &window, RankedAt: now, Position: 0},
+	}
+
+	for _, cursor := range cursors {
+		decoded, err := ranking.DecodeCursor(ranking.EncodeCursor(cursor))
+		if err != nil {
+			t.Fatalf("decoding %+v: %v", cursor, err)
+		}
+		if !decoded.RankedAt.Equal(cursor.RankedAt) || decoded.Position != cursor.Position ||
+			(decoded.Window == nil) != (cursor.Window == nil) ||
+			decoded.Window != nil && (!decoded.Window.CreatedAt.Equal(window.CreatedAt) || decoded.Window.ID != window.ID) {
+			t.Errorf("expected %+v after round trip, got %+v", cursor, decoded)
+		}
+	}
+
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+	invalid := map[string]string{
+		"chronological cursor": pagination.Encode(window),
+		"not base64":           "%%%",
+		"missing window":       encode("ranked:1:2"),
+		"negative position":    encode("ranked:1:-1:-"),
+		"broken window":        encode("ranked:1:2:3"),
+		"other kind":           encode("other:1:2:-"),
+	}
+
+	for name, value := range invalid {
+		if cursor, err := ranking.DecodeCursor(value); err != pagination.ErrInvalidCursor {
+			t.Errorf("%s: expected invalid cursor, got %+v, %v", name, cursor, err)
+		}
+	}
+}
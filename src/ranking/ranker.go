@@ -0,0 +1,129 @@
+// Package ranking order the publications of the home feed, chronologically or weighted by engagement
+package ranking
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// Chronological keep the feed with the most recent publications first
+	Chronological = "chronological"
+	// Engagement put first, inside each window of candidates, the publications with more likes, comments and
+	// reposts from the authors viewer interacts with
+	Engagement = "engagement"
+)
+
+// FeedRanker order a window of candidates from the feed of viewer, received in chronological order.
+// Page cuts the ranked window in pages, so a publication can move to any page of its window
+type FeedRanker interface {
+	Rank(viewerId uint64, publications []models.Publication, now time.Time) ([]models.Publication, error)
+}
+
+// Rankers are the feed rankers a client can choose by name
+type Rankers map[string]FeedRanker
+
+// NewRankers create the chronological ranker and the engagement one, reading engagement from the repositories
+func NewRankers(publications repositories.PublicationRepository, comments repositories.CommentRepository, weights Weights) Rankers {
+	return Rankers{
+		Chronological: ChronologicalRanker{},
+		Engagement:    NewEngagementRanker(publications, comments, weights),
+	}
+}
+
+// ChronologicalRanker keep the publications in the order they were read
+type ChronologicalRanker struct{}
+
+// Rank return publications unchanged
+func (ChronologicalRanker) Rank(viewerId uint64, publications []models.Publication, now time.Time) ([]models.Publication, error) {
+	return publications, nil
+}
+
+// Weights set how much each signal counts in the engagement score
+type Weights struct {
+	Likes        float64
+	Comments     float64
+	Reposts      float64
+	Interactions float64
+	// HalfLife is the age that halves the score of a publication
+	HalfLife time.Duration
+}
+
+// DefaultWeights value a comment as two likes and a repost as three
+var DefaultWeights = Weights{Likes: 1, Comments: 2, Reposts: 3, Interactions: 0.5, HalfLife: 6 * time.Hour}
+
+// EngagementRanker order publications by Score, reposts are scored by the engagement of their original
+type EngagementRanker struct {
+	publications repositories.PublicationRepository
+	comments     repositories.CommentRepository
+	weights      Weights
+}
+
+// NewEngagementRanker create a ranker that read comments and the interactions of viewer from the repositories
+func NewEngagementRanker(publications repositories.PublicationRepository, comments repositories.CommentRepository, weights Weights) *EngagementRanker {
+	return &EngagementRanker{publications: publications, comments: comments, weights: weights}
+}
+
+// Rank return publications sorted by score, ties keep the most recent first so equal inputs give the same order
+func (ranker *EngagementRanker) Rank(viewerId uint64, publications []models.Publication, now time.Time) ([]models.Publication, error) {
+	if len(publications) < 2 {
+		return publications, nil
+	}
+
+	var publicationIds, authorIds []uint64
+	for _, publication := range publications {
+		target := engaged(publication)
+		publicationIds = append(publicationIds, target.ID)
+		authorIds = append(authorIds, target.AuthorId)
+	}
+
+	comments, err := ranker.comments.CountByPublications(publicationIds)
+	if err != nil {
+		return nil, err
+	}
+
+	interactions, err := ranker.publications.CountInteractions(viewerId, authorIds)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[uint64]float64, len(publications))
+	for _, publication := range publications {
+		target := engaged(publication)
+		scores[publication.ID] = Score(target, comments[target.ID], interactions[target.AuthorId], now.Sub(publication.CreatedAt), ranker.weights)
+	}
+
+	ranked := append([]models.Publication(nil), publications...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if scores[ranked[i].ID] == scores[ranked[j].ID] {
+			return ranked[i].ID > ranked[j].ID
+		}
+		return scores[ranked[i].ID] > scores[ranked[j].ID]
+	})
+
+	return ranked, nil
+}
+
+// Score weight the likes, comments and reposts of publication and the interactions of viewer with its author,
+// both on a logarithmic scale so popular publications do not hide everything else, and decay the result with age
+func Score(publication models.Publication, comments, interactions uint64, age time.Duration, weights Weights) float64 {
+	if age < 0 {
+		age = 0
+	}
+
+	engagement := weights.Likes*float64(publication.Likes) + weights.Comments*float64(comments) + weights.Reposts*float64(publication.Reposts)
+	affinity := weights.Interactions * float64(interactions)
+
+	return (1 + math.Log1p(engagement)) * (1 + math.Log1p(affinity)) * math.Pow(0.5, float64(age)/float64(weights.HalfLife))
+}
+
+// engaged return the publication that receives the engagement, the original for reposts visible to viewer
+func engaged(publication models.Publication) models.Publication {
+	if publication.RepostOfId != 0 && publication.Original != nil {
+		return *publication.Original
+	}
+	return publication
+}
@@ -0,0 +1,146 @@
+package ranking_test
+
+import (
+	"api/src/models"
+	"api/src/ranking"
+	"api/src/repositories"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// interactions answer CountInteractions with fixed counts by author
+type interactions struct {
+	repositories.PublicationRepository
+	byAuthor map[uint64]uint64
+}
+
+func (publications interactions) CountInteractions(userId uint64, authorIds []uint64) (map[uint64]uint64, error) {
+	return publications.byAuthor, nil
+}
+
+// comments answer CountByPublications with fixed counts by publication, failing when err is set
+type comments struct {
+	repositories.CommentRepository
+	byPublication map[uint64]uint64
+	err           error
+}
+
+func (comments comments) CountByPublications(publicationIds []uint64) (map[uint64]uint64, error) {
+	return comments.byPublication, comments.err
+}
+
+var now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// post create a publication from author with likes written age before now
+func post(id, authorId, likes uint64, age time.Duration) models.Publication {
+	return models.Publication{ID: id, AuthorId: authorId, Likes: likes, CreatedAt: now.Add(-age)}
+}
+
+func TestScore(t *testing.T) {
+	weights := ranking.Weights{Likes: 1, Comments: 2, Reposts: 3, Interactions: 0.5, HalfLife: time.Hour}
+
+	tests := []struct {
+		name         string
+		publication  models.Publication
+		comments     uint64
+		interactions uint64
+		age          time.Duration
+		score        float64
+	}{
+		{"without engagement", models.Publication{}, 0, 0, 0, 1},
+		{"likes on a logarithmic scale", models.Publication{Likes: 3}, 0, 0, 0, 1 + math.Log(4)},
+		{"weighted likes, comments and reposts", models.Publication{Likes: 1, Reposts: 1}, 1, 0, 0, 1 + math.Log(7)},
+		{"interactions with author", models.Publication{}, 0, 2, 0, 1 + math.Log(2)},
+		{"half life", models.Publication{Likes: 3}, 0, 0, time.Hour, (1 + math.Log(4)) / 2},
+		{"future publications are new", models.Publication{}, 0, 0, -time.Hour, 1},
+	}
+
+	for _, test := range tests {
+		score := ranking.Score(test.publication, test.comments, test.interactions, test.age, weights)
+		if math.Abs(score-test.score) > 1e-9 {
+			t.Errorf("%s: expected %f, got %f", test.name, test.score, score)
+		}
+	}
+}
+
+func TestEngagementRank(t *testing.T) {
+	const alice, bob, carol = 1, 2, 3
+
+	liked := post(2, bob, 3, 6*time.Hour)
+	repost := post(4, alice, 0, 0)
+	repost.RepostOfId, repost.Original = liked.ID, &liked
+	hiddenRepost := post(6, alice, 0, 0)
+	hiddenRepost.RepostOfId = 99
+
+	tests := []struct {
+		name         string
+		publications []models.Publication
+		order        []uint64
+	}{
+		{"nothing to rank", nil, nil},
+		{"one publication", []models.Publication{post(1, alice, 0, 0)}, []uint64{1}},
+		{
+			name: "by score",
+			publications: []models.Publication{
+				post(5, carol, 0, 0), repost, post(3, alice, 0, 0), liked, post(1, alice, 0, 0),
+			},
+			// carol has comments and interactions, the repost is scored by the likes of the original at its own age,
+			// the original lost half of its score with age and the new publications without engagement tie
+			order: []uint64{5, 4, 2, 3, 1},
+		},
+		{"ties keep the most recent first", []models.Publication{post(1, alice, 0, 0), post(3, alice, 0, 0)}, []uint64{3, 1}},
+		{"ties in any input order", []models.Publication{post(3, alice, 0, 0), post(1, alice, 0, 0)}, []uint64{3, 1}},
+		{"repost of a hidden original scored by itself", []models.Publication{post(1, alice, 0, time.Minute), hiddenRepost}, []uint64{6, 1}},
+	}
+
+	ranker := ranking.NewEngagementRanker(
+		interactions{byAuthor: map[uint64]uint64{carol: 2}},
+		comments{byPublication: map[uint64]uint64{5: 1}},
+		ranking.Weights{Likes: 1, Comments: 2, Reposts: 3, Interactions: 0.5, HalfLife: 6 * time.Hour},
+	)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := append([]models.Publication(nil), test.publications...)
+
+			ranked, err := ranker.Rank(alice, test.publications, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var order []uint64
+			for _, publication := range ranked {
+				order = append(order, publication.ID)
+			}
+			if !reflect.DeepEqual(order, test.order) {
+				t.Errorf("expected %v, got %v", test.order, order)
+			}
+
+			if !reflect.DeepEqual(test.publications, input) {
+				t.Errorf("expected the page received unchanged, got %+v", test.publications)
+			}
+		})
+	}
+}
+
+func TestEngagementRankFailsWithRepositories(t *testing.T) {
+	failure := errors.New("database unavailable")
+	ranker := ranking.NewEngagementRanker(interactions{}, comments{err: failure}, ranking.DefaultWeights)
+
+	page := []models.Publication{post(1, 1, 0, 0), post(2, 1, 0, 0)}
+	if _, err := ranker.Rank(1, page, now); !errors.Is(err, failure) {
+		t.Errorf("expected %v, got %v", failure, err)
+	}
+}
+
+func TestChronologicalRankKeepsThePage(t *testing.T) {
+	page := []models.Publication{post(1, 1, 10, 0), post(2, 1, 0, 0)}
+
+	ranked, err := ranking.ChronologicalRanker{}.Rank(1, page, now)
+	if err != nil || !reflect.DeepEqual(ranked, page) {
+		t.Errorf("expected %+v, got %+v, %v", page, ranked, err)
+	}
+}
@@ -0,0 +1,116 @@
+package repositories
+
+// notBlocked return the condition that hides users blocked in any direction by viewer,
+// column is the user id compared and the condition receives viewer id twice
+func notBlocked(column string) string {
+	return `NOT EXISTS (
+		SELECT 1 FROM user_blocks b
+		WHERE (b.user_id = ? AND b.blocked_id = ` + column + `) OR (b.user_id = ` + column + ` AND b.blocked_id = ?)
+	)`
+}
+
+// notMuted return the condition that hides users muted by viewer, receiving viewer id once
+func notMuted(column string) string {
+	return `NOT EXISTS (SELECT 1 FROM user_mutes m WHERE m.user_id = ? AND m.muted_id = ` + column + `)`
+}
+
+// Block make user block other user, removing the follow relationship and pending requests between them
+func (repository users) Block(userId, blockedId uint64) error {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(
+		"INSERT IGNORE INTO user_blocks (user_id, blocked_id) values (?, ?)", userId, blockedId,
+	); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(
+		"DELETE FROM followers WHERE (user_id = ? AND follower_id = ?) OR (user_id = ? AND follower_id = ?)",
+		userId, blockedId, blockedId, userId,
+	); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(
+		"DELETE FROM follow_requests WHERE (user_id = ? AND requester_id = ?) OR (user_id = ? AND requester_id = ?)",
+		userId, blockedId, blockedId, userId,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Unblock remove the block from user to other user
+func (repository users) Unblock(userId, blockedId uint64) error {
+	statement, err := repository.db.Prepare("DELETE FROM user_blocks WHERE user_id = ? AND blocked_id = ?")
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(userId, blockedId); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IsBlocked return if one of the users blocked the other
+func (repository users) IsBlocked(userId, otherId uint64) (bool, error) {
+	var exists bool
+	if err := repository.db.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM user_blocks WHERE (user_id = ? AND blocked_id = ?) OR (user_id = ? AND blocked_id = ?))",
+		userId, otherId, otherId, userId,
+	).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// Mute hide publications from muted user in user feed
+func (repository users) Mute(userId, mutedId uint64) error {
+	statement, err := repository.db.Prepare("INSERT IGNORE INTO user_mutes (user_id, muted_id) values (?, ?)")
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(userId, mutedId); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IsMuted return if user muted other user
+func (repository users) IsMuted(userId, mutedId uint64) (bool, error) {
+	var exists bool
+	if err := repository.db.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM user_mutes WHERE user_id = ? AND muted_id = ?)", userId, mutedId,
+	).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// Unmute show publications from muted user again
+func (repository users) Unmute(userId, mutedId uint64) error {
+	statement, err := repository.db.Prepare("DELETE FROM user_mutes WHERE user_id = ? AND muted_id = ?")
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(userId, mutedId); err != nil {
+		return err
+	}
+
+	return nil
+}
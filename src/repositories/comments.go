@@ -0,0 +1,192 @@
+package repositories
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"database/sql"
+	"strings"
+)
+
+type Comments struct {
+	db *sql.DB
+}
+
+// NewCommentRepository create one repository of comments
+func NewCommentRepository(db *sql.DB) CommentRepository {
+	return &Comments{db}
+}
+
+// Create insert a comment in database
+func (repository Comments) Create(comment models.Comment) (uint64, error) {
+	statement, err := repository.db.Prepare(
+		"INSERT INTO comments (content, publication_id, author_id, parent_comment_id) values (?, ?, ?, ?)",
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer statement.Close()
+
+	var parentCommentId interface{}
+	if comment.ParentCommentId != 0 {
+		parentCommentId = comment.ParentCommentId
+	}
+
+	result, err := statement.Exec(comment.Content, comment.PublicationId, comment.AuthorId, parentCommentId)
+	if err != nil {
+		return 0, err
+	}
+
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastId), nil
+}
+
+// FindById find one comment by comment id
+func (repository Comments) FindById(commentId uint64) (models.Comment, error) {
+	line, err := repository.db.Query(`
+		SELECT c.id, c.content, c.publication_id, c.author_id, COALESCE(c.parent_comment_id, 0), c.createdAt, u.nick
+		FROM comments c INNER JOIN users u ON u.id = c.author_id
+		WHERE c.id = ?`,
+		commentId,
+	)
+	if err != nil {
+		return models.Comment{}, err
+	}
+	defer line.Close()
+
+	var comment models.Comment
+
+	if line.Next() {
+		if err = scanComment(line, &comment); err != nil {
+			return models.Comment{}, err
+		}
+	}
+
+	return comment, nil
+}
+
+// FindByPublication find one page of first level comments from publication, newest first, each with its replies oldest first
+func (repository Comments) FindByPublication(publicationId uint64, page pagination.Params) ([]models.Comment, error) {
+	cursor, cursorArgs := page.Where("c.createdAt", "c.id")
+
+	args := append([]interface{}{publicationId}, cursorArgs...)
+	lines, err := repository.db.Query(`
+		SELECT c.id, c.content, c.publication_id, c.author_id, COALESCE(c.parent_comment_id, 0), c.createdAt, u.nick
+		FROM comments c INNER JOIN users u ON u.id = c.author_id
+		WHERE c.publication_id = ? AND c.parent_comment_id IS NULL AND `+cursor+`
+		ORDER BY c.createdAt DESC, c.id DESC
+		LIMIT ?`,
+		append(args, page.FetchLimit())...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var comments []models.Comment
+	for lines.Next() {
+		var comment models.Comment
+
+		if err = scanComment(lines, &comment); err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, comment)
+	}
+
+	if err = lines.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(comments) == 0 {
+		return comments, nil
+	}
+
+	if err = repository.attachReplies(comments); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func (repository Comments) attachReplies(comments []models.Comment) error {
+	placeholders := make([]string, len(comments))
+	args := make([]interface{}, len(comments))
+	position := make(map[uint64]int, len(comments))
+
+	for i, comment := range comments {
+		placeholders[i] = "?"
+		args[i] = comment.ID
+		position[comment.ID] = i
+	}
+
+	lines, err := repository.db.Query(`
+		SELECT c.id, c.content, c.publication_id, c.author_id, COALESCE(c.parent_comment_id, 0), c.createdAt, u.nick
+		FROM comments c INNER JOIN users u ON u.id = c.author_id
+		WHERE c.parent_comment_id IN (`+strings.Join(placeholders, ", ")+`)
+		ORDER BY c.id`,
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer lines.Close()
+
+	for lines.Next() {
+		var reply models.Comment
+
+		if err = scanComment(lines, &reply); err != nil {
+			return err
+		}
+
+		i := position[reply.ParentCommentId]
+		comments[i].Replies = append(comments[i].Replies, reply)
+	}
+
+	return lines.Err()
+}
+
+// Update edit comment content
+func (repository Comments) Update(commentId uint64, comment models.Comment) error {
+	statement, err := repository.db.Prepare("UPDATE comments SET content = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(comment.Content, commentId); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Delete remove comment and its replies from database
+func (repository Comments) Delete(commentId uint64) error {
+	statement, err := repository.db.Prepare("DELETE FROM comments WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(commentId); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanComment(line *sql.Rows, comment *models.Comment) error {
+	return line.Scan(
+		&comment.ID,
+		&comment.Content,
+		&comment.PublicationId,
+		&comment.AuthorId,
+		&comment.ParentCommentId,
+		&comment.CreatedAt,
+		&comment.AuthorNick,
+	)
+}
@@ -0,0 +1,277 @@
+package repositories
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"database/sql"
+	"strings"
+)
+
+type Conversations struct {
+	db *sql.DB
+}
+
+// NewConversationRepository create one repository of conversations and their messages
+func NewConversationRepository(db *sql.DB) ConversationRepository {
+	return &Conversations{db}
+}
+
+// Create insert a conversation with all members from conversation.MemberIds
+func (repository Conversations) Create(conversation models.Conversation) (uint64, error) {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO conversations () values ()")
+	if err != nil {
+		return 0, err
+	}
+
+	conversationId, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	statement, err := tx.Prepare("INSERT INTO conversation_members (conversation_id, user_id) values (?, ?)")
+	if err != nil {
+		return 0, err
+	}
+	defer statement.Close()
+
+	for _, memberId := range conversation.MemberIds {
+		if _, err = statement.Exec(conversationId, memberId); err != nil {
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return uint64(conversationId), nil
+}
+
+// FindDirect return the id of the conversation that has only the two users, zero when there is none
+func (repository Conversations) FindDirect(userId, otherId uint64) (uint64, error) {
+	var conversationId uint64
+	err := repository.db.QueryRow(`
+		SELECT m.conversation_id FROM conversation_members m
+		INNER JOIN conversation_members o ON o.conversation_id = m.conversation_id AND o.user_id = ?
+		WHERE m.user_id = ? AND (
+			SELECT COUNT(*) FROM conversation_members c WHERE c.conversation_id = m.conversation_id
+		) = 2
+		LIMIT 1`,
+		otherId, userId,
+	).Scan(&conversationId)
+
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return conversationId, err
+}
+
+// FindById find one conversation with its members
+func (repository Conversations) FindById(conversationId uint64) (models.Conversation, error) {
+	var conversation models.Conversation
+	err := repository.db.QueryRow(
+		"SELECT id, createdAt, updatedAt FROM conversations WHERE id = ?", conversationId,
+	).Scan(&conversation.ID, &conversation.CreatedAt, &conversation.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return models.Conversation{}, nil
+	}
+	if err != nil {
+		return models.Conversation{}, err
+	}
+
+	conversations := []models.Conversation{conversation}
+	if err = repository.addMembers(conversations); err != nil {
+		return models.Conversation{}, err
+	}
+
+	return conversations[0], nil
+}
+
+// FindByUser find one page of conversations from user, the most recently active first
+func (repository Conversations) FindByUser(userId uint64, page pagination.Params) ([]models.Conversation, error) {
+	cursor, cursorArgs := page.Where("c.updatedAt", "c.id")
+
+	args := append([]interface{}{userId}, cursorArgs...)
+	lines, err := repository.db.Query(`
+		SELECT c.id, c.createdAt, c.updatedAt FROM conversations c
+		INNER JOIN conversation_members m ON m.conversation_id = c.id
+		WHERE m.user_id = ? AND `+cursor+`
+		ORDER BY c.updatedAt DESC, c.id DESC
+		LIMIT ?`,
+		append(args, page.FetchLimit())...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var conversations []models.Conversation
+	for lines.Next() {
+		var conversation models.Conversation
+
+		if err = lines.Scan(&conversation.ID, &conversation.CreatedAt, &conversation.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		conversations = append(conversations, conversation)
+	}
+
+	if err = lines.Err(); err != nil {
+		return nil, err
+	}
+
+	if err = repository.addMembers(conversations); err != nil {
+		return nil, err
+	}
+
+	return conversations, nil
+}
+
+// CreateMessage insert a message, moving the conversation to the top and marking it read by sender
+func (repository Conversations) CreateMessage(message models.Message) (uint64, error) {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"INSERT INTO messages (conversation_id, sender_id, content) values (?, ?, ?)",
+		message.ConversationId, message.SenderId, message.Content,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	messageId, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err = tx.Exec(
+		"UPDATE conversations SET updatedAt = current_timestamp(6) WHERE id = ?", message.ConversationId,
+	); err != nil {
+		return 0, err
+	}
+
+	if _, err = tx.Exec(
+		"UPDATE conversation_members SET last_read_message_id = ? WHERE conversation_id = ? AND user_id = ?",
+		messageId, message.ConversationId, message.SenderId,
+	); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return uint64(messageId), nil
+}
+
+// FindMessages find one page of messages from conversation, newest first
+func (repository Conversations) FindMessages(conversationId uint64, page pagination.Params) ([]models.Message, error) {
+	cursor, cursorArgs := page.Where("m.createdAt", "m.id")
+
+	args := append([]interface{}{conversationId}, cursorArgs...)
+	lines, err := repository.db.Query(`
+		SELECT m.id, m.conversation_id, m.sender_id, u.nick, m.content, m.createdAt
+		FROM messages m INNER JOIN users u ON u.id = m.sender_id
+		WHERE m.conversation_id = ? AND `+cursor+`
+		ORDER BY m.createdAt DESC, m.id DESC
+		LIMIT ?`,
+		append(args, page.FetchLimit())...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var messages []models.Message
+	for lines.Next() {
+		var message models.Message
+
+		if err = lines.Scan(
+			&message.ID,
+			&message.ConversationId,
+			&message.SenderId,
+			&message.SenderNick,
+			&message.Content,
+			&message.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+// MarkRead move the read position from member up to message, it never goes back
+func (repository Conversations) MarkRead(conversationId, userId, messageId uint64) error {
+	statement, err := repository.db.Prepare(`
+		UPDATE conversation_members SET last_read_message_id = GREATEST(last_read_message_id, ?)
+		WHERE conversation_id = ? AND user_id = ? AND EXISTS (
+			SELECT 1 FROM messages WHERE id = ? AND conversation_id = ?
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(messageId, conversationId, userId, messageId, conversationId); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// addMembers load the members from all conversations with one query
+func (repository Conversations) addMembers(conversations []models.Conversation) error {
+	if len(conversations) == 0 {
+		return nil
+	}
+
+	byId := map[uint64]*models.Conversation{}
+	args := make([]interface{}, 0, len(conversations))
+	for i := range conversations {
+		byId[conversations[i].ID] = &conversations[i]
+		args = append(args, conversations[i].ID)
+	}
+
+	lines, err := repository.db.Query(`
+		SELECT m.conversation_id, m.user_id, u.nick, m.last_read_message_id
+		FROM conversation_members m INNER JOIN users u ON u.id = m.user_id
+		WHERE m.conversation_id IN (?`+strings.Repeat(", ?", len(args)-1)+`)
+		ORDER BY m.joinedAt, m.user_id`,
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer lines.Close()
+
+	for lines.Next() {
+		var conversationId uint64
+		var member models.ConversationMember
+
+		if err = lines.Scan(&conversationId, &member.UserId, &member.Nick, &member.LastReadMessageId); err != nil {
+			return err
+		}
+
+		conversation := byId[conversationId]
+		conversation.Members = append(conversation.Members, member)
+		conversation.MemberIds = append(conversation.MemberIds, member.UserId)
+	}
+
+	return lines.Err()
+}
@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrNotDraft is returned when a draft being edited does not exist or was already published, like by the scheduler
+var ErrNotDraft = errors.New("Publication is not a draft")
+
+// FindDrafts return one page of the drafts and scheduled publications from author
+func (repository Publications) FindDrafts(authorId uint64, page pagination.Params) ([]models.Publication, error) {
+	cursor, cursorArgs := page.Where("p.createdAt", "p.id")
+
+	args := append([]interface{}{authorId}, cursorArgs...)
+	lines, err := repository.db.Query(`
+		SELECT `+publicationColumns+` FROM publications p
+		INNER JOIN users u ON u.id = p.author_id
+		WHERE p.author_id = ? AND p.status <> 'published' AND `+cursor+`
+		ORDER BY p.createdAt DESC, p.id DESC
+		LIMIT ?`,
+		append(args, page.FetchLimit())...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var publications []models.Publication
+
+	for lines.Next() {
+		var publication models.Publication
+
+		if err = scanPublication(lines, &publication); err != nil {
+			return nil, err
+		}
+
+		publications = append(publications, publication)
+	}
+	lines.Close()
+
+	if err = embedOriginals(repository.db, publications, authorId); err != nil {
+		return nil, err
+	}
+
+	return publications, nil
+}
+
+// UpdateDraft update a draft or scheduled publication, failing with ErrNotDraft when it was published meanwhile.
+// The row is locked until the update commits, so the scheduler skips it instead of publishing it in the middle
+func (repository Publications) UpdateDraft(publicationId uint64, publication models.Publication) error {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	if err = tx.QueryRow("SELECT status FROM publications WHERE id = ? FOR UPDATE", publicationId).Scan(&status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotDraft
+		}
+		return err
+	}
+
+	if status == models.StatusPublished {
+		return ErrNotDraft
+	}
+
+	if err = updatePublication(tx, publicationId, publication); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PublishDue publish up to limit scheduled publications whose publishAt is not after now, returning their ids and authors.
+// The rows are locked while they change and the ones locked by other instances are skipped,
+// so each publication is returned exactly once even with many schedulers running
+func (repository Publications) PublishDue(now time.Time, limit int) ([]models.Publication, error) {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	lines, err := tx.Query(`
+		SELECT id, author_id FROM publications
+		WHERE status = 'scheduled' AND publish_at <= ?
+		ORDER BY publish_at, id
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED`,
+		now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var due []models.Publication
+	var ids []interface{}
+
+	for lines.Next() {
+		var publication models.Publication
+
+		if err = lines.Scan(&publication.ID, &publication.AuthorId); err != nil {
+			return nil, err
+		}
+
+		due = append(due, publication)
+		ids = append(ids, publication.ID)
+	}
+	if err = lines.Err(); err != nil {
+		return nil, err
+	}
+	lines.Close()
+
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	if _, err = tx.Exec(`
+		UPDATE publications SET status = 'published', publish_at = NULL, createdAt = CURRENT_TIMESTAMP
+		WHERE id IN (`+placeholders+`)`,
+		ids...,
+	); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return due, nil
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// CountByPublications count the comments and replies of each publication, publications without comments are left out
+func (repository Comments) CountByPublications(publicationIds []uint64) (map[uint64]uint64, error) {
+	if len(publicationIds) == 0 {
+		return map[uint64]uint64{}, nil
+	}
+
+	var args []interface{}
+	for _, id := range publicationIds {
+		args = append(args, id)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")
+	lines, err := repository.db.Query(`
+		SELECT publication_id, COUNT(*) FROM comments
+		WHERE publication_id IN (`+placeholders+`) GROUP BY publication_id`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	return scanCounts(lines)
+}
+
+// CountInteractions count the likes, comments and reposts from user on publications of each author,
+// authors user never interacted with are left out
+func (repository Publications) CountInteractions(userId uint64, authorIds []uint64) (map[uint64]uint64, error) {
+	if len(authorIds) == 0 {
+		return map[uint64]uint64{}, nil
+	}
+
+	args := []interface{}{userId, userId, userId}
+	for _, id := range authorIds {
+		args = append(args, id)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(authorIds)), ", ")
+	lines, err := repository.db.Query(`
+		SELECT p.author_id, COUNT(*) FROM (
+			SELECT publication_id FROM publication_likes WHERE user_id = ?
+			UNION ALL SELECT publication_id FROM comments WHERE author_id = ?
+			UNION ALL SELECT repost_of_id FROM publications WHERE author_id = ? AND repost_of_id IS NOT NULL
+		) i INNER JOIN publications p ON p.id = i.publication_id
+		WHERE p.author_id IN (`+placeholders+`) GROUP BY p.author_id`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	return scanCounts(lines)
+}
+
+// scanCounts read lines of id and count
+func scanCounts(lines *sql.Rows) (map[uint64]uint64, error) {
+	counts := map[uint64]uint64{}
+
+	for lines.Next() {
+		var id, count uint64
+		if err := lines.Scan(&id, &count); err != nil {
+			return nil, err
+		}
+		counts[id] = count
+	}
+
+	return counts, lines.Err()
+}
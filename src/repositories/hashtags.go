@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"database/sql"
+	"time"
+)
+
+type hashtags struct {
+	db *sql.DB
+}
+
+// NewHashtagRepository create a hashtag repository
+func NewHashtagRepository(db *sql.DB) HashtagRepository {
+	return &hashtags{db}
+}
+
+// FindPublications return one page of publications with tag that viewer is allowed to see,
+// without authors blocked in any direction
+func (repository hashtags) FindPublications(tag string, viewerId uint64, page pagination.Params) ([]models.Publication, error) {
+	cursor, cursorArgs := page.Where("p.createdAt", "p.id")
+
+	args := append([]interface{}{tag, viewerId, viewerId, viewerId, viewerId}, cursorArgs...)
+	lines, err := repository.db.Query(`
+		SELECT `+publicationColumns+` FROM publications p
+		INNER JOIN users u ON u.id = p.author_id
+		INNER JOIN publication_hashtags ph ON ph.publication_id = p.id
+		INNER JOIN hashtags h ON h.id = ph.hashtag_id
+		WHERE h.tag = ? AND p.status = 'published' AND `+visibleToViewer+` AND `+notBlocked("p.author_id")+` AND `+cursor+`
+		ORDER BY p.createdAt DESC, p.id DESC
+		LIMIT ?`,
+		append(args, page.FetchLimit())...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var publications []models.Publication
+
+	for lines.Next() {
+		var publication models.Publication
+
+		if err = scanPublication(lines, &publication); err != nil {
+			return nil, err
+		}
+
+		publications = append(publications, publication)
+	}
+	lines.Close()
+
+	if err = embedOriginals(repository.db, publications, viewerId); err != nil {
+		return nil, err
+	}
+
+	return publications, nil
+}
+
+// FindUses count per hour the public publications created since the moment that used each hashtag,
+// publications from private accounts are not counted so trending does not leak them
+func (repository hashtags) FindUses(since time.Time) ([]models.HashtagUse, error) {
+	lines, err := repository.db.Query(`
+		SELECT h.tag, FROM_UNIXTIME(FLOOR(UNIX_TIMESTAMP(p.createdAt) / 3600) * 3600) AS hour, COUNT(*)
+		FROM publication_hashtags ph
+		INNER JOIN hashtags h ON h.id = ph.hashtag_id
+		INNER JOIN publications p ON p.id = ph.publication_id
+		INNER JOIN users u ON u.id = p.author_id
+		WHERE p.createdAt >= ? AND p.status = 'published' AND p.visibility = 'public' AND NOT u.is_private
+		GROUP BY h.tag, hour`,
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var uses []models.HashtagUse
+
+	for lines.Next() {
+		var use models.HashtagUse
+
+		if err = lines.Scan(&use.Tag, &use.Hour, &use.Count); err != nil {
+			return nil, err
+		}
+
+		uses = append(uses, use)
+	}
+
+	return uses, nil
+}
+
+// saveHashtags replace the hashtags linked to publication, creating the ones used for the first time
+func saveHashtags(tx *sql.Tx, publicationId uint64, tags []string) error {
+	if _, err := tx.Exec("DELETE FROM publication_hashtags WHERE publication_id = ?", publicationId); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		result, err := tx.Exec(
+			"INSERT INTO hashtags (tag) values (?) ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)", tag,
+		)
+		if err != nil {
+			return err
+		}
+
+		hashtagId, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.Exec(
+			"INSERT INTO publication_hashtags (publication_id, hashtag_id) values (?, ?)", publicationId, hashtagId,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,360 @@
+package repositories
+
+import (
+	"api/src/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+// mediaColumn read the media attached to publication p as a JSON array of mediaRecord
+const mediaColumn = `(
+	SELECT JSON_ARRAYAGG(JSON_OBJECT(
+		'id', md.id, 'position', md.position, 'contentType', md.content_type, 'size', md.size,
+		'width', md.width, 'height', md.height, 'blurhash', md.blurhash, 'variants', md.variants,
+		'processingFailed', md.processing_failed
+	)) FROM media md WHERE md.publication_id = p.id
+)`
+
+// mediaRecord is one item encoded by mediaColumn
+type mediaRecord struct {
+	ID          uint64          `json:"id"`
+	Position    int             `json:"position"`
+	ContentType string          `json:"contentType"`
+	Size        int64           `json:"size"`
+	Width       int             `json:"width"`
+	Height      int             `json:"height"`
+	Blurhash    *string         `json:"blurhash"`
+	Variants    []variantRecord `json:"variants"`
+	// ProcessingFailed is a number, JSON_OBJECT does not turn booleans of MySQL into true and false
+	ProcessingFailed int `json:"processingFailed"`
+}
+
+// variantRecord is how one variant is saved in the variants JSON column
+type variantRecord struct {
+	Size        int    `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ContentType string `json:"contentType"`
+	StorageKey  string `json:"storageKey"`
+}
+
+// ErrMediaUnavailable is returned when a publication references media that does not exist,
+// belongs to other user or is attached to other publication
+var ErrMediaUnavailable = errors.New("Media not found or already attached to other publication")
+
+type media struct {
+	db *sql.DB
+}
+
+// NewMediaRepository create a media repository
+func NewMediaRepository(db *sql.DB) MediaRepository {
+	return &media{db}
+}
+
+// Create insert uploaded media not attached to any publication yet
+func (repository media) Create(media models.Media) (uint64, error) {
+	statement, err := repository.db.Prepare(
+		"INSERT INTO media (owner_id, storage_key, content_type, size) values (?, ?, ?, ?)",
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer statement.Close()
+
+	result, err := statement.Exec(media.OwnerId, media.StorageKey, media.ContentType, media.Size)
+	if err != nil {
+		return 0, err
+	}
+
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastId), nil
+}
+
+// FindById find one media
+func (repository media) FindById(mediaId uint64) (models.Media, error) {
+	lines, err := repository.db.Query(
+		"SELECT "+mediaColumns+" FROM media WHERE id = ?", mediaId,
+	)
+	if err != nil {
+		return models.Media{}, err
+	}
+	defer lines.Close()
+
+	var media models.Media
+
+	if lines.Next() {
+		if err = scanMedia(lines, &media); err != nil {
+			return models.Media{}, err
+		}
+	}
+
+	return media, nil
+}
+
+// FindOrphans return up to limit media created before the moment that are not attached to any publication
+func (repository media) FindOrphans(createdBefore time.Time, limit int) ([]models.Media, error) {
+	lines, err := repository.db.Query(
+		"SELECT "+mediaColumns+" FROM media WHERE publication_id IS NULL AND createdAt < ? ORDER BY createdAt, id LIMIT ?",
+		createdBefore, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var orphans []models.Media
+
+	for lines.Next() {
+		var media models.Media
+
+		if err = scanMedia(lines, &media); err != nil {
+			return nil, err
+		}
+
+		orphans = append(orphans, media)
+	}
+
+	return orphans, nil
+}
+
+// FindUnprocessed return up to limit images created before the moment whose variants were never saved
+// and that did not fail processing, with id greater than afterId and ordered by id
+func (repository media) FindUnprocessed(createdBefore time.Time, afterId uint64, limit int) ([]models.Media, error) {
+	lines, err := repository.db.Query(
+		"SELECT "+mediaColumns+" FROM media WHERE blurhash IS NULL AND NOT processing_failed "+
+			"AND content_type LIKE 'image/%' AND createdAt < ? AND id > ? ORDER BY id LIMIT ?",
+		createdBefore, afterId, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var pending []models.Media
+
+	for lines.Next() {
+		var media models.Media
+
+		if err = scanMedia(lines, &media); err != nil {
+			return nil, err
+		}
+
+		pending = append(pending, media)
+	}
+
+	return pending, nil
+}
+
+// SaveVariants record the dimensions, blurhash and variants generated for media
+func (repository media) SaveVariants(media models.Media) error {
+	records := make([]variantRecord, 0, len(media.Variants))
+	for _, variant := range media.Variants {
+		records = append(records, variantRecord{
+			Size:        variant.Size,
+			Width:       variant.Width,
+			Height:      variant.Height,
+			ContentType: variant.ContentType,
+			StorageKey:  variant.StorageKey,
+		})
+	}
+
+	variants, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	statement, err := repository.db.Prepare(
+		"UPDATE media SET width = ?, height = ?, blurhash = ?, variants = ? WHERE id = ?",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(media.Width, media.Height, media.Blurhash, string(variants), media.ID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MarkFailed record that media could not be processed, so it is not processed again
+func (repository media) MarkFailed(mediaId uint64) error {
+	statement, err := repository.db.Prepare("UPDATE media SET processing_failed = true WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(mediaId); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Delete remove media, its content must be removed from the blob store by the caller
+func (repository media) Delete(mediaId uint64) error {
+	statement, err := repository.db.Prepare("DELETE FROM media WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(mediaId); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteOrphan remove media only when it is still not attached to a publication, returning false when
+// it was attached meanwhile. The content must be removed by the caller after the row is gone.
+func (repository media) DeleteOrphan(mediaId uint64) (bool, error) {
+	statement, err := repository.db.Prepare("DELETE FROM media WHERE id = ? AND publication_id IS NULL")
+	if err != nil {
+		return false, err
+	}
+	defer statement.Close()
+
+	result, err := statement.Exec(mediaId)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// mediaColumns is the list read by scanMedia from table media
+const mediaColumns = "id, COALESCE(owner_id, 0), COALESCE(publication_id, 0), position, content_type, size, " +
+	"width, height, COALESCE(blurhash, ''), variants, processing_failed, storage_key, createdAt"
+
+func scanMedia(line *sql.Rows, media *models.Media) error {
+	var variants sql.NullString
+
+	if err := line.Scan(
+		&media.ID,
+		&media.OwnerId,
+		&media.PublicationId,
+		&media.Position,
+		&media.ContentType,
+		&media.Size,
+		&media.Width,
+		&media.Height,
+		&media.Blurhash,
+		&variants,
+		&media.Failed,
+		&media.StorageKey,
+		&media.CreatedAt,
+	); err != nil {
+		return err
+	}
+
+	if !variants.Valid {
+		return nil
+	}
+
+	var records []variantRecord
+	if err := json.Unmarshal([]byte(variants.String), &records); err != nil {
+		return err
+	}
+
+	media.Variants = fromVariantRecords(records)
+	return nil
+}
+
+func fromVariantRecords(records []variantRecord) []models.MediaVariant {
+	var variants []models.MediaVariant
+	for _, record := range records {
+		variants = append(variants, models.MediaVariant{
+			Size:        record.Size,
+			Width:       record.Width,
+			Height:      record.Height,
+			ContentType: record.ContentType,
+			StorageKey:  record.StorageKey,
+		})
+	}
+
+	return variants
+}
+
+// attachMedia replace the media attached to publication by mediaIds, in that order.
+// Media must belong to the publication author and not be attached to other publication
+func attachMedia(tx *sql.Tx, publicationId uint64, mediaIds []uint64) error {
+	if _, err := tx.Exec("UPDATE media SET publication_id = NULL WHERE publication_id = ?", publicationId); err != nil {
+		return err
+	}
+
+	for position, mediaId := range mediaIds {
+		result, err := tx.Exec(`
+			UPDATE media SET publication_id = ?, position = ?
+			WHERE id = ? AND publication_id IS NULL AND owner_id = (SELECT author_id FROM publications WHERE id = ?)`,
+			publicationId, position, mediaId, publicationId,
+		)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected != 1 {
+			return ErrMediaUnavailable
+		}
+	}
+
+	return nil
+}
+
+// decodeMedia read the media encoded by mediaColumn in their order, with their URLs and ids
+func decodeMedia(encoded string) ([]models.Media, []uint64, error) {
+	if encoded == "" {
+		return nil, nil, nil
+	}
+
+	var records []mediaRecord
+	if err := json.Unmarshal([]byte(encoded), &records); err != nil {
+		return nil, nil, err
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Position < records[j].Position
+	})
+
+	var media []models.Media
+	var ids []uint64
+	for _, record := range records {
+		item := models.Media{
+			ID:          record.ID,
+			ContentType: record.ContentType,
+			Size:        record.Size,
+			Width:       record.Width,
+			Height:      record.Height,
+			Variants:    fromVariantRecords(record.Variants),
+			Failed:      record.ProcessingFailed != 0,
+			Position:    record.Position,
+		}
+		if record.Blurhash != nil {
+			item.Blurhash = *record.Blurhash
+		}
+
+		media = append(media, item.WithURL())
+		ids = append(ids, record.ID)
+	}
+
+	return media, ids, nil
+}
@@ -0,0 +1,92 @@
+package memory
+
+// Block make user block other user, removing the follow relationship and pending requests between them
+func (repository *users) Block(userId, blockedId uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.users[userId]; !ok {
+		return errForeignKey
+	}
+
+	if _, ok := store.users[blockedId]; !ok {
+		return errForeignKey
+	}
+
+	addRelation(store.blocks, userId, blockedId)
+
+	delete(store.followers[userId], blockedId)
+	delete(store.followers[blockedId], userId)
+	delete(store.requests[userId], blockedId)
+	delete(store.requests[blockedId], userId)
+
+	return nil
+}
+
+// Unblock remove the block from user to other user
+func (repository *users) Unblock(userId, blockedId uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.blocks[userId], blockedId)
+	return nil
+}
+
+// IsBlocked return if one of the users blocked the other
+func (repository *users) IsBlocked(userId, otherId uint64) (bool, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.isBlocked(userId, otherId), nil
+}
+
+// Mute hide publications from muted user in user feed
+func (repository *users) Mute(userId, mutedId uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.users[userId]; !ok {
+		return errForeignKey
+	}
+
+	if _, ok := store.users[mutedId]; !ok {
+		return errForeignKey
+	}
+
+	addRelation(store.mutes, userId, mutedId)
+	return nil
+}
+
+// IsMuted return if user muted other user
+func (repository *users) IsMuted(userId, mutedId uint64) (bool, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.mutes[userId][mutedId], nil
+}
+
+// Unmute show publications from muted user again
+func (repository *users) Unmute(userId, mutedId uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.mutes[userId], mutedId)
+	return nil
+}
+
+func (store *Store) isBlocked(userId, otherId uint64) bool {
+	return store.blocks[userId][otherId] || store.blocks[otherId][userId]
+}
+
+func addRelation(relations map[uint64]map[uint64]bool, userId, otherId uint64) {
+	if relations[userId] == nil {
+		relations[userId] = map[uint64]bool{}
+	}
+	relations[userId][otherId] = true
+}
@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"sort"
+	"time"
+)
+
+type comments struct {
+	store *Store
+}
+
+// Create insert a comment in memory
+func (repository *comments) Create(comment models.Comment) (uint64, error) {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.publications[comment.PublicationId]; !ok {
+		return 0, errForeignKey
+	}
+
+	if _, ok := store.users[comment.AuthorId]; !ok {
+		return 0, errForeignKey
+	}
+
+	if _, ok := store.comments[comment.ParentCommentId]; comment.ParentCommentId != 0 && !ok {
+		return 0, errForeignKey
+	}
+
+	store.lastCommentId++
+	store.comments[store.lastCommentId] = models.Comment{
+		ID:              store.lastCommentId,
+		Content:         comment.Content,
+		PublicationId:   comment.PublicationId,
+		AuthorId:        comment.AuthorId,
+		ParentCommentId: comment.ParentCommentId,
+		CreatedAt:       time.Now(),
+	}
+
+	return store.lastCommentId, nil
+}
+
+// FindById find one comment by comment id
+func (repository *comments) FindById(commentId uint64) (models.Comment, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	comment, ok := store.comments[commentId]
+	if !ok {
+		return models.Comment{}, nil
+	}
+
+	return store.commentWithAuthor(comment), nil
+}
+
+// FindByPublication find one page of first level comments from publication, newest first, each with its replies oldest first
+func (repository *comments) FindByPublication(publicationId uint64, page pagination.Params) ([]models.Comment, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var firstLevel []models.Comment
+	for _, comment := range store.comments {
+		if comment.PublicationId == publicationId && comment.ParentCommentId == 0 {
+			firstLevel = append(firstLevel, store.commentWithAuthor(comment))
+		}
+	}
+
+	sort.Slice(firstLevel, func(i, j int) bool {
+		return pagination.Before(firstLevel[i].CreatedAt, firstLevel[i].ID, firstLevel[j].CreatedAt, firstLevel[j].ID)
+	})
+
+	var result []models.Comment
+	for _, comment := range firstLevel {
+		if len(result) == page.FetchLimit() {
+			break
+		}
+
+		if page.Includes(comment.CreatedAt, comment.ID) {
+			result = append(result, comment)
+		}
+	}
+
+	for i := range result {
+		for _, reply := range store.comments {
+			if reply.ParentCommentId == result[i].ID {
+				result[i].Replies = append(result[i].Replies, store.commentWithAuthor(reply))
+			}
+		}
+		sortComments(result[i].Replies)
+	}
+
+	return result, nil
+}
+
+// Update edit comment content
+func (repository *comments) Update(commentId uint64, comment models.Comment) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if existing, ok := store.comments[commentId]; ok {
+		existing.Content = comment.Content
+		store.comments[commentId] = existing
+	}
+
+	return nil
+}
+
+// Delete remove comment and its replies
+func (repository *comments) Delete(commentId uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.deleteComment(commentId)
+	return nil
+}
+
+func (store *Store) commentWithAuthor(comment models.Comment) models.Comment {
+	comment.AuthorNick = store.users[comment.AuthorId].Nick
+	return comment
+}
+
+func sortComments(comments []models.Comment) {
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].ID < comments[j].ID
+	})
+}
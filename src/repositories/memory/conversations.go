@@ -0,0 +1,216 @@
+package memory
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"sort"
+	"time"
+)
+
+type conversations struct {
+	store *Store
+}
+
+// Create insert a conversation with all members from conversation.MemberIds
+func (repository *conversations) Create(conversation models.Conversation) (uint64, error) {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var members []models.ConversationMember
+	for _, memberId := range conversation.MemberIds {
+		if _, ok := store.users[memberId]; !ok {
+			return 0, errForeignKey
+		}
+		members = append(members, models.ConversationMember{UserId: memberId})
+	}
+
+	now := time.Now()
+	store.lastConversationId++
+	store.conversations[store.lastConversationId] = models.Conversation{
+		ID:        store.lastConversationId,
+		Members:   members,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	return store.lastConversationId, nil
+}
+
+// FindDirect return the id of the conversation that has only the two users, zero when there is none
+func (repository *conversations) FindDirect(userId, otherId uint64) (uint64, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	for id, conversation := range store.conversations {
+		if len(conversation.Members) == 2 && conversation.HasMember(userId) && conversation.HasMember(otherId) {
+			return id, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// FindById find one conversation with its members
+func (repository *conversations) FindById(conversationId uint64) (models.Conversation, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	conversation, ok := store.conversations[conversationId]
+	if !ok {
+		return models.Conversation{}, nil
+	}
+
+	return store.withMembers(conversation), nil
+}
+
+// FindByUser find one page of conversations from user, the most recently active first
+func (repository *conversations) FindByUser(userId uint64, page pagination.Params) ([]models.Conversation, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var conversations []models.Conversation
+	for _, conversation := range store.conversations {
+		if conversation.HasMember(userId) {
+			conversations = append(conversations, store.withMembers(conversation))
+		}
+	}
+
+	sort.Slice(conversations, func(i, j int) bool {
+		return pagination.Before(conversations[i].UpdatedAt, conversations[i].ID, conversations[j].UpdatedAt, conversations[j].ID)
+	})
+
+	var result []models.Conversation
+	for _, conversation := range conversations {
+		if len(result) == page.FetchLimit() {
+			break
+		}
+
+		if page.Includes(conversation.UpdatedAt, conversation.ID) {
+			result = append(result, conversation)
+		}
+	}
+
+	return result, nil
+}
+
+// CreateMessage insert a message, moving the conversation to the top and marking it read by sender
+func (repository *conversations) CreateMessage(message models.Message) (uint64, error) {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	conversation, ok := store.conversations[message.ConversationId]
+	if !ok {
+		return 0, errForeignKey
+	}
+
+	if _, ok = store.users[message.SenderId]; !ok {
+		return 0, errForeignKey
+	}
+
+	store.lastMessageId++
+	message.ID = store.lastMessageId
+	message.SenderNick = ""
+	message.CreatedAt = time.Now()
+	store.messages[message.ID] = message
+
+	conversation.UpdatedAt = message.CreatedAt
+	setLastRead(&conversation, message.SenderId, message.ID)
+	store.conversations[conversation.ID] = conversation
+
+	return message.ID, nil
+}
+
+// FindMessages find one page of messages from conversation, newest first
+func (repository *conversations) FindMessages(conversationId uint64, page pagination.Params) ([]models.Message, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var messages []models.Message
+	for _, message := range store.messages {
+		if message.ConversationId == conversationId {
+			message.SenderNick = store.users[message.SenderId].Nick
+			messages = append(messages, message)
+		}
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return pagination.Before(messages[i].CreatedAt, messages[i].ID, messages[j].CreatedAt, messages[j].ID)
+	})
+
+	var result []models.Message
+	for _, message := range messages {
+		if len(result) == page.FetchLimit() {
+			break
+		}
+
+		if page.Includes(message.CreatedAt, message.ID) {
+			result = append(result, message)
+		}
+	}
+
+	return result, nil
+}
+
+// MarkRead move the read position from member up to message, it never goes back
+func (repository *conversations) MarkRead(conversationId, userId, messageId uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	conversation, ok := store.conversations[conversationId]
+	if !ok || store.messages[messageId].ConversationId != conversationId {
+		return nil
+	}
+
+	setLastRead(&conversation, userId, messageId)
+	store.conversations[conversationId] = conversation
+
+	return nil
+}
+
+// withMembers return a copy of conversation with member ids and nicks filled
+func (store *Store) withMembers(conversation models.Conversation) models.Conversation {
+	members := make([]models.ConversationMember, 0, len(conversation.Members))
+	memberIds := make([]uint64, 0, len(conversation.Members))
+
+	for _, member := range conversation.Members {
+		member.Nick = store.users[member.UserId].Nick
+		members = append(members, member)
+		memberIds = append(memberIds, member.UserId)
+	}
+
+	conversation.Members = members
+	conversation.MemberIds = memberIds
+	return conversation
+}
+
+func setLastRead(conversation *models.Conversation, userId, messageId uint64) {
+	members := make([]models.ConversationMember, len(conversation.Members))
+	copy(members, conversation.Members)
+
+	for i := range members {
+		if members[i].UserId == userId && members[i].LastReadMessageId < messageId {
+			members[i].LastReadMessageId = messageId
+		}
+	}
+
+	conversation.Members = members
+}
+
+func withoutMember(conversation models.Conversation, userId uint64) models.Conversation {
+	var members []models.ConversationMember
+	for _, member := range conversation.Members {
+		if member.UserId != userId {
+			members = append(members, member)
+		}
+	}
+
+	conversation.Members = members
+	return conversation
+}
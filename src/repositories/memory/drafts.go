@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/repositories"
+	"sort"
+	"time"
+)
+
+// FindDrafts return one page of the drafts and scheduled publications from author
+func (repository *publications) FindDrafts(authorId uint64, page pagination.Params) ([]models.Publication, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var drafts []models.Publication
+	for _, publication := range store.publications {
+		if publication.AuthorId == authorId && publication.Status != models.StatusPublished {
+			drafts = append(drafts, store.withOriginal(store.withAuthor(publication), authorId))
+		}
+	}
+
+	return pagePublications(drafts, page), nil
+}
+
+// UpdateDraft update a draft or scheduled publication, failing with ErrNotDraft when it was published meanwhile
+func (repository *publications) UpdateDraft(publicationId uint64, publication models.Publication) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	existing, ok := store.publications[publicationId]
+	if !ok || existing.Status == models.StatusPublished {
+		return repositories.ErrNotDraft
+	}
+
+	return store.updatePublication(existing, publication)
+}
+
+// PublishDue publish up to limit scheduled publications whose publishAt is not after now, returning their ids and authors
+func (repository *publications) PublishDue(now time.Time, limit int) ([]models.Publication, error) {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var due []models.Publication
+	for _, publication := range store.publications {
+		if publication.Status == models.StatusScheduled && !publication.PublishAt.After(now) {
+			due = append(due, publication)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].PublishAt.Equal(*due[j].PublishAt) {
+			return due[i].ID < due[j].ID
+		}
+		return due[i].PublishAt.Before(*due[j].PublishAt)
+	})
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	var published []models.Publication
+	for _, publication := range due {
+		publication.Status = models.StatusPublished
+		publication.PublishAt = nil
+		publication.CreatedAt = time.Now()
+		store.publications[publication.ID] = publication
+
+		published = append(published, models.Publication{ID: publication.ID, AuthorId: publication.AuthorId})
+	}
+
+	return published, nil
+}
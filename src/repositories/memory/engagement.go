@@ -0,0 +1,62 @@
+package memory
+
+// CountByPublications count the comments and replies of each publication, publications without comments are left out
+func (repository *comments) CountByPublications(publicationIds []uint64) (map[uint64]uint64, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	wanted := map[uint64]bool{}
+	for _, id := range publicationIds {
+		wanted[id] = true
+	}
+
+	counts := map[uint64]uint64{}
+	for _, comment := range store.comments {
+		if wanted[comment.PublicationId] {
+			counts[comment.PublicationId]++
+		}
+	}
+
+	return counts, nil
+}
+
+// CountInteractions count the likes, comments and reposts from user on publications of each author,
+// authors user never interacted with are left out
+func (repository *publications) CountInteractions(userId uint64, authorIds []uint64) (map[uint64]uint64, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	wanted := map[uint64]bool{}
+	for _, id := range authorIds {
+		wanted[id] = true
+	}
+
+	counts := map[uint64]uint64{}
+	interact := func(publicationId uint64) {
+		if publication, ok := store.publications[publicationId]; ok && wanted[publication.AuthorId] {
+			counts[publication.AuthorId]++
+		}
+	}
+
+	for publicationId, likes := range store.likes {
+		if _, liked := likes[userId]; liked {
+			interact(publicationId)
+		}
+	}
+
+	for _, comment := range store.comments {
+		if comment.AuthorId == userId {
+			interact(comment.PublicationId)
+		}
+	}
+
+	for originalId, reposters := range store.reposts {
+		if _, reposted := reposters[userId]; reposted {
+			interact(originalId)
+		}
+	}
+
+	return counts, nil
+}
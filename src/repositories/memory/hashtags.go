@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"time"
+)
+
+type hashtags struct {
+	store *Store
+}
+
+// FindPublications return one page of publications with tag that viewer is allowed to see,
+// without authors blocked in any direction
+func (repository *hashtags) FindPublications(tag string, viewerId uint64, page pagination.Params) ([]models.Publication, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var publications []models.Publication
+	for publicationId, tags := range store.hashtags {
+		publication := store.publications[publicationId]
+		if !contains(tags, tag) || publication.Status != models.StatusPublished ||
+			store.isBlocked(viewerId, publication.AuthorId) || !store.canView(publication, viewerId) {
+			continue
+		}
+		publications = append(publications, store.withOriginal(store.withAuthor(publication), viewerId))
+	}
+
+	return pagePublications(publications, page), nil
+}
+
+// FindUses count per hour the public publications created since the moment that used each hashtag,
+// publications from private accounts are not counted so trending does not leak them
+func (repository *hashtags) FindUses(since time.Time) ([]models.HashtagUse, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	type key struct {
+		tag  string
+		hour time.Time
+	}
+
+	counts := map[key]uint64{}
+	for publicationId, tags := range store.hashtags {
+		publication := store.publications[publicationId]
+		if publication.CreatedAt.Before(since) || publication.Status != models.StatusPublished ||
+			publication.Visibility != models.VisibilityPublic || store.users[publication.AuthorId].IsPrivate {
+			continue
+		}
+
+		for _, tag := range tags {
+			counts[key{tag, publication.CreatedAt.Truncate(time.Hour)}]++
+		}
+	}
+
+	var uses []models.HashtagUse
+	for key, count := range counts {
+		uses = append(uses, models.HashtagUse{Tag: key.tag, Hour: key.hour, Count: count})
+	}
+
+	return uses, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, existing := range values {
+		if existing == value {
+			return true
+		}
+	}
+
+	return false
+}
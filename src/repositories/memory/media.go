@@ -0,0 +1,212 @@
+package memory
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"sort"
+	"strings"
+	"time"
+)
+
+type media struct {
+	store *Store
+}
+
+// Create insert uploaded media not attached to any publication yet
+func (repository *media) Create(media models.Media) (uint64, error) {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.users[media.OwnerId]; !ok {
+		return 0, errForeignKey
+	}
+
+	for _, existing := range store.media {
+		if existing.StorageKey == media.StorageKey {
+			return 0, errDuplicateEntry
+		}
+	}
+
+	store.lastMediaId++
+	media.ID = store.lastMediaId
+	media.PublicationId = 0
+	media.CreatedAt = time.Now()
+	store.media[media.ID] = media
+
+	return media.ID, nil
+}
+
+// FindById find one media
+func (repository *media) FindById(mediaId uint64) (models.Media, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.media[mediaId], nil
+}
+
+// FindOrphans return up to limit media created before the moment that are not attached to any publication
+func (repository *media) FindOrphans(createdBefore time.Time, limit int) ([]models.Media, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var orphans []models.Media
+	for _, media := range store.media {
+		if media.PublicationId == 0 && media.CreatedAt.Before(createdBefore) {
+			orphans = append(orphans, media)
+		}
+	}
+
+	sort.Slice(orphans, func(i, j int) bool {
+		if orphans[i].CreatedAt.Equal(orphans[j].CreatedAt) {
+			return orphans[i].ID < orphans[j].ID
+		}
+		return orphans[i].CreatedAt.Before(orphans[j].CreatedAt)
+	})
+
+	if len(orphans) > limit {
+		orphans = orphans[:limit]
+	}
+
+	return orphans, nil
+}
+
+// FindUnprocessed return up to limit images created before the moment whose variants were never saved
+// and that did not fail processing, with id greater than afterId and ordered by id
+func (repository *media) FindUnprocessed(createdBefore time.Time, afterId uint64, limit int) ([]models.Media, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var pending []models.Media
+	for _, media := range store.media {
+		if media.Blurhash == "" && !media.Failed && strings.HasPrefix(media.ContentType, "image/") &&
+			media.CreatedAt.Before(createdBefore) && media.ID > afterId {
+			pending = append(pending, media)
+		}
+	}
+
+	sort.Slice(pending, func(i, j int) bool { return pending[i].ID < pending[j].ID })
+
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+
+	return pending, nil
+}
+
+// SaveVariants record the dimensions, blurhash and variants generated for media
+func (repository *media) SaveVariants(media models.Media) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	existing, ok := store.media[media.ID]
+	if !ok {
+		return nil
+	}
+
+	existing.Width = media.Width
+	existing.Height = media.Height
+	existing.Blurhash = media.Blurhash
+	existing.Variants = append([]models.MediaVariant{}, media.Variants...)
+	store.media[media.ID] = existing
+
+	return nil
+}
+
+// MarkFailed record that media could not be processed, so it is not processed again
+func (repository *media) MarkFailed(mediaId uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if media, ok := store.media[mediaId]; ok {
+		media.Failed = true
+		store.media[mediaId] = media
+	}
+
+	return nil
+}
+
+// Delete remove media, its content must be removed from the blob store by the caller
+func (repository *media) Delete(mediaId uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.media, mediaId)
+	return nil
+}
+
+// DeleteOrphan remove media only when it is still not attached to a publication, returning false when it was
+func (repository *media) DeleteOrphan(mediaId uint64) (bool, error) {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	media, ok := store.media[mediaId]
+	if !ok || media.PublicationId != 0 {
+		return false, nil
+	}
+
+	delete(store.media, mediaId)
+	return true, nil
+}
+
+// checkMedia verify that publication from author can reference all mediaIds, like the MySQL transaction does
+func (store *Store) checkMedia(publicationId, authorId uint64, mediaIds []uint64) error {
+	for _, mediaId := range mediaIds {
+		media, ok := store.media[mediaId]
+		if !ok || media.OwnerId != authorId || (media.PublicationId != 0 && media.PublicationId != publicationId) {
+			return repositories.ErrMediaUnavailable
+		}
+	}
+
+	return nil
+}
+
+// attachMedia replace the media attached to publication by mediaIds, already verified by checkMedia
+func (store *Store) attachMedia(publicationId uint64, mediaIds []uint64) {
+	store.detachMedia(publicationId)
+
+	for position, mediaId := range mediaIds {
+		media := store.media[mediaId]
+		media.PublicationId = publicationId
+		media.Position = position
+		store.media[mediaId] = media
+	}
+
+	if len(mediaIds) > 0 {
+		store.mediaOrder[publicationId] = append([]uint64{}, mediaIds...)
+	}
+}
+
+// detachMedia leave the media from publication without publication, like ON DELETE SET NULL does
+func (store *Store) detachMedia(publicationId uint64) {
+	for _, mediaId := range store.mediaOrder[publicationId] {
+		media := store.media[mediaId]
+		media.PublicationId = 0
+		media.Position = 0
+		store.media[mediaId] = media
+	}
+
+	delete(store.mediaOrder, publicationId)
+}
+
+// publicationMedia return the media attached to publication in their order, like mediaColumn does
+func (store *Store) publicationMedia(publicationId uint64) []models.Media {
+	var media []models.Media
+	for _, mediaId := range store.mediaOrder[publicationId] {
+		attached := store.media[mediaId]
+		attached.OwnerId = 0
+		attached.PublicationId = 0
+		attached.StorageKey = ""
+		attached.CreatedAt = time.Time{}
+		media = append(media, attached.WithURL())
+	}
+
+	return media
+}
@@ -0,0 +1,14 @@
+package memory_test
+
+import (
+	"api/src/repositories"
+	"api/src/repositories/memory"
+	"api/src/repositories/repositorytest"
+	"testing"
+)
+
+func TestRepositories(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositories.Repositories {
+		return memory.NewRepositories()
+	})
+}
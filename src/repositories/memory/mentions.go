@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"strings"
+)
+
+// FindByMention return one page of publications that mention user and viewer is allowed to see,
+// without authors blocked in any direction by viewer
+func (repository *publications) FindByMention(userId, viewerId uint64, page pagination.Params) ([]models.Publication, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var publications []models.Publication
+	for publicationId, mentions := range store.mentions {
+		publication := store.publications[publicationId]
+		if !mentionsUser(mentions, userId) || publication.Status != models.StatusPublished ||
+			store.isBlocked(viewerId, publication.AuthorId) || !store.canView(publication, viewerId) {
+			continue
+		}
+		publications = append(publications, store.withOriginal(store.withAuthor(publication), viewerId))
+	}
+
+	return pagePublications(publications, page), nil
+}
+
+// resolveMentions find the user of each mention like MySQL repository does, comparing nicks without case.
+// Unknown nicks and users that blocked the author are dropped
+func (store *Store) resolveMentions(authorId uint64, mentions []models.Mention) []models.Mention {
+	var resolved []models.Mention
+
+	for _, mention := range mentions {
+		for _, user := range store.users {
+			if strings.EqualFold(user.Nick, mention.Nick) && !store.blocks[user.ID][authorId] {
+				resolved = append(resolved, models.Mention{Offset: mention.Offset, Length: mention.Length, UserId: user.ID})
+				break
+			}
+		}
+	}
+
+	return resolved
+}
+
+// deleteMentionsOf remove the mentions to user from every publication
+func (store *Store) deleteMentionsOf(userId uint64) {
+	for publicationId, mentions := range store.mentions {
+		var kept []models.Mention
+		for _, mention := range mentions {
+			if mention.UserId != userId {
+				kept = append(kept, mention)
+			}
+		}
+		store.mentions[publicationId] = kept
+	}
+}
+
+func mentionsUser(mentions []models.Mention, userId uint64) bool {
+	for _, mention := range mentions {
+		if mention.UserId == userId {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"sort"
+	"time"
+)
+
+type notifications struct {
+	store *Store
+}
+
+// Create insert a notification in user inbox
+func (repository *notifications) Create(notification models.Notification) (uint64, error) {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.users[notification.UserId]; !ok {
+		return 0, errForeignKey
+	}
+
+	return store.createNotification(notification)
+}
+
+// CreateForFollowers insert the notification in the inbox of every follower from actor
+func (repository *notifications) CreateForFollowers(notification models.Notification) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for followerId := range store.followers[notification.ActorId] {
+		notification.UserId = followerId
+		if _, err := store.createNotification(notification); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Find return one page from user inbox, newest first
+func (repository *notifications) Find(userId uint64, page pagination.Params) ([]models.Notification, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var notifications []models.Notification
+	for _, notification := range store.notifications {
+		if notification.UserId == userId {
+			notification.ActorNick = store.users[notification.ActorId].Nick
+			notifications = append(notifications, notification)
+		}
+	}
+
+	sort.Slice(notifications, func(i, j int) bool {
+		return pagination.Before(notifications[i].CreatedAt, notifications[i].ID, notifications[j].CreatedAt, notifications[j].ID)
+	})
+
+	var result []models.Notification
+	for _, notification := range notifications {
+		if len(result) == page.FetchLimit() {
+			break
+		}
+
+		if page.Includes(notification.CreatedAt, notification.ID) {
+			result = append(result, notification)
+		}
+	}
+
+	return result, nil
+}
+
+// CountUnread return how many notifications from user inbox were not read
+func (repository *notifications) CountUnread(userId uint64) (uint64, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var unread uint64
+	for _, notification := range store.notifications {
+		if notification.UserId == userId && !notification.Read {
+			unread++
+		}
+	}
+
+	return unread, nil
+}
+
+// MarkAsRead mark notifications from user inbox as read, all of them when ids is empty
+func (repository *notifications) MarkAsRead(userId uint64, ids []uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	selected := map[uint64]bool{}
+	for _, id := range ids {
+		selected[id] = true
+	}
+
+	for id, notification := range store.notifications {
+		if notification.UserId == userId && (len(ids) == 0 || selected[id]) {
+			notification.Read = true
+			store.notifications[id] = notification
+		}
+	}
+
+	return nil
+}
+
+func (store *Store) createNotification(notification models.Notification) (uint64, error) {
+	if _, ok := store.users[notification.ActorId]; !ok {
+		return 0, errForeignKey
+	}
+
+	store.lastNotificationId++
+	notification.ID = store.lastNotificationId
+	notification.ActorNick = ""
+	notification.Read = false
+	notification.CreatedAt = time.Now()
+	store.notifications[notification.ID] = notification
+
+	return notification.ID, nil
+}
@@ -0,0 +1,285 @@
+package memory
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/repositories"
+	"sort"
+	"time"
+)
+
+type publications struct {
+	store *Store
+}
+
+// Create insert a publication in memory
+func (repository *publications) Create(publication models.Publication) (uint64, error) {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.users[publication.AuthorId]; !ok {
+		return 0, errForeignKey
+	}
+
+	if _, ok := store.publications[publication.QuoteOfId]; publication.QuoteOfId != 0 && !ok {
+		return 0, errForeignKey
+	}
+
+	if err := store.checkMedia(0, publication.AuthorId, publication.MediaIds); err != nil {
+		return 0, err
+	}
+
+	if publication.Status == "" {
+		publication.Status = models.StatusPublished
+	}
+
+	store.lastPublicationId++
+	store.publications[store.lastPublicationId] = models.Publication{
+		ID:         store.lastPublicationId,
+		Title:      publication.Title,
+		Content:    publication.Content,
+		Visibility: publication.Visibility,
+		Status:     publication.Status,
+		PublishAt:  publication.PublishAt,
+		AuthorId:   publication.AuthorId,
+		QuoteOfId:  publication.QuoteOfId,
+		CreatedAt:  time.Now(),
+	}
+	store.publicationIndex.add(store.lastPublicationId, publicationText(publication))
+	store.hashtags[store.lastPublicationId] = publication.Hashtags
+	store.mentions[store.lastPublicationId] = store.resolveMentions(publication.AuthorId, publication.Mentions)
+	store.attachMedia(store.lastPublicationId, publication.MediaIds)
+
+	return store.lastPublicationId, nil
+}
+
+// FindById find one publication that viewer is allowed to see, reposts also need the original to be visible
+func (repository *publications) FindById(publicationId, viewerId uint64) (models.Publication, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	publication, ok := store.publications[publicationId]
+	if !ok || !store.canView(publication, viewerId) || !store.canViewRepost(publication, viewerId) {
+		return models.Publication{}, nil
+	}
+
+	return store.withOriginal(store.withAuthor(publication), viewerId), nil
+}
+
+// Find return one page of the feed, with publications from user and from followed users
+// that are neither blocked nor muted by user. Reposts of an original already in the feed are shown
+// only once, in the position of the most recent one
+func (repository *publications) Find(userId uint64, page pagination.Params) ([]models.Publication, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.findFeed(userId, func(publication models.Publication) bool {
+		return publication.AuthorId == userId || store.follows(publication.AuthorId, userId)
+	}, page), nil
+}
+
+// FindTimeline return one page of the feed of user made only of the publications with the received ids
+// and of the ones from popular authors, filtered and ordered like Find. Publications from authors user
+// no longer follows are left out, so stale ids are harmless
+func (repository *publications) FindTimeline(userId uint64, publicationIds, popularIds []uint64, page pagination.Params) ([]models.Publication, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	cached, popular := map[uint64]bool{}, map[uint64]bool{}
+	for _, id := range publicationIds {
+		cached[id] = true
+	}
+	for _, id := range popularIds {
+		popular[id] = true
+	}
+
+	return store.findFeed(userId, func(publication models.Publication) bool {
+		isFollowed := publication.AuthorId == userId || store.follows(publication.AuthorId, userId)
+		return isFollowed && (cached[publication.ID] || popular[publication.AuthorId])
+	}, page), nil
+}
+
+// findFeed return one page of the publications accepted by isMember that user is allowed to see in the feed
+func (store *Store) findFeed(userId uint64, isMember func(models.Publication) bool, page pagination.Params) []models.Publication {
+	var publications []models.Publication
+	for _, publication := range store.publications {
+		isHidden := store.isBlocked(userId, publication.AuthorId) || store.mutes[userId][publication.AuthorId] ||
+			store.mutes[userId][store.publications[publication.RepostOfId].AuthorId]
+		isPublished := publication.Status == models.StatusPublished
+		if isMember(publication) && isPublished && !isHidden && store.canView(publication, userId) && store.canViewRepost(publication, userId) {
+			publications = append(publications, store.withOriginal(store.withAuthor(publication), userId))
+		}
+	}
+
+	return pagePublications(distinctReposts(publications), page)
+}
+
+// Update edit publication title, content, visibility and status, publishing a draft moves it to the top of the feed
+// and editing the texts of a published one keeps the previous version as a revision
+func (repository *publications) Update(publicationId uint64, publication models.Publication) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if existing, ok := store.publications[publicationId]; ok {
+		return store.updatePublication(existing, publication)
+	}
+
+	return nil
+}
+
+// updatePublication change existing with its revision, hashtags, mentions and media, like the MySQL transaction does
+func (store *Store) updatePublication(existing, publication models.Publication) error {
+	if err := store.checkMedia(existing.ID, existing.AuthorId, publication.MediaIds); err != nil {
+		return err
+	}
+
+	if publication.Status == "" {
+		publication.Status = models.StatusPublished
+	}
+
+	if existing.Status != models.StatusPublished && publication.Status == models.StatusPublished {
+		existing.CreatedAt = time.Now()
+	}
+
+	if existing.Status == models.StatusPublished && (existing.Title != publication.Title || existing.Content != publication.Content) {
+		store.saveRevision(existing)
+		editedAt := time.Now()
+		existing.EditedAt = &editedAt
+	}
+
+	existing.Title = publication.Title
+	existing.Content = publication.Content
+	existing.Visibility = publication.Visibility
+	existing.Status = publication.Status
+	existing.PublishAt = publication.PublishAt
+	store.publications[existing.ID] = existing
+	store.publicationIndex.add(existing.ID, publicationText(existing))
+	store.hashtags[existing.ID] = publication.Hashtags
+	store.mentions[existing.ID] = store.resolveMentions(existing.AuthorId, publication.Mentions)
+	store.attachMedia(existing.ID, publication.MediaIds)
+
+	return nil
+}
+
+// Delete remove publication with its likes and comments
+func (repository *publications) Delete(publicationId uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.deletePublication(publicationId)
+	return nil
+}
+
+// Like register that user liked the publication and increment likes count
+func (repository *publications) Like(publicationId, userId uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	publication, ok := store.publications[publicationId]
+	if !ok {
+		return errForeignKey
+	}
+
+	if _, ok := store.users[userId]; !ok {
+		return errForeignKey
+	}
+
+	if _, liked := store.likes[publicationId][userId]; liked {
+		return repositories.ErrAlreadyLiked
+	}
+
+	if store.likes[publicationId] == nil {
+		store.likes[publicationId] = map[uint64]time.Time{}
+	}
+	store.likes[publicationId][userId] = time.Now()
+
+	publication.Likes++
+	store.publications[publicationId] = publication
+
+	return nil
+}
+
+// Unlike remove user like from publication and decrement likes count
+func (repository *publications) Unlike(publicationId, userId uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, liked := store.likes[publicationId][userId]; !liked {
+		return repositories.ErrNotLiked
+	}
+
+	delete(store.likes[publicationId], userId)
+	store.decrementLikes(publicationId)
+
+	return nil
+}
+
+// FindLikesByPublicationId find one page of users that liked the publication, most recent like first
+func (repository *publications) FindLikesByPublicationId(publicationId uint64, page pagination.Params) ([]models.User, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var users []models.User
+	for userId, likedAt := range store.likes[publicationId] {
+		user := publicUser(store.users[userId])
+		user.RelatedAt = likedAt
+		users = append(users, user)
+	}
+
+	return pageRelated(users, page), nil
+}
+
+// canView apply the same visibility rules of MySQL repository
+func (store *Store) canView(publication models.Publication, viewerId uint64) bool {
+	switch {
+	case publication.AuthorId == viewerId:
+		return true
+	case publication.Status != models.StatusPublished:
+		return false
+	case publication.Visibility == models.VisibilityPublic && !store.users[publication.AuthorId].IsPrivate:
+		return true
+	case publication.Visibility == models.VisibilityPublic, publication.Visibility == models.VisibilityFollowers:
+		return store.follows(publication.AuthorId, viewerId)
+	}
+
+	return false
+}
+
+func (store *Store) withAuthor(publication models.Publication) models.Publication {
+	publication.AuthorNick = store.users[publication.AuthorId].Nick
+	publication.Reposts = uint64(len(store.reposts[publication.ID]))
+	publication.Hashtags = models.ExtractHashtags(publication.Content)
+	publication.Mentions = store.mentions[publication.ID]
+	publication.MediaIds = store.mediaOrder[publication.ID]
+	publication.Media = store.publicationMedia(publication.ID)
+	return publication
+}
+
+// pagePublications order publications like MySQL repository and return the page after cursor
+func pagePublications(publications []models.Publication, page pagination.Params) []models.Publication {
+	sort.Slice(publications, func(i, j int) bool {
+		return pagination.Before(publications[i].CreatedAt, publications[i].ID, publications[j].CreatedAt, publications[j].ID)
+	})
+
+	var result []models.Publication
+	for _, publication := range publications {
+		if len(result) == page.FetchLimit() {
+			break
+		}
+
+		if page.Includes(publication.CreatedAt, publication.ID) {
+			result = append(result, publication)
+		}
+	}
+
+	return result
+}
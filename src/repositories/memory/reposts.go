@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/repositories"
+	"sort"
+	"time"
+)
+
+// Repost create a publication from user sharing the original, visible only where the original is
+func (repository *publications) Repost(originalId, userId uint64) (uint64, error) {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.users[userId]; !ok {
+		return 0, errForeignKey
+	}
+
+	if _, ok := store.publications[originalId]; !ok {
+		return 0, errForeignKey
+	}
+
+	if _, reposted := store.reposts[originalId][userId]; reposted {
+		return 0, repositories.ErrAlreadyReposted
+	}
+
+	store.lastPublicationId++
+	store.publications[store.lastPublicationId] = models.Publication{
+		ID:         store.lastPublicationId,
+		Visibility: models.VisibilityPublic,
+		Status:     models.StatusPublished,
+		AuthorId:   userId,
+		RepostOfId: originalId,
+		CreatedAt:  time.Now(),
+	}
+
+	if store.reposts[originalId] == nil {
+		store.reposts[originalId] = map[uint64]uint64{}
+	}
+	store.reposts[originalId][userId] = store.lastPublicationId
+
+	return store.lastPublicationId, nil
+}
+
+// Unrepost remove the repost of the original made by user
+func (repository *publications) Unrepost(originalId, userId uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	repostId, reposted := store.reposts[originalId][userId]
+	if !reposted {
+		return repositories.ErrNotReposted
+	}
+
+	store.deletePublication(repostId)
+	return nil
+}
+
+// canViewRepost apply to reposts the visibility of their original, like repostVisible does
+func (store *Store) canViewRepost(publication models.Publication, viewerId uint64) bool {
+	if publication.RepostOfId == 0 {
+		return true
+	}
+
+	original, ok := store.publications[publication.RepostOfId]
+	return ok && store.canView(original, viewerId) && !store.isBlocked(viewerId, original.AuthorId)
+}
+
+// withOriginal fill the original of a repost or quote when viewer is allowed to see it
+func (store *Store) withOriginal(publication models.Publication, viewerId uint64) models.Publication {
+	original, ok := store.publications[publication.OriginalId()]
+	if ok && store.canView(original, viewerId) && !store.isBlocked(viewerId, original.AuthorId) {
+		original = store.withAuthor(original)
+		publication.Original = &original
+	}
+
+	return publication
+}
+
+// deleteReposts remove the reposts of publication and leave its quotes without original,
+// like the foreign keys from repost_of_id and quote_of_id do
+func (store *Store) deleteReposts(publicationId uint64) {
+	for _, repostId := range store.reposts[publicationId] {
+		store.deletePublication(repostId)
+	}
+	delete(store.reposts, publicationId)
+
+	for id, publication := range store.publications {
+		if publication.QuoteOfId == publicationId {
+			publication.QuoteOfId = 0
+			store.publications[id] = publication
+		}
+	}
+}
+
+// distinctReposts keep only the most recent publication among an original and its reposts, like the feed query does
+func distinctReposts(publications []models.Publication) []models.Publication {
+	sort.Slice(publications, func(i, j int) bool {
+		return pagination.Before(publications[i].CreatedAt, publications[i].ID, publications[j].CreatedAt, publications[j].ID)
+	})
+
+	var distinct []models.Publication
+	seen := map[uint64]bool{}
+	for _, publication := range publications {
+		item := publication.ID
+		if publication.RepostOfId != 0 {
+			item = publication.RepostOfId
+		}
+
+		if !seen[item] {
+			seen[item] = true
+			distinct = append(distinct, publication)
+		}
+	}
+
+	return distinct
+}
@@ -0,0 +1,29 @@
+package memory
+
+import "api/src/models"
+
+// FindRevisions return the previous versions of publication, oldest first
+func (repository *publications) FindRevisions(publicationId uint64) ([]models.PublicationRevision, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return append([]models.PublicationRevision(nil), store.revisions[publicationId]...), nil
+}
+
+// saveRevision keep the texts of publication dated when they were written, like MySQL repository does
+func (store *Store) saveRevision(publication models.Publication) {
+	createdAt := publication.CreatedAt
+	if publication.EditedAt != nil {
+		createdAt = *publication.EditedAt
+	}
+
+	store.lastRevisionId++
+	store.revisions[publication.ID] = append(store.revisions[publication.ID], models.PublicationRevision{
+		ID:            store.lastRevisionId,
+		PublicationId: publication.ID,
+		Title:         publication.Title,
+		Content:       publication.Content,
+		CreatedAt:     createdAt,
+	})
+}
@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"api/src/models"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// index is an inverted index from terms to the documents containing them, guarded by the store lock
+type index struct {
+	postings map[string]map[uint64]int
+	terms    map[uint64][]string
+}
+
+// match is one document found by the index with its relevance
+type match struct {
+	id    uint64
+	score float64
+}
+
+func newIndex() *index {
+	return &index{
+		postings: map[string]map[uint64]int{},
+		terms:    map[uint64][]string{},
+	}
+}
+
+// add index the text from document, replacing what was indexed before for it
+func (idx *index) add(id uint64, text string) {
+	idx.remove(id)
+
+	terms := tokenize(text)
+	for _, term := range terms {
+		if idx.postings[term] == nil {
+			idx.postings[term] = map[uint64]int{}
+		}
+		idx.postings[term][id]++
+	}
+
+	idx.terms[id] = terms
+}
+
+// remove drop document from the index
+func (idx *index) remove(id uint64) {
+	for _, term := range idx.terms[id] {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+
+	delete(idx.terms, id)
+}
+
+// search return documents with any term from query, ordered by tf-idf relevance and id descending
+func (idx *index) search(query string) []match {
+	scores := map[uint64]float64{}
+	seen := map[string]bool{}
+
+	for _, term := range tokenize(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		documents := idx.postings[term]
+		if len(documents) == 0 {
+			continue
+		}
+
+		idf := math.Log(1 + float64(len(idx.terms))/float64(len(documents)))
+		for id, frequency := range documents {
+			scores[id] += float64(frequency) * idf
+		}
+	}
+
+	matches := make([]match, 0, len(scores))
+	for id, score := range scores {
+		matches = append(matches, match{id, score})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score == matches[j].score {
+			return matches[i].id > matches[j].id
+		}
+		return matches[i].score > matches[j].score
+	})
+
+	return matches
+}
+
+// tokenize split text in lower case words made of letters and digits
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func userText(user models.User) string {
+	return user.Name + " " + user.Nick
+}
+
+func publicationText(publication models.Publication) string {
+	return publication.Title + " " + publication.Content
+}
+
+type search struct {
+	store *Store
+}
+
+// SearchUsers return users whose name or nick match query ordered by relevance,
+// without users blocked by or blocking viewer
+func (repository *search) SearchUsers(query string, viewerId uint64, limit, offset int) ([]models.User, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var users []models.User
+	for _, match := range store.userIndex.search(query) {
+		if store.isBlocked(viewerId, match.id) {
+			continue
+		}
+		users = append(users, publicUser(store.users[match.id]))
+	}
+
+	return window(users, limit, offset), nil
+}
+
+// SearchPublications return publications whose title or content match query ordered by relevance,
+// only the ones viewer is allowed to see and without authors blocked in any direction
+func (repository *search) SearchPublications(query string, viewerId uint64, limit, offset int) ([]models.Publication, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var publications []models.Publication
+	for _, match := range store.publicationIndex.search(query) {
+		publication := store.publications[match.id]
+		if publication.Status != models.StatusPublished || store.isBlocked(viewerId, publication.AuthorId) ||
+			!store.canView(publication, viewerId) {
+			continue
+		}
+		publications = append(publications, store.withOriginal(store.withAuthor(publication), viewerId))
+	}
+
+	return window(publications, limit, offset), nil
+}
+
+// window return the items between offset and offset plus limit, like LIMIT and OFFSET do
+func window[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+
+	items = items[offset:]
+	if len(items) > limit {
+		items = items[:limit]
+	}
+
+	return items
+}
@@ -0,0 +1,231 @@
+// Package memory implement the repositories in memory, used to test controllers without a database
+package memory
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"sync"
+	"time"
+)
+
+// Store hold the state shared by all in memory repositories, mirroring the MySQL tables
+type Store struct {
+	mu sync.RWMutex
+
+	lastUserId         uint64
+	lastPublicationId  uint64
+	lastCommentId      uint64
+	lastRefreshTokenId uint64
+	lastNotificationId uint64
+	lastConversationId uint64
+	lastMessageId      uint64
+	lastMediaId        uint64
+	lastRevisionId     uint64
+
+	users           map[uint64]models.User
+	followers       map[uint64]map[uint64]time.Time // when each follower of a user followed
+	requests        map[uint64]map[uint64]time.Time
+	blocks          map[uint64]map[uint64]bool
+	mutes           map[uint64]map[uint64]bool
+	dismissals      map[uint64]map[uint64]bool // suggestions dismissed by each user
+	publications    map[uint64]models.Publication
+	likes           map[uint64]map[uint64]time.Time
+	revisions       map[uint64][]models.PublicationRevision // previous versions of each publication, oldest first
+	reposts         map[uint64]map[uint64]uint64            // repost ids by original and reposter
+	hashtags        map[uint64][]string
+	mentions        map[uint64][]models.Mention
+	media           map[uint64]models.Media
+	mediaOrder      map[uint64][]uint64 // media ids attached to each publication in their order
+	comments        map[uint64]models.Comment
+	refreshTokens   map[uint64]models.RefreshToken
+	notifications   map[uint64]models.Notification
+	conversations   map[uint64]models.Conversation
+	messages        map[uint64]models.Message
+	revokedTokens   map[string]time.Time
+	revokedFamilies map[string]time.Time
+
+	userIndex        *index
+	publicationIndex *index
+}
+
+// NewStore create an empty store
+func NewStore() *Store {
+	return &Store{
+		users:           map[uint64]models.User{},
+		followers:       map[uint64]map[uint64]time.Time{},
+		requests:        map[uint64]map[uint64]time.Time{},
+		blocks:          map[uint64]map[uint64]bool{},
+		mutes:           map[uint64]map[uint64]bool{},
+		dismissals:      map[uint64]map[uint64]bool{},
+		publications:    map[uint64]models.Publication{},
+		likes:           map[uint64]map[uint64]time.Time{},
+		revisions:       map[uint64][]models.PublicationRevision{},
+		reposts:         map[uint64]map[uint64]uint64{},
+		hashtags:        map[uint64][]string{},
+		mentions:        map[uint64][]models.Mention{},
+		media:           map[uint64]models.Media{},
+		mediaOrder:      map[uint64][]uint64{},
+		comments:        map[uint64]models.Comment{},
+		refreshTokens:   map[uint64]models.RefreshToken{},
+		notifications:   map[uint64]models.Notification{},
+		conversations:   map[uint64]models.Conversation{},
+		messages:        map[uint64]models.Message{},
+		revokedTokens:   map[string]time.Time{},
+		revokedFamilies: map[string]time.Time{},
+
+		userIndex:        newIndex(),
+		publicationIndex: newIndex(),
+	}
+}
+
+// NewRepositories create all repositories sharing one empty store
+func NewRepositories() repositories.Repositories {
+	return NewStore().Repositories()
+}
+
+// Repositories return all repositories backed by the store
+func (store *Store) Repositories() repositories.Repositories {
+	return repositories.Repositories{
+		Users:         &users{store},
+		Publications:  &publications{store},
+		Comments:      &comments{store},
+		Tokens:        &tokens{store},
+		Notifications: &notifications{store},
+		Conversations: &conversations{store},
+		Hashtags:      &hashtags{store},
+		Media:         &media{store},
+		Search:        &search{store},
+	}
+}
+
+// deleteUser remove user and everything that reference the user, like ON DELETE CASCADE does
+func (store *Store) deleteUser(userId uint64) {
+	delete(store.users, userId)
+	store.userIndex.remove(userId)
+	delete(store.followers, userId)
+
+	for _, followers := range store.followers {
+		delete(followers, userId)
+	}
+
+	delete(store.requests, userId)
+	for _, requests := range store.requests {
+		delete(requests, userId)
+	}
+
+	for _, relations := range []map[uint64]map[uint64]bool{store.blocks, store.mutes, store.dismissals} {
+		delete(relations, userId)
+		for _, users := range relations {
+			delete(users, userId)
+		}
+	}
+
+	for id, publication := range store.publications {
+		if publication.AuthorId == userId {
+			store.deletePublication(id)
+		}
+	}
+
+	store.deleteMentionsOf(userId)
+
+	for id, media := range store.media {
+		if media.OwnerId == userId {
+			media.OwnerId = 0
+			store.media[id] = media
+		}
+	}
+
+	for publicationId, likes := range store.likes {
+		if _, ok := likes[userId]; ok {
+			delete(likes, userId)
+			store.decrementLikes(publicationId)
+		}
+	}
+
+	for id, comment := range store.comments {
+		if comment.AuthorId == userId {
+			store.deleteComment(id)
+		}
+	}
+
+	for id, token := range store.refreshTokens {
+		if token.UserId == userId {
+			delete(store.refreshTokens, id)
+		}
+	}
+
+	for id, notification := range store.notifications {
+		if notification.UserId == userId || notification.ActorId == userId {
+			delete(store.notifications, id)
+		}
+	}
+
+	for id, conversation := range store.conversations {
+		if conversation.HasMember(userId) {
+			store.conversations[id] = withoutMember(conversation, userId)
+		}
+	}
+
+	for id, message := range store.messages {
+		if message.SenderId == userId {
+			delete(store.messages, id)
+		}
+	}
+}
+
+func (store *Store) deletePublication(publicationId uint64) {
+	if publication, ok := store.publications[publicationId]; ok && publication.RepostOfId != 0 {
+		delete(store.reposts[publication.RepostOfId], publication.AuthorId)
+	}
+	store.deleteReposts(publicationId)
+
+	delete(store.publications, publicationId)
+	store.publicationIndex.remove(publicationId)
+	delete(store.likes, publicationId)
+	delete(store.revisions, publicationId)
+	delete(store.hashtags, publicationId)
+	delete(store.mentions, publicationId)
+	store.detachMedia(publicationId)
+
+	for id, comment := range store.comments {
+		if comment.PublicationId == publicationId {
+			delete(store.comments, id)
+		}
+	}
+
+	for id, notification := range store.notifications {
+		if notification.PublicationId == publicationId {
+			delete(store.notifications, id)
+		}
+	}
+}
+
+func (store *Store) deleteComment(commentId uint64) {
+	delete(store.comments, commentId)
+
+	for id, notification := range store.notifications {
+		if notification.CommentId == commentId {
+			delete(store.notifications, id)
+		}
+	}
+
+	for id, comment := range store.comments {
+		if comment.ParentCommentId == commentId {
+			store.deleteComment(id)
+		}
+	}
+}
+
+func (store *Store) decrementLikes(publicationId uint64) {
+	publication, ok := store.publications[publicationId]
+	if ok && publication.Likes > 0 {
+		publication.Likes--
+		store.publications[publicationId] = publication
+	}
+}
+
+// publicUser return user without the fields that MySQL repository does not select
+func publicUser(user models.User) models.User {
+	user.Password = ""
+	return user
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"sort"
+)
+
+// FindSuggestions return up to limit users that user may want to follow, ranked by how many users followed by user
+// follow them and how many followers of user also follow them. Users already followed or asked to be followed,
+// blocked in any direction or dismissed are left out
+func (repository *users) FindSuggestions(userId uint64, limit int) ([]models.Suggestion, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var suggestions []models.Suggestion
+	for candidateId, followers := range store.followers {
+		suggestion := models.Suggestion{User: publicUser(store.users[candidateId])}
+
+		for followerId := range followers {
+			if store.follows(followerId, userId) {
+				suggestion.FollowedByFollowing++
+			}
+			if store.follows(userId, followerId) {
+				suggestion.SharedFollowers++
+			}
+		}
+
+		if suggestion.Score() == 0 || candidateId == userId || store.follows(candidateId, userId) ||
+			store.dismissals[userId][candidateId] || store.isBlocked(userId, candidateId) {
+			continue
+		}
+
+		if _, ok := store.requests[candidateId][userId]; ok {
+			continue
+		}
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Score() != b.Score() {
+			return a.Score() > b.Score()
+		}
+		if a.FollowedByFollowing != b.FollowedByFollowing {
+			return a.FollowedByFollowing > b.FollowedByFollowing
+		}
+		return a.User.ID > b.User.ID
+	})
+
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	return suggestions, nil
+}
+
+// DismissSuggestion stop suggesting dismissed user to user
+func (repository *users) DismissSuggestion(userId, dismissedId uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.users[userId]; !ok {
+		return errForeignKey
+	}
+
+	if _, ok := store.users[dismissedId]; !ok {
+		return errForeignKey
+	}
+
+	addRelation(store.dismissals, userId, dismissedId)
+	return nil
+}
+
+// FindMutualFollowers find one page of users that follow both user and other user, hiding the ones blocked by user
+func (repository *users) FindMutualFollowers(userId, otherId uint64, page pagination.Params) ([]models.User, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var users []models.User
+	for followerId := range store.followers[userId] {
+		if store.follows(otherId, followerId) && !store.isBlocked(userId, followerId) {
+			users = append(users, publicUser(store.users[followerId]))
+		}
+	}
+
+	return pageUsers(users, page), nil
+}
@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"time"
+)
+
+type tokens struct {
+	store *Store
+}
+
+// CreateRefreshToken insert a refresh token in memory
+func (repository *tokens) CreateRefreshToken(token models.RefreshToken) (uint64, error) {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.insertRefreshToken(token)
+}
+
+// FindRefreshTokenByHash find refresh token by token hash
+func (repository *tokens) FindRefreshTokenByHash(tokenHash string) (models.RefreshToken, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	for _, token := range store.refreshTokens {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+
+	return models.RefreshToken{}, nil
+}
+
+// RotateRefreshToken revoke the used refresh token and insert the new one atomically
+func (repository *tokens) RotateRefreshToken(usedTokenId uint64, newToken models.RefreshToken) (uint64, error) {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	usedToken, ok := store.refreshTokens[usedTokenId]
+	if !ok || usedToken.RevokedAt != nil {
+		return 0, repositories.ErrRefreshTokenReused
+	}
+
+	now := time.Now()
+	usedToken.RevokedAt = &now
+	store.refreshTokens[usedTokenId] = usedToken
+
+	return store.insertRefreshToken(newToken)
+}
+
+// RevokeFamily revoke all refresh tokens from the same family and reject the access tokens
+// issued to it until expiresAt, when the last one of them expires
+func (repository *tokens) RevokeFamily(familyId string, expiresAt time.Time) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+	for id, token := range store.refreshTokens {
+		if token.FamilyId == familyId && token.RevokedAt == nil {
+			token.RevokedAt = &now
+			store.refreshTokens[id] = token
+		}
+	}
+
+	for id, expiration := range store.revokedFamilies {
+		if expiration.Before(now) {
+			delete(store.revokedFamilies, id)
+		}
+	}
+
+	if expiresAt.After(store.revokedFamilies[familyId]) {
+		store.revokedFamilies[familyId] = expiresAt
+	}
+
+	return nil
+}
+
+// RevokeAccessToken store access token id until it expires, removing already expired ones
+func (repository *tokens) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now()
+	for id, expiration := range store.revokedTokens {
+		if expiration.Before(now) {
+			delete(store.revokedTokens, id)
+		}
+	}
+
+	if _, ok := store.revokedTokens[jti]; !ok {
+		store.revokedTokens[jti] = expiresAt
+	}
+
+	return nil
+}
+
+// IsAccessTokenRevoked verify if access token id or the family it was issued to were revoked
+func (repository *tokens) IsAccessTokenRevoked(jti, familyId string) (bool, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	if _, revoked := store.revokedTokens[jti]; revoked {
+		return true, nil
+	}
+
+	expiresAt, revoked := store.revokedFamilies[familyId]
+	return revoked && !expiresAt.Before(time.Now()), nil
+}
+
+func (store *Store) insertRefreshToken(token models.RefreshToken) (uint64, error) {
+	if _, ok := store.users[token.UserId]; !ok {
+		return 0, errForeignKey
+	}
+
+	for _, existing := range store.refreshTokens {
+		if existing.TokenHash == token.TokenHash {
+			return 0, errDuplicateEntry
+		}
+	}
+
+	store.lastRefreshTokenId++
+	token.ID = store.lastRefreshTokenId
+	token.RevokedAt = nil
+	store.refreshTokens[token.ID] = token
+
+	return token.ID, nil
+}
@@ -0,0 +1,418 @@
+package memory
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/repositories"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	errDuplicateEntry = errors.New("Duplicate entry")
+	errForeignKey     = errors.New("Cannot add or update a child row: a foreign key constraint fails")
+)
+
+type users struct {
+	store *Store
+}
+
+// Create insert a user in memory
+func (repository *users) Create(user models.User) (uint64, error) {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, existing := range store.users {
+		if existing.Nick == user.Nick || existing.Email == user.Email {
+			return 0, errDuplicateEntry
+		}
+	}
+
+	store.lastUserId++
+	user.ID = store.lastUserId
+	user.CreatedAt = time.Now()
+	store.users[user.ID] = user
+	store.userIndex.add(user.ID, userText(user))
+
+	return user.ID, nil
+}
+
+// Find return one page of users filtered by name or nick, without users blocked by or blocking viewer
+func (repository *users) Find(nameOrNick string, viewerId uint64, page pagination.Params) ([]models.User, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	nameOrNick = strings.ToLower(nameOrNick)
+
+	var users []models.User
+	for _, user := range store.users {
+		if store.isBlocked(viewerId, user.ID) {
+			continue
+		}
+
+		if strings.Contains(strings.ToLower(user.Name), nameOrNick) ||
+			strings.Contains(strings.ToLower(user.Nick), nameOrNick) {
+			users = append(users, publicUser(user))
+		}
+	}
+
+	return pageUsers(users, page), nil
+}
+
+// FindByID return a user
+func (repository *users) FindByID(ID uint64) (models.User, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	user, ok := store.users[ID]
+	if !ok {
+		return models.User{}, nil
+	}
+
+	return publicUser(user), nil
+}
+
+// Update edit user name, nick, email and privacy, approving the pending follow requests when the account is public
+func (repository *users) Update(ID uint64, user models.User) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	existing, ok := store.users[ID]
+	if !ok {
+		return nil
+	}
+
+	for id, other := range store.users {
+		if id != ID && (other.Nick == user.Nick || other.Email == user.Email) {
+			return errDuplicateEntry
+		}
+	}
+
+	existing.Name = user.Name
+	existing.Nick = user.Nick
+	existing.Email = user.Email
+	existing.IsPrivate = user.IsPrivate
+	store.users[ID] = existing
+
+	// public accounts have no pending requests, everyone that asked becomes a follower
+	if !user.IsPrivate {
+		for requesterId := range store.requests[ID] {
+			store.addFollower(ID, requesterId)
+		}
+		delete(store.requests, ID)
+	}
+
+	store.userIndex.add(ID, userText(existing))
+
+	return nil
+}
+
+// Delete remove user and everything that belongs to the user
+func (repository *users) Delete(ID uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.deleteUser(ID)
+	return nil
+}
+
+// FindByEmail find user by email and return user id and user password hash
+func (repository *users) FindByEmail(email string) (models.User, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	for _, user := range store.users {
+		if user.Email == email {
+			return models.User{ID: user.ID, Password: user.Password}, nil
+		}
+	}
+
+	return models.User{}, nil
+}
+
+// Follower add follower user id
+func (repository *users) Follower(userId, followerId uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.users[userId]; !ok {
+		return errForeignKey
+	}
+
+	if _, ok := store.users[followerId]; !ok {
+		return errForeignKey
+	}
+
+	if store.follows(userId, followerId) {
+		return errDuplicateEntry
+	}
+
+	store.addFollower(userId, followerId)
+
+	return nil
+}
+
+// Unfollow remove follower user id, reporting if follower was following user
+func (repository *users) Unfollow(userId, followerId uint64) (bool, error) {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	following := store.follows(userId, followerId)
+	delete(store.followers[userId], followerId)
+	return following, nil
+}
+
+// FindFollowersByUserId find one page of followers from user, most recent follow first
+func (repository *users) FindFollowersByUserId(userId uint64, page pagination.Params) ([]models.User, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var followers []models.User
+	for followerId, followedAt := range store.followers[userId] {
+		follower := publicUser(store.users[followerId])
+		follower.RelatedAt = followedAt
+		followers = append(followers, follower)
+	}
+
+	return pageRelated(followers, page), nil
+}
+
+// FindFollowingByUserId find one page of users that user is following, most recent follow first
+func (repository *users) FindFollowingByUserId(userId uint64, page pagination.Params) ([]models.User, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var users []models.User
+	for followedId, followers := range store.followers {
+		if followedAt, ok := followers[userId]; ok {
+			user := publicUser(store.users[followedId])
+			user.RelatedAt = followedAt
+			users = append(users, user)
+		}
+	}
+
+	return pageRelated(users, page), nil
+}
+
+// FindFollowerIds return the ids of up to limit followers from user, in no particular order
+func (repository *users) FindFollowerIds(userId uint64, limit int) ([]uint64, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var ids []uint64
+	for followerId := range store.followers[userId] {
+		if len(ids) == limit {
+			break
+		}
+		ids = append(ids, followerId)
+	}
+
+	return ids, nil
+}
+
+// FindAudience return the users among userIds that follow author without muting the author
+func (repository *users) FindAudience(authorId uint64, userIds []uint64) ([]uint64, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var ids []uint64
+	for _, userId := range userIds {
+		if store.follows(authorId, userId) && !store.mutes[userId][authorId] {
+			ids = append(ids, userId)
+		}
+	}
+
+	return ids, nil
+}
+
+// IsFollower return if follower id follows user id
+func (repository *users) IsFollower(userId, followerId uint64) (bool, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.follows(userId, followerId), nil
+}
+
+// CreateFollowRequest add a pending request from requester to follow user
+func (repository *users) CreateFollowRequest(userId, requesterId uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.users[userId]; !ok {
+		return errForeignKey
+	}
+
+	if _, ok := store.users[requesterId]; !ok {
+		return errForeignKey
+	}
+
+	if _, ok := store.requests[userId][requesterId]; ok {
+		return repositories.ErrFollowRequestExists
+	}
+
+	if store.requests[userId] == nil {
+		store.requests[userId] = map[uint64]time.Time{}
+	}
+	store.requests[userId][requesterId] = time.Now()
+
+	return nil
+}
+
+// FindFollowRequests find one page of pending follow requests sent to user
+func (repository *users) FindFollowRequests(userId uint64, page pagination.Params) ([]models.FollowRequest, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var requests []models.FollowRequest
+	for requesterId, createdAt := range store.requests[userId] {
+		requester := store.users[requesterId]
+		requests = append(requests, models.FollowRequest{
+			UserId:        userId,
+			RequesterId:   requesterId,
+			RequesterName: requester.Name,
+			RequesterNick: requester.Nick,
+			CreatedAt:     createdAt,
+		})
+	}
+
+	sort.Slice(requests, func(i, j int) bool {
+		return pagination.Before(requests[i].CreatedAt, requests[i].RequesterId, requests[j].CreatedAt, requests[j].RequesterId)
+	})
+
+	var result []models.FollowRequest
+	for _, request := range requests {
+		if len(result) == page.FetchLimit() {
+			break
+		}
+
+		if page.Includes(request.CreatedAt, request.RequesterId) {
+			result = append(result, request)
+		}
+	}
+
+	return result, nil
+}
+
+// ApproveFollowRequest remove the pending request and make requester a follower of user
+func (repository *users) ApproveFollowRequest(userId, requesterId uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.requests[userId][requesterId]; !ok {
+		return repositories.ErrFollowRequestNotFound
+	}
+
+	delete(store.requests[userId], requesterId)
+	store.addFollower(userId, requesterId)
+
+	return nil
+}
+
+// DeleteFollowRequest remove the pending request, used to reject or cancel it
+func (repository *users) DeleteFollowRequest(userId, requesterId uint64) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.requests[userId][requesterId]; !ok {
+		return repositories.ErrFollowRequestNotFound
+	}
+
+	delete(store.requests[userId], requesterId)
+	return nil
+}
+
+// FindPasswordById find user password by user id
+func (repository *users) FindPasswordById(userId uint64) (string, error) {
+	store := repository.store
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.users[userId].Password, nil
+}
+
+// UpdateUserPassword update user password by user id
+func (repository *users) UpdateUserPassword(userId uint64, password string) error {
+	store := repository.store
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if user, ok := store.users[userId]; ok {
+		user.Password = password
+		store.users[userId] = user
+	}
+
+	return nil
+}
+
+// pageUsers order users like MySQL repository and return the page after cursor
+func pageUsers(users []models.User, page pagination.Params) []models.User {
+	sort.Slice(users, func(i, j int) bool {
+		return pagination.Before(users[i].CreatedAt, users[i].ID, users[j].CreatedAt, users[j].ID)
+	})
+
+	var result []models.User
+	for _, user := range users {
+		if len(result) == page.FetchLimit() {
+			break
+		}
+
+		if page.Includes(user.CreatedAt, user.ID) {
+			result = append(result, user)
+		}
+	}
+
+	return result
+}
+
+// pageRelated order users by when their relation began, like MySQL repository, and return the page after cursor
+func pageRelated(users []models.User, page pagination.Params) []models.User {
+	sort.Slice(users, func(i, j int) bool {
+		return pagination.Before(users[i].RelatedAt, users[i].ID, users[j].RelatedAt, users[j].ID)
+	})
+
+	var result []models.User
+	for _, user := range users {
+		if len(result) == page.FetchLimit() {
+			break
+		}
+
+		if page.Includes(user.RelatedAt, user.ID) {
+			result = append(result, user)
+		}
+	}
+
+	return result
+}
+
+// follows report if follower follows user
+func (store *Store) follows(userId, followerId uint64) bool {
+	_, ok := store.followers[userId][followerId]
+	return ok
+}
+
+// addFollower make follower follow user from now
+func (store *Store) addFollower(userId, followerId uint64) {
+	if store.followers[userId] == nil {
+		store.followers[userId] = map[uint64]time.Time{}
+	}
+	store.followers[userId][followerId] = time.Now()
+}
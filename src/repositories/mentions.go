@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// mentionsColumn read the mentions from publication p as offset:length:userId items separated by commas
+const mentionsColumn = `(
+	SELECT GROUP_CONCAT(CONCAT(pm.mention_offset, ':', pm.mention_length, ':', pm.user_id) ORDER BY pm.mention_offset)
+	FROM publication_mentions pm WHERE pm.publication_id = p.id
+)`
+
+// FindByMention return one page of publications that mention user and viewer is allowed to see,
+// without authors blocked in any direction by viewer
+func (repository Publications) FindByMention(userId, viewerId uint64, page pagination.Params) ([]models.Publication, error) {
+	cursor, cursorArgs := page.Where("p.createdAt", "p.id")
+
+	args := append([]interface{}{userId, viewerId, viewerId, viewerId, viewerId}, cursorArgs...)
+	lines, err := repository.db.Query(`
+		SELECT `+publicationColumns+` FROM publications p
+		INNER JOIN users u ON u.id = p.author_id
+		WHERE EXISTS (
+			SELECT 1 FROM publication_mentions m WHERE m.publication_id = p.id AND m.user_id = ?
+		) AND p.status = 'published' AND `+visibleToViewer+` AND `+notBlocked("p.author_id")+` AND `+cursor+`
+		ORDER BY p.createdAt DESC, p.id DESC
+		LIMIT ?`,
+		append(args, page.FetchLimit())...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var publications []models.Publication
+
+	for lines.Next() {
+		var publication models.Publication
+
+		if err = scanPublication(lines, &publication); err != nil {
+			return nil, err
+		}
+
+		publications = append(publications, publication)
+	}
+	lines.Close()
+
+	if err = embedOriginals(repository.db, publications, viewerId); err != nil {
+		return nil, err
+	}
+
+	return publications, nil
+}
+
+// saveMentions replace the mentions from publication, resolving each nick to a user.
+// Unknown nicks and users that blocked the author are not saved
+func saveMentions(tx *sql.Tx, publicationId uint64, mentions []models.Mention) error {
+	if _, err := tx.Exec("DELETE FROM publication_mentions WHERE publication_id = ?", publicationId); err != nil {
+		return err
+	}
+
+	for _, mention := range mentions {
+		if _, err := tx.Exec(`
+			INSERT INTO publication_mentions (publication_id, user_id, mention_offset, mention_length)
+			SELECT p.id, u.id, ?, ? FROM publications p
+			INNER JOIN users u ON u.nick = ?
+			WHERE p.id = ? AND NOT EXISTS (
+				SELECT 1 FROM user_blocks b WHERE b.user_id = u.id AND b.blocked_id = p.author_id
+			)`,
+			mention.Offset, mention.Length, mention.Nick, publicationId,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeMentions read the mentions encoded by mentionsColumn
+func decodeMentions(encoded string) []models.Mention {
+	if encoded == "" {
+		return nil
+	}
+
+	var mentions []models.Mention
+	for _, item := range strings.Split(encoded, ",") {
+		var mention models.Mention
+		if _, err := fmt.Sscanf(item, "%d:%d:%d", &mention.Offset, &mention.Length, &mention.UserId); err == nil {
+			mentions = append(mentions, mention)
+		}
+	}
+
+	return mentions
+}
@@ -0,0 +1,79 @@
+package repositories_test
+
+import (
+	"api/src/migrations"
+	"api/src/repositories"
+	"api/src/repositories/repositorytest"
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql" //Driver
+)
+
+// TestMySQLRepositories run the suite against the database in MYSQL_TEST_DSN, which must enable parseTime.
+// Every table of that database is truncated before each case, so never point it to real data
+func TestMySQLRepositories(t *testing.T) {
+	dsn := os.Getenv("MYSQL_TEST_DSN")
+	if dsn == "" {
+		t.Skip("MYSQL_TEST_DSN not set")
+	}
+
+	migrationDB, err := sql.Open("mysql", dsn+"&multiStatements=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer migrationDB.Close()
+
+	migrator, err := migrations.NewMigrator(migrationDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = migrator.Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	repositorytest.Run(t, func(t *testing.T) repositories.Repositories {
+		truncate(t, migrationDB)
+		return repositories.NewMySQLRepositories(db)
+	})
+}
+
+// truncate empty every table but the applied migrations
+func truncate(t *testing.T, db *sql.DB) {
+	t.Helper()
+
+	lines, err := db.Query(
+		"SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name <> 'schema_migrations'",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tables []string
+	for lines.Next() {
+		var table string
+		if err = lines.Scan(&table); err != nil {
+			t.Fatal(err)
+		}
+		tables = append(tables, table)
+	}
+	lines.Close()
+
+	statements := "SET FOREIGN_KEY_CHECKS = 0;"
+	for _, table := range tables {
+		statements += "TRUNCATE TABLE `" + table + "`;"
+	}
+	statements += "SET FOREIGN_KEY_CHECKS = 1;"
+
+	if _, err = db.Exec(statements); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"database/sql"
+	"strings"
+)
+
+type Notifications struct {
+	db *sql.DB
+}
+
+// NewNotificationRepository create one repository of notifications
+func NewNotificationRepository(db *sql.DB) NotificationRepository {
+	return &Notifications{db}
+}
+
+// Create insert a notification in user inbox
+func (repository Notifications) Create(notification models.Notification) (uint64, error) {
+	statement, err := repository.db.Prepare(
+		"INSERT INTO notifications (user_id, actor_id, type, publication_id, comment_id) values (?, ?, ?, ?, ?)",
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer statement.Close()
+
+	result, err := statement.Exec(
+		notification.UserId,
+		notification.ActorId,
+		notification.Type,
+		nullableId(notification.PublicationId),
+		nullableId(notification.CommentId),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastId), nil
+}
+
+// CreateForFollowers insert the notification in the inbox of every follower from actor
+func (repository Notifications) CreateForFollowers(notification models.Notification) error {
+	statement, err := repository.db.Prepare(`
+		INSERT INTO notifications (user_id, actor_id, type, publication_id, comment_id)
+		SELECT f.follower_id, f.user_id, ?, ?, ? FROM followers f WHERE f.user_id = ?
+	`)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(
+		notification.Type,
+		nullableId(notification.PublicationId),
+		nullableId(notification.CommentId),
+		notification.ActorId,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Find return one page from user inbox, newest first
+func (repository Notifications) Find(userId uint64, page pagination.Params) ([]models.Notification, error) {
+	cursor, cursorArgs := page.Where("n.createdAt", "n.id")
+
+	args := append([]interface{}{userId}, cursorArgs...)
+	lines, err := repository.db.Query(`
+		SELECT n.id, n.user_id, n.actor_id, u.nick, n.type, COALESCE(n.publication_id, 0),
+		COALESCE(n.comment_id, 0), n.read_at IS NOT NULL, n.createdAt
+		FROM notifications n INNER JOIN users u ON u.id = n.actor_id
+		WHERE n.user_id = ? AND `+cursor+`
+		ORDER BY n.createdAt DESC, n.id DESC
+		LIMIT ?`,
+		append(args, page.FetchLimit())...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var notifications []models.Notification
+	for lines.Next() {
+		var notification models.Notification
+
+		if err = lines.Scan(
+			&notification.ID,
+			&notification.UserId,
+			&notification.ActorId,
+			&notification.ActorNick,
+			&notification.Type,
+			&notification.PublicationId,
+			&notification.CommentId,
+			&notification.Read,
+			&notification.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
+
+// CountUnread return how many notifications from user inbox were not read
+func (repository Notifications) CountUnread(userId uint64) (uint64, error) {
+	var unread uint64
+	if err := repository.db.QueryRow(
+		"SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL", userId,
+	).Scan(&unread); err != nil {
+		return 0, err
+	}
+
+	return unread, nil
+}
+
+// MarkAsRead mark notifications from user inbox as read, all of them when ids is empty
+func (repository Notifications) MarkAsRead(userId uint64, ids []uint64) error {
+	query := "UPDATE notifications SET read_at = current_timestamp WHERE user_id = ? AND read_at IS NULL"
+	args := []interface{}{userId}
+
+	if len(ids) > 0 {
+		query += " AND id IN (?" + strings.Repeat(", ?", len(ids)-1) + ")"
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+
+	statement, err := repository.db.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(args...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// nullableId convert zero ids to NULL for optional foreign keys
+func nullableId(id uint64) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
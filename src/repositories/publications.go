@@ -2,7 +2,33 @@ package repositories
 
 import (
 	"api/src/models"
+	"api/src/pagination"
 	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const mysqlDuplicateEntry = 1062
+
+// publicationColumns is the list read by scanPublication, from publications p joined with users u,
+// mentions and media come encoded by mentionsColumn and mediaColumn
+const publicationColumns = "p.id, p.title, p.content, p.visibility, p.status, p.publish_at, p.author_id, p.likes, p.createdAt, p.edited_at, u.nick, " +
+	"COALESCE(p.repost_of_id, 0), COALESCE(p.quote_of_id, 0), " + repostsColumn + ", " + mentionsColumn + ", " + mediaColumn
+
+// visibleToViewer is the condition that every query reading publications p by author u must apply, receiving viewer id twice.
+// Authors see all their publications, followers see public and followers only ones and everybody else only public ones.
+// Public publications from private accounts are visible only to followers, drafts and scheduled ones only to author.
+const visibleToViewer = `(p.author_id = ? OR (p.status = 'published' AND ((p.visibility = 'public' AND NOT u.is_private) OR (p.visibility IN ('public', 'followers') AND EXISTS (
+	SELECT 1 FROM followers vf WHERE vf.user_id = p.author_id AND vf.follower_id = ?
+)))))`
+
+var (
+	// ErrAlreadyLiked is returned when user tries to like the same publication twice
+	ErrAlreadyLiked = errors.New("Publication already liked by this user")
+	// ErrNotLiked is returned when user tries to unlike a publication not liked before
+	ErrNotLiked = errors.New("Publication was not liked by this user")
 )
 
 type Publications struct {
@@ -10,20 +36,27 @@ type Publications struct {
 }
 
 // NewPublicationRepository create one repository of publication
-func NewPublicationRepository(db *sql.DB) *Publications {
+func NewPublicationRepository(db *sql.DB) PublicationRepository {
 	return &Publications{db}
 }
 
 func (repository Publications) Create(Publication models.Publication) (uint64, error) {
-	statement, err := repository.db.Prepare(
-		"INSERT INTO publications (title, content, author_id) values (?, ?, ?)",
-	)
+	tx, err := repository.db.Begin()
 	if err != nil {
 		return 0, err
 	}
-	defer statement.Close()
+	defer tx.Rollback()
 
-	result, err := statement.Exec(Publication.Title, Publication.Content, Publication.AuthorId)
+	status := Publication.Status
+	if status == "" {
+		status = models.StatusPublished
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO publications (title, content, visibility, status, publish_at, author_id, quote_of_id) values (?, ?, ?, ?, ?, ?, ?)",
+		Publication.Title, Publication.Content, Publication.Visibility, status, Publication.PublishAt, Publication.AuthorId,
+		nullableId(Publication.QuoteOfId),
+	)
 	if err != nil {
 		return 0, err
 	}
@@ -33,15 +66,32 @@ func (repository Publications) Create(Publication models.Publication) (uint64, e
 		return 0, err
 	}
 
+	if err = saveHashtags(tx, uint64(lastId), Publication.Hashtags); err != nil {
+		return 0, err
+	}
+
+	if err = saveMentions(tx, uint64(lastId), Publication.Mentions); err != nil {
+		return 0, err
+	}
+
+	if err = attachMedia(tx, uint64(lastId), Publication.MediaIds); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
 	return uint64(lastId), nil
 }
 
-func (repository Publications) FindById(publicationId uint64) (models.Publication, error) {
+// FindById find one publication that viewer is allowed to see, reposts also need the original to be visible
+func (repository Publications) FindById(publicationId, viewerId uint64) (models.Publication, error) {
 	line, err := repository.db.Query(`
-		SELECT p.*, u.nick FROM publications
+		SELECT `+publicationColumns+` FROM publications
 		p INNER JOIN users u 
-		ON u.id = p.author_id WHERE p.id = ?`,
-		publicationId,
+		ON u.id = p.author_id WHERE p.id = ? AND `+visibleToViewer+` AND `+repostVisible,
+		publicationId, viewerId, viewerId, viewerId, viewerId, viewerId, viewerId,
 	)
 	if err != nil {
 		return models.Publication{}, err
@@ -50,31 +100,83 @@ func (repository Publications) FindById(publicationId uint64) (models.Publicatio
 
 	var publication models.Publication
 
-	if line.Next() {
-		if err = line.Scan(
-			&publication.ID,
-			&publication.Title,
-			&publication.Content,
-			&publication.AuthorId,
-			&publication.Likes,
-			&publication.CreatedAt,
-			&publication.AuthorNick,
-		); err != nil {
-			return models.Publication{}, err
+	if !line.Next() {
+		return publication, nil
+	}
+
+	if err = scanPublication(line, &publication); err != nil {
+		return models.Publication{}, err
+	}
+	line.Close()
+
+	found := []models.Publication{publication}
+	if err = embedOriginals(repository.db, found, viewerId); err != nil {
+		return models.Publication{}, err
+	}
+
+	return found[0], nil
+}
+
+// Find return one page of the feed, with publications from user and from followed users
+// that are neither blocked nor muted by user. Reposts of an original already in the feed are shown
+// only once, in the position of the most recent one
+func (repository Publications) Find(userId uint64, page pagination.Params) ([]models.Publication, error) {
+	return repository.findFeed(userId, `(p.author_id = ? OR EXISTS (
+		SELECT 1 FROM followers f WHERE f.user_id = p.author_id AND f.follower_id = ?
+	))`, []interface{}{userId, userId}, page)
+}
+
+// FindTimeline return one page of the feed of user made only of the publications with the received ids
+// and of the ones from popular authors, filtered and ordered like Find. Publications from authors user
+// no longer follows are left out, so stale ids are harmless
+func (repository Publications) FindTimeline(userId uint64, publicationIds, popularIds []uint64, page pagination.Params) ([]models.Publication, error) {
+	if len(publicationIds) == 0 && len(popularIds) == 0 {
+		return nil, nil
+	}
+
+	var candidates []string
+	args := []interface{}{userId, userId}
+
+	if len(publicationIds) > 0 {
+		candidates = append(candidates, "p.id IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(publicationIds)), ", ")+")")
+		for _, id := range publicationIds {
+			args = append(args, id)
 		}
 	}
 
-	return publication, nil
+	if len(popularIds) > 0 {
+		candidates = append(candidates, "p.author_id IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(popularIds)), ", ")+")")
+		for _, id := range popularIds {
+			args = append(args, id)
+		}
+	}
+
+	return repository.findFeed(userId, `(p.author_id = ? OR EXISTS (
+		SELECT 1 FROM followers f WHERE f.user_id = p.author_id AND f.follower_id = ?
+	)) AND (`+strings.Join(candidates, " OR ")+`)`, args, page)
 }
 
-func (repository Publications) Find(userId uint64) ([]models.Publication, error) {
+// findFeed read one page of the publications that satisfy membership and that user is allowed to see in the feed
+func (repository Publications) findFeed(userId uint64, membership string, membershipArgs []interface{}, page pagination.Params) ([]models.Publication, error) {
+	cursor, cursorArgs := page.Where("p.createdAt", "p.id")
+
+	args := append(membershipArgs, userId, userId, userId, userId, userId, userId, userId, userId, userId, userId)
 	lines, err := repository.db.Query(`
-		SELECT DISTINCT p.*, u.nick FROM publications p 
+		SELECT `+publicationColumns+` FROM (
+			SELECT p.id, ROW_NUMBER() OVER (
+				PARTITION BY COALESCE(p.repost_of_id, p.id) ORDER BY p.createdAt DESC, p.id DESC
+			) AS occurrence
+			FROM publications p
+			INNER JOIN users u ON u.id = p.author_id
+			WHERE `+membership+` AND p.status = 'published' AND `+visibleToViewer+` AND `+notBlocked("p.author_id")+` AND `+notMuted("p.author_id")+`
+			AND `+repostVisible+` AND `+notMuted("(SELECT o.author_id FROM publications o WHERE o.id = p.repost_of_id)")+`
+		) feed
+		INNER JOIN publications p ON p.id = feed.id
 		INNER JOIN users u ON u.id = p.author_id
-		INNER JOIN followers f on p.author_id = f.user_id
-		WHERE u.id = ? or f.follower_id = ?
-		ORDER BY 1 DESC`,
-		userId, userId,
+		WHERE feed.occurrence = 1 AND `+cursor+`
+		ORDER BY p.createdAt DESC, p.id DESC
+		LIMIT ?`,
+		append(append(args, cursorArgs...), page.FetchLimit())...,
 	)
 	if err != nil {
 		return nil, err
@@ -87,38 +189,70 @@ func (repository Publications) Find(userId uint64) ([]models.Publication, error)
 
 		var publication models.Publication
 
-		if err = lines.Scan(
-			&publication.ID,
-			&publication.Title,
-			&publication.Content,
-			&publication.AuthorId,
-			&publication.Likes,
-			&publication.CreatedAt,
-			&publication.AuthorNick,
-		); err != nil {
+		if err = scanPublication(lines, &publication); err != nil {
 			return nil, err
 		}
 
 		publications = append(publications, publication)
 
 	}
+	lines.Close()
+
+	if err = embedOriginals(repository.db, publications, userId); err != nil {
+		return nil, err
+	}
 
 	return publications, nil
 }
 
 func (repository Publications) Update(publicationId uint64, publication models.Publication) error {
-	statement, err := repository.db.Prepare("UPDATE publications SET title = ?, content = ? WHERE id = ?")
+	tx, err := repository.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	defer statement.Close()
+	if err = updatePublication(tx, publicationId, publication); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
 
-	if _, err = statement.Exec(publication.Title, publication.Content, publicationId); err != nil {
+// updatePublication change publication with its revision, hashtags, mentions and media inside tx
+func updatePublication(tx *sql.Tx, publicationId uint64, publication models.Publication) error {
+	status := publication.Status
+	if status == "" {
+		status = models.StatusPublished
+	}
+
+	if err := saveRevision(tx, publicationId, publication); err != nil {
 		return err
 	}
 
-	return nil
+	// createdAt and edited_at are assigned before title, content and status, so they still compare the previous values,
+	// as bytes like saveRevision does
+	if _, err := tx.Exec(`
+		UPDATE publications SET
+			createdAt = IF(status <> 'published' AND ? = 'published', CURRENT_TIMESTAMP, createdAt),
+			edited_at = IF(status = 'published' AND (BINARY title <> ? OR BINARY content <> ?), CURRENT_TIMESTAMP, edited_at),
+			title = ?, content = ?, visibility = ?, status = ?, publish_at = ?
+		WHERE id = ?`,
+		status, publication.Title, publication.Content,
+		publication.Title, publication.Content, publication.Visibility, status, publication.PublishAt, publicationId,
+	); err != nil {
+		return err
+	}
+
+	if err := saveHashtags(tx, publicationId, publication.Hashtags); err != nil {
+		return err
+	}
+
+	if err := saveMentions(tx, publicationId, publication.Mentions); err != nil {
+		return err
+	}
+
+	return attachMedia(tx, publicationId, publication.MediaIds)
 }
 
 func (repository Publications) Delete(publicationId uint64) error {
@@ -134,3 +268,145 @@ func (repository Publications) Delete(publicationId uint64) error {
 
 	return nil
 }
+
+// Like register that user liked the publication and increment likes count in same transaction
+func (repository Publications) Like(publicationId, userId uint64) error {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(
+		"INSERT INTO publication_likes (publication_id, user_id) values (?, ?)",
+		publicationId, userId,
+	); err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			return ErrAlreadyLiked
+		}
+		return err
+	}
+
+	if _, err = tx.Exec("UPDATE publications SET likes = likes + 1 WHERE id = ?", publicationId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Unlike remove user like from publication and decrement likes count in same transaction
+func (repository Publications) Unlike(publicationId, userId uint64) error {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"DELETE FROM publication_likes WHERE publication_id = ? AND user_id = ?",
+		publicationId, userId,
+	)
+	if err != nil {
+		return err
+	}
+
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affectedRows == 0 {
+		return ErrNotLiked
+	}
+
+	if _, err = tx.Exec(
+		"UPDATE publications SET likes = likes - 1 WHERE id = ? AND likes > 0",
+		publicationId,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// FindLikesByPublicationId find one page of users that liked the publication, most recent like first
+func (repository Publications) FindLikesByPublicationId(publicationId uint64, page pagination.Params) ([]models.User, error) {
+	cursor, cursorArgs := page.Where("l.createdAt", "l.user_id")
+
+	args := append([]interface{}{publicationId}, cursorArgs...)
+	lines, err := repository.db.Query(`
+		SELECT u.id, u.name, u.nick, u.email, u.createdAt, l.createdAt
+		FROM users u INNER JOIN publication_likes l ON u.id = l.user_id
+		WHERE l.publication_id = ? AND `+cursor+`
+		ORDER BY l.createdAt DESC, l.user_id DESC LIMIT ?`,
+		append(args, page.FetchLimit())...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var users []models.User
+
+	for lines.Next() {
+		var user models.User
+
+		if err = lines.Scan(
+			&user.ID,
+			&user.Name,
+			&user.Nick,
+			&user.Email,
+			&user.CreatedAt,
+			&user.RelatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// scanPublication read one line of publicationColumns, hashtags are extracted from content like Prepare does
+func scanPublication(line *sql.Rows, publication *models.Publication) error {
+	var mentions, media sql.NullString
+	var publishAt, editedAt sql.NullTime
+
+	if err := line.Scan(
+		&publication.ID,
+		&publication.Title,
+		&publication.Content,
+		&publication.Visibility,
+		&publication.Status,
+		&publishAt,
+		&publication.AuthorId,
+		&publication.Likes,
+		&publication.CreatedAt,
+		&editedAt,
+		&publication.AuthorNick,
+		&publication.RepostOfId,
+		&publication.QuoteOfId,
+		&publication.Reposts,
+		&mentions,
+		&media,
+	); err != nil {
+		return err
+	}
+
+	if publishAt.Valid {
+		publication.PublishAt = &publishAt.Time
+	}
+
+	if editedAt.Valid {
+		publication.EditedAt = &editedAt.Time
+	}
+
+	publication.Hashtags = models.ExtractHashtags(publication.Content)
+	publication.Mentions = decodeMentions(mentions.String)
+
+	var err error
+	publication.Media, publication.MediaIds, err = decodeMedia(media.String)
+	return err
+}
@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"database/sql"
+	"time"
+)
+
+// UserRepository persist users and the relationship between them
+type UserRepository interface {
+	Create(user models.User) (uint64, error)
+	Find(nameOrNick string, viewerId uint64, page pagination.Params) ([]models.User, error)
+	FindByID(ID uint64) (models.User, error)
+	Update(ID uint64, user models.User) error
+	Delete(ID uint64) error
+	FindByEmail(email string) (models.User, error)
+	Follower(userId, followerId uint64) error
+	Unfollow(userId, followerId uint64) (bool, error)
+	FindFollowersByUserId(userId uint64, page pagination.Params) ([]models.User, error)
+	FindFollowingByUserId(userId uint64, page pagination.Params) ([]models.User, error)
+	FindFollowerIds(userId uint64, limit int) ([]uint64, error)
+	FindAudience(authorId uint64, userIds []uint64) ([]uint64, error)
+	IsFollower(userId, followerId uint64) (bool, error)
+	CreateFollowRequest(userId, requesterId uint64) error
+	FindFollowRequests(userId uint64, page pagination.Params) ([]models.FollowRequest, error)
+	ApproveFollowRequest(userId, requesterId uint64) error
+	DeleteFollowRequest(userId, requesterId uint64) error
+	Block(userId, blockedId uint64) error
+	Unblock(userId, blockedId uint64) error
+	IsBlocked(userId, otherId uint64) (bool, error)
+	Mute(userId, mutedId uint64) error
+	IsMuted(userId, mutedId uint64) (bool, error)
+	Unmute(userId, mutedId uint64) error
+	FindSuggestions(userId uint64, limit int) ([]models.Suggestion, error)
+	DismissSuggestion(userId, dismissedId uint64) error
+	FindMutualFollowers(userId, otherId uint64, page pagination.Params) ([]models.User, error)
+	FindPasswordById(userId uint64) (string, error)
+	UpdateUserPassword(userId uint64, password string) error
+}
+
+// PublicationRepository persist publications with their likes, hashtags, mentions and attached media
+type PublicationRepository interface {
+	Create(publication models.Publication) (uint64, error)
+	FindById(publicationId, viewerId uint64) (models.Publication, error)
+	Find(userId uint64, page pagination.Params) ([]models.Publication, error)
+	FindTimeline(userId uint64, publicationIds, popularIds []uint64, page pagination.Params) ([]models.Publication, error)
+	FindByMention(userId, viewerId uint64, page pagination.Params) ([]models.Publication, error)
+	FindDrafts(authorId uint64, page pagination.Params) ([]models.Publication, error)
+	PublishDue(now time.Time, limit int) ([]models.Publication, error)
+	Update(publicationId uint64, publication models.Publication) error
+	UpdateDraft(publicationId uint64, publication models.Publication) error
+	FindRevisions(publicationId uint64) ([]models.PublicationRevision, error)
+	Delete(publicationId uint64) error
+	Like(publicationId, userId uint64) error
+	Unlike(publicationId, userId uint64) error
+	Repost(originalId, userId uint64) (uint64, error)
+	Unrepost(originalId, userId uint64) error
+	FindLikesByPublicationId(publicationId uint64, page pagination.Params) ([]models.User, error)
+	CountInteractions(userId uint64, authorIds []uint64) (map[uint64]uint64, error)
+}
+
+// CommentRepository persist comments from publications
+type CommentRepository interface {
+	Create(comment models.Comment) (uint64, error)
+	FindById(commentId uint64) (models.Comment, error)
+	FindByPublication(publicationId uint64, page pagination.Params) ([]models.Comment, error)
+	CountByPublications(publicationIds []uint64) (map[uint64]uint64, error)
+	Update(commentId uint64, comment models.Comment) error
+	Delete(commentId uint64) error
+}
+
+// NotificationRepository persist the notifications inbox from users
+type NotificationRepository interface {
+	Create(notification models.Notification) (uint64, error)
+	CreateForFollowers(notification models.Notification) error
+	Find(userId uint64, page pagination.Params) ([]models.Notification, error)
+	CountUnread(userId uint64) (uint64, error)
+	MarkAsRead(userId uint64, ids []uint64) error
+}
+
+// ConversationRepository persist direct conversations, their members and messages
+type ConversationRepository interface {
+	Create(conversation models.Conversation) (uint64, error)
+	FindDirect(userId, otherId uint64) (uint64, error)
+	FindById(conversationId uint64) (models.Conversation, error)
+	FindByUser(userId uint64, page pagination.Params) ([]models.Conversation, error)
+	CreateMessage(message models.Message) (uint64, error)
+	FindMessages(conversationId uint64, page pagination.Params) ([]models.Message, error)
+	MarkRead(conversationId, userId, messageId uint64) error
+}
+
+// MediaRepository persist uploaded media, attached to publications by PublicationRepository
+type MediaRepository interface {
+	Create(media models.Media) (uint64, error)
+	FindById(mediaId uint64) (models.Media, error)
+	FindOrphans(createdBefore time.Time, limit int) ([]models.Media, error)
+	FindUnprocessed(createdBefore time.Time, afterId uint64, limit int) ([]models.Media, error)
+	SaveVariants(media models.Media) error
+	MarkFailed(mediaId uint64) error
+	Delete(mediaId uint64) error
+	DeleteOrphan(mediaId uint64) (bool, error)
+}
+
+// HashtagRepository read the hashtags linked to publications, which are saved by PublicationRepository
+type HashtagRepository interface {
+	FindPublications(tag string, viewerId uint64, page pagination.Params) ([]models.Publication, error)
+	FindUses(since time.Time) ([]models.HashtagUse, error)
+}
+
+// Searcher find users and publications by relevance to a full text query, paginated by offset
+// because relevance has no stable cursor
+type Searcher interface {
+	SearchUsers(query string, viewerId uint64, limit, offset int) ([]models.User, error)
+	SearchPublications(query string, viewerId uint64, limit, offset int) ([]models.Publication, error)
+}
+
+// TokenRepository persist refresh tokens and revoked access tokens
+type TokenRepository interface {
+	CreateRefreshToken(token models.RefreshToken) (uint64, error)
+	FindRefreshTokenByHash(tokenHash string) (models.RefreshToken, error)
+	RotateRefreshToken(usedTokenId uint64, newToken models.RefreshToken) (uint64, error)
+	RevokeFamily(familyId string, expiresAt time.Time) error
+	RevokeAccessToken(jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(jti, familyId string) (bool, error)
+}
+
+// Repositories group all repositories used by the API
+type Repositories struct {
+	Users         UserRepository
+	Publications  PublicationRepository
+	Comments      CommentRepository
+	Tokens        TokenRepository
+	Notifications NotificationRepository
+	Conversations ConversationRepository
+	Hashtags      HashtagRepository
+	Media         MediaRepository
+	Search        Searcher
+}
+
+// NewMySQLRepositories create all repositories backed by the MySQL connection
+func NewMySQLRepositories(db *sql.DB) Repositories {
+	return Repositories{
+		Users:         NewUserRepository(db),
+		Publications:  NewPublicationRepository(db),
+		Comments:      NewCommentRepository(db),
+		Tokens:        NewTokenRepository(db),
+		Notifications: NewNotificationRepository(db),
+		Conversations: NewConversationRepository(db),
+		Hashtags:      NewHashtagRepository(db),
+		Media:         NewMediaRepository(db),
+		Search:        NewSearcher(db),
+	}
+}
@@ -0,0 +1,115 @@
+package repositorytest
+
+import (
+	"api/src/repositories"
+	"testing"
+)
+
+func testBlocksAndMutes(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{
+		Users: []string{"alice", "bob", "carol"},
+		Follows: []Follow{
+			{Follower: "bob", User: "alice"},
+			{Follower: "alice", User: "bob"},
+			{Follower: "alice", User: "carol"},
+		},
+		Publications: []Post{
+			{Author: "alice", Title: "alice"},
+			{Author: "bob", Title: "bob"},
+			{Author: "carol", Title: "carol"},
+		},
+	})
+	aliceId, bobId, carolId := world.User("alice"), world.User("bob"), world.User("carol")
+
+	check(t, "block", repos.Users.Block(aliceId, bobId))
+
+	for _, pair := range [][2]string{{"alice", "bob"}, {"bob", "alice"}} {
+		userId, otherId := world.User(pair[0]), world.User(pair[1])
+
+		if following, _ := repos.Users.IsFollower(userId, otherId); following {
+			t.Errorf("block must remove %s following %s", pair[1], pair[0])
+		}
+
+		if blocked, err := repos.Users.IsBlocked(userId, otherId); err != nil || !blocked {
+			t.Errorf("expected block between %s and %s, got %v, %v", pair[0], pair[1], blocked, err)
+		}
+	}
+
+	searches := []struct {
+		viewer string
+		nicks  []string
+	}{
+		{"bob", []string{"bob", "carol"}},
+		{"alice", []string{"alice", "carol"}},
+		{"carol", []string{"alice", "bob", "carol"}},
+	}
+
+	for _, search := range searches {
+		found, err := repos.Users.Find("", world.User(search.viewer), allItems)
+		check(t, "find users", err)
+		expect(t, "users found by "+search.viewer, world.Nicks(found), search.nicks)
+	}
+
+	// follow rows were removed, so force them back to check the feed filters alone
+	world.Follow("alice", "bob")
+
+	feeds := []struct {
+		name   string
+		change func() error
+		titles []string
+	}{
+		{"blocked", func() error { return nil }, []string{"carol", "alice"}},
+		{"blocked and muted", func() error { return repos.Users.Mute(aliceId, carolId) }, []string{"alice"}},
+		{"blocked", func() error { return repos.Users.Unmute(aliceId, carolId) }, []string{"carol", "alice"}},
+		{"nothing hidden", func() error { return repos.Users.Unblock(aliceId, bobId) }, []string{"carol", "bob", "alice"}},
+	}
+
+	for _, feed := range feeds {
+		check(t, feed.name, feed.change())
+
+		found, err := repos.Publications.Find(aliceId, allItems)
+		check(t, "feed", err)
+		expect(t, "feed with "+feed.name, world.Titles(found), feed.titles)
+	}
+}
+
+func testAudience(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{
+		Users: []string{"author", "follower", "muter", "stranger", "offline"},
+		Follows: []Follow{
+			{Follower: "follower", User: "author"},
+			{Follower: "muter", User: "author"},
+			{Follower: "offline", User: "author"},
+		},
+	})
+	check(t, "mute", repos.Users.Mute(world.User("muter"), world.User("author")))
+
+	candidates := func(nicks ...string) []uint64 {
+		var ids []uint64
+		for _, nick := range nicks {
+			ids = append(ids, world.User(nick))
+		}
+		return ids
+	}
+
+	audiences := []struct {
+		name       string
+		candidates []uint64
+		audience   []string
+	}{
+		{"connected followers that did not mute", candidates("follower", "muter", "stranger", "author"), []string{"follower"}},
+		{"nobody connected", nil, nil},
+		{"only strangers", candidates("stranger"), nil},
+	}
+
+	for _, audience := range audiences {
+		ids, err := repos.Users.FindAudience(world.User("author"), audience.candidates)
+		check(t, audience.name, err)
+
+		var nicks []string
+		for _, id := range ids {
+			nicks = append(nicks, world.nick(id))
+		}
+		expect(t, audience.name, nicks, audience.audience)
+	}
+}
@@ -0,0 +1,82 @@
+package repositorytest
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/repositories"
+	"testing"
+)
+
+func testComments(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{
+		Users:        []string{"alice", "bob"},
+		Publications: []Post{{Author: "alice", Title: "post"}},
+	})
+	postId := world.Publication("post")
+
+	ids := map[string]uint64{}
+	comments := []struct {
+		content string
+		author  string
+		parent  string
+	}{
+		{"first", "bob", ""},
+		{"second", "alice", ""},
+		{"reply", "alice", "first"},
+	}
+
+	for _, comment := range comments {
+		id, err := repos.Comments.Create(models.Comment{
+			Content: comment.content, PublicationId: postId, AuthorId: world.User(comment.author), ParentCommentId: ids[comment.parent],
+		})
+		check(t, "create "+comment.content, err)
+		ids[comment.content] = id
+	}
+
+	reply, err := repos.Comments.FindById(ids["reply"])
+	check(t, "find reply", err)
+	if reply.ParentCommentId != ids["first"] || reply.AuthorNick != "alice" {
+		t.Errorf("unexpected reply %+v", reply)
+	}
+
+	pages := []struct {
+		name    string
+		content string
+		replies []string
+	}{
+		{"newest first", "second", nil},
+		{"after cursor", "first", []string{"reply"}},
+	}
+
+	var after *pagination.Cursor
+	for _, page := range pages {
+		found, err := repos.Comments.FindByPublication(postId, pagination.Params{Limit: 1, After: after})
+		check(t, "find page", err)
+		if len(found) == 0 || found[0].ID != ids[page.content] {
+			t.Fatalf("%s: expected %s, got %+v", page.name, page.content, found)
+		}
+
+		var replies []string
+		for _, reply := range found[0].Replies {
+			replies = append(replies, reply.Content)
+		}
+		expect(t, "replies of "+page.content, replies, page.replies)
+
+		cursor := found[0].Cursor()
+		after = &cursor
+	}
+
+	if found, err := repos.Comments.FindByPublication(postId, pagination.Params{Limit: 1, After: after}); err != nil || len(found) != 0 {
+		t.Errorf("expected nothing after the oldest comment, got %+v, %v", found, err)
+	}
+
+	check(t, "update", repos.Comments.Update(ids["first"], models.Comment{Content: "edited"}))
+	if comment, _ := repos.Comments.FindById(ids["first"]); comment.Content != "edited" {
+		t.Errorf("comment was not updated %+v", comment)
+	}
+
+	check(t, "delete", repos.Comments.Delete(ids["first"]))
+	if comment, _ := repos.Comments.FindById(ids["reply"]); comment.ID != 0 {
+		t.Errorf("reply was not deleted with parent %+v", comment)
+	}
+}
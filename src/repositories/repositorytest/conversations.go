@@ -0,0 +1,89 @@
+package repositorytest
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/repositories"
+	"testing"
+)
+
+func testConversations(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{Users: []string{"alice", "bob", "carol"}})
+	aliceId, bobId, carolId := world.User("alice"), world.User("bob"), world.User("carol")
+
+	directId, err := repos.Conversations.Create(models.Conversation{MemberIds: []uint64{aliceId, bobId}})
+	check(t, "create direct", err)
+
+	groupId, err := repos.Conversations.Create(models.Conversation{MemberIds: []uint64{aliceId, bobId, carolId}})
+	check(t, "create group", err)
+
+	directs := []struct {
+		user, other string
+		id          uint64
+	}{
+		{"alice", "bob", directId},
+		{"bob", "alice", directId},
+		{"alice", "carol", 0},
+	}
+
+	for _, direct := range directs {
+		found, err := repos.Conversations.FindDirect(world.User(direct.user), world.User(direct.other))
+		check(t, "find direct", err)
+		if found != direct.id {
+			t.Errorf("%s and %s: expected direct conversation %d, got %d", direct.user, direct.other, direct.id, found)
+		}
+	}
+
+	group, err := repos.Conversations.FindById(groupId)
+	check(t, "find group", err)
+	if len(group.Members) != 3 || !group.HasMember(carolId) || group.Members[0].Nick == "" {
+		t.Fatalf("unexpected group %+v", group)
+	}
+
+	var messageIds []uint64
+	for _, content := range []string{"first", "second", "third"} {
+		id, err := repos.Conversations.CreateMessage(models.Message{ConversationId: directId, SenderId: aliceId, Content: content})
+		check(t, "send "+content, err)
+		messageIds = append(messageIds, id)
+	}
+
+	inboxes := []struct {
+		user          string
+		conversations []uint64
+	}{
+		{"alice", []uint64{directId, groupId}},
+		{"bob", []uint64{directId, groupId}},
+		{"carol", []uint64{groupId}},
+	}
+
+	for _, inbox := range inboxes {
+		conversations, err := repos.Conversations.FindByUser(world.User(inbox.user), allItems)
+		check(t, "conversations of "+inbox.user, err)
+
+		var ids []uint64
+		for _, conversation := range conversations {
+			ids = append(ids, conversation.ID)
+		}
+		expect(t, "conversations of "+inbox.user+" with last message first", ids, inbox.conversations)
+	}
+
+	messages, err := repos.Conversations.FindMessages(directId, pagination.Params{Limit: 2})
+	check(t, "find messages", err)
+	if len(messages) != 3 || messages[0].ID != messageIds[2] || messages[0].SenderNick != "alice" {
+		t.Errorf("expected newest message first with one extra item, got %+v", messages)
+	}
+
+	check(t, "mark read", repos.Conversations.MarkRead(directId, bobId, messageIds[1]))
+	// read position never goes back
+	check(t, "mark older read", repos.Conversations.MarkRead(directId, bobId, messageIds[0]))
+
+	direct, _ := repos.Conversations.FindById(directId)
+	read := map[uint64]uint64{}
+	for _, member := range direct.Members {
+		read[member.UserId] = member.LastReadMessageId
+	}
+
+	if read[aliceId] != messageIds[2] || read[bobId] != messageIds[1] {
+		t.Errorf("expected alice to read her messages and bob to keep the newest read, got %v", read)
+	}
+}
@@ -0,0 +1,104 @@
+package repositorytest
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testDrafts(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{
+		Users:        []string{"alice", "bob"},
+		Follows:      []Follow{{Follower: "bob", User: "alice"}},
+		Publications: []Post{{Author: "alice", Title: "published"}},
+	})
+	aliceId, bobId := world.User("alice"), world.User("bob")
+
+	publishAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	world.Create("draft", models.Publication{
+		Title: "draft", Content: "not yet", Status: models.StatusDraft, AuthorId: aliceId,
+	})
+	scheduled := world.Create("scheduled", models.Publication{
+		Title: "scheduled", Content: "later", Status: models.StatusScheduled, PublishAt: &publishAt, AuthorId: aliceId,
+	})
+
+	if found, _ := repos.Publications.FindById(world.Publication("draft"), bobId); found.ID != 0 {
+		t.Errorf("drafts must be visible only to author, got %+v", found)
+	}
+
+	found, _ := repos.Publications.FindById(scheduled, aliceId)
+	if found.Status != models.StatusScheduled || found.PublishAt == nil || !found.PublishAt.Equal(publishAt) {
+		t.Errorf("expected scheduled publication to author, got %+v", found)
+	}
+
+	state := func(name string, feed, drafts []string) {
+		t.Helper()
+
+		for _, nick := range []string{"alice", "bob"} {
+			found, err := repos.Publications.Find(world.User(nick), allItems)
+			check(t, name, err)
+			expect(t, name+": feed of "+nick, world.Titles(found), feed)
+		}
+
+		found, err := repos.Publications.FindDrafts(aliceId, allItems)
+		check(t, name, err)
+		expect(t, name+": drafts", world.Titles(found), drafts)
+	}
+
+	state("before publishing", []string{"published"}, []string{"scheduled", "draft"})
+
+	runs := []struct {
+		name string
+		now  time.Time
+		due  []string
+	}{
+		{"nothing due yet", time.Now(), nil},
+		{"scheduled publication due", publishAt.Add(time.Second), []string{"scheduled"}},
+		{"published only once", publishAt.Add(time.Second), nil},
+	}
+
+	for _, run := range runs {
+		due, err := repos.Publications.PublishDue(run.now, 10)
+		check(t, run.name, err)
+		expect(t, run.name, world.Titles(due), run.due)
+
+		for _, publication := range due {
+			if publication.AuthorId != aliceId {
+				t.Errorf("%s: expected author of due publications, got %+v", run.name, publication)
+			}
+		}
+	}
+
+	state("after scheduled publication", []string{"scheduled", "published"}, []string{"draft"})
+
+	// an edit read the publication as scheduled before the scheduler published it
+	later := publishAt.Add(time.Hour)
+	refused := []struct {
+		name          string
+		publicationId uint64
+	}{
+		{"edit of a published draft", scheduled},
+		{"edit of a missing draft", 999},
+	}
+
+	for _, edit := range refused {
+		err := repos.Publications.UpdateDraft(edit.publicationId, models.Publication{
+			Title: "scheduled", Content: "edited", Visibility: models.VisibilityPublic, Status: models.StatusScheduled, PublishAt: &later,
+		})
+		if !errors.Is(err, repositories.ErrNotDraft) {
+			t.Errorf("%s: expected ErrNotDraft, got %v", edit.name, err)
+		}
+	}
+
+	if found, _ := repos.Publications.FindById(scheduled, aliceId); found.Status != models.StatusPublished || found.Content != "later" {
+		t.Errorf("expected refused edit to keep the publication, got %+v", found)
+	}
+
+	check(t, "publish draft", repos.Publications.UpdateDraft(world.Publication("draft"), models.Publication{
+		Title: "draft", Content: "now", Visibility: models.VisibilityPublic, Status: models.StatusPublished,
+	}))
+
+	state("after draft published", []string{"draft", "scheduled", "published"}, nil)
+}
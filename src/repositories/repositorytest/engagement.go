@@ -0,0 +1,69 @@
+package repositorytest
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"testing"
+)
+
+func testEngagement(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{
+		Users: []string{"alice", "bob", "carol"},
+		Publications: []Post{
+			{Author: "alice", Title: "first"},
+			{Author: "alice", Title: "second"},
+			{Author: "bob", Title: "quiet"},
+		},
+	})
+	first, second, quiet := world.Publication("first"), world.Publication("second"), world.Publication("quiet")
+	carolId := world.User("carol")
+
+	for _, publicationId := range []uint64{first, first, second} {
+		_, err := repos.Comments.Create(models.Comment{Content: "nice", PublicationId: publicationId, AuthorId: carolId})
+		check(t, "comment", err)
+	}
+	check(t, "like", repos.Publications.Like(first, carolId))
+	_, err := repos.Publications.Repost(second, carolId)
+	check(t, "repost", err)
+
+	comments, err := repos.Comments.CountByPublications([]uint64{first, second, quiet})
+	check(t, "count comments", err)
+	if len(comments) != 2 || comments[first] != 2 || comments[second] != 1 {
+		t.Errorf("expected comments counted by publication, got %v", comments)
+	}
+
+	interactions := []struct {
+		user    string
+		authors []string
+		counts  map[string]uint64
+	}{
+		{"carol", []string{"alice", "bob"}, map[string]uint64{"alice": 5}},
+		{"carol", []string{"bob"}, map[string]uint64{}},
+		{"bob", []string{"alice"}, map[string]uint64{}},
+	}
+
+	for _, interaction := range interactions {
+		var authorIds []uint64
+		for _, nick := range interaction.authors {
+			authorIds = append(authorIds, world.User(nick))
+		}
+
+		counts, err := repos.Publications.CountInteractions(world.User(interaction.user), authorIds)
+		check(t, "count interactions", err)
+
+		got := map[string]uint64{}
+		for authorId, count := range counts {
+			got[world.nick(authorId)] = count
+		}
+
+		if len(got) != len(interaction.counts) {
+			t.Errorf("%s with %v: expected %v, got %v", interaction.user, interaction.authors, interaction.counts, got)
+			continue
+		}
+		for nick, count := range interaction.counts {
+			if got[nick] != count {
+				t.Errorf("%s with %v: expected %v, got %v", interaction.user, interaction.authors, interaction.counts, got)
+			}
+		}
+	}
+}
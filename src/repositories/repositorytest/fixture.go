@@ -0,0 +1,204 @@
+package repositorytest
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// Graph describe the users, follows and publications that a case starts from. Users are referenced
+// by nick and publications by title, so cases declare their state instead of building it step by step
+type Graph struct {
+	Users        []string
+	Follows      []Follow
+	Publications []Post
+}
+
+// Follow describe Follower following User
+type Follow struct {
+	Follower string
+	User     string
+}
+
+// Post describe a publication from Author, public when Visibility is empty and with content derived from
+// Title when Content is empty
+type Post struct {
+	Author     string
+	Title      string
+	Content    string
+	Visibility string
+}
+
+// World hold the repositories of a case with the ids created from its graph
+type World struct {
+	t     *testing.T
+	repos repositories.Repositories
+
+	users        map[string]uint64
+	publications map[string]uint64
+	nicks        map[uint64]string
+	titles       map[uint64]string
+}
+
+// Build insert graph in repos in the order it was declared, publications are created oldest first
+func Build(t *testing.T, repos repositories.Repositories, graph Graph) *World {
+	t.Helper()
+
+	world := &World{
+		t:            t,
+		repos:        repos,
+		users:        map[string]uint64{},
+		publications: map[string]uint64{},
+		nicks:        map[uint64]string{},
+		titles:       map[uint64]string{},
+	}
+
+	for _, nick := range graph.Users {
+		world.AddUser(nick)
+	}
+	for _, follow := range graph.Follows {
+		world.Follow(follow.Follower, follow.User)
+	}
+	for _, post := range graph.Publications {
+		world.Publish(post)
+	}
+
+	return world
+}
+
+// AddUser insert a user with name, email and password derived from nick
+func (world *World) AddUser(nick string) uint64 {
+	world.t.Helper()
+
+	id, err := world.repos.Users.Create(models.User{
+		Name:     "User " + nick,
+		Nick:     nick,
+		Email:    nick + "@devbook.com",
+		Password: "hash-" + nick,
+	})
+	if err != nil {
+		world.t.Fatalf("create user %s: %v", nick, err)
+	}
+
+	world.users[nick] = id
+	world.nicks[id] = nick
+	return id
+}
+
+// Follow make follower follow user
+func (world *World) Follow(follower, user string) {
+	world.t.Helper()
+
+	if err := world.repos.Users.Follower(world.User(user), world.User(follower)); err != nil {
+		world.t.Fatalf("%s following %s: %v", follower, user, err)
+	}
+}
+
+// Unfollow remove follower from the followers of user, failing the test when follower was not following
+func (world *World) Unfollow(follower, user string) {
+	world.t.Helper()
+
+	if unfollowed, err := world.repos.Users.Unfollow(world.User(user), world.User(follower)); err != nil || !unfollowed {
+		world.t.Fatalf("%s unfollowing %s: %v, %v", follower, user, unfollowed, err)
+	}
+}
+
+// Publish insert post, preparing it like the API does so hashtags and mentions are extracted
+func (world *World) Publish(post Post) uint64 {
+	world.t.Helper()
+
+	if post.Content == "" {
+		post.Content = "Content of " + post.Title
+	}
+
+	return world.Create(post.Title, models.Publication{
+		Title:      post.Title,
+		Content:    post.Content,
+		Visibility: post.Visibility,
+		AuthorId:   world.User(post.Author),
+	})
+}
+
+// Create insert publication, referenced by title afterwards
+func (world *World) Create(title string, publication models.Publication) uint64 {
+	world.t.Helper()
+
+	if publication.Visibility == "" {
+		publication.Visibility = models.VisibilityPublic
+	}
+
+	if err := publication.Prepare(); err != nil {
+		world.t.Fatalf("prepare publication %s: %v", title, err)
+	}
+
+	id, err := world.repos.Publications.Create(publication)
+	if err != nil {
+		world.t.Fatalf("create publication %s: %v", title, err)
+	}
+
+	world.Name(id, title)
+	return id
+}
+
+// Name reference the publication with id by title, used for publications created outside the world like reposts
+func (world *World) Name(id uint64, title string) {
+	world.publications[title] = id
+	world.titles[id] = title
+}
+
+// User return the id of the user with nick
+func (world *World) User(nick string) uint64 {
+	world.t.Helper()
+
+	id, ok := world.users[nick]
+	if !ok {
+		world.t.Fatalf("unknown user %s", nick)
+	}
+	return id
+}
+
+// Publication return the id of the publication with title
+func (world *World) Publication(title string) uint64 {
+	world.t.Helper()
+
+	id, ok := world.publications[title]
+	if !ok {
+		world.t.Fatalf("unknown publication %s", title)
+	}
+	return id
+}
+
+// Titles return the titles of publications in their order, publications unknown to the world are shown by id
+func (world *World) Titles(publications []models.Publication) []string {
+	titles := []string{}
+	for _, publication := range publications {
+		titles = append(titles, world.title(publication.ID))
+	}
+	return titles
+}
+
+// Nicks return the nicks of users sorted, so they can be compared regardless of order
+func (world *World) Nicks(users []models.User) []string {
+	nicks := []string{}
+	for _, user := range users {
+		nicks = append(nicks, world.nick(user.ID))
+	}
+	sort.Strings(nicks)
+	return nicks
+}
+
+func (world *World) title(id uint64) string {
+	if title, ok := world.titles[id]; ok {
+		return title
+	}
+	return "#" + strconv.FormatUint(id, 10)
+}
+
+func (world *World) nick(id uint64) string {
+	if nick, ok := world.nicks[id]; ok {
+		return nick
+	}
+	return "#" + strconv.FormatUint(id, 10)
+}
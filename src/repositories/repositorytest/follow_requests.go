@@ -0,0 +1,87 @@
+package repositorytest
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"errors"
+	"testing"
+)
+
+func testFollowRequests(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{
+		Users:        []string{"owner", "requester", "rejected"},
+		Publications: []Post{{Author: "owner", Title: "hidden"}},
+	})
+	ownerId, requesterId, rejectedId := world.User("owner"), world.User("requester"), world.User("rejected")
+
+	check(t, "make owner private", repos.Users.Update(ownerId, models.User{
+		Name: "User owner", Nick: "owner", Email: "owner@devbook.com", IsPrivate: true,
+	}))
+
+	owner, err := repos.Users.FindByID(ownerId)
+	check(t, "find owner", err)
+	if !owner.IsPrivate {
+		t.Fatal("expected private account")
+	}
+
+	for _, id := range []uint64{requesterId, rejectedId} {
+		check(t, "request to follow", repos.Users.CreateFollowRequest(ownerId, id))
+	}
+
+	if err = repos.Users.CreateFollowRequest(ownerId, requesterId); !errors.Is(err, repositories.ErrFollowRequestExists) {
+		t.Errorf("expected ErrFollowRequestExists, got %v", err)
+	}
+
+	requests, err := repos.Users.FindFollowRequests(ownerId, allItems)
+	check(t, "find requests", err)
+	if len(requests) != 2 || requests[0].RequesterNick == "" {
+		t.Fatalf("expected two pending requests with requester nick, got %+v", requests)
+	}
+
+	if publication, _ := repos.Publications.FindById(world.Publication("hidden"), requesterId); publication.ID != 0 {
+		t.Error("pending requester must not see publications from private account")
+	}
+
+	check(t, "approve", repos.Users.ApproveFollowRequest(ownerId, requesterId))
+	if err = repos.Users.ApproveFollowRequest(ownerId, requesterId); !errors.Is(err, repositories.ErrFollowRequestNotFound) {
+		t.Errorf("expected ErrFollowRequestNotFound, got %v", err)
+	}
+
+	check(t, "reject", repos.Users.DeleteFollowRequest(ownerId, rejectedId))
+
+	requesters := []struct {
+		nick      string
+		following bool
+	}{
+		{"requester", true},
+		{"rejected", false},
+	}
+
+	for _, requester := range requesters {
+		following, err := repos.Users.IsFollower(ownerId, world.User(requester.nick))
+		check(t, "is follower", err)
+		if following != requester.following {
+			t.Errorf("%s: expected following %v", requester.nick, requester.following)
+		}
+
+		publication, err := repos.Publications.FindById(world.Publication("hidden"), world.User(requester.nick))
+		check(t, "find hidden", err)
+		if visible := publication.ID != 0; visible != requester.following {
+			t.Errorf("%s: expected publications of private account visible %v", requester.nick, requester.following)
+		}
+	}
+
+	if requests, _ = repos.Users.FindFollowRequests(ownerId, allItems); len(requests) != 0 {
+		t.Errorf("expected no pending requests, got %+v", requests)
+	}
+
+	check(t, "request again", repos.Users.CreateFollowRequest(ownerId, rejectedId))
+	check(t, "make owner public", repos.Users.Update(ownerId, models.User{Name: "User owner", Nick: "owner", Email: "owner@devbook.com"}))
+
+	if following, _ := repos.Users.IsFollower(ownerId, rejectedId); !following {
+		t.Error("pending requester must follow the account that turned public")
+	}
+	if requests, _ = repos.Users.FindFollowRequests(ownerId, allItems); len(requests) != 0 {
+		t.Errorf("expected requests approved when the account turned public, got %+v", requests)
+	}
+}
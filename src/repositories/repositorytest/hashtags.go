@@ -0,0 +1,79 @@
+package repositorytest
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"testing"
+	"time"
+)
+
+func testHashtags(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{
+		Users: []string{"alice", "bob", "carol"},
+		Publications: []Post{
+			{Author: "alice", Title: "first", Content: "learning #Go and #mysql"},
+			{Author: "bob", Title: "second", Content: "more #go"},
+			{Author: "bob", Title: "hidden", Content: "secret #go", Visibility: models.VisibilityFollowers},
+		},
+	})
+
+	found, err := repos.Hashtags.FindPublications("go", world.User("carol"), allItems)
+	check(t, "find by hashtag", err)
+	expect(t, "visible publications with hashtag", world.Titles(found), []string{"second", "first"})
+	if len(found) == 2 {
+		expect(t, "hashtags from content", found[1].Hashtags, []string{"go", "mysql"})
+	}
+
+	type search struct {
+		name   string
+		tag    string
+		viewer string
+		titles []string
+	}
+
+	publications := func(searches []search) {
+		t.Helper()
+
+		for _, search := range searches {
+			found, err := repos.Hashtags.FindPublications(search.tag, world.User(search.viewer), allItems)
+			check(t, search.name, err)
+			expect(t, search.name, world.Titles(found), search.titles)
+		}
+	}
+
+	world.Follow("carol", "bob")
+	publications([]search{
+		{"follower sees followers only publications", "go", "carol", []string{"hidden", "second", "first"}},
+		{"stranger misses followers only publications", "go", "alice", []string{"second", "first"}},
+	})
+
+	check(t, "block", repos.Users.Block(world.User("alice"), world.User("carol")))
+	publications([]search{
+		{"blocked user misses blocker publications", "go", "carol", []string{"hidden", "second"}},
+		{"blocker misses blocked publications", "go", "alice", []string{"second", "first"}},
+	})
+
+	uses, err := repos.Hashtags.FindUses(time.Now().Add(-time.Hour))
+	check(t, "find uses", err)
+
+	counts := map[string]uint64{}
+	for _, use := range uses {
+		counts[use.Tag] += use.Count
+	}
+	if counts["go"] != 2 || counts["mysql"] != 1 || len(counts) != 2 {
+		t.Errorf("expected uses only from public publications, got %v", counts)
+	}
+
+	updated := models.Publication{Title: "first", Content: "now #rust", Visibility: models.VisibilityPublic}
+	check(t, "prepare", updated.Prepare())
+	check(t, "update", repos.Publications.Update(world.Publication("first"), updated))
+
+	publications([]search{
+		{"old hashtags replaced", "mysql", "bob", nil},
+		{"new hashtag", "rust", "bob", []string{"first"}},
+	})
+
+	if uses, _ := repos.Hashtags.FindUses(time.Now().Add(time.Hour)); len(uses) != 0 {
+		t.Errorf("expected no uses after since, got %+v", uses)
+	}
+}
@@ -0,0 +1,57 @@
+package repositorytest
+
+import (
+	"api/src/pagination"
+	"api/src/repositories"
+	"errors"
+	"testing"
+)
+
+func testLikes(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{
+		Users:        []string{"alice", "bob"},
+		Publications: []Post{{Author: "alice", Title: "post"}},
+	})
+	postId, aliceId, bobId := world.Publication("post"), world.User("alice"), world.User("bob")
+
+	steps := []struct {
+		name   string
+		change func() error
+		err    error
+		likes  uint64
+		likers []string
+	}{
+		{"bob likes", func() error { return repos.Publications.Like(postId, bobId) }, nil, 1, []string{"bob"}},
+		{"bob likes again", func() error { return repos.Publications.Like(postId, bobId) }, repositories.ErrAlreadyLiked, 1, []string{"bob"}},
+		{"alice likes", func() error { return repos.Publications.Like(postId, aliceId) }, nil, 2, []string{"alice", "bob"}},
+		{"bob unlikes", func() error { return repos.Publications.Unlike(postId, bobId) }, nil, 1, []string{"alice"}},
+		{"bob unlikes again", func() error { return repos.Publications.Unlike(postId, bobId) }, repositories.ErrNotLiked, 1, []string{"alice"}},
+	}
+
+	for _, step := range steps {
+		if err := step.change(); !errors.Is(err, step.err) {
+			t.Fatalf("%s: expected error %v, got %v", step.name, step.err, err)
+		}
+
+		if publication, _ := repos.Publications.FindById(postId, aliceId); publication.Likes != step.likes {
+			t.Errorf("%s: expected %d likes, got %d", step.name, step.likes, publication.Likes)
+		}
+
+		users, err := repos.Publications.FindLikesByPublicationId(postId, allItems)
+		check(t, "find likes", err)
+		expect(t, step.name, world.Nicks(users), step.likers)
+	}
+
+	check(t, "bob likes again", repos.Publications.Like(postId, bobId))
+
+	first, err := repos.Publications.FindLikesByPublicationId(postId, pagination.Params{Limit: 1})
+	check(t, "first page of likes", err)
+	if len(first) != 2 || first[0].ID != bobId || first[1].ID != aliceId {
+		t.Errorf("expected bob, the most recent like, before alice and the extra row, got %v", world.Nicks(first))
+	}
+
+	after := first[0].RelationCursor()
+	second, err := repos.Publications.FindLikesByPublicationId(postId, pagination.Params{Limit: 1, After: &after})
+	check(t, "second page of likes", err)
+	expect(t, "second page of likes", world.Nicks(second), []string{"alice"})
+}
@@ -0,0 +1,148 @@
+package repositorytest
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+func testMedia(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{Users: []string{"alice", "bob"}})
+	aliceId, bobId := world.User("alice"), world.User("bob")
+
+	media := map[string]uint64{}
+	keys := map[uint64]string{}
+	for _, key := range []string{"alice/1.png", "alice/2.png", "bob/1.png"} {
+		ownerId := aliceId
+		if key[0] == 'b' {
+			ownerId = bobId
+		}
+
+		id, err := repos.Media.Create(models.Media{OwnerId: ownerId, ContentType: "image/png", Size: 10, StorageKey: key})
+		check(t, "upload "+key, err)
+		media[key], keys[id] = id, key
+	}
+	first, second, bobs := media["alice/1.png"], media["alice/2.png"], media["bob/1.png"]
+
+	if _, err := repos.Media.Create(models.Media{OwnerId: aliceId, ContentType: "image/png", StorageKey: "alice/1.png"}); err == nil {
+		t.Error("expected error for duplicated storage key")
+	}
+
+	found, err := repos.Media.FindById(first)
+	if err != nil || found.OwnerId != aliceId || found.PublicationId != 0 || found.StorageKey != "alice/1.png" {
+		t.Errorf("unexpected media %+v, %v", found, err)
+	}
+
+	unprocessed := func(name string, createdBefore time.Time, afterId uint64, limit int, want []uint64) {
+		t.Helper()
+
+		found, err := repos.Media.FindUnprocessed(createdBefore, afterId, limit)
+		check(t, name, err)
+
+		var got []uint64
+		for _, media := range found {
+			got = append(got, media.ID)
+		}
+		expect(t, name, got, want)
+	}
+
+	video, err := repos.Media.Create(models.Media{OwnerId: aliceId, ContentType: "video/mp4", Size: 10, StorageKey: "alice/3.mp4"})
+	check(t, "upload video", err)
+	keys[video] = "alice/3.mp4"
+
+	unprocessed("images never processed", time.Now().Add(time.Minute), 0, 10, []uint64{first, second, bobs})
+	unprocessed("images after id", time.Now().Add(time.Minute), first, 1, []uint64{second})
+	unprocessed("nothing uploaded before the moment", time.Now().Add(-time.Minute), 0, 10, nil)
+
+	processed := models.Media{ID: first, Width: 800, Height: 600, Blurhash: "LEHV6nWB2yk8pyo0adR*.7kCMdnj"}
+	processed.Variants = []models.MediaVariant{{Size: 150, Width: 150, Height: 112, ContentType: "image/jpeg", StorageKey: "alice/1_150.jpg"}}
+	check(t, "save variants", repos.Media.SaveVariants(processed))
+
+	unprocessed("processed image", time.Now().Add(time.Minute), 0, 10, []uint64{second, bobs})
+
+	check(t, "mark failed", repos.Media.MarkFailed(bobs))
+	check(t, "mark missing media failed", repos.Media.MarkFailed(bobs+100))
+	unprocessed("image that failed processing", time.Now().Add(time.Minute), 0, 10, []uint64{second})
+
+	if found, _ := repos.Media.FindById(bobs); !found.Failed || found.Blurhash != "" {
+		t.Errorf("expected media marked as failed, got %+v", found)
+	}
+
+	found, _ = repos.Media.FindById(first)
+	if found.Width != 800 || found.Blurhash != processed.Blurhash || len(found.Variants) != 1 || found.Variants[0].StorageKey != "alice/1_150.jpg" {
+		t.Errorf("expected variants saved, got %+v", found)
+	}
+
+	publication := models.Publication{Title: "title", Content: "content", Visibility: models.VisibilityPublic, AuthorId: aliceId}
+	publication.MediaIds = []uint64{bobs}
+	if _, err := repos.Publications.Create(publication); !errors.Is(err, repositories.ErrMediaUnavailable) {
+		t.Errorf("expected media from other user to be refused, got %v", err)
+	}
+
+	publication.MediaIds = []uint64{second, first}
+	publicationId := world.Create("with media", publication)
+
+	created, _ := repos.Publications.FindById(publicationId, bobId)
+	expect(t, "media in the order sent", created.MediaIds, []uint64{second, first})
+	if len(created.Media) != 2 || created.Media[1].Blurhash != processed.Blurhash || created.Media[1].Variants[0].URL == "" {
+		t.Errorf("expected media with variants in publication, got %+v", created.Media)
+	}
+
+	if _, err := repos.Publications.Create(publication); !errors.Is(err, repositories.ErrMediaUnavailable) {
+		t.Errorf("expected media attached to other publication to be refused, got %v", err)
+	}
+
+	orphans := func(name string, createdBefore time.Time, want []string) {
+		t.Helper()
+
+		found, err := repos.Media.FindOrphans(createdBefore, 10)
+		check(t, name, err)
+
+		got := []string{}
+		for _, orphan := range found {
+			got = append(got, keys[orphan.ID])
+		}
+		sort.Strings(got)
+		expect(t, name, got, want)
+	}
+
+	orphans("only media never attached", time.Now().Add(time.Minute), []string{"alice/3.mp4", "bob/1.png"})
+
+	publication.MediaIds = []uint64{first}
+	check(t, "detach", repos.Publications.Update(publicationId, publication))
+
+	orphans("media removed from publication", time.Now().Add(time.Minute), []string{"alice/2.png", "alice/3.mp4", "bob/1.png"})
+	orphans("nothing older than the grace period", time.Now().Add(-time.Minute), nil)
+
+	claims := []struct {
+		name    string
+		mediaId uint64
+		deleted bool
+	}{
+		{"attached media", first, false},
+		{"orphan", second, true},
+		{"media already removed", second, false},
+	}
+
+	for _, claim := range claims {
+		deleted, err := repos.Media.DeleteOrphan(claim.mediaId)
+		check(t, "delete "+claim.name, err)
+
+		if found, _ := repos.Media.FindById(claim.mediaId); deleted != claim.deleted || (found.ID == 0) != (claim.mediaId == second) {
+			t.Errorf("delete %s: expected deleted %v, got %v with %+v left", claim.name, claim.deleted, deleted, found)
+		}
+	}
+
+	check(t, "delete publication", repos.Publications.Delete(publicationId))
+	if found, _ := repos.Media.FindById(first); found.PublicationId != 0 {
+		t.Errorf("deleting publication must detach media, got %+v", found)
+	}
+
+	check(t, "delete media", repos.Media.Delete(first))
+	if found, _ := repos.Media.FindById(first); found.ID != 0 {
+		t.Errorf("expected media removed, got %+v", found)
+	}
+}
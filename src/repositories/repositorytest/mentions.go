@@ -0,0 +1,41 @@
+package repositorytest
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"testing"
+)
+
+func testMentions(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{Users: []string{"alice", "bob", "carol"}})
+	aliceId, bobId, carolId := world.User("alice"), world.User("bob"), world.User("carol")
+
+	check(t, "block", repos.Users.Block(carolId, aliceId))
+	greeting := world.Publish(Post{Author: "alice", Title: "greeting", Content: "hi @bob, @carol and @nobody"})
+
+	found, err := repos.Publications.FindById(greeting, aliceId)
+	check(t, "find greeting", err)
+	expect(t, "mentions of users that exist and did not block the author", found.Mentions, []models.Mention{{Offset: 3, Length: 4, UserId: bobId}})
+
+	mentions := func(name string, titles map[string][]string) {
+		t.Helper()
+
+		for nick, want := range titles {
+			found, err := repos.Publications.FindByMention(world.User(nick), world.User(nick), allItems)
+			check(t, name, err)
+			expect(t, name+" for "+nick, world.Titles(found), want)
+		}
+	}
+
+	mentions("mentions", map[string][]string{"bob": {"greeting"}, "carol": nil})
+
+	check(t, "block author", repos.Users.Block(bobId, aliceId))
+	mentions("mentions from blocked author", map[string][]string{"bob": nil})
+	check(t, "unblock author", repos.Users.Unblock(bobId, aliceId))
+	mentions("mentions after unblock", map[string][]string{"bob": {"greeting"}})
+
+	updated := models.Publication{Title: "greeting", Content: "bye", AuthorId: aliceId}
+	check(t, "prepare", updated.Prepare())
+	check(t, "update", repos.Publications.Update(greeting, updated))
+	mentions("mentions replaced by update", map[string][]string{"bob": nil})
+}
@@ -0,0 +1,98 @@
+package repositorytest
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"testing"
+)
+
+func testNotifications(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{
+		Users: []string{"alice", "bob", "carol"},
+		Follows: []Follow{
+			{Follower: "bob", User: "alice"},
+			{Follower: "carol", User: "alice"},
+		},
+		Publications: []Post{{Author: "alice", Title: "news"}},
+	})
+	aliceId, newsId := world.User("alice"), world.Publication("news")
+
+	followId, err := repos.Notifications.Create(models.Notification{
+		UserId: aliceId, ActorId: world.User("bob"), Type: models.NotificationFollow,
+	})
+	check(t, "notify follow", err)
+
+	_, err = repos.Notifications.Create(models.Notification{
+		UserId: aliceId, ActorId: world.User("carol"), Type: models.NotificationLike, PublicationId: newsId,
+	})
+	check(t, "notify like", err)
+
+	check(t, "notify followers", repos.Notifications.CreateForFollowers(models.Notification{
+		ActorId: aliceId, Type: models.NotificationPublication, PublicationId: newsId,
+	}))
+
+	type received struct {
+		Actor       string
+		Type        string
+		Publication string
+	}
+
+	inbox := func(t *testing.T, nick string) []received {
+		t.Helper()
+
+		notifications, err := repos.Notifications.Find(world.User(nick), allItems)
+		check(t, "inbox of "+nick, err)
+
+		found := []received{}
+		for _, notification := range notifications {
+			if notification.ActorNick != world.nick(notification.ActorId) {
+				t.Errorf("notification %d without actor nick", notification.ID)
+			}
+
+			title := ""
+			if notification.PublicationId != 0 {
+				title = world.title(notification.PublicationId)
+			}
+			found = append(found, received{world.nick(notification.ActorId), notification.Type, title})
+		}
+		return found
+	}
+
+	inboxes := []struct {
+		nick          string
+		notifications []received
+	}{
+		{"alice", []received{{"carol", models.NotificationLike, "news"}, {"bob", models.NotificationFollow, ""}}},
+		{"bob", []received{{"alice", models.NotificationPublication, "news"}}},
+		{"carol", []received{{"alice", models.NotificationPublication, "news"}}},
+	}
+
+	for _, expected := range inboxes {
+		expect(t, "inbox of "+expected.nick, inbox(t, expected.nick), expected.notifications)
+	}
+
+	reads := []struct {
+		name   string
+		user   string
+		ids    []uint64
+		unread uint64
+	}{
+		{"nothing read", "", nil, 2},
+		{"follow read", "alice", []uint64{followId}, 1},
+		{"other user reads everything", "bob", nil, 1},
+		{"everything read", "alice", nil, 0},
+	}
+
+	for _, read := range reads {
+		if read.user != "" {
+			check(t, read.name, repos.Notifications.MarkAsRead(world.User(read.user), read.ids))
+		}
+
+		if unread, _ := repos.Notifications.CountUnread(aliceId); unread != read.unread {
+			t.Errorf("%s: expected %d unread, got %d", read.name, read.unread, unread)
+		}
+	}
+
+	check(t, "delete publication", repos.Publications.Delete(newsId))
+	expect(t, "notifications of deleted publication removed", inbox(t, "alice"), []received{{"bob", models.NotificationFollow, ""}})
+}
@@ -0,0 +1,150 @@
+package repositorytest
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/repositories"
+	"testing"
+)
+
+func testPublications(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{
+		Users:   []string{"alice", "bob", "carol"},
+		Follows: []Follow{{Follower: "bob", User: "alice"}},
+		Publications: []Post{
+			{Author: "alice", Title: "alice post"},
+			{Author: "bob", Title: "bob post"},
+			{Author: "carol", Title: "carol post"},
+		},
+	})
+	aliceId, postId := world.User("alice"), world.Publication("alice post")
+
+	publication, err := repos.Publications.FindById(postId, aliceId)
+	check(t, "find publication", err)
+	if publication.Title != "alice post" || publication.AuthorId != aliceId || publication.AuthorNick != "alice" {
+		t.Errorf("unexpected publication %+v", publication)
+	}
+
+	feeds := []struct {
+		viewer string
+		titles []string
+	}{
+		{"alice", []string{"alice post"}},
+		{"bob", []string{"bob post", "alice post"}},
+		{"carol", []string{"carol post"}},
+	}
+
+	for _, feed := range feeds {
+		found, err := repos.Publications.Find(world.User(feed.viewer), allItems)
+		check(t, "feed of "+feed.viewer, err)
+		expect(t, "feed of "+feed.viewer, world.Titles(found), feed.titles)
+	}
+
+	check(t, "update publication", repos.Publications.Update(postId, models.Publication{
+		Title: "edited", Content: "new content", Visibility: models.VisibilityPublic,
+	}))
+	publication, _ = repos.Publications.FindById(postId, aliceId)
+	if publication.Title != "edited" || publication.Content != "new content" {
+		t.Errorf("publication was not updated %+v", publication)
+	}
+
+	check(t, "delete publication", repos.Publications.Delete(postId))
+	if publication, _ = repos.Publications.FindById(postId, aliceId); publication.ID != 0 {
+		t.Errorf("publication was not deleted %+v", publication)
+	}
+}
+
+func testVisibility(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{
+		Users: []string{"author", "follower", "former", "stranger"},
+		Follows: []Follow{
+			{Follower: "follower", User: "author"},
+			{Follower: "former", User: "author"},
+		},
+		Publications: []Post{
+			{Author: "author", Title: "public", Visibility: models.VisibilityPublic},
+			{Author: "author", Title: "followers", Visibility: models.VisibilityFollowers},
+			{Author: "author", Title: "private", Visibility: models.VisibilityPrivate},
+		},
+	})
+
+	world.Unfollow("former", "author")
+
+	viewers := []struct {
+		viewer  string
+		visible []string
+		feed    []string
+	}{
+		{"author", []string{"private", "followers", "public"}, []string{"private", "followers", "public"}},
+		{"follower", []string{"followers", "public"}, []string{"followers", "public"}},
+		{"former", []string{"public"}, nil},
+		{"stranger", []string{"public"}, nil},
+	}
+
+	for _, viewer := range viewers {
+		viewerId := world.User(viewer.viewer)
+
+		visible := []string{}
+		for _, title := range []string{"private", "followers", "public"} {
+			publication, err := repos.Publications.FindById(world.Publication(title), viewerId)
+			check(t, viewer.viewer+" viewing "+title, err)
+
+			if publication.ID != 0 {
+				visible = append(visible, title)
+			}
+		}
+		expect(t, "publications visible to "+viewer.viewer, visible, viewer.visible)
+
+		feed, err := repos.Publications.Find(viewerId, allItems)
+		check(t, "feed of "+viewer.viewer, err)
+		expect(t, "feed of "+viewer.viewer, world.Titles(feed), viewer.feed)
+	}
+}
+
+func testPagination(t *testing.T, repos repositories.Repositories) {
+	titles := []string{"one", "two", "three", "four", "five"}
+
+	graph := Graph{Users: []string{"alice"}}
+	for _, title := range titles {
+		graph.Publications = append(graph.Publications, Post{Author: "alice", Title: title})
+	}
+	world := Build(t, repos, graph)
+
+	limits := []struct {
+		limit int
+		pages int
+	}{
+		{1, 5},
+		{2, 3},
+		{5, 1},
+		{pagination.MaxLimit, 1},
+	}
+
+	for _, limit := range limits {
+		params := pagination.Params{Limit: limit.limit}
+		var seen []string
+		pages := 0
+
+		for {
+			publications, err := repos.Publications.Find(world.User("alice"), params)
+			check(t, "find page", err)
+
+			page := pagination.NewPage(publications, params, models.Publication.Cursor)
+			seen = append(seen, world.Titles(page.Data.([]models.Publication))...)
+			pages++
+
+			if !page.HasMore {
+				break
+			}
+
+			cursor, err := pagination.Decode(page.NextCursor)
+			check(t, "decode cursor", err)
+			params.After = &cursor
+		}
+
+		if pages != limit.pages {
+			t.Errorf("limit %d: expected %d pages, got %d", limit.limit, limit.pages, pages)
+		}
+		expect(t, "publications newest first without repetition", seen, []string{"five", "four", "three", "two", "one"})
+	}
+}
@@ -0,0 +1,79 @@
+// Package repositorytest hold the behavioral suite that every implementation of the repositories must pass
+package repositorytest
+
+import (
+	"api/src/pagination"
+	"api/src/repositories"
+	"reflect"
+	"testing"
+)
+
+// allItems is a page big enough to hold everything created by one test
+var allItems = pagination.Params{Limit: pagination.MaxLimit}
+
+// Factory create empty repositories for one test, MySQL implementations must start from empty tables
+type Factory func(t *testing.T) repositories.Repositories
+
+// Case is one behavior of the suite, run against empty repositories
+type Case struct {
+	Name string
+	Test func(t *testing.T, repos repositories.Repositories)
+}
+
+// Cases list the behaviors of the suite, grouped by the repository feature they cover
+var Cases = []Case{
+	{"Users", testUsers},
+	{"Followers", testFollowers},
+	{"FollowOrder", testFollowOrder},
+	{"Publications", testPublications},
+	{"Visibility", testVisibility},
+	{"FollowRequests", testFollowRequests},
+	{"BlocksAndMutes", testBlocksAndMutes},
+	{"Audience", testAudience},
+	{"Pagination", testPagination},
+	{"Likes", testLikes},
+	{"Comments", testComments},
+	{"Tokens", testTokens},
+	{"Notifications", testNotifications},
+	{"Conversations", testConversations},
+	{"Search", testSearch},
+	{"Hashtags", testHashtags},
+	{"Mentions", testMentions},
+	{"Media", testMedia},
+	{"Reposts", testReposts},
+	{"Drafts", testDrafts},
+	{"Revisions", testRevisions},
+	{"Engagement", testEngagement},
+	{"Timeline", testTimeline},
+	{"Suggestions", testSuggestions},
+}
+
+// Run execute every case of the suite against the repositories created by factory
+func Run(t *testing.T, factory Factory) {
+	for _, c := range Cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) { c.Test(t, factory(t)) })
+	}
+}
+
+// expect report an error when got differs from want, nil and empty slices are the same
+func expect[T any](t *testing.T, what string, got, want []T) {
+	t.Helper()
+
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: expected %v, got %v", what, want, got)
+	}
+}
+
+// check fail the test when err is not nil, describing the step that failed
+func check(t *testing.T, step string, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("%s: %v", step, err)
+	}
+}
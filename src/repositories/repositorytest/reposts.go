@@ -0,0 +1,91 @@
+package repositorytest
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"errors"
+	"testing"
+)
+
+func testReposts(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{
+		Users: []string{"alice", "bob", "carol", "dave", "erin"},
+		Follows: []Follow{
+			{Follower: "carol", User: "alice"},
+			{Follower: "carol", User: "bob"},
+			{Follower: "carol", User: "dave"},
+			{Follower: "bob", User: "erin"},
+		},
+		Publications: []Post{
+			{Author: "alice", Title: "original"},
+			{Author: "erin", Title: "hidden", Visibility: models.VisibilityFollowers},
+		},
+	})
+	original, carolId := world.Publication("original"), world.User("carol")
+
+	repost := func(title, nick, as string) {
+		t.Helper()
+
+		id, err := repos.Publications.Repost(world.Publication(title), world.User(nick))
+		check(t, nick+" reposting "+title, err)
+		world.Name(id, as)
+	}
+
+	repost("original", "bob", "bob repost")
+	if _, err := repos.Publications.Repost(original, world.User("bob")); !errors.Is(err, repositories.ErrAlreadyReposted) {
+		t.Errorf("expected ErrAlreadyReposted, got %v", err)
+	}
+	repost("original", "dave", "dave repost")
+	// carol does not follow erin, so she cannot see erin followers only publication reposted by bob
+	repost("hidden", "bob", "hidden repost")
+
+	if found, _ := repos.Publications.FindById(original, carolId); found.Reposts != 2 {
+		t.Errorf("expected 2 reposts, got %d", found.Reposts)
+	}
+
+	found, _ := repos.Publications.FindById(world.Publication("bob repost"), carolId)
+	if found.RepostOfId != original || found.Original == nil || found.Original.ID != original || found.Original.AuthorId != world.User("alice") {
+		t.Errorf("expected repost with original embedded, got %+v", found)
+	}
+
+	if found, _ := repos.Publications.FindById(world.Publication("hidden repost"), carolId); found.ID != 0 {
+		t.Errorf("repost must follow the original visibility, got %+v", found)
+	}
+
+	feed := func(name string, titles []string) {
+		t.Helper()
+
+		found, err := repos.Publications.Find(carolId, allItems)
+		check(t, name, err)
+		expect(t, name, world.Titles(found), titles)
+	}
+
+	feed("original and its reposts once", []string{"dave repost"})
+
+	world.Create("quote", models.Publication{
+		Title: "quote", Content: "look at this", AuthorId: world.User("bob"), QuoteOfId: original,
+	})
+
+	found, _ = repos.Publications.FindById(world.Publication("quote"), carolId)
+	if found.QuoteOfId != original || found.Original == nil || found.Original.ID != original {
+		t.Errorf("expected quote with original embedded, got %+v", found)
+	}
+
+	check(t, "unrepost", repos.Publications.Unrepost(original, world.User("dave")))
+	if err := repos.Publications.Unrepost(original, world.User("dave")); !errors.Is(err, repositories.ErrNotReposted) {
+		t.Errorf("expected ErrNotReposted, got %v", err)
+	}
+
+	feed("quote and remaining repost", []string{"quote", "bob repost"})
+
+	check(t, "delete original", repos.Publications.Delete(original))
+
+	if found, _ := repos.Publications.FindById(world.Publication("bob repost"), world.User("bob")); found.ID != 0 {
+		t.Errorf("deleting original must remove its reposts, got %+v", found)
+	}
+
+	found, _ = repos.Publications.FindById(world.Publication("quote"), carolId)
+	if found.ID == 0 || found.QuoteOfId != 0 || found.Original != nil {
+		t.Errorf("deleting original must keep quotes without it, got %+v", found)
+	}
+}
@@ -0,0 +1,74 @@
+package repositorytest
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"testing"
+)
+
+func testRevisions(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{
+		Users:        []string{"alice"},
+		Publications: []Post{{Author: "alice", Title: "revised"}},
+	})
+	aliceId, revisedId := world.User("alice"), world.Publication("revised")
+	original, _ := repos.Publications.FindById(revisedId, aliceId)
+
+	edits := []struct {
+		name       string
+		content    string
+		visibility string
+		revisions  []string
+	}{
+		{"visibility change", original.Content, models.VisibilityFollowers, nil},
+		{"first edit", "second version", models.VisibilityPublic, []string{original.Content}},
+		{"second edit", "third version", models.VisibilityPublic, []string{original.Content, "second version"}},
+		{"case only edit", "Third Version", models.VisibilityPublic, []string{original.Content, "second version", "third version"}},
+	}
+
+	var firstEdit models.Publication
+	for _, edit := range edits {
+		check(t, edit.name, repos.Publications.Update(revisedId, models.Publication{
+			Title: "revised", Content: edit.content, Visibility: edit.visibility, Status: models.StatusPublished,
+		}))
+
+		revisions, err := repos.Publications.FindRevisions(revisedId)
+		check(t, edit.name, err)
+
+		var contents []string
+		for _, revision := range revisions {
+			contents = append(contents, revision.Content)
+		}
+		expect(t, edit.name+": revisions", contents, edit.revisions)
+
+		found, _ := repos.Publications.FindById(revisedId, aliceId)
+		if edited := found.EditedAt != nil; edited != (len(edit.revisions) > 0) {
+			t.Errorf("%s: expected edited %v, got %+v", edit.name, !edited, found)
+		}
+
+		if len(edit.revisions) == 1 {
+			firstEdit = found
+		}
+	}
+
+	revisions, _ := repos.Publications.FindRevisions(revisedId)
+	if len(revisions) == 3 {
+		if !revisions[0].CreatedAt.Equal(original.CreatedAt) {
+			t.Errorf("expected first revision dated at creation, got %+v", revisions[0])
+		}
+		if firstEdit.EditedAt == nil || !revisions[1].CreatedAt.Equal(*firstEdit.EditedAt) {
+			t.Errorf("expected second revision dated at first edit, got %+v", revisions[1])
+		}
+	}
+
+	draft := world.Create("draft", models.Publication{Title: "draft", Content: "draft", Status: models.StatusDraft, AuthorId: aliceId})
+	check(t, "edit draft", repos.Publications.Update(draft, models.Publication{Title: "draft", Content: "changed", Status: models.StatusDraft}))
+	if revisions, _ := repos.Publications.FindRevisions(draft); len(revisions) != 0 {
+		t.Errorf("drafts must not keep revisions, got %+v", revisions)
+	}
+
+	check(t, "delete", repos.Publications.Delete(revisedId))
+	if revisions, _ := repos.Publications.FindRevisions(revisedId); len(revisions) != 0 {
+		t.Errorf("expected revisions removed with publication, got %+v", revisions)
+	}
+}
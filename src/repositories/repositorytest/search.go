@@ -0,0 +1,70 @@
+package repositorytest
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"testing"
+)
+
+func testSearch(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{
+		Users:   []string{"alice", "bob", "carol"},
+		Follows: []Follow{{Follower: "alice", User: "bob"}},
+		Publications: []Post{
+			{Author: "bob", Title: "golang golang tips"},
+			{Author: "carol", Title: "golang and mysql"},
+			{Author: "bob", Title: "golang secrets", Visibility: models.VisibilityFollowers},
+			{Author: "carol", Title: "unrelated"},
+		},
+	})
+
+	type search struct {
+		name          string
+		query         string
+		viewer        string
+		limit, offset int
+		results       []string
+	}
+
+	publications := func(searches []search) {
+		t.Helper()
+
+		for _, search := range searches {
+			found, err := repos.Search.SearchPublications(search.query, world.User(search.viewer), search.limit, search.offset)
+			check(t, search.name, err)
+			expect(t, search.name, world.Titles(found), search.results)
+		}
+	}
+
+	users := func(searches []search) {
+		t.Helper()
+
+		for _, search := range searches {
+			found, err := repos.Search.SearchUsers(search.query, world.User(search.viewer), search.limit, search.offset)
+			check(t, search.name, err)
+			expect(t, search.name, world.Nicks(found), search.results)
+		}
+	}
+
+	publications([]search{
+		{"by relevance", "golang", "alice", 10, 0, []string{"golang golang tips", "golang secrets", "golang and mysql"}},
+		{"respecting visibility", "golang", "carol", 10, 0, []string{"golang golang tips", "golang and mysql"}},
+		{"second result", "golang", "alice", 1, 1, []string{"golang secrets"}},
+		{"nothing matching", "rust", "alice", 10, 0, nil},
+	})
+	users([]search{{"user by nick", "carol", "alice", 10, 0, []string{"carol"}}})
+
+	check(t, "block", repos.Users.Block(world.User("carol"), world.User("alice")))
+
+	publications([]search{
+		{"without blocker", "golang", "alice", 10, 0, []string{"golang golang tips", "golang secrets"}},
+		{"blocker keeps searching", "golang", "bob", 10, 0, []string{"golang golang tips", "golang secrets", "golang and mysql"}},
+	})
+	users([]search{{"blocker not found", "carol", "alice", 10, 0, nil}})
+
+	check(t, "update", repos.Publications.Update(world.Publication("golang golang tips"), models.Publication{
+		Title: "rust", Content: "rust", Visibility: models.VisibilityPublic,
+	}))
+
+	publications([]search{{"by new content", "rust", "alice", 10, 0, []string{"golang golang tips"}}})
+}
@@ -0,0 +1,64 @@
+package repositorytest
+
+import (
+	"api/src/pagination"
+	"api/src/repositories"
+	"testing"
+)
+
+func testSuggestions(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{
+		Users: []string{"alice", "bob", "carol", "dave", "erin", "frank"},
+		Follows: []Follow{
+			// alice follows bob and carol and is followed by dave
+			{Follower: "alice", User: "bob"},
+			{Follower: "alice", User: "carol"},
+			{Follower: "dave", User: "alice"},
+			// erin is followed by bob and carol, frank by bob and dave
+			{Follower: "bob", User: "erin"},
+			{Follower: "carol", User: "erin"},
+			{Follower: "bob", User: "frank"},
+			{Follower: "dave", User: "frank"},
+		},
+	})
+	aliceId := world.User("alice")
+
+	type suggested struct {
+		Nick                string
+		FollowedByFollowing uint64
+		SharedFollowers     uint64
+	}
+
+	suggestions := func(name, nick string, limit int, want []suggested) {
+		t.Helper()
+
+		found, err := repos.Users.FindSuggestions(world.User(nick), limit)
+		check(t, name, err)
+
+		got := []suggested{}
+		for _, suggestion := range found {
+			got = append(got, suggested{world.nick(suggestion.User.ID), suggestion.FollowedByFollowing, suggestion.SharedFollowers})
+		}
+		expect(t, name, got, want)
+	}
+
+	mutual := func(name, nick, other string, want []string) {
+		t.Helper()
+
+		found, err := repos.Users.FindMutualFollowers(world.User(nick), world.User(other), pagination.Params{Limit: 10})
+		check(t, name, err)
+		expect(t, name, world.Nicks(found), want)
+	}
+
+	suggestions("ranked by users followed", "alice", 10, []suggested{{"erin", 2, 0}, {"frank", 1, 1}})
+	suggestions("limited", "alice", 1, []suggested{{"erin", 2, 0}})
+	mutual("dave following alice and frank", "alice", "frank", []string{"dave"})
+	mutual("nobody following dave and bob", "dave", "bob", nil)
+
+	check(t, "dismiss", repos.Users.DismissSuggestion(aliceId, world.User("erin")))
+	check(t, "block", repos.Users.Block(world.User("frank"), aliceId))
+	suggestions("dismissed and blocked users left out", "alice", 10, nil)
+
+	world.Follow("dave", "bob")
+	suggestions("ties with most recent user first", "dave", 10, []suggested{{"erin", 1, 0}, {"carol", 1, 0}})
+}
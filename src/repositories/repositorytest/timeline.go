@@ -0,0 +1,78 @@
+package repositorytest
+
+import (
+	"api/src/pagination"
+	"api/src/repositories"
+	"testing"
+)
+
+func testTimeline(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{
+		Users: []string{"alice", "bob", "carol", "dave"},
+		Follows: []Follow{
+			{Follower: "alice", User: "bob"},
+			{Follower: "alice", User: "carol"},
+			{Follower: "carol", User: "bob"},
+		},
+		Publications: []Post{
+			{Author: "alice", Title: "own"},
+			{Author: "bob", Title: "bob"},
+			{Author: "carol", Title: "carol"},
+			{Author: "dave", Title: "dave"},
+		},
+	})
+	aliceId, bobId := world.User("alice"), world.User("bob")
+
+	limits := []struct {
+		limit int
+		count int
+	}{
+		{10, 2},
+		{1, 1},
+	}
+
+	for _, limit := range limits {
+		followerIds, err := repos.Users.FindFollowerIds(bobId, limit.limit)
+		check(t, "find follower ids", err)
+		if len(followerIds) != limit.count {
+			t.Errorf("limit %d: expected %d followers of bob, got %v", limit.limit, limit.count, followerIds)
+		}
+	}
+
+	type timeline struct {
+		name    string
+		cached  []string
+		popular []string
+		titles  []string
+	}
+
+	timelines := func(timelines []timeline) {
+		t.Helper()
+
+		for _, timeline := range timelines {
+			var cachedIds, popularIds []uint64
+			for _, title := range timeline.cached {
+				cachedIds = append(cachedIds, world.Publication(title))
+			}
+			for _, nick := range timeline.popular {
+				popularIds = append(popularIds, world.User(nick))
+			}
+
+			found, err := repos.Publications.FindTimeline(aliceId, cachedIds, popularIds, pagination.Params{Limit: 10})
+			check(t, timeline.name, err)
+			expect(t, timeline.name, world.Titles(found), timeline.titles)
+		}
+	}
+
+	timelines([]timeline{
+		{"cached from followed authors", []string{"bob", "own", "dave"}, nil, []string{"bob", "own"}},
+		{"followed popular authors", []string{"own"}, []string{"carol", "dave"}, []string{"carol", "own"}},
+		{"empty", nil, nil, nil},
+	})
+
+	world.Unfollow("alice", "bob")
+
+	timelines([]timeline{
+		{"stale entries from unfollowed author", []string{"bob", "own"}, nil, []string{"own"}},
+	})
+}
@@ -0,0 +1,68 @@
+package repositorytest
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testTokens(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{Users: []string{"alice"}})
+	aliceId := world.User("alice")
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	refreshToken := func(hash string) models.RefreshToken {
+		return models.RefreshToken{UserId: aliceId, FamilyId: "family", TokenHash: hash, ExpiresAt: expiresAt}
+	}
+
+	firstId, err := repos.Tokens.CreateRefreshToken(refreshToken("hash-1"))
+	check(t, "create refresh token", err)
+
+	first, err := repos.Tokens.FindRefreshTokenByHash("hash-1")
+	check(t, "find refresh token", err)
+	if first.ID != firstId || first.UserId != aliceId || first.RevokedAt != nil || !first.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("unexpected refresh token %+v", first)
+	}
+
+	secondId, err := repos.Tokens.RotateRefreshToken(firstId, refreshToken("hash-2"))
+	check(t, "rotate", err)
+
+	if first, _ = repos.Tokens.FindRefreshTokenByHash("hash-1"); first.RevokedAt == nil {
+		t.Error("rotated token must be revoked")
+	}
+
+	if _, err = repos.Tokens.RotateRefreshToken(firstId, refreshToken("hash-3")); !errors.Is(err, repositories.ErrRefreshTokenReused) {
+		t.Errorf("expected ErrRefreshTokenReused, got %v", err)
+	}
+
+	revocations := []struct {
+		name    string
+		revoke  func() error
+		jti     string
+		family  string
+		revoked bool
+	}{
+		{"nothing revoked", func() error { return nil }, "jti", "family", false},
+		{"family revoked", func() error { return repos.Tokens.RevokeFamily("family", expiresAt) }, "jti", "family", true},
+		{"other family", func() error { return nil }, "jti", "other-family", false},
+		{"token revoked", func() error { return repos.Tokens.RevokeAccessToken("jti", expiresAt) }, "jti", "other-family", true},
+		{"other token", func() error { return nil }, "other-jti", "other-family", false},
+		{"expired family", func() error { return repos.Tokens.RevokeFamily("expired", time.Now().Add(-time.Minute)) }, "other-jti", "expired", false},
+	}
+
+	for _, revocation := range revocations {
+		check(t, revocation.name, revocation.revoke())
+
+		revoked, err := repos.Tokens.IsAccessTokenRevoked(revocation.jti, revocation.family)
+		check(t, "is revoked", err)
+		if revoked != revocation.revoked {
+			t.Errorf("%s: expected revoked %v", revocation.name, revocation.revoked)
+		}
+	}
+
+	if second, _ := repos.Tokens.FindRefreshTokenByHash("hash-2"); second.ID != secondId || second.RevokedAt == nil {
+		t.Errorf("refresh tokens of the family were not revoked %+v", second)
+	}
+}
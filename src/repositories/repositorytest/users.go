@@ -0,0 +1,178 @@
+package repositorytest
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/repositories"
+	"testing"
+	"time"
+)
+
+func testUsers(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{Users: []string{"alice", "bob"}})
+	aliceId := world.User("alice")
+
+	if _, err := repos.Users.Create(models.User{Name: "Other", Nick: "alice", Email: "other@devbook.com", Password: "x"}); err == nil {
+		t.Error("expected error creating user with duplicated nick")
+	}
+
+	alice, err := repos.Users.FindByID(aliceId)
+	check(t, "find alice", err)
+	if alice.Nick != "alice" || alice.Email != "alice@devbook.com" || alice.Password != "" || alice.CreatedAt.IsZero() {
+		t.Errorf("unexpected user %+v", alice)
+	}
+
+	missing, err := repos.Users.FindByID(aliceId + 100)
+	if err != nil || missing.ID != 0 {
+		t.Errorf("expected empty user, got %+v, %v", missing, err)
+	}
+
+	searches := []struct {
+		filter string
+		nicks  []string
+	}{
+		{"ali", []string{"alice"}},
+		{"b", []string{"bob"}},
+		{"", []string{"alice", "bob"}},
+		{"nobody", nil},
+	}
+
+	for _, search := range searches {
+		found, err := repos.Users.Find(search.filter, 0, allItems)
+		check(t, "find "+search.filter, err)
+		expect(t, "users matching "+search.filter, world.Nicks(found), search.nicks)
+	}
+
+	byEmail, err := repos.Users.FindByEmail("alice@devbook.com")
+	if err != nil || byEmail.ID != aliceId || byEmail.Password != "hash-alice" {
+		t.Errorf("unexpected user by email %+v, %v", byEmail, err)
+	}
+
+	check(t, "update alice", repos.Users.Update(aliceId, models.User{Name: "Alice", Nick: "alice2", Email: "alice2@devbook.com"}))
+	alice, _ = repos.Users.FindByID(aliceId)
+	if alice.Name != "Alice" || alice.Nick != "alice2" || alice.Email != "alice2@devbook.com" {
+		t.Errorf("user was not updated %+v", alice)
+	}
+
+	check(t, "update password", repos.Users.UpdateUserPassword(aliceId, "new-hash"))
+	if password, _ := repos.Users.FindPasswordById(aliceId); password != "new-hash" {
+		t.Errorf("expected new password, got %q", password)
+	}
+
+	check(t, "delete alice", repos.Users.Delete(aliceId))
+	if alice, _ = repos.Users.FindByID(aliceId); alice.ID != 0 {
+		t.Errorf("user was not deleted %+v", alice)
+	}
+}
+
+func testFollowers(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{
+		Users: []string{"alice", "bob", "carol"},
+		Follows: []Follow{
+			{Follower: "bob", User: "alice"},
+			{Follower: "carol", User: "alice"},
+			{Follower: "bob", User: "carol"},
+		},
+	})
+
+	if err := repos.Users.Follower(world.User("alice"), world.User("bob")); err == nil {
+		t.Error("expected error following twice")
+	}
+
+	relations := func(t *testing.T, followers, following map[string][]string) {
+		t.Helper()
+
+		for nick, want := range followers {
+			found, err := repos.Users.FindFollowersByUserId(world.User(nick), allItems)
+			check(t, "followers of "+nick, err)
+			expect(t, "followers of "+nick, world.Nicks(found), want)
+		}
+
+		for nick, want := range following {
+			found, err := repos.Users.FindFollowingByUserId(world.User(nick), allItems)
+			check(t, "following of "+nick, err)
+			expect(t, "following of "+nick, world.Nicks(found), want)
+		}
+	}
+
+	relations(t,
+		map[string][]string{"alice": {"bob", "carol"}, "bob": nil, "carol": {"bob"}},
+		map[string][]string{"alice": nil, "bob": {"alice", "carol"}, "carol": {"alice"}},
+	)
+
+	world.Unfollow("bob", "alice")
+	if unfollowed, err := repos.Users.Unfollow(world.User("alice"), world.User("bob")); err != nil || unfollowed {
+		t.Errorf("expected unfollowing twice to report nothing removed, got %v, %v", unfollowed, err)
+	}
+
+	relations(t,
+		map[string][]string{"alice": {"carol"}},
+		map[string][]string{"bob": {"carol"}},
+	)
+
+	check(t, "delete carol", repos.Users.Delete(world.User("carol")))
+	relations(t,
+		map[string][]string{"alice": nil},
+		map[string][]string{"bob": nil},
+	)
+}
+
+func testFollowOrder(t *testing.T, repos repositories.Repositories) {
+	world := Build(t, repos, Graph{
+		Users: []string{"alice", "bob", "carol", "dave"},
+		Follows: []Follow{
+			{Follower: "dave", User: "alice"},
+			{Follower: "carol", User: "bob"},
+		},
+	})
+
+	// MySQL keeps follows to the second, so the last follow must happen in the next one
+	time.Sleep(1100 * time.Millisecond)
+	world.Follow("carol", "alice")
+
+	lists := []struct {
+		name string
+		find func(page pagination.Params) ([]models.User, error)
+		want []string
+	}{
+		{
+			name: "followers of alice",
+			find: func(page pagination.Params) ([]models.User, error) {
+				return repos.Users.FindFollowersByUserId(world.User("alice"), page)
+			},
+			want: []string{"carol", "dave"},
+		},
+		{
+			name: "following of carol",
+			find: func(page pagination.Params) ([]models.User, error) {
+				return repos.Users.FindFollowingByUserId(world.User("carol"), page)
+			},
+			want: []string{"alice", "bob"},
+		},
+	}
+
+	for _, list := range lists {
+		params := pagination.Params{Limit: 1}
+		var seen []string
+
+		for {
+			found, err := list.find(params)
+			check(t, list.name, err)
+
+			page := pagination.NewPage(found, params, models.User.RelationCursor)
+			for _, user := range page.Data.([]models.User) {
+				seen = append(seen, world.nick(user.ID))
+			}
+
+			if !page.HasMore {
+				break
+			}
+
+			cursor, err := pagination.Decode(page.NextCursor)
+			check(t, "decode cursor", err)
+			params.After = &cursor
+		}
+
+		expect(t, list.name+" most recent follow first", seen, list.want)
+	}
+}
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"api/src/models"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// repostsColumn count the reposts of publication p
+const repostsColumn = "(SELECT COUNT(*) FROM publications rp WHERE rp.repost_of_id = p.id)"
+
+// repostVisible is the condition that hides reposts p when viewer is not allowed to see the original
+// or the original author is blocked in any direction, receiving viewer id four times
+var repostVisible = `(p.repost_of_id IS NULL OR EXISTS (
+	SELECT 1 FROM publications o INNER JOIN users ou ON ou.id = o.author_id
+	WHERE o.id = p.repost_of_id AND (o.author_id = ? OR (o.visibility = 'public' AND NOT ou.is_private) OR (o.visibility IN ('public', 'followers') AND EXISTS (
+		SELECT 1 FROM followers ovf WHERE ovf.user_id = o.author_id AND ovf.follower_id = ?
+	))) AND ` + notBlocked("o.author_id") + `
+))`
+
+var (
+	// ErrAlreadyReposted is returned when user tries to repost the same publication twice
+	ErrAlreadyReposted = errors.New("Publication already reposted by this user")
+	// ErrNotReposted is returned when user tries to undo a repost never made
+	ErrNotReposted = errors.New("Publication was not reposted by this user")
+)
+
+// Repost create a publication from user sharing the original, visible only where the original is
+func (repository Publications) Repost(originalId, userId uint64) (uint64, error) {
+	statement, err := repository.db.Prepare(
+		"INSERT INTO publications (title, content, visibility, author_id, repost_of_id) values ('', '', ?, ?, ?)",
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer statement.Close()
+
+	result, err := statement.Exec(models.VisibilityPublic, userId, originalId)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			return 0, ErrAlreadyReposted
+		}
+		return 0, err
+	}
+
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastId), nil
+}
+
+// Unrepost remove the repost of the original made by user
+func (repository Publications) Unrepost(originalId, userId uint64) error {
+	result, err := repository.db.Exec(
+		"DELETE FROM publications WHERE repost_of_id = ? AND author_id = ?",
+		originalId, userId,
+	)
+	if err != nil {
+		return err
+	}
+
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affectedRows == 0 {
+		return ErrNotReposted
+	}
+
+	return nil
+}
+
+// embedOriginals fill the original of reposts and quotes from publications,
+// when viewer is allowed to see it and its author is not blocked in any direction
+func embedOriginals(db *sql.DB, publications []models.Publication, viewerId uint64) error {
+	var ids []interface{}
+	for _, publication := range publications {
+		if originalId := publication.OriginalId(); originalId != 0 {
+			ids = append(ids, originalId)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	lines, err := db.Query(`
+		SELECT `+publicationColumns+` FROM publications p
+		INNER JOIN users u ON u.id = p.author_id
+		WHERE p.id IN (`+placeholders+`) AND `+visibleToViewer+` AND `+notBlocked("p.author_id"),
+		append(ids, viewerId, viewerId, viewerId, viewerId)...,
+	)
+	if err != nil {
+		return err
+	}
+	defer lines.Close()
+
+	originals := map[uint64]models.Publication{}
+
+	for lines.Next() {
+		var original models.Publication
+
+		if err = scanPublication(lines, &original); err != nil {
+			return err
+		}
+
+		originals[original.ID] = original
+	}
+
+	for i := range publications {
+		if original, ok := originals[publications[i].OriginalId()]; ok {
+			publications[i].Original = &original
+		}
+	}
+
+	return nil
+}
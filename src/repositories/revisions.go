@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"api/src/models"
+	"database/sql"
+)
+
+// saveRevision keep the current title and content of a published publication before publication replaces them,
+// the revision is dated when that version was written. Drafts and unchanged texts keep no revision.
+// Texts are compared as bytes, since the case insensitive collation of the table would ignore case only edits
+func saveRevision(tx *sql.Tx, publicationId uint64, publication models.Publication) error {
+	_, err := tx.Exec(`
+		INSERT INTO publication_revisions (publication_id, title, content, createdAt)
+		SELECT id, title, content, COALESCE(edited_at, createdAt) FROM publications
+		WHERE id = ? AND status = 'published' AND (BINARY title <> ? OR BINARY content <> ?)`,
+		publicationId, publication.Title, publication.Content,
+	)
+	return err
+}
+
+// FindRevisions return the previous versions of publication, oldest first
+func (repository Publications) FindRevisions(publicationId uint64) ([]models.PublicationRevision, error) {
+	lines, err := repository.db.Query(`
+		SELECT id, publication_id, title, content, createdAt FROM publication_revisions
+		WHERE publication_id = ? ORDER BY createdAt, id`,
+		publicationId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var revisions []models.PublicationRevision
+
+	for lines.Next() {
+		var revision models.PublicationRevision
+
+		if err = lines.Scan(
+			&revision.ID,
+			&revision.PublicationId,
+			&revision.Title,
+			&revision.Content,
+			&revision.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"api/src/models"
+	"database/sql"
+)
+
+type search struct {
+	db *sql.DB
+}
+
+// NewSearcher create a searcher backed by the FULLTEXT indexes from users and publications
+func NewSearcher(db *sql.DB) Searcher {
+	return &search{db}
+}
+
+// SearchUsers return users whose name or nick match query ordered by relevance,
+// without users blocked by or blocking viewer
+func (repository search) SearchUsers(query string, viewerId uint64, limit, offset int) ([]models.User, error) {
+	lines, err := repository.db.Query(`
+		SELECT id, name, nick, email, is_private, createdAt FROM users
+		WHERE MATCH (name, nick) AGAINST (? IN NATURAL LANGUAGE MODE) AND `+notBlocked("users.id")+`
+		ORDER BY MATCH (name, nick) AGAINST (? IN NATURAL LANGUAGE MODE) DESC, id DESC
+		LIMIT ? OFFSET ?`,
+		query, viewerId, viewerId, query, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var users []models.User
+
+	for lines.Next() {
+		var user models.User
+
+		if err = lines.Scan(
+			&user.ID,
+			&user.Name,
+			&user.Nick,
+			&user.Email,
+			&user.IsPrivate,
+			&user.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// SearchPublications return publications whose title or content match query ordered by relevance,
+// only the ones viewer is allowed to see and without authors blocked in any direction
+func (repository search) SearchPublications(query string, viewerId uint64, limit, offset int) ([]models.Publication, error) {
+	lines, err := repository.db.Query(`
+		SELECT `+publicationColumns+` FROM publications p
+		INNER JOIN users u ON u.id = p.author_id
+		WHERE MATCH (p.title, p.content) AGAINST (? IN NATURAL LANGUAGE MODE)
+		AND p.status = 'published' AND `+visibleToViewer+` AND `+notBlocked("p.author_id")+`
+		ORDER BY MATCH (p.title, p.content) AGAINST (? IN NATURAL LANGUAGE MODE) DESC, p.id DESC
+		LIMIT ? OFFSET ?`,
+		query, viewerId, viewerId, viewerId, viewerId, query, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var publications []models.Publication
+
+	for lines.Next() {
+		var publication models.Publication
+
+		if err = scanPublication(lines, &publication); err != nil {
+			return nil, err
+		}
+
+		publications = append(publications, publication)
+	}
+	lines.Close()
+
+	if err = embedOriginals(repository.db, publications, viewerId); err != nil {
+		return nil, err
+	}
+
+	return publications, nil
+}
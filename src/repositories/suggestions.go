@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"api/src/models"
+	"api/src/pagination"
+)
+
+// FindSuggestions return up to limit users that user may want to follow, ranked by how many users followed by user
+// follow them and how many followers of user also follow them. Users already followed or asked to be followed,
+// blocked in any direction or dismissed are left out
+func (repository users) FindSuggestions(userId uint64, limit int) ([]models.Suggestion, error) {
+	lines, err := repository.db.Query(`
+		SELECT u.id, u.name, u.nick, u.email, u.is_private, u.createdAt,
+			SUM(c.following) AS following, SUM(c.shared) AS shared
+		FROM (
+			SELECT f.user_id AS candidate_id, 1 AS following, 0 AS shared
+			FROM followers v INNER JOIN followers f ON f.follower_id = v.user_id
+			WHERE v.follower_id = ?
+			UNION ALL
+			SELECT f.user_id, 0, 1
+			FROM followers v INNER JOIN followers f ON f.follower_id = v.follower_id
+			WHERE v.user_id = ?
+		) c INNER JOIN users u ON u.id = c.candidate_id
+		WHERE u.id <> ?
+			AND NOT EXISTS (SELECT 1 FROM followers f WHERE f.user_id = u.id AND f.follower_id = ?)
+			AND NOT EXISTS (SELECT 1 FROM follow_requests r WHERE r.user_id = u.id AND r.requester_id = ?)
+			AND NOT EXISTS (SELECT 1 FROM suggestion_dismissals d WHERE d.user_id = ? AND d.dismissed_id = u.id)
+			AND `+notBlocked("u.id")+`
+		GROUP BY u.id, u.name, u.nick, u.email, u.is_private, u.createdAt
+		ORDER BY ? * SUM(c.following) + SUM(c.shared) DESC, SUM(c.following) DESC, u.id DESC
+		LIMIT ?
+	`, userId, userId, userId, userId, userId, userId, userId, userId, models.FollowingWeight, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var suggestions []models.Suggestion
+	for lines.Next() {
+		var suggestion models.Suggestion
+
+		if err = lines.Scan(
+			&suggestion.User.ID,
+			&suggestion.User.Name,
+			&suggestion.User.Nick,
+			&suggestion.User.Email,
+			&suggestion.User.IsPrivate,
+			&suggestion.User.CreatedAt,
+			&suggestion.FollowedByFollowing,
+			&suggestion.SharedFollowers,
+		); err != nil {
+			return nil, err
+		}
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions, nil
+}
+
+// DismissSuggestion stop suggesting dismissed user to user
+func (repository users) DismissSuggestion(userId, dismissedId uint64) error {
+	statement, err := repository.db.Prepare("INSERT IGNORE INTO suggestion_dismissals (user_id, dismissed_id) values (?, ?)")
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(userId, dismissedId); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindMutualFollowers find one page of users that follow both user and other user, hiding the ones blocked by user
+func (repository users) FindMutualFollowers(userId, otherId uint64, page pagination.Params) ([]models.User, error) {
+	cursor, cursorArgs := page.Where("u.createdAt", "u.id")
+
+	args := append([]interface{}{userId, otherId, userId, userId}, cursorArgs...)
+	lines, err := repository.db.Query(`
+		SELECT u.id, u.name, u.nick, u.email, u.is_private, u.createdAt
+		FROM users u
+		INNER JOIN followers a ON a.follower_id = u.id AND a.user_id = ?
+		INNER JOIN followers b ON b.follower_id = u.id AND b.user_id = ?
+		WHERE `+notBlocked("u.id")+` AND `+cursor+`
+		ORDER BY u.createdAt DESC, u.id DESC LIMIT ?
+	`, append(args, page.FetchLimit())...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var users []models.User
+	for lines.Next() {
+		var user models.User
+
+		if err = lines.Scan(
+			&user.ID,
+			&user.Name,
+			&user.Nick,
+			&user.Email,
+			&user.IsPrivate,
+			&user.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
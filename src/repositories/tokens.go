@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	"api/src/models"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrRefreshTokenReused is returned when a refresh token already rotated or revoked is used again
+var ErrRefreshTokenReused = errors.New("Refresh token was already used")
+
+type Tokens struct {
+	db *sql.DB
+}
+
+// NewTokenRepository create one repository of tokens
+func NewTokenRepository(db *sql.DB) TokenRepository {
+	return &Tokens{db}
+}
+
+// CreateRefreshToken insert a refresh token in database
+func (repository Tokens) CreateRefreshToken(token models.RefreshToken) (uint64, error) {
+	statement, err := repository.db.Prepare(
+		"INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at) values (?, ?, ?, ?)",
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer statement.Close()
+
+	result, err := statement.Exec(token.UserId, token.FamilyId, token.TokenHash, token.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastId), nil
+}
+
+// FindRefreshTokenByHash find refresh token by token hash
+func (repository Tokens) FindRefreshTokenByHash(tokenHash string) (models.RefreshToken, error) {
+	line, err := repository.db.Query(
+		"SELECT id, user_id, family_id, token_hash, expires_at, revoked_at FROM refresh_tokens WHERE token_hash = ?",
+		tokenHash,
+	)
+	if err != nil {
+		return models.RefreshToken{}, err
+	}
+	defer line.Close()
+
+	var token models.RefreshToken
+
+	if line.Next() {
+		if err = line.Scan(
+			&token.ID,
+			&token.UserId,
+			&token.FamilyId,
+			&token.TokenHash,
+			&token.ExpiresAt,
+			&token.RevokedAt,
+		); err != nil {
+			return models.RefreshToken{}, err
+		}
+	}
+
+	return token, nil
+}
+
+// RotateRefreshToken revoke the used refresh token and insert the new one in same transaction.
+// If used token was already revoked ErrRefreshTokenReused is returned and nothing is inserted.
+func (repository Tokens) RotateRefreshToken(usedTokenId uint64, newToken models.RefreshToken) (uint64, error) {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"UPDATE refresh_tokens SET revoked_at = current_timestamp WHERE id = ? AND revoked_at IS NULL",
+		usedTokenId,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if affectedRows == 0 {
+		return 0, ErrRefreshTokenReused
+	}
+
+	result, err = tx.Exec(
+		"INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at) values (?, ?, ?, ?)",
+		newToken.UserId, newToken.FamilyId, newToken.TokenHash, newToken.ExpiresAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	lastId, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastId), tx.Commit()
+}
+
+// RevokeFamily revoke all refresh tokens from the same family and reject the access tokens
+// issued to it until expiresAt, when the last one of them expires
+func (repository Tokens) RevokeFamily(familyId string, expiresAt time.Time) error {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(
+		"UPDATE refresh_tokens SET revoked_at = current_timestamp WHERE family_id = ? AND revoked_at IS NULL",
+		familyId,
+	); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec("DELETE FROM revoked_families WHERE expires_at < current_timestamp"); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(
+		"INSERT INTO revoked_families (family_id, expires_at) values (?, ?) ON DUPLICATE KEY UPDATE expires_at = GREATEST(expires_at, VALUES(expires_at))",
+		familyId, expiresAt,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeAccessToken store access token id until it expires, removing already expired ones
+func (repository Tokens) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	if _, err := repository.db.Exec("DELETE FROM revoked_tokens WHERE expires_at < current_timestamp"); err != nil {
+		return err
+	}
+
+	statement, err := repository.db.Prepare(
+		"INSERT IGNORE INTO revoked_tokens (jti, expires_at) values (?, ?)",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(jti, expiresAt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IsAccessTokenRevoked verify if access token id or the family it was issued to were revoked
+func (repository Tokens) IsAccessTokenRevoked(jti, familyId string) (bool, error) {
+	var revoked bool
+	if err := repository.db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = ?)
+			OR EXISTS (SELECT 1 FROM revoked_families WHERE family_id = ? AND expires_at >= current_timestamp)`,
+		jti, familyId,
+	).Scan(&revoked); err != nil {
+		return false, err
+	}
+
+	return revoked, nil
+}
@@ -2,8 +2,20 @@ package repositories
 
 import (
 	"api/src/models"
+	"api/src/pagination"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var (
+	// ErrFollowRequestExists is returned when user asks to follow the same private account twice
+	ErrFollowRequestExists = errors.New("Follow request already sent to this user")
+	// ErrFollowRequestNotFound is returned when there is no pending request from requester
+	ErrFollowRequestNotFound = errors.New("Follow request not found")
 )
 
 type users struct {
@@ -11,14 +23,14 @@ type users struct {
 }
 
 //NewUserRepository create a user repository
-func NewUserRepository(db *sql.DB) *users {
+func NewUserRepository(db *sql.DB) UserRepository {
 	return &users{db}
 }
 
 //Create insert a user in database
 func (repository users) Create(user models.User) (uint64, error) {
 	statement, err := repository.db.Prepare(
-		"insert into users (name, nick, email, password) values(?, ?, ?, ?)",
+		"insert into users (name, nick, email, password, is_private) values(?, ?, ?, ?, ?)",
 	)
 
 	if err != nil {
@@ -26,7 +38,7 @@ func (repository users) Create(user models.User) (uint64, error) {
 	}
 	defer statement.Close()
 
-	result, err := statement.Exec(user.Name, user.Nick, user.Email, user.Password)
+	result, err := statement.Exec(user.Name, user.Nick, user.Email, user.Password, user.IsPrivate)
 	if err != nil {
 		return 0, err
 	}
@@ -39,13 +51,16 @@ func (repository users) Create(user models.User) (uint64, error) {
 	return uint64(lastInsertId), nil
 }
 
-//Find return all user filtered by name or nick
-func (repository users) Find(nameOrNick string) ([]models.User, error) {
+//Find return one page of users filtered by name or nick, without users blocked by or blocking viewer
+func (repository users) Find(nameOrNick string, viewerId uint64, page pagination.Params) ([]models.User, error) {
 	nameOrNick = fmt.Sprintf("%%%s%%", nameOrNick) //%nameOrNick%
+	cursor, cursorArgs := page.Where("createdAt", "id")
 
+	args := append([]interface{}{nameOrNick, nameOrNick, viewerId, viewerId}, cursorArgs...)
 	lines, err := repository.db.Query(
-		"SELECT id, name, nick, email, createdAt FROM users WHERE name LIKE ? or nick LIKE ?",
-		nameOrNick, nameOrNick,
+		"SELECT id, name, nick, email, is_private, createdAt FROM users WHERE (name LIKE ? or nick LIKE ?) AND "+
+			notBlocked("users.id")+" AND "+cursor+" ORDER BY createdAt DESC, id DESC LIMIT ?",
+		append(args, page.FetchLimit())...,
 	)
 
 	if err != nil {
@@ -64,6 +79,7 @@ func (repository users) Find(nameOrNick string) ([]models.User, error) {
 			&user.Name,
 			&user.Nick,
 			&user.Email,
+			&user.IsPrivate,
 			&user.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -78,7 +94,7 @@ func (repository users) Find(nameOrNick string) ([]models.User, error) {
 //FindByID return a user from database
 func (repository users) FindByID(ID uint64) (models.User, error) {
 	line, err := repository.db.Query(
-		"SELECT id, name, nick, email, createAt FROM users WHERE id = ?",
+		"SELECT id, name, nick, email, is_private, createdAt FROM users WHERE id = ?",
 		ID,
 	)
 
@@ -95,6 +111,7 @@ func (repository users) FindByID(ID uint64) (models.User, error) {
 			&user.Name,
 			&user.Nick,
 			&user.Email,
+			&user.IsPrivate,
 			&user.CreatedAt,
 		); err != nil {
 			return models.User{}, err
@@ -104,21 +121,34 @@ func (repository users) FindByID(ID uint64) (models.User, error) {
 	return user, nil
 }
 
-//Update edit user in database
+//Update edit user in database, approving the pending follow requests when the account is public
 func (repository users) Update(ID uint64, user models.User) error {
-	statement, err := repository.db.Prepare(
-		"UPDATE users SET name = ?, nick = ?, email = ? WHERE id = ?",
-	)
+	tx, err := repository.db.Begin()
 	if err != nil {
 		return err
 	}
-	defer statement.Close()
+	defer tx.Rollback()
 
-	if _, err = statement.Exec(user.Name, user.Nick, user.Email, ID); err != nil {
+	if _, err = tx.Exec(
+		"UPDATE users SET name = ?, nick = ?, email = ?, is_private = ? WHERE id = ?",
+		user.Name, user.Nick, user.Email, user.IsPrivate, ID,
+	); err != nil {
 		return err
 	}
 
-	return nil
+	if !user.IsPrivate {
+		if _, err = tx.Exec(
+			"INSERT IGNORE INTO followers (user_id, follower_id) SELECT user_id, requester_id FROM follow_requests WHERE user_id = ?", ID,
+		); err != nil {
+			return err
+		}
+
+		if _, err = tx.Exec("DELETE FROM follow_requests WHERE user_id = ?", ID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
 //Delete remove user from database
@@ -172,28 +202,39 @@ func (repository users) Follower(userId, followerId uint64) error {
 	return nil
 }
 
-func (repository users) Unfollow(userId, followerId uint64) error {
+// Unfollow remove follower user id, reporting if follower was following user
+func (repository users) Unfollow(userId, followerId uint64) (bool, error) {
 	statement, err := repository.db.Prepare(
 		"DELETE FROM followers WHERE user_id = ? AND follower_id = ?",
 	)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer statement.Close()
 
-	if _, err = statement.Exec(userId, followerId); err != nil {
-		return err
+	result, err := statement.Exec(userId, followerId)
+	if err != nil {
+		return false, err
 	}
 
-	return nil
+	affectedRows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affectedRows == 1, nil
 }
 
-// FindFollowersByUserId find all follow from user
-func (repository users) FindFollowersByUserId(userId uint64) ([]models.User, error) {
+// FindFollowersByUserId find one page of followers from user, most recent follow first
+func (repository users) FindFollowersByUserId(userId uint64, page pagination.Params) ([]models.User, error) {
+	cursor, cursorArgs := page.Where("f.createdAt", "f.follower_id")
+
+	args := append([]interface{}{userId}, cursorArgs...)
 	lines, err := repository.db.Query(`
-		SELECT u.id, u.name, u.nick, u.email, u.createdAt
-		FROM users u INNER JOIN followers f ON u.id = f.follower_id WHERE f.user_id = ?
-	`, userId,
+		SELECT u.id, u.name, u.nick, u.email, u.is_private, u.createdAt, f.createdAt
+		FROM users u INNER JOIN followers f ON u.id = f.follower_id WHERE f.user_id = ? AND `+cursor+`
+		ORDER BY f.createdAt DESC, f.follower_id DESC LIMIT ?
+	`, append(args, page.FetchLimit())...,
 	)
 	if err != nil {
 		return nil, err
@@ -209,7 +250,9 @@ func (repository users) FindFollowersByUserId(userId uint64) ([]models.User, err
 			&follower.Name,
 			&follower.Nick,
 			&follower.Email,
+			&follower.IsPrivate,
 			&follower.CreatedAt,
+			&follower.RelatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -220,12 +263,16 @@ func (repository users) FindFollowersByUserId(userId uint64) ([]models.User, err
 	return followers, nil
 }
 
-// FindFollowingByUserId find all users that user is following
-func (repository users) FindFollowingByUserId(userId uint64) ([]models.User, error) {
+// FindFollowingByUserId find one page of users that user is following, most recent follow first
+func (repository users) FindFollowingByUserId(userId uint64, page pagination.Params) ([]models.User, error) {
+	cursor, cursorArgs := page.Where("f.createdAt", "f.user_id")
+
+	args := append([]interface{}{userId}, cursorArgs...)
 	lines, err := repository.db.Query(`
-		SELECT u.id, u.name, u.nick, u.email, u.createdAt
-		FROM users u INNER JOIN followers f ON u.id = f.user_id WHERE f.follower_id = ?
-	`, userId,
+		SELECT u.id, u.name, u.nick, u.email, u.is_private, u.createdAt, f.createdAt
+		FROM users u INNER JOIN followers f ON u.id = f.user_id WHERE f.follower_id = ? AND `+cursor+`
+		ORDER BY f.createdAt DESC, f.user_id DESC LIMIT ?
+	`, append(args, page.FetchLimit())...,
 	)
 	if err != nil {
 		return nil, err
@@ -242,7 +289,9 @@ func (repository users) FindFollowingByUserId(userId uint64) ([]models.User, err
 			&user.Name,
 			&user.Nick,
 			&user.Email,
+			&user.IsPrivate,
 			&user.CreatedAt,
+			&user.RelatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -254,6 +303,199 @@ func (repository users) FindFollowingByUserId(userId uint64) ([]models.User, err
 	return users, nil
 }
 
+// FindFollowerIds return the ids of up to limit followers from user, in no particular order
+func (repository users) FindFollowerIds(userId uint64, limit int) ([]uint64, error) {
+	lines, err := repository.db.Query(
+		"SELECT follower_id FROM followers WHERE user_id = ? LIMIT ?",
+		userId, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var ids []uint64
+	for lines.Next() {
+		var id uint64
+
+		if err = lines.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// FindAudience return the users among userIds that follow author without muting the author, in one query
+func (repository users) FindAudience(authorId uint64, userIds []uint64) ([]uint64, error) {
+	if len(userIds) == 0 {
+		return nil, nil
+	}
+
+	args := []interface{}{authorId}
+	for _, id := range userIds {
+		args = append(args, id)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(userIds)), ", ")
+	lines, err := repository.db.Query(`
+		SELECT f.follower_id FROM followers f
+		WHERE f.user_id = ? AND f.follower_id IN (`+placeholders+`) AND NOT EXISTS (
+			SELECT 1 FROM user_mutes m WHERE m.user_id = f.follower_id AND m.muted_id = f.user_id
+		)`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var ids []uint64
+	for lines.Next() {
+		var id uint64
+
+		if err = lines.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// IsFollower return if follower id follows user id
+func (repository users) IsFollower(userId, followerId uint64) (bool, error) {
+	var exists bool
+	if err := repository.db.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM followers WHERE user_id = ? AND follower_id = ?)",
+		userId, followerId,
+	).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// CreateFollowRequest add a pending request from requester to follow user
+func (repository users) CreateFollowRequest(userId, requesterId uint64) error {
+	statement, err := repository.db.Prepare(
+		"INSERT INTO follow_requests (user_id, requester_id) values (?, ?)",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(userId, requesterId); err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			return ErrFollowRequestExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// FindFollowRequests find one page of pending follow requests sent to user
+func (repository users) FindFollowRequests(userId uint64, page pagination.Params) ([]models.FollowRequest, error) {
+	cursor, cursorArgs := page.Where("r.createdAt", "r.requester_id")
+
+	args := append([]interface{}{userId}, cursorArgs...)
+	lines, err := repository.db.Query(`
+		SELECT r.user_id, r.requester_id, u.name, u.nick, r.createdAt
+		FROM follow_requests r INNER JOIN users u ON u.id = r.requester_id WHERE r.user_id = ? AND `+cursor+`
+		ORDER BY r.createdAt DESC, r.requester_id DESC LIMIT ?
+	`, append(args, page.FetchLimit())...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	var requests []models.FollowRequest
+	for lines.Next() {
+		var request models.FollowRequest
+
+		if err = lines.Scan(
+			&request.UserId,
+			&request.RequesterId,
+			&request.RequesterName,
+			&request.RequesterNick,
+			&request.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, request)
+	}
+
+	return requests, nil
+}
+
+// ApproveFollowRequest remove the pending request and make requester a follower of user
+func (repository users) ApproveFollowRequest(userId, requesterId uint64) error {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"DELETE FROM follow_requests WHERE user_id = ? AND requester_id = ?", userId, requesterId,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrFollowRequestNotFound
+	}
+
+	if _, err = tx.Exec(
+		"INSERT IGNORE INTO followers (user_id, follower_id) values (?, ?)", userId, requesterId,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteFollowRequest remove the pending request, used to reject or cancel it
+func (repository users) DeleteFollowRequest(userId, requesterId uint64) error {
+	statement, err := repository.db.Prepare(
+		"DELETE FROM follow_requests WHERE user_id = ? AND requester_id = ?",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	result, err := statement.Exec(userId, requesterId)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrFollowRequestNotFound
+	}
+
+	return nil
+}
+
 // FindPasswordById find user password by user id
 func (repository users) FindPasswordById(userId uint64) (string, error) {
 	line, err := repository.db.Query("SELECT password FROM users where id = ?", userId)
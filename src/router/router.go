@@ -1,13 +1,14 @@
 package router
 
 import (
+	"api/src/controllers"
 	"api/src/router/routes"
 
 	"github.com/gorilla/mux"
 )
 
 //Generate return new router with configs routes
-func Generate() *mux.Router {
+func Generate(handler *controllers.Handler) *mux.Router {
 	r := mux.NewRouter()
-	return routes.Configure(r)
+	return routes.Configure(r, handler)
 }
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+func routesComments(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                   "/publications/{publicationId}/comments",
+			Method:                http.MethodPost,
+			Function:              handler.CreateComment,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/publications/{publicationId}/comments",
+			Method:                http.MethodGet,
+			Function:              handler.FindCommentsByPublication,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/publications/{publicationId}/comments/{commentId}",
+			Method:                http.MethodPut,
+			Function:              handler.UpdateComment,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/publications/{publicationId}/comments/{commentId}",
+			Method:                http.MethodDelete,
+			Function:              handler.DeleteComment,
+			RequireAuthentication: true,
+		},
+	}
+}
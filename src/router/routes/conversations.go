@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+func conversationRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                   "/conversations",
+			Method:                http.MethodPost,
+			Function:              handler.CreateConversation,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/conversations",
+			Method:                http.MethodGet,
+			Function:              handler.FindConversations,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/conversations/{conversationId}",
+			Method:                http.MethodGet,
+			Function:              handler.FindConversation,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/conversations/{conversationId}/messages",
+			Method:                http.MethodGet,
+			Function:              handler.FindMessages,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/conversations/{conversationId}/messages",
+			Method:                http.MethodPost,
+			Function:              handler.SendMessage,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/conversations/{conversationId}/read",
+			Method:                http.MethodPost,
+			Function:              handler.MarkConversationRead,
+			RequireAuthentication: true,
+		},
+		{
+			// authenticated by the controller, browsers send the token as WebSocket subprotocol
+			URI:                   "/ws",
+			Method:                http.MethodGet,
+			Function:              handler.DirectMessagesSocket,
+			RequireAuthentication: false,
+		},
+	}
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+func draftRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                   "/drafts",
+			Method:                http.MethodGet,
+			Function:              handler.FindDrafts,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/drafts/{publicationId}",
+			Method:                http.MethodPut,
+			Function:              handler.UpdateDraft,
+			RequireAuthentication: true,
+		},
+	}
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+func hashtagRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                   "/hashtags/trending",
+			Method:                http.MethodGet,
+			Function:              handler.FindTrendingHashtags,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/hashtags/{tag}/publications",
+			Method:                http.MethodGet,
+			Function:              handler.FindPublicationsByHashtag,
+			RequireAuthentication: true,
+		},
+	}
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+func healthRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                   "/health/db",
+			Method:                http.MethodGet,
+			Function:              handler.DatabaseHealth,
+			RequireAuthentication: false,
+		},
+	}
+}
@@ -5,9 +5,31 @@ import (
 	"net/http"
 )
 
-var loginRoute = Route{
-	URI:                   "/login",
-	Method:                http.MethodPost,
-	Function:              controllers.Login,
-	RequireAuthentication: false,
+func loginRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                   "/login",
+			Method:                http.MethodPost,
+			Function:              handler.Login,
+			RequireAuthentication: false,
+		},
+		{
+			URI:                   "/token/refresh",
+			Method:                http.MethodPost,
+			Function:              handler.RefreshToken,
+			RequireAuthentication: false,
+		},
+		{
+			URI:                   "/logout",
+			Method:                http.MethodPost,
+			Function:              handler.Logout,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/.well-known/jwks.json",
+			Method:                http.MethodGet,
+			Function:              controllers.JWKS,
+			RequireAuthentication: false,
+		},
+	}
 }
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+func mediaRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                   "/media",
+			Method:                http.MethodPost,
+			Function:              handler.UploadMedia,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/media/{mediaId}",
+			Method:                http.MethodGet,
+			Function:              handler.FindMedia,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/media/{mediaId}/content",
+			Method:                http.MethodGet,
+			Function:              handler.FindMediaContent,
+			RequireAuthentication: true,
+		},
+	}
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+func notificationRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                   "/notifications",
+			Method:                http.MethodGet,
+			Function:              handler.FindNotifications,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/notifications/read",
+			Method:                http.MethodPost,
+			Function:              handler.MarkNotificationsAsRead,
+			RequireAuthentication: true,
+		},
+	}
+}
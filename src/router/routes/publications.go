@@ -5,35 +5,73 @@ import (
 	"net/http"
 )
 
-var routesPublications = []Route{
-	{
-		URI:                   "/publications",
-		Method:                http.MethodPost,
-		Function:              controllers.CreatePublication,
-		RequireAuthentication: true,
-	},
-	{
-		URI:                   "/publications",
-		Method:                http.MethodGet,
-		Function:              controllers.FindAllPublicationsByUser,
-		RequireAuthentication: true,
-	},
-	{
-		URI:                   "/publications/{publicationId}",
-		Method:                http.MethodGet,
-		Function:              controllers.FindPublicationByID,
-		RequireAuthentication: true,
-	},
-	{
-		URI:                   "/publications/{publicationId}",
-		Method:                http.MethodPut,
-		Function:              controllers.UpdatePublicationByID,
-		RequireAuthentication: true,
-	},
-	{
-		URI:                   "/publications/{publicationId}",
-		Method:                http.MethodDelete,
-		Function:              controllers.DeletePublicationByID,
-		RequireAuthentication: true,
-	},
+func routesPublications(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                   "/publications",
+			Method:                http.MethodPost,
+			Function:              handler.CreatePublication,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/publications",
+			Method:                http.MethodGet,
+			Function:              handler.FindAllPublicationsByUser,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/publications/{publicationId}",
+			Method:                http.MethodGet,
+			Function:              handler.FindPublicationByID,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/publications/{publicationId}",
+			Method:                http.MethodPut,
+			Function:              handler.UpdatePublicationByID,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/publications/{publicationId}",
+			Method:                http.MethodDelete,
+			Function:              handler.DeletePublicationByID,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/publications/{publicationId}/like",
+			Method:                http.MethodPost,
+			Function:              handler.LikePublication,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/publications/{publicationId}/unlike",
+			Method:                http.MethodPost,
+			Function:              handler.UnlikePublication,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/publications/{publicationId}/repost",
+			Method:                http.MethodPost,
+			Function:              handler.RepostPublication,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/publications/{publicationId}/repost",
+			Method:                http.MethodDelete,
+			Function:              handler.UndoRepost,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/publications/{publicationId}/likes",
+			Method:                http.MethodGet,
+			Function:              handler.FindPublicationLikes,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/publications/{publicationId}/revisions",
+			Method:                http.MethodGet,
+			Function:              handler.FindPublicationRevisions,
+			RequireAuthentication: true,
+		},
+	}
 }
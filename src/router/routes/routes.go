@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"api/src/controllers"
 	"api/src/middlewares"
 	"net/http"
 
@@ -16,10 +17,19 @@ type Route struct {
 }
 
 //Configure add all routes into Router
-func Configure(r *mux.Router) *mux.Router {
-	routes := userRoutes
-	routes = append(routes, loginRoute)
-	routes = append(routes, routesPublications...)
+func Configure(r *mux.Router, handler *controllers.Handler) *mux.Router {
+	routes := userRoutes(handler)
+	routes = append(routes, loginRoutes(handler)...)
+	routes = append(routes, routesPublications(handler)...)
+	routes = append(routes, draftRoutes(handler)...)
+	routes = append(routes, routesComments(handler)...)
+	routes = append(routes, notificationRoutes(handler)...)
+	routes = append(routes, streamRoutes(handler)...)
+	routes = append(routes, searchRoutes(handler)...)
+	routes = append(routes, hashtagRoutes(handler)...)
+	routes = append(routes, mediaRoutes(handler)...)
+	routes = append(routes, conversationRoutes(handler)...)
+	routes = append(routes, healthRoutes(handler)...)
 
 	for _, route := range routes {
 
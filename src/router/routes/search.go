@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+func searchRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                   "/search",
+			Method:                http.MethodGet,
+			Function:              handler.Search,
+			RequireAuthentication: true,
+		},
+	}
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+func streamRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                   "/stream",
+			Method:                http.MethodGet,
+			Function:              handler.Stream,
+			RequireAuthentication: true,
+		},
+	}
+}
@@ -5,65 +5,134 @@ import (
 	"net/http"
 )
 
-var userRoutes = []Route{
-	{
-		URI:                   "/users",
-		Method:                http.MethodPost,
-		Function:              controllers.CreateUser,
-		RequireAuthentication: false,
-	},
-	{
-		URI:                   "/users",
-		Method:                http.MethodGet,
-		Function:              controllers.FindAllUsersFilteredByNameOrNick,
-		RequireAuthentication: true,
-	},
-	{
-		URI:                   "/users/{userId}",
-		Method:                http.MethodGet,
-		Function:              controllers.FindUserById,
-		RequireAuthentication: true,
-	},
-	{
-		URI:                   "/users/{userId}",
-		Method:                http.MethodPut,
-		Function:              controllers.UpdateUserById,
-		RequireAuthentication: true,
-	},
-	{
-		URI:                   "/users/{userId}",
-		Method:                http.MethodDelete,
-		Function:              controllers.DeleteUser,
-		RequireAuthentication: true,
-	},
-	{
-		URI:                   "/users/{userId}/follower",
-		Method:                http.MethodPost,
-		Function:              controllers.FollowerUser,
-		RequireAuthentication: true,
-	},
-	{
-		URI:                   "/users/{userId}/unfollow",
-		Method:                http.MethodPost,
-		Function:              controllers.UnfollowUser,
-		RequireAuthentication: true,
-	},
-	{
-		URI:                   "/users/{userId}/followers",
-		Method:                http.MethodGet,
-		Function:              controllers.FindFollowers,
-		RequireAuthentication: true,
-	},
-	{
-		URI:                   "/users/{userId}/following",
-		Method:                http.MethodGet,
-		Function:              controllers.FindFollowing,
-		RequireAuthentication: true,
-	},
-	{
-		URI:                   "/users/{userId}/password",
-		Method:                http.MethodPost,
-		Function:              controllers.UpdatePassword,
-		RequireAuthentication: true,
-	},
+func userRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                   "/users",
+			Method:                http.MethodPost,
+			Function:              handler.CreateUser,
+			RequireAuthentication: false,
+		},
+		{
+			URI:                   "/users",
+			Method:                http.MethodGet,
+			Function:              handler.FindAllUsersFilteredByNameOrNick,
+			RequireAuthentication: true,
+		},
+		{
+			// registered before /users/{userId}, which would match suggestions as the user id
+			URI:                   "/users/suggestions",
+			Method:                http.MethodGet,
+			Function:              handler.FindSuggestions,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/users/suggestions/{userId}",
+			Method:                http.MethodDelete,
+			Function:              handler.DismissSuggestion,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/users/{userId}",
+			Method:                http.MethodGet,
+			Function:              handler.FindUserById,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/users/{userId}",
+			Method:                http.MethodPut,
+			Function:              handler.UpdateUserById,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/users/{userId}",
+			Method:                http.MethodDelete,
+			Function:              handler.DeleteUser,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/users/{userId}/follower",
+			Method:                http.MethodPost,
+			Function:              handler.FollowerUser,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/users/{userId}/unfollow",
+			Method:                http.MethodPost,
+			Function:              handler.UnfollowUser,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/users/{userId}/followers",
+			Method:                http.MethodGet,
+			Function:              handler.FindFollowers,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/users/{userId}/following",
+			Method:                http.MethodGet,
+			Function:              handler.FindFollowing,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/users/{userId}/mutual",
+			Method:                http.MethodGet,
+			Function:              handler.FindMutualFollowers,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/users/{userId}/follow-requests",
+			Method:                http.MethodGet,
+			Function:              handler.FindFollowRequests,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/users/{userId}/follow-requests/{requesterId}/approve",
+			Method:                http.MethodPost,
+			Function:              handler.ApproveFollowRequest,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/users/{userId}/follow-requests/{requesterId}/reject",
+			Method:                http.MethodPost,
+			Function:              handler.RejectFollowRequest,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/users/{userId}/block",
+			Method:                http.MethodPost,
+			Function:              handler.BlockUser,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/users/{userId}/block",
+			Method:                http.MethodDelete,
+			Function:              handler.UnblockUser,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/users/{userId}/mute",
+			Method:                http.MethodPost,
+			Function:              handler.MuteUser,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/users/{userId}/mute",
+			Method:                http.MethodDelete,
+			Function:              handler.UnmuteUser,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/users/{userId}/mentions",
+			Method:                http.MethodGet,
+			Function:              handler.FindMentions,
+			RequireAuthentication: true,
+		},
+		{
+			URI:                   "/users/{userId}/password",
+			Method:                http.MethodPost,
+			Function:              handler.UpdatePassword,
+			RequireAuthentication: true,
+		},
+	}
 }
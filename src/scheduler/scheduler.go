@@ -0,0 +1,108 @@
+// Package scheduler publish in background the publications scheduled for later
+package scheduler
+
+import (
+	"api/src/events"
+	"api/src/repositories"
+	"log"
+	"sync"
+	"time"
+)
+
+// Scheduler publish the scheduled publications when their time comes, announcing each one like a new publication.
+// Many instances can run against the same database, the repository hands each publication to only one of them
+type Scheduler struct {
+	publications repositories.PublicationRepository
+	events       events.Publisher
+	batchSize    int
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewScheduler create a scheduler publishing up to batchSize publications for each query
+func NewScheduler(publications repositories.PublicationRepository, publisher events.Publisher, batchSize int) *Scheduler {
+	return &Scheduler{publications: publications, events: publisher, batchSize: batchSize, done: make(chan struct{})}
+}
+
+// Start publish the due publications now and then every interval until Close
+func (scheduler *Scheduler) Start(interval time.Duration) {
+	scheduler.wg.Add(1)
+	go func() {
+		defer scheduler.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if published, err := scheduler.Run(time.Now()); err != nil {
+				log.Printf("scheduler: run failed after publishing %d publications: %v", published, err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-scheduler.done:
+				return
+			}
+		}
+	}()
+}
+
+// Run publish every publication due at now, returning how many were published
+func (scheduler *Scheduler) Run(now time.Time) (int, error) {
+	published := 0
+
+	for {
+		due, err := scheduler.publications.PublishDue(now, scheduler.batchSize)
+		if err != nil || len(due) == 0 {
+			return published, err
+		}
+
+		for _, publication := range due {
+			if err = scheduler.announce(publication.ID, publication.AuthorId); err != nil {
+				log.Printf("scheduler: could not announce publication %d: %v", publication.ID, err)
+			}
+			published++
+		}
+
+		select {
+		case <-scheduler.done:
+			return published, nil
+		default:
+		}
+	}
+}
+
+// Close stop the scheduler and wait the run in progress to finish
+func (scheduler *Scheduler) Close() {
+	scheduler.closeOnce.Do(func() { close(scheduler.done) })
+	scheduler.wg.Wait()
+}
+
+// announce publish the events of a new publication and of each user it mentions
+func (scheduler *Scheduler) announce(publicationId, authorId uint64) error {
+	publication, err := scheduler.publications.FindById(publicationId, authorId)
+	if err != nil || publication.ID == 0 {
+		return err
+	}
+
+	scheduler.events.Publish(events.Event{Type: events.PublicationCreated, ActorId: authorId, PublicationId: publicationId})
+
+	notified := map[uint64]bool{}
+	for _, mention := range publication.Mentions {
+		if notified[mention.UserId] {
+			continue
+		}
+		notified[mention.UserId] = true
+
+		scheduler.events.Publish(events.Event{
+			Type:          events.UserMentioned,
+			ActorId:       authorId,
+			UserId:        mention.UserId,
+			PublicationId: publicationId,
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package stream
+
+import (
+	"api/src/events"
+	"api/src/models"
+	"api/src/repositories"
+	"log"
+)
+
+// Feed push new publications to the connected users that would see them in their feed
+type Feed struct {
+	hub          *Hub
+	users        repositories.UserRepository
+	publications repositories.PublicationRepository
+}
+
+// NewFeed create a feed publisher for the hub
+func NewFeed(hub *Hub, repos repositories.Repositories) *Feed {
+	return &Feed{hub: hub, users: repos.Users, publications: repos.Publications}
+}
+
+// Handle consume publication and repost events. The author and the followers among the users connected or
+// able to resume are found with one query, so the cost of a post does not grow with every connected user
+func (feed *Feed) Handle(event events.Event) {
+	if event.Type != events.PublicationCreated && event.Type != events.PublicationReposted {
+		return
+	}
+
+	active := feed.hub.ActiveUsers()
+	if len(active) == 0 {
+		return
+	}
+
+	audience, err := feed.users.FindAudience(event.ActorId, active)
+	if err != nil {
+		log.Printf("stream: could not find the audience of publication %d: %v", event.PublicationId, err)
+		return
+	}
+
+	for _, userId := range active {
+		if userId == event.ActorId {
+			audience = append(audience, userId)
+		}
+	}
+
+	if len(audience) == 0 {
+		return
+	}
+
+	publication, err := feed.publications.FindById(event.PublicationId, event.ActorId)
+	if err != nil || publication.ID == 0 {
+		if err != nil {
+			log.Printf("stream: could not read publication %d: %v", event.PublicationId, err)
+		}
+		return
+	}
+
+	for _, userId := range audience {
+		if err := feed.push(userId, publication); err != nil {
+			log.Printf("stream: could not push publication %d to user %d: %v", event.PublicationId, userId, err)
+		}
+	}
+}
+
+// push send publication, read as its author, to user. Followers see every publication of the author but the
+// private ones, only reposts and quotes are read again because their original may be hidden from user
+func (feed *Feed) push(userId uint64, publication models.Publication) error {
+	if userId != publication.AuthorId {
+		if publication.Visibility == models.VisibilityPrivate {
+			return nil
+		}
+
+		if publication.OriginalId() != 0 {
+			var err error
+			if publication, err = feed.publications.FindById(publication.ID, userId); err != nil || publication.ID == 0 {
+				return err
+			}
+		}
+	}
+
+	return feed.hub.Push(userId, EventPublication, publication)
+}
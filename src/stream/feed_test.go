@@ -0,0 +1,124 @@
+package stream_test
+
+import (
+	"api/src/events"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/repositories/memory"
+	"api/src/stream"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// countingUsers count the relationship queries made by the feed
+type countingUsers struct {
+	repositories.UserRepository
+	audiences, single int
+}
+
+func (users *countingUsers) FindAudience(authorId uint64, userIds []uint64) ([]uint64, error) {
+	users.audiences++
+	return users.UserRepository.FindAudience(authorId, userIds)
+}
+
+func (users *countingUsers) IsFollower(userId, followerId uint64) (bool, error) {
+	users.single++
+	return users.UserRepository.IsFollower(userId, followerId)
+}
+
+func (users *countingUsers) IsMuted(userId, mutedId uint64) (bool, error) {
+	users.single++
+	return users.UserRepository.IsMuted(userId, mutedId)
+}
+
+func TestFeedPushesPublicationsToConnectedAudience(t *testing.T) {
+	repos := memory.NewRepositories()
+	ids := map[string]uint64{}
+	for _, nick := range []string{"alice", "bob", "carol", "dave", "frank"} {
+		id, err := repos.Users.Create(models.User{Name: nick, Nick: nick, Email: nick + "@devbook.com", Password: nick})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[nick] = id
+	}
+
+	// bob and carol follow alice but carol muted her, alice follows frank and dave follows nobody
+	for _, err := range []error{
+		repos.Users.Follower(ids["alice"], ids["bob"]),
+		repos.Users.Follower(ids["alice"], ids["carol"]),
+		repos.Users.Mute(ids["carol"], ids["alice"]),
+		repos.Users.Follower(ids["frank"], ids["alice"]),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	create := func(publication models.Publication) uint64 {
+		id, err := repos.Publications.Create(publication)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	public := create(models.Publication{Title: "public", Content: "hi", Visibility: models.VisibilityPublic, Status: models.StatusPublished, AuthorId: ids["alice"]})
+	private := create(models.Publication{Title: "private", Content: "me", Visibility: models.VisibilityPrivate, Status: models.StatusPublished, AuthorId: ids["alice"]})
+	hidden := create(models.Publication{Title: "hidden", Content: "friends", Visibility: models.VisibilityFollowers, Status: models.StatusPublished, AuthorId: ids["frank"]})
+	repost, err := repos.Publications.Repost(hidden, ids["alice"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		event      events.Event
+		recipients []string
+	}{
+		{"public publication", events.Event{Type: events.PublicationCreated, ActorId: ids["alice"], PublicationId: public}, []string{"alice", "bob"}},
+		{"private publication", events.Event{Type: events.PublicationCreated, ActorId: ids["alice"], PublicationId: private}, []string{"alice"}},
+		{"repost of an original hidden from followers", events.Event{Type: events.PublicationReposted, ActorId: ids["alice"], PublicationId: repost}, []string{"alice"}},
+		{"other events", events.Event{Type: events.PublicationLiked, ActorId: ids["alice"], PublicationId: public}, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hub := stream.NewHub(10, 10, time.Minute)
+			defer hub.Close()
+
+			subscriptions := map[string]*stream.Subscription{}
+			for _, nick := range []string{"alice", "bob", "carol", "dave"} {
+				subscriptions[nick], _ = hub.Subscribe(ids[nick], 0)
+			}
+
+			users := &countingUsers{UserRepository: repos.Users}
+			feed := stream.NewFeed(hub, repositories.Repositories{Users: users, Publications: repos.Publications})
+			feed.Handle(test.event)
+
+			recipients := []string{}
+			for nick, subscription := range subscriptions {
+				select {
+				case message := <-subscription.Messages:
+					var publication models.Publication
+					if err := json.Unmarshal(message.Data, &publication); err != nil || publication.ID != test.event.PublicationId {
+						t.Errorf("%s received unexpected message %s", nick, message.Data)
+					}
+					recipients = append(recipients, nick)
+				default:
+				}
+			}
+			sort.Strings(recipients)
+
+			if !reflect.DeepEqual(recipients, test.recipients) {
+				t.Errorf("expected %v to receive the publication, got %v", test.recipients, recipients)
+			}
+
+			if users.single != 0 || users.audiences > 1 {
+				t.Errorf("expected at most one audience query, got %d and %d single checks", users.audiences, users.single)
+			}
+		})
+	}
+}
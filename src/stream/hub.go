@@ -0,0 +1,206 @@
+// Package stream push live updates to connected users through an in-process pub/sub hub
+package stream
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	// EventPublication carry a new publication for the user feed
+	EventPublication = "publication"
+	// EventNotification carry a new notification from the user inbox
+	EventNotification = "notification"
+)
+
+// Message is one update sent to a user, ID is increasing so clients can resume after it
+type Message struct {
+	ID    uint64
+	Event string
+	Data  []byte
+}
+
+// Subscription receive the messages from one user connection. Messages is closed when the
+// hub disconnects the subscription, because the client was too slow or the hub was closed.
+type Subscription struct {
+	UserId   uint64
+	Messages <-chan Message
+
+	messages chan Message
+	closed   bool
+}
+
+// Hub deliver messages to every connection from a user and keep the last messages from each user
+// to replay them when a client reconnects. Publishing never blocks: a connection whose buffer is
+// full is disconnected and can resume with Last-Event-ID.
+type Hub struct {
+	mu            sync.Mutex
+	lastId        uint64
+	bufferSize    int
+	historySize   int
+	resumeWindow  time.Duration
+	closed        bool
+	subscriptions map[uint64]map[*Subscription]bool
+	history       map[uint64][]Message
+	disconnected  map[uint64]time.Time
+}
+
+// NewHub create a hub where each connection buffer bufferSize messages. The last historySize
+// messages from each user are kept while the user is connected and for resumeWindow after the
+// last connection closes.
+func NewHub(bufferSize, historySize int, resumeWindow time.Duration) *Hub {
+	return &Hub{
+		// ids start from the clock so they keep increasing after a restart
+		lastId:        uint64(time.Now().UnixNano()),
+		bufferSize:    bufferSize,
+		historySize:   historySize,
+		resumeWindow:  resumeWindow,
+		subscriptions: map[uint64]map[*Subscription]bool{},
+		history:       map[uint64][]Message{},
+		disconnected:  map[uint64]time.Time{},
+	}
+}
+
+// Push send data encoded as JSON to all connections from user
+func (hub *Hub) Push(userId uint64, event string, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.closed {
+		return nil
+	}
+
+	if !hub.keepsHistory(userId) {
+		return nil
+	}
+
+	hub.lastId++
+	message := Message{ID: hub.lastId, Event: event, Data: encoded}
+
+	history := append(hub.history[userId], message)
+	if len(history) > hub.historySize {
+		history = history[len(history)-hub.historySize:]
+	}
+	hub.history[userId] = history
+
+	for subscription := range hub.subscriptions[userId] {
+		select {
+		case subscription.messages <- message:
+		default:
+			hub.disconnect(subscription)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe register a connection from user and return the messages published after lastEventId,
+// lastEventId zero means the client is not resuming and nothing is replayed
+func (hub *Hub) Subscribe(userId, lastEventId uint64) (*Subscription, []Message) {
+	messages := make(chan Message, hub.bufferSize)
+	subscription := &Subscription{UserId: userId, Messages: messages, messages: messages}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.closed {
+		subscription.closed = true
+		close(messages)
+		return subscription, nil
+	}
+
+	if hub.subscriptions[userId] == nil {
+		hub.subscriptions[userId] = map[*Subscription]bool{}
+	}
+	delete(hub.disconnected, userId)
+	hub.subscriptions[userId][subscription] = true
+
+	var replay []Message
+	if lastEventId != 0 {
+		for _, message := range hub.history[userId] {
+			if message.ID > lastEventId {
+				replay = append(replay, message)
+			}
+		}
+	}
+
+	return subscription, replay
+}
+
+// Unsubscribe remove the connection from hub
+func (hub *Hub) Unsubscribe(subscription *Subscription) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.disconnect(subscription)
+}
+
+// ActiveUsers return the ids from users with open connections or that can still resume,
+// the ones whose messages are recorded by Push
+func (hub *Hub) ActiveUsers() []uint64 {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	users := make([]uint64, 0, len(hub.subscriptions)+len(hub.disconnected))
+	for userId := range hub.subscriptions {
+		users = append(users, userId)
+	}
+
+	for userId := range hub.disconnected {
+		if hub.keepsHistory(userId) {
+			users = append(users, userId)
+		}
+	}
+
+	return users
+}
+
+// Close disconnect every connection and stop accepting new ones
+func (hub *Hub) Close() {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.closed = true
+	for _, subscriptions := range hub.subscriptions {
+		for subscription := range subscriptions {
+			hub.disconnect(subscription)
+		}
+	}
+}
+
+func (hub *Hub) disconnect(subscription *Subscription) {
+	if subscription.closed {
+		return
+	}
+
+	subscription.closed = true
+	close(subscription.messages)
+
+	delete(hub.subscriptions[subscription.UserId], subscription)
+	if len(hub.subscriptions[subscription.UserId]) == 0 {
+		delete(hub.subscriptions, subscription.UserId)
+		hub.disconnected[subscription.UserId] = time.Now()
+	}
+}
+
+// keepsHistory return if messages to user must be recorded, forgetting users that left
+// more than the resume window ago
+func (hub *Hub) keepsHistory(userId uint64) bool {
+	if len(hub.subscriptions[userId]) > 0 {
+		return true
+	}
+
+	if disconnectedAt, ok := hub.disconnected[userId]; ok && time.Since(disconnectedAt) <= hub.resumeWindow {
+		return true
+	}
+
+	delete(hub.disconnected, userId)
+	delete(hub.history, userId)
+	return false
+}
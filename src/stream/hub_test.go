@@ -0,0 +1,83 @@
+package stream
+
+import (
+	"testing"
+	"time"
+)
+
+// drain return the events waiting in subscription and if its channel was closed
+func drain(subscription *Subscription) ([]Message, bool) {
+	var messages []Message
+	for {
+		select {
+		case message, ok := <-subscription.Messages:
+			if !ok {
+				return messages, true
+			}
+			messages = append(messages, message)
+		default:
+			return messages, false
+		}
+	}
+}
+
+func TestHubDisconnectsSlowClients(t *testing.T) {
+	hub := NewHub(2, 10, time.Minute)
+	defer hub.Close()
+
+	slow, _ := hub.Subscribe(1, 0)
+	for i := 0; i < 3; i++ {
+		if err := hub.Push(1, EventNotification, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	messages, closed := drain(slow)
+	if len(messages) != 2 || !closed {
+		t.Fatalf("expected the buffered messages and the connection closed, got %d messages and closed %v", len(messages), closed)
+	}
+
+	// the client resumes after the last message it read and receives the one it missed
+	resumed, replay := hub.Subscribe(1, messages[1].ID)
+	defer hub.Unsubscribe(resumed)
+
+	if len(replay) != 1 || string(replay[0].Data) != "2" {
+		t.Errorf("expected the dropped message replayed, got %+v", replay)
+	}
+}
+
+func TestHubResumeWindow(t *testing.T) {
+	tests := []struct {
+		name   string
+		window time.Duration
+		active bool
+		replay int
+	}{
+		{"inside the window", time.Minute, true, 1},
+		{"after the window", 0, false, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hub := NewHub(10, 10, test.window)
+			defer hub.Close()
+
+			subscription, _ := hub.Subscribe(1, 0)
+			hub.Push(1, EventPublication, "first")
+			first, _ := drain(subscription)
+			hub.Unsubscribe(subscription)
+
+			time.Sleep(time.Millisecond)
+			hub.Push(1, EventPublication, "while away")
+
+			if active := len(hub.ActiveUsers()) == 1; active != test.active {
+				t.Errorf("expected active %v", test.active)
+			}
+
+			_, replay := hub.Subscribe(1, first[0].ID)
+			if len(replay) != test.replay {
+				t.Errorf("expected %d messages replayed, got %+v", test.replay, replay)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package timeline
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// MemoryStore keep the timelines in the process memory, they are lost when the API restarts
+type MemoryStore struct {
+	capacity int
+
+	mu        sync.RWMutex
+	timelines map[uint64][]Entry
+	// building hold the entries added to timelines between Prepare and Build
+	building map[uint64][]Entry
+	// popular hold the order in which each popular author was last marked
+	popular map[uint64]uint64
+	marks   uint64
+}
+
+// NewMemoryStore create a store that keep up to capacity entries in each timeline
+func NewMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{
+		capacity:  capacity,
+		timelines: map[uint64][]Entry{},
+		building:  map[uint64][]Entry{},
+		popular:   map[uint64]uint64{},
+	}
+}
+
+// Cached report if the timeline of user is in the store
+func (store *MemoryStore) Cached(ctx context.Context, userId uint64) (bool, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	_, ok := store.timelines[userId]
+	return ok, nil
+}
+
+// Prepare start building the timeline of user when it is not cached
+func (store *MemoryStore) Prepare(ctx context.Context, userId uint64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	_, cached := store.timelines[userId]
+	_, building := store.building[userId]
+	if !cached && !building {
+		store.building[userId] = []Entry{}
+	}
+
+	return nil
+}
+
+// Build store entries and the ones added since Prepare as the timeline of user and cache it
+func (store *MemoryStore) Build(ctx context.Context, userId uint64, entries []Entry) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	timeline, ok := store.timelines[userId]
+	if added, building := store.building[userId]; building {
+		timeline, ok = append(timeline, added...), true
+		delete(store.building, userId)
+	}
+
+	if ok {
+		store.timelines[userId] = store.trim(merge(timeline, entries))
+	}
+	return nil
+}
+
+// Add insert entries in the timelines of users that are cached or being built, ignoring the others
+func (store *MemoryStore) Add(ctx context.Context, userIds []uint64, entries []Entry) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for _, userId := range userIds {
+		if timeline, ok := store.timelines[userId]; ok {
+			store.timelines[userId] = store.trim(merge(timeline, entries))
+		} else if added, ok := store.building[userId]; ok {
+			store.building[userId] = merge(added, entries)
+		}
+	}
+
+	return nil
+}
+
+// Range return the entries of the timeline of user, most recent first
+func (store *MemoryStore) Range(ctx context.Context, userId uint64) ([]Entry, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return append([]Entry(nil), store.timelines[userId]...), nil
+}
+
+// RemoveAuthor delete the entries from author in the timeline of user
+func (store *MemoryStore) RemoveAuthor(ctx context.Context, userId, authorId uint64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	timeline, ok := store.timelines[userId]
+	if !ok {
+		return nil
+	}
+
+	kept := []Entry{}
+	for _, entry := range timeline {
+		if entry.AuthorId != authorId {
+			kept = append(kept, entry)
+		}
+	}
+	store.timelines[userId] = kept
+
+	return nil
+}
+
+// Delete drop the timeline of user
+func (store *MemoryStore) Delete(ctx context.Context, userId uint64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.timelines, userId)
+	delete(store.building, userId)
+	return nil
+}
+
+// MarkPopular remember that the publications of author are read with the timeline instead of fanned out
+func (store *MemoryStore) MarkPopular(ctx context.Context, authorId uint64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.marks++
+	store.popular[authorId] = store.marks
+	return nil
+}
+
+// Popular return the authors marked as popular, most recently marked first
+func (store *MemoryStore) Popular(ctx context.Context) ([]uint64, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	var authorIds []uint64
+	for authorId := range store.popular {
+		authorIds = append(authorIds, authorId)
+	}
+	sort.Slice(authorIds, func(i, j int) bool { return store.popular[authorIds[i]] > store.popular[authorIds[j]] })
+
+	return authorIds, nil
+}
+
+// trim sort timeline and drop the entries beyond capacity, never returning nil so an empty timeline stays cached
+func (store *MemoryStore) trim(timeline []Entry) []Entry {
+	sortEntries(timeline)
+
+	if len(timeline) > store.capacity {
+		timeline = timeline[:store.capacity]
+	}
+
+	if timeline == nil {
+		return []Entry{}
+	}
+
+	return timeline
+}
+
+// merge append to timeline the entries it does not have yet
+func merge(timeline, entries []Entry) []Entry {
+	for _, entry := range entries {
+		if !containsEntry(timeline, entry.PublicationId) {
+			timeline = append(timeline, entry)
+		}
+	}
+	return timeline
+}
+
+func containsEntry(timeline []Entry, publicationId uint64) bool {
+	for _, entry := range timeline {
+		if entry.PublicationId == publicationId {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,300 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// cachedMember marks a timeline as cached, with score +inf it is never trimmed and keeps empty timelines in the store
+	cachedMember = "cached"
+	// buildingMember takes the place of the cached member while a timeline is built, entries added
+	// in the meantime are kept with it and merged by Build
+	buildingMember = "building"
+	// popularKey is the sorted set of authors whose publications are not fanned out, scored by the last time
+	// they were marked
+	popularKey = "timeline:popular-authors"
+)
+
+// prepareScript add the building member in ARGV[2] to the timeline at KEYS[1] unless the cached member in ARGV[1]
+// is there, expiring the timeline in ARGV[3] seconds when it is positive
+const prepareScript = `
+if redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], '+inf', ARGV[2])
+if tonumber(ARGV[3]) > 0 then
+	redis.call('EXPIRE', KEYS[1], ARGV[3])
+end
+return 1
+`
+
+// buildScript replace the building member in ARGV[2] of the timeline at KEYS[1] by the cached member in ARGV[1],
+// insert the score and member pairs after ARGV[4], trim it to the rank in ARGV[3] and expire it in ARGV[4] seconds
+// when it is positive. A timeline with neither member was dropped while it was built and is left missing
+const buildScript = `
+local building = redis.call('ZREM', KEYS[1], ARGV[2]) == 1
+if not building and not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], '+inf', ARGV[1])
+for i = 5, #ARGV, 2 do
+	redis.call('ZADD', KEYS[1], ARGV[i], ARGV[i + 1])
+end
+redis.call('ZREMRANGEBYRANK', KEYS[1], 0, ARGV[3])
+if tonumber(ARGV[4]) > 0 then
+	redis.call('EXPIRE', KEYS[1], ARGV[4])
+end
+return 1
+`
+
+// addScript insert the score and member pairs after ARGV[3] in the timeline at KEYS[1] and trim it to the rank
+// in ARGV[3], only when the cached member in ARGV[1] or the building member in ARGV[2] is there. Checking and
+// writing in one script keeps ZADD from creating again a timeline that expired in between, without a marker
+// member or an expiration
+const addScript = `
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) and not redis.call('ZSCORE', KEYS[1], ARGV[2]) then
+	return 0
+end
+for i = 4, #ARGV, 2 do
+	redis.call('ZADD', KEYS[1], ARGV[i], ARGV[i + 1])
+end
+redis.call('ZREMRANGEBYRANK', KEYS[1], 0, ARGV[3])
+return 1
+`
+
+// RedisStore keep each timeline in a sorted set scored by the creation time of its publications,
+// working with any server that speaks the Redis protocol
+type RedisStore struct {
+	client   *RedisClient
+	capacity int
+	ttl      time.Duration
+}
+
+// NewRedisStore create a store that keep up to capacity entries in each timeline. Timelines not read for ttl expire,
+// a zero ttl keeps them until they are dropped
+func NewRedisStore(client *RedisClient, capacity int, ttl time.Duration) *RedisStore {
+	return &RedisStore{client: client, capacity: capacity, ttl: ttl}
+}
+
+// Cached report if the timeline of user is in the store
+func (store *RedisStore) Cached(ctx context.Context, userId uint64) (bool, error) {
+	score, err := store.client.Do(ctx, "ZSCORE", timelineKey(userId), cachedMember)
+	return score != nil, err
+}
+
+// Prepare start building the timeline of user when it is not cached
+func (store *RedisStore) Prepare(ctx context.Context, userId uint64) error {
+	_, err := store.client.Do(ctx, "EVAL", prepareScript, "1", timelineKey(userId), cachedMember, buildingMember, store.ttlSeconds())
+	return err
+}
+
+// Build store entries and the ones added since Prepare as the timeline of user and cache it
+func (store *RedisStore) Build(ctx context.Context, userId uint64, entries []Entry) error {
+	args := []string{"EVAL", buildScript, "1", timelineKey(userId), cachedMember, buildingMember, store.trimRank(), store.ttlSeconds()}
+	_, err := store.client.Do(ctx, append(args, members(entries)...)...)
+	return err
+}
+
+// Add insert entries in the timelines of users that are cached or being built, ignoring the others.
+// Timelines keep their expiration, adding to a timeline does not count as reading it
+func (store *RedisStore) Add(ctx context.Context, userIds []uint64, entries []Entry) error {
+	if len(userIds) == 0 || len(entries) == 0 {
+		return nil
+	}
+
+	args := append([]string{cachedMember, buildingMember, store.trimRank()}, members(entries)...)
+
+	commands := make([][]string, len(userIds))
+	for i, userId := range userIds {
+		commands[i] = append([]string{"EVAL", addScript, "1", timelineKey(userId)}, args...)
+	}
+
+	return checkReplies(store.client.Pipeline(ctx, commands))
+}
+
+// Range return the entries of the timeline of user, most recent first
+func (store *RedisStore) Range(ctx context.Context, userId uint64) ([]Entry, error) {
+	entries, _, err := store.rangeMembers(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// scores keep only milliseconds, so entries created in the same one are ordered again
+	sortEntries(entries)
+	return entries, nil
+}
+
+// RemoveAuthor delete the entries from author in the timeline of user
+func (store *RedisStore) RemoveAuthor(ctx context.Context, userId, authorId uint64) error {
+	entries, encoded, err := store.rangeMembers(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	command := []string{"ZREM", timelineKey(userId)}
+	for i, entry := range entries {
+		if entry.AuthorId == authorId {
+			command = append(command, encoded[i])
+		}
+	}
+
+	if len(command) == 2 {
+		return nil
+	}
+
+	_, err = store.client.Do(ctx, command...)
+	return err
+}
+
+// Delete drop the timeline of user
+func (store *RedisStore) Delete(ctx context.Context, userId uint64) error {
+	_, err := store.client.Do(ctx, "DEL", timelineKey(userId))
+	return err
+}
+
+// MarkPopular remember that the publications of author are read with the timeline instead of fanned out
+func (store *RedisStore) MarkPopular(ctx context.Context, authorId uint64) error {
+	marked := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	_, err := store.client.Do(ctx, "ZADD", popularKey, marked, strconv.FormatUint(authorId, 10))
+	return err
+}
+
+// Popular return the authors marked as popular, most recently marked first
+func (store *RedisStore) Popular(ctx context.Context) ([]uint64, error) {
+	reply, err := store.client.Do(ctx, "ZREVRANGE", popularKey, "0", "-1")
+	if err != nil {
+		return nil, err
+	}
+
+	items, _ := reply.([]interface{})
+
+	var authorIds []uint64
+	for _, item := range items {
+		value, _ := item.(string)
+		authorId, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, errProtocol
+		}
+		authorIds = append(authorIds, authorId)
+	}
+
+	return authorIds, nil
+}
+
+// rangeMembers read the entries of the timeline of user with their encoded members, in the same order,
+// refreshing the expiration of the timeline
+func (store *RedisStore) rangeMembers(ctx context.Context, userId uint64) ([]Entry, []string, error) {
+	key := timelineKey(userId)
+
+	commands := [][]string{{"ZREVRANGE", key, "0", "-1"}}
+	if store.ttl > 0 {
+		commands = append(commands, store.expire(key))
+	}
+
+	replies, err := store.client.Pipeline(ctx, commands)
+	if err = checkReplies(replies, err); err != nil {
+		return nil, nil, err
+	}
+
+	items, _ := replies[0].([]interface{})
+
+	var entries []Entry
+	var encoded []string
+	for _, item := range items {
+		member, _ := item.(string)
+		if member == cachedMember || member == buildingMember {
+			continue
+		}
+
+		entry, err := parseMember(member)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		entries = append(entries, entry)
+		encoded = append(encoded, member)
+	}
+
+	return entries, encoded, nil
+}
+
+// trimRank return the rank of the newest entry dropped when a timeline is trimmed to capacity, counting
+// its cached or building member, which is never dropped
+func (store *RedisStore) trimRank() string {
+	return strconv.Itoa(-store.capacity - 2)
+}
+
+func (store *RedisStore) expire(key string) []string {
+	return []string{"EXPIRE", key, store.ttlSeconds()}
+}
+
+// ttlSeconds return the expiration of timelines in seconds, zero when they do not expire
+func (store *RedisStore) ttlSeconds() string {
+	return strconv.Itoa(int(store.ttl / time.Second))
+}
+
+func timelineKey(userId uint64) string {
+	return "timeline:" + strconv.FormatUint(userId, 10)
+}
+
+// members encode entries as the score and member pairs of ZADD, members carry the whole entry
+// so timelines can be trimmed by author and ordered precisely without reading the database
+func members(entries []Entry) []string {
+	args := make([]string, 0, len(entries)*2)
+	for _, entry := range entries {
+		args = append(args,
+			strconv.FormatInt(entry.CreatedAt.UnixMilli(), 10),
+			fmt.Sprintf("%d:%d:%d", entry.PublicationId, entry.AuthorId, entry.CreatedAt.UnixNano()),
+		)
+	}
+	return args
+}
+
+func parseMember(member string) (Entry, error) {
+	parts := strings.Split(member, ":")
+	if len(parts) != 3 {
+		return Entry{}, errProtocol
+	}
+
+	publicationId, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return Entry{}, errProtocol
+	}
+
+	authorId, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return Entry{}, errProtocol
+	}
+
+	createdAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return Entry{}, errProtocol
+	}
+
+	return Entry{PublicationId: publicationId, AuthorId: authorId, CreatedAt: time.Unix(0, createdAt)}, nil
+}
+
+// checkReplies return the first error of a pipeline, including error replies and the ones inside EXEC
+func checkReplies(replies []interface{}, err error) error {
+	if err != nil {
+		return err
+	}
+
+	for _, reply := range replies {
+		switch reply := reply.(type) {
+		case RedisError:
+			return reply
+		case []interface{}:
+			if err := checkReplies(reply, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package timeline
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// RedisError is an error reply sent by the server
+type RedisError string
+
+func (err RedisError) Error() string {
+	return string(err)
+}
+
+// errProtocol is returned when the server sends something that is not a RESP reply
+var errProtocol = errors.New("timeline: invalid reply from redis")
+
+// RedisClient is a minimal client of the Redis protocol (RESP2), enough for the commands RedisStore sends.
+// Replies are returned as string, int64, nil or []interface{}, error replies as RedisError
+type RedisClient struct {
+	addr     string
+	password string
+	db       int
+	timeout  time.Duration
+
+	idle chan *redisConn
+}
+
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewRedisClient create a client that keep up to poolSize idle connections to addr, authenticating with
+// password when it is not empty and selecting db. Commands without deadline in the context wait up to timeout
+func NewRedisClient(addr, password string, db, poolSize int, timeout time.Duration) *RedisClient {
+	return &RedisClient{addr: addr, password: password, db: db, timeout: timeout, idle: make(chan *redisConn, poolSize)}
+}
+
+// Do send one command and return its reply
+func (client *RedisClient) Do(ctx context.Context, args ...string) (interface{}, error) {
+	replies, err := client.Pipeline(ctx, [][]string{args})
+	if err != nil {
+		return nil, err
+	}
+
+	if err, ok := replies[0].(RedisError); ok {
+		return nil, err
+	}
+
+	return replies[0], nil
+}
+
+// Pipeline send all commands before reading their replies, error replies are returned
+// in their position instead of failing the whole pipeline
+func (client *RedisClient) Pipeline(ctx context.Context, commands [][]string) ([]interface{}, error) {
+	conn, err := client.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(client.timeout)
+	}
+	if err = conn.conn.SetDeadline(deadline); err != nil {
+		conn.conn.Close()
+		return nil, err
+	}
+
+	replies, err := conn.exchange(commands)
+	if err != nil {
+		// the connection may have unread replies, so it is not reused
+		conn.conn.Close()
+		return nil, err
+	}
+
+	client.put(conn)
+	return replies, nil
+}
+
+// Close close the idle connections, connections in use are closed when they are returned
+func (client *RedisClient) Close() {
+	for {
+		select {
+		case conn := <-client.idle:
+			conn.conn.Close()
+		default:
+			return
+		}
+	}
+}
+
+// get take an idle connection or open a new one
+func (client *RedisClient) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-client.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: client.timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", client.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &redisConn{conn: netConn, reader: bufio.NewReader(netConn)}
+
+	var setup [][]string
+	if client.password != "" {
+		setup = append(setup, []string{"AUTH", client.password})
+	}
+	if client.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(client.db)})
+	}
+
+	if len(setup) > 0 {
+		netConn.SetDeadline(time.Now().Add(client.timeout))
+
+		replies, err := conn.exchange(setup)
+		if err == nil {
+			for _, reply := range replies {
+				if replyErr, ok := reply.(RedisError); ok {
+					err = replyErr
+					break
+				}
+			}
+		}
+		if err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+// put return conn to the pool, closing it when the pool is full
+func (client *RedisClient) put(conn *redisConn) {
+	select {
+	case client.idle <- conn:
+	default:
+		conn.conn.Close()
+	}
+}
+
+// exchange write the commands and read one reply for each
+func (conn *redisConn) exchange(commands [][]string) ([]interface{}, error) {
+	writer := bufio.NewWriter(conn.conn)
+	for _, command := range commands {
+		fmt.Fprintf(writer, "*%d\r\n", len(command))
+		for _, arg := range command {
+			fmt.Fprintf(writer, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+
+	replies := make([]interface{}, len(commands))
+	for i := range replies {
+		reply, err := conn.read()
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = reply
+	}
+
+	return replies, nil
+}
+
+// read parse one reply
+func (conn *redisConn) read() (interface{}, error) {
+	line, err := conn.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errProtocol
+	}
+	kind, value := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return value, nil
+	case '-':
+		return RedisError(value), nil
+	case ':':
+		return strconv.ParseInt(value, 10, 64)
+	case '$':
+		length, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, errProtocol
+		}
+		if length < 0 {
+			return nil, nil
+		}
+
+		data := make([]byte, length+2)
+		if _, err = io.ReadFull(conn.reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:length]), nil
+	case '*':
+		length, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, errProtocol
+		}
+		if length < 0 {
+			return nil, nil
+		}
+
+		items := make([]interface{}, length)
+		for i := range items {
+			if items[i], err = conn.read(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+
+	return nil, errProtocol
+}
@@ -0,0 +1,289 @@
+package timeline
+
+import (
+	"api/src/events"
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/repositories"
+	"context"
+	"log"
+	"sync"
+)
+
+// fanOutBatch is how many followers receive a publication in each call to the store
+const fanOutBatch = 500
+
+// Service write publications to the timelines of followers with a pool of workers and read the home feed from them.
+// Authors with more than maxFanOut followers are not fanned out, their publications are read with the timeline instead
+type Service struct {
+	store        Store
+	publications repositories.PublicationRepository
+	users        repositories.UserRepository
+	capacity     int
+	maxFanOut    int
+
+	// queues hold the jobs of each worker, the jobs of one timeline always go to the same worker so they run in order
+	queues []chan job
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// job is one update of the timelines run by a worker
+type job func(ctx context.Context)
+
+// NewService create a service whose workers, at least one, run the updates queued by Handle, up to queueSize
+// wait for each worker before Handle blocks. Timelines keep capacity entries, the feed beyond them is read from the database
+func NewService(store Store, publications repositories.PublicationRepository, users repositories.UserRepository,
+	capacity, maxFanOut, workers, queueSize int) *Service {
+	service := &Service{
+		store:        store,
+		publications: publications,
+		users:        users,
+		capacity:     capacity,
+		maxFanOut:    maxFanOut,
+		queues:       make([]chan job, workers),
+	}
+
+	for i := range service.queues {
+		jobs := make(chan job, queueSize)
+		service.queues[i] = jobs
+
+		service.wg.Add(1)
+		go func() {
+			defer service.wg.Done()
+
+			for job := range jobs {
+				job(context.Background())
+			}
+		}()
+	}
+
+	return service
+}
+
+// Handle queue the updates of timelines caused by event: new publications and reposts are fanned out and the
+// timelines of users whose relationships changed are updated. It is subscribed to the events bus without loss,
+// so it only queues and a full queue holds back publishers instead of losing updates
+func (service *Service) Handle(event events.Event) {
+	switch event.Type {
+	case events.PublicationCreated, events.PublicationReposted:
+		service.enqueue(event.ActorId, func(ctx context.Context) {
+			if err := service.FanOut(ctx, event.PublicationId, event.ActorId); err != nil {
+				log.Printf("timeline: fan out of publication %d failed: %v", event.PublicationId, err)
+			}
+		})
+	case events.UserFollowed:
+		service.enqueue(event.ActorId, func(ctx context.Context) { service.backfill(ctx, event.ActorId, event.UserId) })
+	case events.FollowRequestApproved:
+		service.enqueue(event.UserId, func(ctx context.Context) { service.backfill(ctx, event.UserId, event.ActorId) })
+	case events.UserUnfollowed:
+		service.enqueue(event.ActorId, func(ctx context.Context) { service.removeAuthor(ctx, event.ActorId, event.UserId) })
+	case events.UserBlocked:
+		service.enqueue(event.ActorId, func(ctx context.Context) { service.removeAuthor(ctx, event.ActorId, event.UserId) })
+		service.enqueue(event.UserId, func(ctx context.Context) { service.removeAuthor(ctx, event.UserId, event.ActorId) })
+	case events.UserUnmuted:
+		// publications from muted authors were skipped when the timeline was built, so it is built again
+		service.enqueue(event.ActorId, func(ctx context.Context) { service.invalidate(ctx, event.ActorId) })
+	}
+}
+
+// FanOut add the publication to the timelines of author and followers, or mark author as popular when
+// there are too many followers. Publications that no longer exist or are not published are ignored
+func (service *Service) FanOut(ctx context.Context, publicationId, authorId uint64) error {
+	publication, err := service.publications.FindById(publicationId, authorId)
+	if err != nil || publication.ID == 0 || publication.Status != models.StatusPublished {
+		return err
+	}
+
+	followerIds, err := service.users.FindFollowerIds(authorId, service.maxFanOut+1)
+	if err != nil {
+		return err
+	}
+
+	if len(followerIds) > service.maxFanOut {
+		if err = service.store.MarkPopular(ctx, authorId); err != nil {
+			return err
+		}
+		followerIds = nil
+	}
+
+	entries := []Entry{entryOf(publication)}
+	userIds := append([]uint64{authorId}, followerIds...)
+
+	for start := 0; start < len(userIds); start += fanOutBatch {
+		end := start + fanOutBatch
+		if end > len(userIds) {
+			end = len(userIds)
+		}
+
+		if err = service.store.Add(ctx, userIds[start:end], entries); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Feed return one page of the home feed of user, building the timeline when it is not cached.
+// Pages beyond the cached entries and failures of the store are served by the database
+func (service *Service) Feed(ctx context.Context, userId uint64, page pagination.Params) ([]models.Publication, error) {
+	entries, popularIds, err := service.read(ctx, userId)
+	if err != nil {
+		log.Printf("timeline: reading timeline of %d failed: %v", userId, err)
+		return service.publications.Find(userId, page)
+	}
+
+	var publicationIds []uint64
+	for _, entry := range entries {
+		if page.Includes(entry.CreatedAt, entry.PublicationId) {
+			publicationIds = append(publicationIds, entry.PublicationId)
+		}
+	}
+
+	publications, err := service.publications.FindTimeline(userId, publicationIds, popularIds, page)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) >= service.capacity && !service.withinCache(entries, publications, page) {
+		return service.publications.Find(userId, page)
+	}
+
+	return publications, nil
+}
+
+// Close stop accepting updates and wait the workers to run the ones already queued
+func (service *Service) Close() {
+	service.mu.Lock()
+	if !service.closed {
+		service.closed = true
+		for _, jobs := range service.queues {
+			close(jobs)
+		}
+	}
+	service.mu.Unlock()
+
+	service.wg.Wait()
+}
+
+// withinCache report if the page read from a full timeline is complete, which needs it to be filled
+// without reaching past the oldest cached entry, older publications may be missing from the timeline
+func (service *Service) withinCache(entries []Entry, publications []models.Publication, page pagination.Params) bool {
+	if len(publications) < page.FetchLimit() {
+		return false
+	}
+
+	oldest, last := entries[len(entries)-1], publications[len(publications)-1]
+	return !pagination.Before(oldest.CreatedAt, oldest.PublicationId, last.CreatedAt, last.ID)
+}
+
+// read return the cached entries of user, building them first when needed, and the popular authors
+func (service *Service) read(ctx context.Context, userId uint64) ([]Entry, []uint64, error) {
+	cached, err := service.store.Cached(ctx, userId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !cached {
+		if err = service.build(ctx, userId); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	entries, err := service.store.Range(ctx, userId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	popularIds, err := service.store.Popular(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return entries, popularIds, nil
+}
+
+// build cache the most recent publications of the feed of user, read from the database. The timeline is
+// prepared first, so publications fanned out while the database is read are kept
+func (service *Service) build(ctx context.Context, userId uint64) error {
+	if err := service.store.Prepare(ctx, userId); err != nil {
+		return err
+	}
+
+	publications, err := service.publications.Find(userId, pagination.Params{Limit: service.capacity})
+	if err != nil {
+		return err
+	}
+
+	return service.store.Build(ctx, userId, entriesOf(publications))
+}
+
+// backfill add the recent publications of author to the timeline of the new follower
+func (service *Service) backfill(ctx context.Context, followerId, authorId uint64) {
+	cached, err := service.store.Cached(ctx, followerId)
+	if err != nil || !cached {
+		service.fail(ctx, followerId, err)
+		return
+	}
+
+	publications, err := service.publications.FindTimeline(followerId, nil, []uint64{authorId}, pagination.Params{Limit: service.capacity})
+	if err == nil {
+		err = service.store.Add(ctx, []uint64{followerId}, entriesOf(publications))
+	}
+	service.fail(ctx, followerId, err)
+}
+
+// removeAuthor trim the entries from author out of the timeline of user. A full timeline may have dropped
+// older entries already and the feed takes a timeline with free space as complete, so it is built again instead
+func (service *Service) removeAuthor(ctx context.Context, userId, authorId uint64) {
+	entries, err := service.store.Range(ctx, userId)
+	if err == nil && len(entries) >= service.capacity {
+		service.invalidate(ctx, userId)
+		return
+	}
+
+	if err == nil {
+		err = service.store.RemoveAuthor(ctx, userId, authorId)
+	}
+	service.fail(ctx, userId, err)
+}
+
+// invalidate drop the timeline of user so the next read builds it again
+func (service *Service) invalidate(ctx context.Context, userId uint64) {
+	if err := service.store.Delete(ctx, userId); err != nil {
+		log.Printf("timeline: dropping timeline of %d failed: %v", userId, err)
+	}
+}
+
+// fail drop the timeline of user after an update failed with err, so it is not left incomplete
+func (service *Service) fail(ctx context.Context, userId uint64, err error) {
+	if err == nil {
+		return
+	}
+
+	log.Printf("timeline: updating timeline of %d failed: %v", userId, err)
+	service.invalidate(ctx, userId)
+}
+
+// enqueue schedule job in the queue of the worker of userId, waiting while it is full. Jobs after Close are ignored
+func (service *Service) enqueue(userId uint64, job job) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	if !service.closed {
+		service.queues[userId%uint64(len(service.queues))] <- job
+	}
+}
+
+func entryOf(publication models.Publication) Entry {
+	return Entry{PublicationId: publication.ID, AuthorId: publication.AuthorId, CreatedAt: publication.CreatedAt}
+}
+
+func entriesOf(publications []models.Publication) []Entry {
+	entries := make([]Entry, 0, len(publications))
+	for _, publication := range publications {
+		entries = append(entries, entryOf(publication))
+	}
+	return entries
+}
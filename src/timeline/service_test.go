@@ -0,0 +1,310 @@
+package timeline_test
+
+import (
+	"api/src/events"
+	"api/src/models"
+	"api/src/pagination"
+	"api/src/repositories"
+	"api/src/repositories/memory"
+	"api/src/timeline"
+	"context"
+	"reflect"
+	"testing"
+)
+
+// world hold the users and publications of a service test, with the repositories and the store they share
+type world struct {
+	t        *testing.T
+	repos    repositories.Repositories
+	store    *timeline.MemoryStore
+	capacity int
+	users    map[string]uint64
+}
+
+func newWorld(t *testing.T, capacity int, nicks ...string) *world {
+	w := &world{t: t, repos: memory.NewRepositories(), store: timeline.NewMemoryStore(capacity), capacity: capacity, users: map[string]uint64{}}
+
+	for _, nick := range nicks {
+		userId, err := w.repos.Users.Create(models.User{Name: nick, Nick: nick, Email: nick + "@devbook.com", Password: nick})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.users[nick] = userId
+	}
+
+	return w
+}
+
+// service create a service over the world, fanning out to at most maxFanOut followers
+func (w *world) service(maxFanOut int) *timeline.Service {
+	return timeline.NewService(w.store, w.repos.Publications, w.repos.Users, w.capacity, maxFanOut, 2, 10)
+}
+
+// handle send events to a new service and wait its workers to run the updates they queued
+func (w *world) handle(maxFanOut int, queued ...events.Event) {
+	service := w.service(maxFanOut)
+	for _, event := range queued {
+		service.Handle(event)
+	}
+	service.Close()
+}
+
+// follow make follower follow user
+func (w *world) follow(follower, user string) {
+	if err := w.repos.Users.Follower(w.users[user], w.users[follower]); err != nil {
+		w.t.Fatal(err)
+	}
+}
+
+// publish create a publication of author, returning its id
+func (w *world) publish(author string) uint64 {
+	publication := models.Publication{Title: "post", Content: "from " + author, Visibility: models.VisibilityPublic, AuthorId: w.users[author]}
+	publicationId, err := w.repos.Publications.Create(publication)
+	if err != nil {
+		w.t.Fatal(err)
+	}
+	return publicationId
+}
+
+// feed return the ids of the first page of the feed of user, building the timeline when it is not cached
+func (w *world) feed(user string) []uint64 {
+	publications, err := w.service(10).Feed(context.Background(), w.users[user], pagination.Params{Limit: 10})
+	if err != nil {
+		w.t.Fatal(err)
+	}
+	return ids(publications)
+}
+
+// cached report if the timeline of user is cached
+func (w *world) cached(user string) bool {
+	cached, err := w.store.Cached(context.Background(), w.users[user])
+	if err != nil {
+		w.t.Fatal(err)
+	}
+	return cached
+}
+
+func ids(publications []models.Publication) []uint64 {
+	ids := []uint64{}
+	for _, publication := range publications {
+		ids = append(ids, publication.ID)
+	}
+	return ids
+}
+
+func TestFanOutToFollowers(t *testing.T) {
+	w := newWorld(t, 10, "alice", "bob", "carol")
+	w.follow("bob", "alice")
+	w.follow("carol", "alice")
+
+	if ids := w.feed("bob"); len(ids) != 0 {
+		t.Fatalf("expected an empty feed, got %v", ids)
+	}
+
+	publicationId := w.publish("alice")
+	w.handle(10, events.Event{Type: events.PublicationCreated, ActorId: w.users["alice"], PublicationId: publicationId})
+
+	if ids := publications(t, w.store, w.users["bob"]); !reflect.DeepEqual(ids, []uint64{publicationId}) {
+		t.Errorf("expected the publication in the timeline of the follower, got %v", ids)
+	}
+	if w.cached("carol") || w.cached("alice") {
+		t.Error("expected timelines that were not cached to be left for the next read")
+	}
+	if ids := w.feed("bob"); !reflect.DeepEqual(ids, []uint64{publicationId}) {
+		t.Errorf("expected the publication in the feed, got %v", ids)
+	}
+}
+
+func TestPopularAuthorsAreReadWithTheTimeline(t *testing.T) {
+	w := newWorld(t, 10, "alice", "bob", "carol", "dave")
+	w.follow("bob", "alice")
+	w.follow("carol", "alice")
+	w.feed("bob")
+	w.feed("dave")
+
+	publicationId := w.publish("alice")
+	w.handle(1, events.Event{Type: events.PublicationCreated, ActorId: w.users["alice"], PublicationId: publicationId})
+
+	if ids := publications(t, w.store, w.users["bob"]); len(ids) != 0 {
+		t.Errorf("expected the publication of a popular author not fanned out, got %v", ids)
+	}
+
+	popular, err := w.store.Popular(context.Background())
+	if err != nil || !reflect.DeepEqual(popular, []uint64{w.users["alice"]}) {
+		t.Errorf("expected alice marked popular, got %v, %v", popular, err)
+	}
+
+	if ids := w.feed("bob"); !reflect.DeepEqual(ids, []uint64{publicationId}) {
+		t.Errorf("expected the publication read with the timeline of the follower, got %v", ids)
+	}
+	if ids := w.feed("dave"); len(ids) != 0 {
+		t.Errorf("expected the publication left out of the feed of other users, got %v", ids)
+	}
+}
+
+func TestBackfillNewFollows(t *testing.T) {
+	tests := []struct {
+		name   string
+		follow func(w *world) events.Event
+	}{
+		{
+			name: "follow",
+			follow: func(w *world) events.Event {
+				w.follow("bob", "alice")
+				return events.Event{Type: events.UserFollowed, ActorId: w.users["bob"], UserId: w.users["alice"]}
+			},
+		},
+		{
+			name: "approved follow request",
+			follow: func(w *world) events.Event {
+				if err := w.repos.Users.CreateFollowRequest(w.users["alice"], w.users["bob"]); err != nil {
+					w.t.Fatal(err)
+				}
+				if err := w.repos.Users.ApproveFollowRequest(w.users["alice"], w.users["bob"]); err != nil {
+					w.t.Fatal(err)
+				}
+				return events.Event{Type: events.FollowRequestApproved, ActorId: w.users["alice"], UserId: w.users["bob"]}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := newWorld(t, 10, "alice", "bob", "carol")
+			w.feed("bob")
+
+			first, second := w.publish("alice"), w.publish("alice")
+			w.handle(10, test.follow(w))
+
+			expected := []uint64{second, first}
+			if ids := publications(t, w.store, w.users["bob"]); !reflect.DeepEqual(ids, expected) {
+				t.Errorf("expected the recent publications of alice in the timeline, got %v", ids)
+			}
+			if w.cached("carol") {
+				t.Error("expected a timeline that was not cached to be left for the next read")
+			}
+		})
+	}
+}
+
+func TestTrimEndedFollows(t *testing.T) {
+	tests := []struct {
+		name      string
+		end       func(w *world) events.Event
+		timelines map[string][]string
+	}{
+		{
+			name: "unfollow",
+			end: func(w *world) events.Event {
+				if _, err := w.repos.Users.Unfollow(w.users["alice"], w.users["bob"]); err != nil {
+					w.t.Fatal(err)
+				}
+				return events.Event{Type: events.UserUnfollowed, ActorId: w.users["bob"], UserId: w.users["alice"]}
+			},
+			timelines: map[string][]string{"alice": {"bob", "alice"}, "bob": {"carol", "bob"}},
+		},
+		{
+			name: "block",
+			end: func(w *world) events.Event {
+				if err := w.repos.Users.Block(w.users["alice"], w.users["bob"]); err != nil {
+					w.t.Fatal(err)
+				}
+				return events.Event{Type: events.UserBlocked, ActorId: w.users["alice"], UserId: w.users["bob"]}
+			},
+			timelines: map[string][]string{"alice": {"alice"}, "bob": {"carol", "bob"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := newWorld(t, 10, "alice", "bob", "carol")
+			w.follow("bob", "alice")
+			w.follow("bob", "carol")
+			w.follow("alice", "bob")
+
+			publicationIds := map[string]uint64{}
+			for _, author := range []string{"alice", "bob", "carol"} {
+				publicationIds[author] = w.publish(author)
+			}
+			w.feed("alice")
+			w.feed("bob")
+
+			w.handle(10, test.end(w))
+
+			for user, authors := range test.timelines {
+				expected := []uint64{}
+				for _, author := range authors {
+					expected = append(expected, publicationIds[author])
+				}
+
+				if ids := publications(t, w.store, w.users[user]); !reflect.DeepEqual(ids, expected) {
+					t.Errorf("expected the timeline of %s to keep the publications of %v, got %v", user, authors, ids)
+				}
+			}
+		})
+	}
+}
+
+func TestUnmuteRebuildsTimeline(t *testing.T) {
+	w := newWorld(t, 10, "alice", "bob")
+	w.follow("bob", "alice")
+	if err := w.repos.Users.Mute(w.users["bob"], w.users["alice"]); err != nil {
+		t.Fatal(err)
+	}
+
+	publicationId := w.publish("alice")
+	if ids := w.feed("bob"); len(ids) != 0 {
+		t.Fatalf("expected the muted author left out of the feed, got %v", ids)
+	}
+
+	if err := w.repos.Users.Unmute(w.users["bob"], w.users["alice"]); err != nil {
+		t.Fatal(err)
+	}
+	w.handle(10, events.Event{Type: events.UserUnmuted, ActorId: w.users["bob"], UserId: w.users["alice"]})
+
+	if w.cached("bob") {
+		t.Error("expected the timeline dropped after the unmute")
+	}
+	if ids := w.feed("bob"); !reflect.DeepEqual(ids, []uint64{publicationId}) {
+		t.Errorf("expected the publication of the unmuted author in the feed, got %v", ids)
+	}
+}
+
+func TestFeedBeyondTheCacheIsReadFromTheDatabase(t *testing.T) {
+	w := newWorld(t, capacity, "alice", "bob")
+	w.follow("bob", "alice")
+
+	var publicationIds []uint64
+	for i := 0; i < 5; i++ {
+		publicationIds = append([]uint64{w.publish("alice")}, publicationIds...)
+	}
+
+	service := w.service(10)
+	page := pagination.Params{Limit: 2}
+
+	var pages [][]uint64
+	for request := 0; request < 5; request++ {
+		publications, err := service.Feed(context.Background(), w.users["bob"], page)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hasMore := len(publications) > page.Limit
+		if hasMore {
+			publications = publications[:page.Limit]
+		}
+		pages = append(pages, ids(publications))
+
+		if !hasMore {
+			break
+		}
+		last := publications[len(publications)-1].Cursor()
+		page.After = &last
+	}
+
+	// the timeline keeps the three most recent publications, the older ones are read from the database
+	expected := [][]uint64{publicationIds[:2], publicationIds[2:4], publicationIds[4:]}
+	if !reflect.DeepEqual(pages, expected) {
+		t.Errorf("expected pages %v, got %v", expected, pages)
+	}
+}
@@ -0,0 +1,308 @@
+package timeline_test
+
+import (
+	"api/src/timeline"
+	"context"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// capacity is the number of entries kept in each timeline by the stores under test
+const capacity = 3
+
+// storeCase is one behavior every store must have, run against an empty store
+type storeCase struct {
+	name string
+	test func(t *testing.T, store timeline.Store)
+}
+
+var storeCases = []storeCase{
+	{"BuildAndAdd", testBuildAndAdd},
+	{"AddWhileBuilding", testAddWhileBuilding},
+	{"Trim", testTrim},
+	{"RemoveAuthor", testRemoveAuthor},
+	{"Popular", testPopular},
+}
+
+// entry is a publication created minutes into the day the tests take place
+func entry(publicationId, authorId uint64, minutes int) timeline.Entry {
+	createdAt := time.Date(2026, 1, 1, 0, minutes, 0, 0, time.UTC)
+	return timeline.Entry{PublicationId: publicationId, AuthorId: authorId, CreatedAt: createdAt}
+}
+
+// publications return the ids of the entries in the timeline of user, in the order they are read
+func publications(t *testing.T, store timeline.Store, userId uint64) []uint64 {
+	t.Helper()
+
+	entries, err := store.Range(context.Background(), userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []uint64{}
+	for _, entry := range entries {
+		ids = append(ids, entry.PublicationId)
+	}
+	return ids
+}
+
+// build prepare and build the timeline of user with entries
+func build(t *testing.T, store timeline.Store, userId uint64, entries []timeline.Entry) {
+	t.Helper()
+	ctx := context.Background()
+
+	if err := store.Prepare(ctx, userId); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Build(ctx, userId, entries); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testBuildAndAdd(t *testing.T, store timeline.Store) {
+	ctx := context.Background()
+
+	for userId, entries := range map[uint64][]timeline.Entry{1: {entry(10, 7, 1), entry(11, 8, 2)}, 2: nil} {
+		build(t, store, userId, entries)
+	}
+	if err := store.Add(ctx, []uint64{1, 2, 3}, []timeline.Entry{entry(12, 7, 3)}); err != nil {
+		t.Fatal(err)
+	}
+
+	timelines := []struct {
+		userId       uint64
+		cached       bool
+		publications []uint64
+	}{
+		{1, true, []uint64{12, 11, 10}},
+		{2, true, []uint64{12}},
+		{3, false, []uint64{}},
+	}
+
+	for _, expected := range timelines {
+		cached, err := store.Cached(ctx, expected.userId)
+		if err != nil || cached != expected.cached {
+			t.Errorf("expected timeline of %d cached %v, got %v, %v", expected.userId, expected.cached, cached, err)
+		}
+
+		if ids := publications(t, store, expected.userId); !reflect.DeepEqual(ids, expected.publications) {
+			t.Errorf("expected timeline of %d %v, got %v", expected.userId, expected.publications, ids)
+		}
+	}
+}
+
+func testAddWhileBuilding(t *testing.T, store timeline.Store) {
+	ctx := context.Background()
+
+	for _, userId := range []uint64{1, 2, 3} {
+		if err := store.Prepare(ctx, userId); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the fan out reaches the timelines while their publications are read from the database
+	if err := store.Add(ctx, []uint64{1, 2, 3}, []timeline.Entry{entry(12, 7, 3), entry(13, 8, 4)}); err != nil {
+		t.Fatal(err)
+	}
+	if cached, err := store.Cached(ctx, 1); err != nil || cached {
+		t.Errorf("expected a timeline being built not cached yet, got %v, %v", cached, err)
+	}
+
+	// a timeline dropped while it is built, like after an unmute, is not cached with what was read before
+	if err := store.Delete(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, userId := range []uint64{1, 2, 3} {
+		if err := store.Build(ctx, userId, []timeline.Entry{entry(10, 7, 1), entry(11, 8, 2), entry(12, 7, 3)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a second build merges into the timeline cached by the first one
+	build(t, store, 2, []timeline.Entry{entry(14, 7, 5)})
+
+	timelines := []struct {
+		userId       uint64
+		cached       bool
+		publications []uint64
+	}{
+		{1, true, []uint64{13, 12, 11}},
+		{2, true, []uint64{14, 13, 12}},
+		{3, false, []uint64{}},
+	}
+
+	for _, expected := range timelines {
+		cached, err := store.Cached(ctx, expected.userId)
+		if err != nil || cached != expected.cached {
+			t.Errorf("expected timeline of %d cached %v, got %v, %v", expected.userId, expected.cached, cached, err)
+		}
+
+		if ids := publications(t, store, expected.userId); !reflect.DeepEqual(ids, expected.publications) {
+			t.Errorf("expected timeline of %d %v, got %v", expected.userId, expected.publications, ids)
+		}
+	}
+}
+
+func testTrim(t *testing.T, store timeline.Store) {
+	ctx := context.Background()
+
+	build(t, store, 1, []timeline.Entry{entry(10, 7, 1), entry(11, 7, 2), entry(12, 7, 3), entry(13, 7, 4)})
+	if ids := publications(t, store, 1); !reflect.DeepEqual(ids, []uint64{13, 12, 11}) {
+		t.Errorf("expected build to keep the newest entries, got %v", ids)
+	}
+
+	if err := store.Add(ctx, []uint64{1}, []timeline.Entry{entry(14, 7, 5), entry(9, 7, 0)}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := publications(t, store, 1); !reflect.DeepEqual(ids, []uint64{14, 13, 12}) {
+		t.Errorf("expected add to keep the newest entries, got %v", ids)
+	}
+
+	if cached, err := store.Cached(ctx, 1); err != nil || !cached {
+		t.Errorf("expected a trimmed timeline to stay cached, got %v, %v", cached, err)
+	}
+}
+
+func testRemoveAuthor(t *testing.T, store timeline.Store) {
+	ctx := context.Background()
+
+	build(t, store, 1, []timeline.Entry{entry(10, 7, 1), entry(11, 8, 2), entry(12, 7, 3)})
+
+	removals := []struct {
+		authorId     uint64
+		publications []uint64
+	}{
+		{9, []uint64{12, 11, 10}},
+		{7, []uint64{11}},
+		{8, []uint64{}},
+	}
+
+	for _, removal := range removals {
+		if err := store.RemoveAuthor(ctx, 1, removal.authorId); err != nil {
+			t.Fatal(err)
+		}
+		if ids := publications(t, store, 1); !reflect.DeepEqual(ids, removal.publications) {
+			t.Errorf("removing author %d: expected %v, got %v", removal.authorId, removal.publications, ids)
+		}
+	}
+
+	if cached, err := store.Cached(ctx, 1); err != nil || !cached {
+		t.Errorf("expected an emptied timeline to stay cached, got %v, %v", cached, err)
+	}
+
+	if err := store.RemoveAuthor(ctx, 2, 7); err != nil {
+		t.Fatal(err)
+	}
+	if cached, err := store.Cached(ctx, 2); err != nil || cached {
+		t.Errorf("expected removing from a missing timeline to leave it missing, got %v, %v", cached, err)
+	}
+}
+
+func testPopular(t *testing.T, store timeline.Store) {
+	ctx := context.Background()
+
+	marks := []struct {
+		authorId uint64
+		popular  []uint64
+	}{
+		{7, []uint64{7}},
+		{8, []uint64{8, 7}},
+		{9, []uint64{9, 8, 7}},
+		{7, []uint64{7, 9, 8}},
+		{10, []uint64{10, 7, 9, 8}},
+	}
+
+	for _, mark := range marks {
+		// marks in the same millisecond are not ordered by the redis store
+		time.Sleep(2 * time.Millisecond)
+
+		if err := store.MarkPopular(ctx, mark.authorId); err != nil {
+			t.Fatal(err)
+		}
+
+		popular, err := store.Popular(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(popular, mark.popular) {
+			t.Errorf("after marking %d: expected popular %v, got %v", mark.authorId, mark.popular, popular)
+		}
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	for _, c := range storeCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) { c.test(t, timeline.NewMemoryStore(capacity)) })
+	}
+}
+
+// TestRedisStore run the cases against the server in REDIS_ADDR, selecting REDIS_DB. The database is
+// flushed before each case, so never point it to real data
+func TestRedisStore(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR not set")
+	}
+	db, _ := strconv.Atoi(os.Getenv("REDIS_DB"))
+
+	client := timeline.NewRedisClient(addr, os.Getenv("REDIS_PASSWORD"), db, 2, 5*time.Second)
+	defer client.Close()
+
+	flush := func(t *testing.T) {
+		if _, err := client.Do(context.Background(), "FLUSHDB"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, c := range storeCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			flush(t)
+			c.test(t, timeline.NewRedisStore(client, capacity, time.Minute))
+		})
+	}
+
+	t.Run("AddKeepsExpiration", func(t *testing.T) {
+		flush(t)
+		store := timeline.NewRedisStore(client, capacity, time.Minute)
+		ctx := context.Background()
+
+		build(t, store, 1, []timeline.Entry{entry(10, 7, 1)})
+		if err := store.Add(ctx, []uint64{1}, []timeline.Entry{entry(11, 7, 2)}); err != nil {
+			t.Fatal(err)
+		}
+
+		ttl, err := client.Do(ctx, "TTL", "timeline:1")
+		if seconds, _ := ttl.(int64); err != nil || seconds <= 0 || seconds > 60 {
+			t.Errorf("expected the timeline to keep expiring within a minute, got %v, %v", ttl, err)
+		}
+	})
+
+	t.Run("AddRacingExpiry", func(t *testing.T) {
+		flush(t)
+		store := timeline.NewRedisStore(client, capacity, time.Second)
+		ctx := context.Background()
+
+		build(t, store, 1, []timeline.Entry{entry(10, 7, 1)})
+		if cached, err := store.Cached(ctx, 1); err != nil || !cached {
+			t.Fatalf("expected the timeline cached before it expires, got %v, %v", cached, err)
+		}
+
+		// the fan out saw the timeline cached, but it expires before the entries are added
+		time.Sleep(1100 * time.Millisecond)
+
+		if err := store.Add(ctx, []uint64{1}, []timeline.Entry{entry(11, 7, 2)}); err != nil {
+			t.Fatal(err)
+		}
+
+		exists, err := client.Do(ctx, "EXISTS", "timeline:1")
+		if err != nil || exists != int64(0) {
+			t.Errorf("expected add to leave the expired timeline missing, got %v, %v", exists, err)
+		}
+	})
+}
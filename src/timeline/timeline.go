@@ -0,0 +1,49 @@
+// Package timeline keep a materialized home feed for each user, written when publications are created
+// by fanning them out to followers, so reading the feed does not need to join the followers table
+package timeline
+
+import (
+	"api/src/pagination"
+	"context"
+	"sort"
+	"time"
+)
+
+// Entry is one publication in a timeline, enough to order the timeline and to trim entries by author
+type Entry struct {
+	PublicationId uint64
+	AuthorId      uint64
+	CreatedAt     time.Time
+}
+
+// Store persist the timelines, keeping only the most recent entries of each one.
+// A timeline that is not cached is built again from the database the next time it is read
+type Store interface {
+	// Cached report if the timeline of user is in the store, an empty timeline can be cached
+	Cached(ctx context.Context, userId uint64) (bool, error)
+	// Prepare start building the timeline of user when it is not cached. Until Build, Add keeps the entries of
+	// the timeline apart, so publications fanned out while the database is read are not lost
+	Prepare(ctx context.Context, userId uint64) error
+	// Build store entries and the ones added since Prepare as the timeline of user and cache it. Nothing is stored
+	// when the timeline was dropped since Prepare, and entries are merged into a timeline another build cached
+	Build(ctx context.Context, userId uint64, entries []Entry) error
+	// Add insert entries in the timelines of users that are cached or being built, ignoring the others
+	Add(ctx context.Context, userIds []uint64, entries []Entry) error
+	// Range return the entries of the timeline of user, most recent first
+	Range(ctx context.Context, userId uint64) ([]Entry, error)
+	// RemoveAuthor delete the entries from author in the timeline of user
+	RemoveAuthor(ctx context.Context, userId, authorId uint64) error
+	// Delete drop the timeline of user
+	Delete(ctx context.Context, userId uint64) error
+	// MarkPopular remember that the publications of author are read with the timeline instead of fanned out
+	MarkPopular(ctx context.Context, authorId uint64) error
+	// Popular return the authors marked as popular, most recently marked first
+	Popular(ctx context.Context) ([]uint64, error)
+}
+
+// sortEntries order entries like the feed, most recent first
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return pagination.Before(entries[i].CreatedAt, entries[i].PublicationId, entries[j].CreatedAt, entries[j].PublicationId)
+	})
+}
@@ -0,0 +1,133 @@
+// Package trending rank the hashtags used recently, refreshed by a background worker so requests only read the ranking
+package trending
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Worker keep the trending hashtags, scoring each use with an exponential decay over its age
+type Worker struct {
+	hashtags repositories.HashtagRepository
+	window   time.Duration
+	halfLife time.Duration
+	limit    int
+
+	mu       sync.RWMutex
+	trending models.Trending
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewWorker create a worker that rank up to limit hashtags used inside window,
+// a use loses half of its weight every halfLife
+func NewWorker(hashtags repositories.HashtagRepository, window, halfLife time.Duration, limit int) *Worker {
+	return &Worker{
+		hashtags: hashtags,
+		window:   window,
+		halfLife: halfLife,
+		limit:    limit,
+		trending: models.Trending{Hashtags: []models.TrendingHashtag{}},
+		done:     make(chan struct{}),
+	}
+}
+
+// Start refresh the ranking now and then every interval until Close
+func (worker *Worker) Start(interval time.Duration) {
+	worker.wg.Add(1)
+	go func() {
+		defer worker.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := worker.Refresh(time.Now()); err != nil {
+				log.Printf("trending: refresh failed: %v", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-worker.done:
+				return
+			}
+		}
+	}()
+}
+
+// Refresh compute the ranking with the uses inside the window ending at now
+func (worker *Worker) Refresh(now time.Time) error {
+	uses, err := worker.hashtags.FindUses(now.Add(-worker.window))
+	if err != nil {
+		return err
+	}
+
+	trending := models.Trending{Hashtags: Rank(uses, now, worker.halfLife, worker.limit), UpdatedAt: now}
+
+	worker.mu.Lock()
+	worker.trending = trending
+	worker.mu.Unlock()
+
+	return nil
+}
+
+// Trending return the ranking from the last refresh
+func (worker *Worker) Trending() models.Trending {
+	worker.mu.RLock()
+	defer worker.mu.RUnlock()
+
+	return worker.trending
+}
+
+// Close stop the worker and wait the refresh running to finish
+func (worker *Worker) Close() {
+	worker.closeOnce.Do(func() { close(worker.done) })
+	worker.wg.Wait()
+}
+
+// Rank sum the decayed weight of the uses from each hashtag and return the limit best ones,
+// ties are ordered by tag so the ranking is stable between refreshes
+func Rank(uses []models.HashtagUse, now time.Time, halfLife time.Duration, limit int) []models.TrendingHashtag {
+	byTag := map[string]*models.TrendingHashtag{}
+
+	for _, use := range uses {
+		hashtag, ok := byTag[use.Tag]
+		if !ok {
+			hashtag = &models.TrendingHashtag{Tag: use.Tag}
+			byTag[use.Tag] = hashtag
+		}
+
+		age := now.Sub(use.Hour)
+		if age < 0 {
+			age = 0
+		}
+
+		hashtag.Uses += use.Count
+		hashtag.Score += float64(use.Count) * math.Pow(0.5, float64(age)/float64(halfLife))
+	}
+
+	ranking := make([]models.TrendingHashtag, 0, len(byTag))
+	for _, hashtag := range byTag {
+		ranking = append(ranking, *hashtag)
+	}
+
+	sort.Slice(ranking, func(i, j int) bool {
+		if ranking[i].Score == ranking[j].Score {
+			return ranking[i].Tag < ranking[j].Tag
+		}
+		return ranking[i].Score > ranking[j].Score
+	})
+
+	if len(ranking) > limit {
+		ranking = ranking[:limit]
+	}
+
+	return ranking
+}
@@ -0,0 +1,116 @@
+package trending_test
+
+import (
+	"api/src/models"
+	"api/src/repositories/memory"
+	"api/src/trending"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRank(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	use := func(tag string, age time.Duration, count uint64) models.HashtagUse {
+		return models.HashtagUse{Tag: tag, Hour: now.Add(-age), Count: count}
+	}
+
+	tests := []struct {
+		name    string
+		uses    []models.HashtagUse
+		limit   int
+		ranking []models.TrendingHashtag
+	}{
+		{
+			name:    "nothing used",
+			limit:   10,
+			ranking: []models.TrendingHashtag{},
+		},
+		{
+			name:  "uses lose half of their weight every half life",
+			uses:  []models.HashtagUse{use("old", 2*time.Hour, 8), use("new", 0, 3), use("old", 4*time.Hour, 4)},
+			limit: 10,
+			ranking: []models.TrendingHashtag{
+				{Tag: "old", Uses: 12, Score: 5},
+				{Tag: "new", Uses: 3, Score: 3},
+			},
+		},
+		{
+			name:  "ties ordered by tag",
+			uses:  []models.HashtagUse{use("go", 0, 2), use("beta", 0, 2), use("alpha", 2*time.Hour, 4)},
+			limit: 10,
+			ranking: []models.TrendingHashtag{
+				{Tag: "alpha", Uses: 4, Score: 2},
+				{Tag: "beta", Uses: 2, Score: 2},
+				{Tag: "go", Uses: 2, Score: 2},
+			},
+		},
+		{
+			name:    "uses from the future count as now",
+			uses:    []models.HashtagUse{use("clock", -time.Hour, 2)},
+			limit:   10,
+			ranking: []models.TrendingHashtag{{Tag: "clock", Uses: 2, Score: 2}},
+		},
+		{
+			name:    "only the best up to limit",
+			uses:    []models.HashtagUse{use("a", 0, 1), use("b", 0, 3), use("c", 0, 2)},
+			limit:   2,
+			ranking: []models.TrendingHashtag{{Tag: "b", Uses: 3, Score: 3}, {Tag: "c", Uses: 2, Score: 2}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ranking := trending.Rank(test.uses, now, 2*time.Hour, test.limit)
+			if !reflect.DeepEqual(ranking, test.ranking) {
+				t.Errorf("expected %+v, got %+v", test.ranking, ranking)
+			}
+		})
+	}
+}
+
+func TestWorkerRefreshRanksUsesInsideWindow(t *testing.T) {
+	repos := memory.NewRepositories()
+	authorId, err := repos.Users.Create(models.User{Name: "alice", Nick: "alice", Email: "alice@devbook.com", Password: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, content := range []string{"#go and #mysql", "more #go"} {
+		if _, err = repos.Publications.Create(models.Publication{
+			Title: "post", Content: content, Hashtags: models.ExtractHashtags(content),
+			Visibility: models.VisibilityPublic, Status: models.StatusPublished, AuthorId: authorId,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	worker := trending.NewWorker(repos.Hashtags, time.Hour, time.Hour, 10)
+	if hashtags := worker.Trending().Hashtags; len(hashtags) != 0 {
+		t.Fatalf("expected no ranking before the first refresh, got %+v", hashtags)
+	}
+
+	refreshes := []struct {
+		name string
+		now  time.Time
+		tags []string
+	}{
+		{"uses inside window", time.Now(), []string{"go", "mysql"}},
+		{"window without uses", time.Now().Add(3 * time.Hour), []string{}},
+	}
+
+	for _, refresh := range refreshes {
+		if err = worker.Refresh(refresh.now); err != nil {
+			t.Fatal(err)
+		}
+
+		tags := []string{}
+		for _, hashtag := range worker.Trending().Hashtags {
+			tags = append(tags, hashtag.Tag)
+		}
+
+		if !reflect.DeepEqual(tags, refresh.tags) || !worker.Trending().UpdatedAt.Equal(refresh.now) {
+			t.Errorf("%s: expected %v, got %v", refresh.name, refresh.tags, tags)
+		}
+	}
+}